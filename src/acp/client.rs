@@ -27,9 +27,18 @@ struct Terminal {
 type Terminals = Arc<Mutex<HashMap<String, Terminal>>>;
 type TerminalCounter = Arc<Mutex<u64>>;
 
+/// Count terminals whose command has not exited yet
+fn running_terminals(terminals: &HashMap<String, Terminal>) -> usize {
+    terminals.values().filter(|t| t.exit_code.is_none()).count()
+}
+
 /// Events from an agent connection
 #[derive(Debug)]
 pub enum AgentEvent {
+    /// The agent process was started
+    Spawned {
+        pid: Option<u32>,
+    },
     Initialized {
         agent_info: Option<AgentInfo>,
         agent_capabilities: Option<Value>,
@@ -58,11 +67,19 @@ pub enum AgentEvent {
     },
     PromptComplete {
         stop_reason: StopReason,
+        /// Pretty-printed prompt result (stop reason, usage, meta) for debug display
+        raw_json: Option<String>,
     },
     FileWritten {
         session_id: String,
         path: String,
         diff: String,
+        /// 1-based line numbers in the new content that were added or modified
+        changed_lines: Vec<usize>,
+    },
+    /// Number of agent-run terminal commands still executing changed
+    TerminalsChanged {
+        running: usize,
     },
     Error {
         message: String,
@@ -93,7 +110,9 @@ impl AgentConnection {
             .current_dir(cwd)
             .stdin(Stdio::piped())
             .stdout(Stdio::piped())
-            .stderr(Stdio::null());
+            .stderr(Stdio::null())
+            // Don't leave the agent running if amux exits without killing it
+            .kill_on_drop(true);
 
         // For Claude Code ACP adapter, pass custom Claude executable if available
         if matches!(agent_type, AgentType::ClaudeCode)
@@ -134,10 +153,32 @@ impl AgentConnection {
         let terminal_counter: TerminalCounter = Arc::new(Mutex::new(0));
 
         tokio::spawn(async move {
-            let reader = BufReader::new(stdout);
-            let mut lines = reader.lines();
+            // Read raw lines (no length cap) so a single huge message, e.g. one carrying a
+            // base64 image, or one with invalid UTF-8 doesn't end the stream
+            let mut reader = BufReader::new(stdout);
+            let mut buf = Vec::new();
+
+            loop {
+                buf.clear();
+                match reader.read_until(b'\n', &mut buf).await {
+                    Ok(0) => break,
+                    Ok(_) => {}
+                    Err(e) => {
+                        log::log(&format!("Failed to read agent output: {}", e));
+                        break;
+                    }
+                }
 
-            while let Ok(Some(line)) = lines.next_line().await {
+                let line = match std::str::from_utf8(&buf) {
+                    Ok(line) => line.trim_end().to_string(),
+                    Err(_) => {
+                        log::log(&format!(
+                            "Agent sent a line with invalid UTF-8 ({} bytes), decoding lossily",
+                            buf.len()
+                        ));
+                        String::from_utf8_lossy(&buf).trim_end().to_string()
+                    }
+                };
                 if line.trim().is_empty() {
                     continue;
                 }
@@ -179,6 +220,7 @@ impl AgentConnection {
                                 let _ = event_tx_clone
                                     .send(AgentEvent::PromptComplete {
                                         stop_reason: prompt.stop_reason,
+                                        raw_json: serde_json::to_string_pretty(&result).ok(),
                                     })
                                     .await;
                             } else if result.is_null() {
@@ -401,16 +443,20 @@ impl AgentConnection {
                                         {
                                             Ok(()) => {
                                                 // Generate and send diff
+                                                let old = old_content.as_deref().unwrap_or("");
                                                 let diff = generate_diff(
-                                                    old_content.as_deref().unwrap_or(""),
+                                                    old,
                                                     &fs_params.content,
                                                     &fs_params.path,
                                                 );
+                                                let changed_lines =
+                                                    changed_lines(old, &fs_params.content);
                                                 let _ = event_tx_clone
                                                     .send(AgentEvent::FileWritten {
                                                         session_id: fs_params.session_id.clone(),
                                                         path: fs_params.path.clone(),
                                                         diff,
+                                                        changed_lines,
                                                     })
                                                     .await;
 
@@ -464,7 +510,7 @@ impl AgentConnection {
                                         };
 
                                         // Insert placeholder terminal (command running)
-                                        {
+                                        let running = {
                                             let mut terms = terminals.lock().await;
                                             terms.insert(
                                                 terminal_id.clone(),
@@ -475,7 +521,11 @@ impl AgentConnection {
                                                     child: None,
                                                 },
                                             );
-                                        }
+                                            running_terminals(&terms)
+                                        };
+                                        let _ = event_tx_clone
+                                            .send(AgentEvent::TerminalsChanged { running })
+                                            .await;
 
                                         // Respond immediately with terminal ID
                                         let result = serde_json::json!({
@@ -512,6 +562,7 @@ impl AgentConnection {
                                         // Spawn command execution in background - doesn't block message loop
                                         let terminals_clone = Arc::clone(&terminals);
                                         let terminal_id_clone = terminal_id.clone();
+                                        let terminal_event_tx = event_tx_clone.clone();
                                         tokio::spawn(async move {
                                             // Use tokio::process::Command directly (async native)
                                             let mut cmd = Command::new("sh");
@@ -525,6 +576,7 @@ impl AgentConnection {
                                             }
                                             cmd.stdout(Stdio::piped());
                                             cmd.stderr(Stdio::piped());
+                                            cmd.kill_on_drop(true);
 
                                             let result = cmd.output().await;
 
@@ -565,6 +617,11 @@ impl AgentConnection {
                                                     }
                                                 }
                                             }
+                                            let running = running_terminals(&terms);
+                                            drop(terms); // Release lock before await
+                                            let _ = terminal_event_tx
+                                                .send(AgentEvent::TerminalsChanged { running })
+                                                .await;
                                         });
                                     }
                                     Err(e) => {
@@ -737,7 +794,11 @@ impl AgentConnection {
                                     Ok(term_params) => {
                                         let mut terms = terminals.lock().await;
                                         terms.remove(&term_params.terminal_id);
+                                        let running = running_terminals(&terms);
                                         drop(terms);
+                                        let _ = event_tx_clone
+                                            .send(AgentEvent::TerminalsChanged { running })
+                                            .await;
                                         let result = serde_json::json!({
                                             "jsonrpc": "2.0",
                                             "id": id,
@@ -774,7 +835,11 @@ impl AgentConnection {
                     Err(e) => {
                         let _ = event_tx_clone
                             .send(AgentEvent::Error {
-                                message: format!("Parse error: {}", e),
+                                message: format!(
+                                    "Parse error (skipped {} byte message): {}",
+                                    line.len(),
+                                    e
+                                ),
                             })
                             .await;
                     }
@@ -994,6 +1059,11 @@ impl AgentConnection {
         self.send(request).await
     }
 
+    /// Process ID of the agent, while it is running
+    pub fn pid(&self) -> Option<u32> {
+        self.child.id()
+    }
+
     /// Kill the agent process
     pub async fn kill(&mut self) -> Result<()> {
         self.child.kill().await?;
@@ -1002,6 +1072,17 @@ impl AgentConnection {
 }
 
 /// Generate a unified diff between old and new content with line numbers
+/// Line numbers (1-based) in `new` that were inserted or modified compared to `old`
+fn changed_lines(old: &str, new: &str) -> Vec<usize> {
+    use similar::{ChangeTag, TextDiff};
+
+    TextDiff::from_lines(old, new)
+        .iter_all_changes()
+        .filter(|change| change.tag() == ChangeTag::Insert)
+        .filter_map(|change| change.new_index().map(|i| i + 1))
+        .collect()
+}
+
 fn generate_diff(old: &str, new: &str, _path: &str) -> String {
     use similar::{ChangeTag, TextDiff};
 
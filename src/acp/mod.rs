@@ -5,4 +5,5 @@ pub use client::{AgentConnection, AgentEvent};
 pub use protocol::{
     AgentCommand, AskUserOption, AskUserResponse, ContentBlock, McpServer, ModelInfo,
     PermissionKind, PermissionOptionId, PermissionOptionInfo, PlanEntry, PlanStatus, SessionUpdate,
+    StopReason,
 };
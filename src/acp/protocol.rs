@@ -252,6 +252,20 @@ pub enum StopReason {
     Unknown,
 }
 
+impl StopReason {
+    /// Short human-readable description of why a turn ended
+    pub fn description(&self) -> &'static str {
+        match self {
+            StopReason::EndTurn => "finished",
+            StopReason::MaxTokens => "hit the max token limit (response truncated)",
+            StopReason::MaxTurnRequests => "hit the max model requests per turn",
+            StopReason::Cancelled => "cancelled",
+            StopReason::Refusal => "refused to continue",
+            StopReason::Unknown => "stopped for an unknown reason",
+        }
+    }
+}
+
 // ============================================================================
 // Session update types
 // ============================================================================
@@ -373,6 +387,8 @@ pub enum SessionUpdate {
     ToolCallUpdate {
         tool_call_id: String,
         status: String,
+        /// Text of the update's content blocks (e.g. fetched pages, search results)
+        content: Option<String>,
     },
     Plan {
         entries: Vec<PlanEntry>,
@@ -472,6 +488,18 @@ impl<'de> serde::Deserialize<'de> for SessionUpdate {
                     .and_then(|v| v.as_str())
                     .unwrap_or("")
                     .to_string(),
+                // content: [{ "type": "content", "content": { "type": "text", "text": ... } }]
+                content: value
+                    .get("content")
+                    .and_then(|v| v.as_array())
+                    .map(|blocks| {
+                        blocks
+                            .iter()
+                            .filter_map(|block| block.pointer("/content/text")?.as_str())
+                            .collect::<Vec<_>>()
+                            .join("\n")
+                    })
+                    .filter(|text| !text.is_empty()),
             }),
             Some("plan") => {
                 let entries = value
@@ -0,0 +1,82 @@
+//! Claude API health from the Anthropic status page.
+//!
+//! With an `[api_status]` section in the config, amux polls a Statuspage
+//! `status.json` endpoint (by default status.anthropic.com) and shows the
+//! result in the modeline. When several agents stall at once while the API is
+//! degraded, the modeline says so, so the sessions don't have to be debugged
+//! one by one. The endpoint is fetched with `http::get`, which handles HTTPS.
+
+use std::time::Duration;
+
+use serde_json::Value;
+
+use crate::http;
+
+/// How long an agent has to be working without output to count as stalled
+pub const STALL_AFTER: Duration = Duration::from_secs(90);
+
+/// Overall status reported by the status page
+#[derive(Debug, Clone, PartialEq)]
+pub struct ApiStatus {
+    /// Statuspage indicator: "none", "minor", "major", "critical" or "maintenance"
+    pub indicator: String,
+    /// Human readable summary, e.g. "Partially Degraded Service"
+    pub description: String,
+}
+
+impl ApiStatus {
+    /// Whether the API is not fully operational
+    pub fn is_degraded(&self) -> bool {
+        self.indicator != "none"
+    }
+
+    /// Whether the outage is serious (major or critical)
+    pub fn is_major(&self) -> bool {
+        matches!(self.indicator.as_str(), "major" | "critical")
+    }
+}
+
+/// Parse a Statuspage `status.json` response
+pub fn parse(text: &str) -> Option<ApiStatus> {
+    let value: Value = serde_json::from_str(text).ok()?;
+    let status = value.get("status")?;
+    Some(ApiStatus {
+        indicator: status.get("indicator")?.as_str()?.to_string(),
+        description: status
+            .get("description")
+            .and_then(Value::as_str)
+            .unwrap_or_default()
+            .to_string(),
+    })
+}
+
+/// Fetch the status from `url`
+pub async fn fetch(url: &str) -> Result<ApiStatus, String> {
+    let body = http::get(url, http::TIMEOUT).await?;
+    parse(&body).ok_or_else(|| format!("unexpected response from {}", url))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse() {
+        let status = parse(
+            r#"{"page":{"id":"x","name":"Anthropic"},"status":{"indicator":"minor","description":"Partially Degraded Service"}}"#,
+        )
+        .unwrap();
+        assert_eq!(status.indicator, "minor");
+        assert_eq!(status.description, "Partially Degraded Service");
+        assert!(status.is_degraded());
+        assert!(!status.is_major());
+
+        let ok =
+            parse(r#"{"status":{"indicator":"none","description":"All Systems Operational"}}"#)
+                .unwrap();
+        assert!(!ok.is_degraded());
+
+        assert!(parse("<html>").is_none());
+        assert!(parse(r#"{"status":{}}"#).is_none());
+    }
+}
@@ -1,10 +1,40 @@
 use std::path::PathBuf;
 
-use crate::config::McpServerConfig;
+use crate::api_status::{self, ApiStatus};
+use crate::archive::{self, SearchFilter, SearchHit, Snippet};
+use crate::attachments;
+use crate::audit::{self, AuditEntry};
+use crate::clipboard;
+use crate::config::{
+    AlertConfig, AlertEvent, ApiStatusConfig, Config, DiffWarningConfig, McpServerConfig,
+    ProjectConfig, RowFadeConfig, RowField, Thresholds, UsageLimitsConfig, project_thresholds,
+};
+use crate::dataset;
+use crate::exclude::Excludes;
+use crate::focus;
+use crate::frame::{self, FrameLimiter};
+use crate::hidden;
+use crate::log;
+use crate::notes;
 use crate::notification::{NotificationConfig, NotificationManager};
+use crate::otlp;
+use crate::permalink::{self, Permalink};
 use crate::picker::Picker;
-use crate::session::{AgentAvailability, AgentType, Session, SessionManager};
+use crate::procs::{self, Process, TreeRow};
+use crate::queue::{WorkOrder, WorkQueue};
+use crate::redact::Redactor;
+use crate::scope;
+use crate::scroll::ScrollAccelerator;
+use crate::serve;
+use crate::session::{
+    AgentAvailability, AgentType, MessageTag, OutputType, RecentFile, Session, SessionManager,
+    SessionState, WorkspaceSnapshot, default_permission_mode, load_jsonl,
+};
+use crate::snapshot;
+use crate::telemetry;
+use crate::transcript;
 use crate::tui::interaction::InteractionRegistry;
+use crate::usage::{self, ScanProgress, UsageWindows};
 
 /// Sort/view mode for the session list
 #[derive(Debug, Clone, Copy, PartialEq, Default)]
@@ -16,6 +46,8 @@ pub enum SortMode {
     Grouped,
     /// Sessions grouped by agent type
     ByAgent,
+    /// Sessions grouped by epic, with each epic's combined progress
+    ByEpic,
     /// Sorted alphabetically by name
     ByName,
     /// Sorted by creation time (oldest first)
@@ -30,7 +62,8 @@ impl SortMode {
         match self {
             SortMode::List => SortMode::Grouped,
             SortMode::Grouped => SortMode::ByAgent,
-            SortMode::ByAgent => SortMode::ByName,
+            SortMode::ByAgent => SortMode::ByEpic,
+            SortMode::ByEpic => SortMode::ByName,
             SortMode::ByName => SortMode::ByCreatedTime,
             SortMode::ByCreatedTime => SortMode::Priority,
             SortMode::Priority => SortMode::List,
@@ -43,6 +76,7 @@ impl SortMode {
             SortMode::List => "list",
             SortMode::Grouped => "grouped",
             SortMode::ByAgent => "by agent",
+            SortMode::ByEpic => "by epic",
             SortMode::ByName => "by name",
             SortMode::ByCreatedTime => "by time",
             SortMode::Priority => "priority",
@@ -65,6 +99,20 @@ pub enum InputMode {
     WorktreeCleanupRepoPicker, // Selecting git repo for worktree cleanup
     BugReport,                 // Entering bug report description
     ClearConfirm,              // Confirming session clear
+    KillIdleConfirm,           // Confirming the kill of all idle sessions
+    Stats,                     // Conversation statistics popup
+    AuditLog,                  // Recorded destructive actions
+    PlanHistory,               // Timeline of plan changes
+    Tagging,                   // Moving between messages to tag them
+    RecentFiles,               // Browsing files recently written by the agent
+    ProcessTree,               // Browsing the processes running under the agent
+    WorkspaceDiff,             // Diffing the work tree between two points in time
+    ReplyTemplates,            // Picking a quick reply to send
+    Notes,                     // Editing the selected session's notes
+    EpicInput,                 // Linking the selected session to an epic
+    Queue,                     // Queued prompts and work orders
+    Search,                    // Archive search screen
+    VerifyOutput,              // Output of the selected session's verify command
 }
 
 /// Entry in the folder picker
@@ -407,6 +455,270 @@ pub struct WorktreeEntry {
     pub is_merged: bool,
 }
 
+/// State for the recently written files browser
+#[derive(Debug, Clone)]
+pub struct RecentFilesState {
+    pub files: Vec<RecentFile>,
+    pub selected: usize,
+    /// Current content of the selected file (None if it could not be read)
+    pub preview: Option<Vec<String>>,
+}
+
+impl RecentFilesState {
+    pub fn new(files: Vec<RecentFile>) -> Self {
+        let mut state = Self {
+            files,
+            selected: 0,
+            preview: None,
+        };
+        state.load_preview();
+        state
+    }
+
+    /// Read the selected file from disk for the preview pane
+    pub fn load_preview(&mut self) {
+        self.preview = self.selected_item().and_then(|file| {
+            std::fs::read_to_string(&file.path)
+                .ok()
+                .map(|content| content.lines().map(str::to_string).collect())
+        });
+    }
+}
+
+impl Picker for RecentFilesState {
+    type Item = RecentFile;
+
+    fn items(&self) -> &[Self::Item] {
+        &self.files
+    }
+
+    fn selected_index(&self) -> usize {
+        self.selected
+    }
+
+    fn set_selected_index(&mut self, index: usize) {
+        self.selected = index;
+    }
+}
+
+/// A prompt or work order in the queue view
+#[derive(Debug, Clone, PartialEq)]
+pub enum QueueItem {
+    /// Prompt queued while the session's agent was busy
+    Prompt {
+        session_id: String,
+        index: usize,
+        text: String,
+    },
+    /// Work order for whichever agent in the project goes idle first
+    Order(WorkOrder),
+}
+
+impl QueueItem {
+    pub fn text(&self) -> &str {
+        match self {
+            QueueItem::Prompt { text, .. } => text,
+            QueueItem::Order(order) => &order.text,
+        }
+    }
+}
+
+/// What the queue view's input line edits
+#[derive(Debug, Clone)]
+pub enum QueueEditTarget {
+    Item(QueueItem),
+    /// New work order for a project
+    NewOrder(PathBuf),
+}
+
+/// State for the queue view
+#[derive(Debug, Clone, Default)]
+pub struct QueueViewState {
+    pub selected: usize,
+    /// Item being edited or work order being added, with its text
+    pub edit: Option<(QueueEditTarget, TextEditState)>,
+}
+
+/// State for the archive search screen
+#[derive(Debug, Clone)]
+pub struct SearchViewState {
+    pub input: TextEditState,
+    /// Query of the results shown, or of the search running
+    pub searched: Option<String>,
+    pub searching: bool,
+    /// Search for the event loop to start (query, terms, filter)
+    pending: Option<(String, String, SearchFilter)>,
+    pub hits: Vec<SearchHit>,
+    /// Selected matching line, counting across all hits
+    pub selected: usize,
+    pub error: Option<String>,
+    /// Archived message opened from a result (title, text)
+    pub preview: Option<(String, String)>,
+}
+
+impl SearchViewState {
+    fn new() -> Self {
+        Self {
+            input: TextEditState::new(String::new()),
+            searched: None,
+            searching: false,
+            pending: None,
+            hits: vec![],
+            selected: 0,
+            error: None,
+            preview: None,
+        }
+    }
+
+    /// Matching lines of all hits, best session first
+    pub fn results(&self) -> Vec<(&SearchHit, &Snippet)> {
+        self.hits
+            .iter()
+            .flat_map(|hit| hit.snippets.iter().map(move |snippet| (hit, snippet)))
+            .collect()
+    }
+}
+
+/// State for the process tree below the selected session's agent
+#[derive(Debug, Clone)]
+pub struct ProcessTreeState {
+    /// PID of the agent process at the root of the tree
+    pub root: u32,
+    /// Latest process listing (None until the first one arrives)
+    pub processes: Option<Result<Vec<Process>, String>>,
+    /// The tree as shown, with collapsed subtrees left out
+    pub rows: Vec<TreeRow>,
+    /// PIDs whose children are hidden
+    pub collapsed: std::collections::HashSet<u32>,
+    pub selected: usize,
+    last_refresh: Option<std::time::Instant>,
+    refresh_running: bool,
+}
+
+impl ProcessTreeState {
+    pub fn new(root: u32) -> Self {
+        Self {
+            root,
+            processes: None,
+            rows: vec![],
+            collapsed: std::collections::HashSet::new(),
+            selected: 0,
+            last_refresh: None,
+            refresh_running: false,
+        }
+    }
+
+    /// Rebuild the rows, keeping the same process selected if it's still there
+    fn rebuild(&mut self) {
+        let selected_pid = self.selected_item().map(|row| row.process.pid);
+        self.rows = match &self.processes {
+            Some(Ok(processes)) => procs::tree(processes, self.root, &self.collapsed),
+            _ => vec![],
+        };
+        self.selected = selected_pid
+            .and_then(|pid| self.rows.iter().position(|row| row.process.pid == pid))
+            .unwrap_or(0)
+            .min(self.rows.len().saturating_sub(1));
+    }
+}
+
+impl Picker for ProcessTreeState {
+    type Item = TreeRow;
+
+    fn items(&self) -> &[Self::Item] {
+        &self.rows
+    }
+
+    fn selected_index(&self) -> usize {
+        self.selected
+    }
+
+    fn set_selected_index(&mut self, index: usize) {
+        self.selected = index;
+    }
+}
+
+/// State for diffing a session's work tree between two snapshots
+#[derive(Debug, Clone)]
+pub struct WorkspaceDiffState {
+    pub snapshots: Vec<WorkspaceSnapshot>,
+    pub selected: usize,
+    /// Snapshot marked as the start of the range
+    pub from: Option<usize>,
+    /// Diff of the marked range (None until both ends are picked; Err if git failed)
+    pub diff: Option<Result<Vec<String>, String>>,
+    /// First diff line shown
+    pub diff_scroll: usize,
+}
+
+impl WorkspaceDiffState {
+    pub fn new(snapshots: Vec<WorkspaceSnapshot>) -> Self {
+        Self {
+            selected: snapshots.len().saturating_sub(1),
+            snapshots,
+            from: None,
+            diff: None,
+            diff_scroll: 0,
+        }
+    }
+
+    /// Mark the selected snapshot; returns the (older, newer) trees once
+    /// both ends of the range are marked
+    pub fn mark(&mut self) -> Option<(String, String)> {
+        let Some(from) = self.from else {
+            self.from = Some(self.selected);
+            return None;
+        };
+        let (older, newer) = (from.min(self.selected), from.max(self.selected));
+        self.from = Some(older);
+        self.selected = newer;
+        self.diff_scroll = 0;
+        Some((
+            self.snapshots[older].tree.clone(),
+            self.snapshots[newer].tree.clone(),
+        ))
+    }
+}
+
+impl Picker for WorkspaceDiffState {
+    type Item = WorkspaceSnapshot;
+
+    fn items(&self) -> &[Self::Item] {
+        &self.snapshots
+    }
+
+    fn selected_index(&self) -> usize {
+        self.selected
+    }
+
+    fn set_selected_index(&mut self, index: usize) {
+        self.selected = index;
+    }
+}
+
+/// State for the quick reply menu
+#[derive(Debug, Clone)]
+pub struct ReplyTemplatesState {
+    pub templates: Vec<String>,
+    pub selected: usize,
+}
+
+impl Picker for ReplyTemplatesState {
+    type Item = String;
+
+    fn items(&self) -> &[Self::Item] {
+        &self.templates
+    }
+
+    fn selected_index(&self) -> usize {
+        self.selected
+    }
+
+    fn set_selected_index(&mut self, index: usize) {
+        self.selected = index;
+    }
+}
+
 /// State for the worktree picker
 #[derive(Debug, Clone)]
 pub struct WorktreePickerState {
@@ -653,6 +965,80 @@ impl BugReportState {
     }
 }
 
+/// Text being edited with a cursor (session notes, epic names)
+#[derive(Debug, Clone)]
+pub struct TextEditState {
+    pub text: String,
+    /// Cursor position in bytes
+    pub cursor_position: usize,
+}
+
+impl TextEditState {
+    pub fn new(text: String) -> Self {
+        let cursor_position = text.len();
+        Self {
+            text,
+            cursor_position,
+        }
+    }
+
+    pub fn input_char(&mut self, c: char) {
+        self.text.insert(self.cursor_position, c);
+        self.cursor_position += c.len_utf8();
+    }
+
+    pub fn input_backspace(&mut self) {
+        if self.cursor_position > 0 {
+            let mut new_pos = self.cursor_position - 1;
+            while new_pos > 0 && !self.text.is_char_boundary(new_pos) {
+                new_pos -= 1;
+            }
+            self.text.remove(new_pos);
+            self.cursor_position = new_pos;
+        }
+    }
+
+    pub fn input_delete(&mut self) {
+        if self.cursor_position < self.text.len() {
+            self.text.remove(self.cursor_position);
+        }
+    }
+
+    pub fn input_left(&mut self) {
+        if self.cursor_position > 0 {
+            let mut new_pos = self.cursor_position - 1;
+            while new_pos > 0 && !self.text.is_char_boundary(new_pos) {
+                new_pos -= 1;
+            }
+            self.cursor_position = new_pos;
+        }
+    }
+
+    pub fn input_right(&mut self) {
+        if self.cursor_position < self.text.len() {
+            let mut new_pos = self.cursor_position + 1;
+            while new_pos < self.text.len() && !self.text.is_char_boundary(new_pos) {
+                new_pos += 1;
+            }
+            self.cursor_position = new_pos;
+        }
+    }
+
+    /// Move to the start of the current line
+    pub fn input_home(&mut self) {
+        self.cursor_position = self.text[..self.cursor_position]
+            .rfind('\n')
+            .map_or(0, |i| i + 1);
+    }
+
+    /// Move to the end of the current line
+    pub fn input_end(&mut self) {
+        self.cursor_position += self.text[self.cursor_position..]
+            .find('\n')
+            .unwrap_or(self.text.len() - self.cursor_position);
+    }
+}
+
 /// Configuration for git worktrees
 #[derive(Debug, Clone)]
 pub struct WorktreeConfig {
@@ -682,9 +1068,89 @@ impl WorktreeConfig {
     }
 }
 
+/// A transcript file opened read-only (`amux view <FILE>`, `amux serve
+/// --tui`). It's read in the background once its session is selected, and
+/// after that only what was appended to it.
+#[derive(Debug)]
+struct SharedSession {
+    /// App session showing the transcript
+    id: String,
+    transcript: serve::SharedTranscript,
+    /// Modification time when last looked at
+    modified: Option<std::time::SystemTime>,
+    /// Text read so far (redacted in the shared view)
+    text: String,
+    /// Bytes of the file read so far
+    len: u64,
+    /// Whether the file changed since it was read
+    stale: bool,
+    /// Whether a read is running
+    loading: bool,
+}
+
 /// Spinner frames for loading animation
 pub const SPINNER_FRAMES: &[&str] = &["⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"];
 
+/// Entries shown in the audit log popup
+const AUDIT_LOG_ENTRIES: usize = 20;
+
+/// How long the screen stays inverted for a visual alert
+const FLASH_DURATION: std::time::Duration = std::time::Duration::from_millis(150);
+
+/// How long a toast stays up (errors stay twice as long)
+const TOAST_DURATION: std::time::Duration = std::time::Duration::from_secs(4);
+
+/// How often the config file is checked for changes
+const CONFIG_CHECK_INTERVAL: std::time::Duration = std::time::Duration::from_secs(1);
+
+/// How long the selection has to stay on a session before its conversation
+/// is laid out, so holding j/k doesn't lay out every session passed. The
+/// layout itself still happens in the frame after that, on the UI thread.
+const DETAIL_DEBOUNCE: std::time::Duration = std::time::Duration::from_millis(150);
+
+/// How often the session snapshot file is rewritten (when enabled)
+const SNAPSHOT_INTERVAL: std::time::Duration = std::time::Duration::from_secs(5);
+
+/// How often a focus request from `amux open amux://focus/…` is looked for
+const FOCUS_CHECK_INTERVAL: std::time::Duration = std::time::Duration::from_millis(250);
+
+/// How often work orders from `amux enqueue` are looked for
+const QUEUE_CHECK_INTERVAL: std::time::Duration = std::time::Duration::from_millis(500);
+
+/// How often the read-only view (`amux serve --tui`) looks for new agents
+/// and transcript changes
+const SHARED_REFRESH_INTERVAL: std::time::Duration = std::time::Duration::from_secs(2);
+
+/// How often session output is checked against the memory budget
+const MEMORY_CHECK_INTERVAL: std::time::Duration = std::time::Duration::from_secs(10);
+
+/// Memory budget for session output unless configured, in MB
+const DEFAULT_MEMORY_BUDGET_MB: usize = 512;
+
+/// Output lines a session keeps when it's trimmed for the memory budget
+const MIN_KEPT_LINES: usize = 2000;
+
+/// Minutes a Bash command runs before it's flagged, unless configured
+const DEFAULT_LONG_COMMAND_MINUTES: u64 = 5;
+
+/// Minutes a session is idle before 'Z' kills it, unless configured
+const DEFAULT_IDLE_KILL_MINUTES: u64 = 60;
+
+/// Modification time of the config file (None if it doesn't exist)
+fn config_modified() -> Option<std::time::SystemTime> {
+    std::fs::metadata(Config::config_path())
+        .and_then(|metadata| metadata.modified())
+        .ok()
+}
+
+/// A short-lived message shown in the top right corner
+#[derive(Debug, Clone)]
+pub struct Toast {
+    pub message: String,
+    pub is_error: bool,
+    pub until: std::time::Instant,
+}
+
 /// State for a running bash command
 #[derive(Debug, Clone)]
 pub struct RunningBashCommand {
@@ -743,6 +1209,22 @@ pub struct App {
     pub folder_picker: Option<FolderPickerState>,
     pub agent_picker: Option<AgentPickerState>,
     pub session_picker: Option<SessionPickerState>,
+    pub recent_files: Option<RecentFilesState>,
+    pub process_tree: Option<ProcessTreeState>,
+    pub workspace_diff: Option<WorkspaceDiffState>,
+    /// Lines the verify output is scrolled back from its end (0 follows new output)
+    pub verify_scroll: usize,
+    pub reply_menu: Option<ReplyTemplatesState>,
+    /// Notes being edited for the selected session
+    pub notes_editor: Option<TextEditState>,
+    /// Epic name being entered for the selected session
+    pub epic_input: Option<TextEditState>,
+    /// Queue view ('Q')
+    pub queue_view: Option<QueueViewState>,
+    /// Work orders waiting for an idle agent in their project
+    pub work_queue: WorkQueue,
+    /// Archive search screen ('/')
+    pub search_view: Option<SearchViewState>,
     pub worktree_picker: Option<WorktreePickerState>,
     pub branch_input: Option<BranchInputState>,
     pub worktree_cleanup: Option<WorktreeCleanupState>,
@@ -757,6 +1239,10 @@ pub struct App {
     pub interactions: InteractionRegistry,
     /// Mapping from display index to internal session index, updated during render
     pub session_display_order: SessionDisplayOrder,
+    /// First visible line of the session list, updated during render
+    pub session_list_top: usize,
+    /// Sessions opened as tabs above the conversation, by session ID
+    pub tabs: Vec<String>,
     /// Counter for generating unique session IDs
     next_session_id: u64,
     /// Session list sort/view mode
@@ -765,8 +1251,106 @@ pub struct App {
     pub log_path: Option<PathBuf>,
     /// Unique session ID for this amux instance (for matching logs)
     pub session_id: Option<String>,
-    /// Debug mode: show raw ACP JSON under tool calls (toggle with 't')
+    /// Debug mode: show raw ACP JSON under tool calls and turn results (toggle with 't')
     pub debug_tool_json: bool,
+    /// Show sessions hidden with 'D' in the session list (toggle with 'H')
+    pub show_hidden: bool,
+    /// Leave headless (Agent SDK / CI) sessions out of the session list
+    pub hide_headless: bool,
+    /// Show agent reasoning under thought lines (toggle with 'T')
+    pub show_thinking: bool,
+    /// Show the full text of web tool results instead of just their links (toggle with 'W')
+    pub show_web_bodies: bool,
+    /// Preview the agent's latest message under each session in the list (toggle with 'P')
+    pub show_previews: bool,
+    /// Show only errors, failed tools and warnings in the conversation (toggle with 'F')
+    pub errors_only: bool,
+    /// Command run with 'V' to verify an agent's work (from config)
+    pub verify_command: Option<String>,
+    /// Command run with 'S' to summarize a transcript (from config)
+    pub summary_command: Option<String>,
+    /// Redaction rules applied to exported transcripts (from config)
+    pub redactor: Redactor,
+    /// Follow-up prompts offered in the quick reply menu (from config)
+    pub reply_templates: Vec<String>,
+    /// Fields shown for each session in the sidebar, in order (from config)
+    pub row_fields: Vec<RowField>,
+    /// Audit log entries shown while the audit log popup is open
+    pub audit_entries: Vec<AuditEntry>,
+    /// Directories besides a session's own where agent writes don't raise a warning (from config)
+    pub allowed_write_dirs: Vec<PathBuf>,
+    /// Diff sizes at which session diff stats are flagged for review (from config)
+    pub diff_warnings: DiffWarningConfig,
+    /// Trace exporter for agent turns (from config, None when not configured)
+    pub otlp: Option<otlp::Exporter>,
+    /// Opt-in telemetry reporter (from config, None unless enabled)
+    pub telemetry: Option<telemetry::Reporter>,
+    /// Terminal bell / flash per event type (from config)
+    pub alerts: AlertConfig,
+    /// Dimming of idle session rows (from config)
+    pub row_fade: RowFadeConfig,
+    /// Screen is shown inverted until this time (visual alert)
+    pub flash_until: Option<std::time::Instant>,
+    /// Message shown in the top right corner until it expires
+    pub toast: Option<Toast>,
+    /// Modification time of the config file when it was last applied
+    config_modified: Option<std::time::SystemTime>,
+    /// Last time the config file was checked for changes
+    last_config_check: std::time::Instant,
+    /// When the selection last moved with j/k
+    selection_moved_at: Option<std::time::Instant>,
+    /// Status page polling for Claude API health (from config; off when None)
+    pub api_status_config: Option<ApiStatusConfig>,
+    /// Latest API status, or why it couldn't be fetched
+    pub api_status: Option<Result<ApiStatus, String>>,
+    /// When the API status was last polled
+    last_api_status_check: Option<std::time::Instant>,
+    /// Whether an API status poll is running
+    api_status_running: bool,
+    /// Project directories left out of the folder picker (from config)
+    pub excludes: Excludes,
+    /// Write a JSON snapshot of the sessions for statuslines (from config)
+    pub snapshot: bool,
+    /// Last time the snapshot file was written
+    last_snapshot: std::time::Instant,
+    /// Coalesces repaints while agents stream output
+    pub frames: FrameLimiter,
+    /// Bytes of session output kept before old output is dropped (from config)
+    pub memory_budget: usize,
+    /// When each session was last selected, to trim the least recently viewed first
+    viewed_at: std::collections::HashMap<String, std::time::Instant>,
+    /// Last time session output was checked against the memory budget
+    last_memory_check: std::time::Instant,
+    /// Last time a focus request was looked for
+    last_focus_check: std::time::Instant,
+    /// Last time the work queue file was looked at
+    last_queue_check: std::time::Instant,
+    /// Read-only view of another amux's fleet (`amux serve --tui`): only keys
+    /// that change what's shown work
+    pub view_only: bool,
+    /// Transcript files open read-only, read as their sessions are selected
+    shared: Vec<SharedSession>,
+    /// Last time shared transcripts were refreshed
+    last_shared_refresh: Option<std::time::Instant>,
+    /// How long a Bash command runs without a result before it's flagged (from config)
+    pub long_command_after: std::time::Duration,
+    /// How long a session is idle before 'Z' kills its agent (from config)
+    pub idle_kill_after: std::time::Duration,
+    /// How long a working agent goes without output before it counts as stalled (from config)
+    pub stall_after: std::time::Duration,
+    /// Per-project threshold overrides (from config)
+    projects: Vec<ProjectConfig>,
+    /// Threshold overrides per session directory, from `projects` and `.amux.toml`
+    /// files (re-read when the config changes)
+    project_thresholds: std::collections::HashMap<PathBuf, Thresholds>,
+    /// Render without colors and with ASCII glyphs (NO_COLOR or --no-color)
+    pub plain_mode: bool,
+    /// Render one column of labeled regions for screen readers (--screen-reader)
+    pub screen_reader: bool,
+    /// Latest session status change, read out first in screen reader mode
+    pub announcement: Option<String>,
+    /// Status last announced per session ID
+    pub announced_statuses: std::collections::HashMap<String, String>,
     /// MCP servers to pass to agent sessions
     pub mcp_servers: Vec<McpServerConfig>,
     /// Whether the input is in bash mode (first char is '!')
@@ -777,15 +1361,48 @@ pub struct App {
     pub notifications: NotificationManager,
     /// Last time git diff stats were refreshed
     pub last_git_refresh: std::time::Instant,
+    /// Refresh git stats on the next tick regardless of the interval
+    pub git_refresh_due: bool,
+    /// Whether a git refresh of all sessions is running in the background
+    pub git_refresh_running: bool,
+    /// Generation of the most recently started git refresh
+    git_generation: u64,
+    /// Last time sessions the agent wrote files in were refreshed on their own
+    pub last_dirty_git_refresh: std::time::Instant,
+    /// Whether the terminal window has focus (background refreshes pause without it)
+    pub focused: bool,
+    /// Tokens used in Claude's subscription windows, once scanned (running
+    /// totals while the first scan is in progress)
+    pub usage: Option<UsageWindows>,
+    /// Progress of the first usage scan, while it runs
+    pub usage_progress: Option<ScanProgress>,
+    /// The last usage scan skipped directories or files to stay within budget
+    pub usage_truncated: bool,
+    /// Limits the usage is shown against (from config)
+    pub usage_limits: UsageLimitsConfig,
+    /// What earlier usage scans read, so the next one reads only what changed
+    pub usage_cache: std::sync::Arc<std::sync::Mutex<usage::ScanCache>>,
+    /// Last time usage was scanned (None until the first scan)
+    last_usage_refresh: Option<std::time::Instant>,
+    /// Whether a usage scan is running in the background
+    usage_refresh_running: bool,
+    /// Last time working agents' network connections were sampled
+    last_network_sample: Option<std::time::Instant>,
+    /// Whether a network sample is running in the background
+    network_sample_running: bool,
+    /// Session whose tokens were counted for the statistics popup
+    stats_tokens_for: Option<String>,
+    /// Tokens the session in the statistics popup used (None until counted,
+    /// or without a session file)
+    pub stats_tokens: Option<usage::SessionTokens>,
+    /// Step acceleration for held line-scroll keys and fast mouse wheels
+    pub scroll_accel: ScrollAccelerator,
+    /// Output index of the message selected in tagging mode
+    pub tag_cursor: Option<usize>,
 }
 
 impl App {
-    pub fn new(
-        start_dir: PathBuf,
-        worktree_config: WorktreeConfig,
-        mcp_servers: Vec<McpServerConfig>,
-        notification_config: NotificationConfig,
-    ) -> Self {
+    pub fn new(start_dir: PathBuf, worktree_config: WorktreeConfig) -> Self {
         Self {
             sessions: SessionManager::new(),
             input_mode: InputMode::Normal,
@@ -795,6 +1412,16 @@ impl App {
             folder_picker: None,
             agent_picker: None,
             session_picker: None,
+            recent_files: None,
+            process_tree: None,
+            workspace_diff: None,
+            verify_scroll: 0,
+            reply_menu: None,
+            notes_editor: None,
+            epic_input: None,
+            queue_view: None,
+            work_queue: WorkQueue::new(),
+            search_view: None,
             worktree_picker: None,
             branch_input: None,
             worktree_cleanup: None,
@@ -807,16 +1434,84 @@ impl App {
             worktree_config,
             interactions: InteractionRegistry::new(),
             session_display_order: SessionDisplayOrder::default(),
+            session_list_top: 0,
+            tabs: vec![],
             next_session_id: 1,
             sort_mode: SortMode::default(),
             log_path: None,
             session_id: None,
             debug_tool_json: false,
-            mcp_servers,
+            show_hidden: false,
+            hide_headless: false,
+            show_thinking: false,
+            show_web_bodies: false,
+            show_previews: false,
+            errors_only: false,
+            verify_command: None,
+            summary_command: None,
+            redactor: Redactor::default(),
+            reply_templates: vec![],
+            row_fields: Config::default().row_fields(),
+            audit_entries: vec![],
+            allowed_write_dirs: vec![],
+            diff_warnings: DiffWarningConfig::default(),
+            otlp: None,
+            telemetry: None,
+            alerts: AlertConfig::default(),
+            row_fade: RowFadeConfig::default(),
+            flash_until: None,
+            toast: None,
+            config_modified: config_modified(),
+            last_config_check: std::time::Instant::now(),
+            selection_moved_at: None,
+            excludes: Excludes::default(),
+            api_status_config: None,
+            api_status: None,
+            last_api_status_check: None,
+            api_status_running: false,
+            snapshot: false,
+            frames: FrameLimiter::default(),
+            memory_budget: DEFAULT_MEMORY_BUDGET_MB << 20,
+            viewed_at: std::collections::HashMap::new(),
+            last_memory_check: std::time::Instant::now(),
+            last_focus_check: std::time::Instant::now(),
+            last_queue_check: std::time::Instant::now(),
+            view_only: false,
+            shared: vec![],
+            last_shared_refresh: None,
+            long_command_after: std::time::Duration::from_secs(DEFAULT_LONG_COMMAND_MINUTES * 60),
+            idle_kill_after: std::time::Duration::from_secs(DEFAULT_IDLE_KILL_MINUTES * 60),
+            stall_after: api_status::STALL_AFTER,
+            projects: vec![],
+            project_thresholds: std::collections::HashMap::new(),
+            last_snapshot: std::time::Instant::now(),
+            plain_mode: false,
+            screen_reader: false,
+            announcement: None,
+            announced_statuses: std::collections::HashMap::new(),
+            mcp_servers: vec![],
             bash_mode: false,
             running_bash_command: None,
-            notifications: NotificationManager::new(notification_config),
+            notifications: NotificationManager::new(NotificationConfig::default()),
             last_git_refresh: std::time::Instant::now(),
+            git_refresh_due: false,
+            git_refresh_running: false,
+            git_generation: 0,
+            last_dirty_git_refresh: std::time::Instant::now(),
+            focused: true,
+            usage: None,
+            usage_progress: None,
+            usage_truncated: false,
+            usage_limits: UsageLimitsConfig::default(),
+            usage_cache: Default::default(),
+            last_usage_refresh: None,
+            usage_refresh_running: false,
+            last_network_sample: None,
+            network_sample_running: false,
+            stats_tokens_for: None,
+            stats_tokens: None,
+            scroll_accel: ScrollAccelerator::default(),
+            tag_cursor: None,
         }
     }
 
@@ -825,6 +1520,150 @@ impl App {
         self.debug_tool_json = !self.debug_tool_json;
     }
 
+    /// Toggle display of agent reasoning (thought blocks)
+    pub fn toggle_show_thinking(&mut self) {
+        self.show_thinking = !self.show_thinking;
+    }
+
+    /// Toggle display of full web tool results
+    pub fn toggle_show_web_bodies(&mut self) {
+        self.show_web_bodies = !self.show_web_bodies;
+    }
+
+    /// Toggle the errors-only conversation filter, jumping to the latest error
+    pub fn toggle_errors_only(&mut self) {
+        self.errors_only = !self.errors_only;
+        if let Some(session) = self.sessions.selected_session_mut() {
+            session.scroll_to_bottom();
+        }
+    }
+
+    /// Toggle previews of the agent's latest message in the session list
+    pub fn toggle_show_previews(&mut self) {
+        self.show_previews = !self.show_previews;
+    }
+
+    /// Export the selected session's transcript (redacted) and report the file path
+    pub fn export_selected_transcript(&mut self) {
+        let Some(session) = self.sessions.selected_session() else {
+            return;
+        };
+        // Include the output moved to disk for the memory budget
+        let result = session
+            .with_spilled_output()
+            .and_then(|session| transcript::export(&session, &self.redactor));
+
+        if let Some(session) = self.sessions.selected_session_mut() {
+            match result {
+                Ok(path) => session.add_output(
+                    format!("Transcript exported to {} (redacted)", path.display()),
+                    OutputType::SystemMessage,
+                ),
+                Err(e) => session.add_output(
+                    format!("Failed to export transcript: {}", e),
+                    OutputType::Error,
+                ),
+            }
+            session.scroll_to_bottom();
+        }
+    }
+
+    /// Archive the selected session's transcript and metadata to ~/.amux/archive
+    pub fn archive_selected(&mut self) {
+        let Some(session) = self.sessions.selected_session() else {
+            return;
+        };
+        // Include the output moved to disk for the memory budget
+        let result = session
+            .with_spilled_output()
+            .and_then(|session| archive::archive(&session));
+
+        if let Some(session) = self.sessions.selected_session_mut() {
+            match result {
+                Ok(path) => session.add_output(
+                    format!("Session archived to {}", path.display()),
+                    OutputType::SystemMessage,
+                ),
+                Err(e) => session.add_output(
+                    format!("Failed to archive session: {}", e),
+                    OutputType::Error,
+                ),
+            }
+            session.scroll_to_bottom();
+        }
+    }
+
+    /// Hide or unhide the selected session in the session list
+    pub fn toggle_hide_selected(&mut self) {
+        if let Some(session) = self.sessions.selected_session_mut() {
+            session.hidden = !session.hidden;
+            // Remember it for when the session is resumed
+            if let Some(id) = &session.acp_session_id
+                && let Err(e) = hidden::set_hidden(id, session.hidden)
+            {
+                log::log(&format!("Failed to save hidden sessions: {}", e));
+            }
+        }
+        // Move off the session if it just disappeared from the list
+        self.save_input_to_session();
+        self.skip_hidden_sessions(true);
+        self.restore_input_from_session();
+    }
+
+    /// Mute or unmute desktop notifications for the selected session
+    pub fn toggle_mute_selected(&mut self) {
+        if let Some(session) = self.sessions.selected_session() {
+            let name = session.name.clone();
+            self.notifications.toggle_mute(&name);
+        }
+    }
+
+    /// Toggle whether hidden sessions are shown in the session list
+    pub fn toggle_show_hidden(&mut self) {
+        self.show_hidden = !self.show_hidden;
+        self.save_input_to_session();
+        self.skip_hidden_sessions(true);
+        self.restore_input_from_session();
+    }
+
+    /// Toggle whether headless (Agent SDK / CI) sessions are left out of the session list
+    pub fn toggle_hide_headless(&mut self) {
+        self.hide_headless = !self.hide_headless;
+        self.save_input_to_session();
+        self.skip_hidden_sessions(true);
+        self.restore_input_from_session();
+    }
+
+    /// Number of sessions currently hidden
+    pub fn hidden_session_count(&self) -> usize {
+        self.sessions.sessions().iter().filter(|s| s.hidden).count()
+    }
+
+    /// Whether a session shows up in the session list (interrupted sessions
+    /// always do, so they can be resumed)
+    pub fn is_listed(&self, session: &Session) -> bool {
+        session.interrupted
+            || ((self.show_hidden || !session.hidden) && !(self.hide_headless && session.headless))
+    }
+
+    /// Advance the selection past sessions left out of the list
+    fn skip_hidden_sessions(&mut self, forward: bool) {
+        for _ in 0..self.sessions.len() {
+            if self
+                .sessions
+                .selected_session()
+                .is_none_or(|s| self.is_listed(s))
+            {
+                return;
+            }
+            if forward {
+                self.sessions.select_next();
+            } else {
+                self.sessions.select_prev();
+            }
+        }
+    }
+
     /// Get the internal session index for a display index (1-9 hotkeys)
     /// Returns None if the display index is out of bounds
     pub fn internal_index_for_display(&self, display_idx: usize) -> Option<usize> {
@@ -901,27 +1740,586 @@ impl App {
         }
     }
 
-    /// Advance spinner animation (every other tick to slow it down)
-    pub fn tick_spinner(&mut self) {
-        self.spinner_tick += 1;
-        if self.spinner_tick.is_multiple_of(2) {
-            self.spinner_frame = (self.spinner_frame + 1) % SPINNER_FRAMES.len();
+    /// Ring the bell and/or flash the screen as configured for an event
+    pub fn alert(&mut self, event: AlertEvent) {
+        use std::io::Write;
+
+        let cue = self.alerts.cue(event);
+        if cue.bell() {
+            let mut stdout = std::io::stdout();
+            let _ = stdout.write_all(b"\x07");
+            let _ = stdout.flush();
+        }
+        if cue.flash() {
+            self.flash_until = Some(std::time::Instant::now() + FLASH_DURATION);
         }
     }
 
-    /// Get current spinner character
-    pub fn spinner(&self) -> &'static str {
-        SPINNER_FRAMES[self.spinner_frame]
+    /// Show a message in the top right corner for a few seconds
+    pub fn show_toast(&mut self, message: impl Into<String>, is_error: bool) {
+        let duration = if is_error {
+            TOAST_DURATION * 2
+        } else {
+            TOAST_DURATION
+        };
+        self.toast = Some(Toast {
+            message: message.into(),
+            is_error,
+            until: std::time::Instant::now() + duration,
+        });
     }
 
-    /// Check if git diff stats should be refreshed (every 5 seconds)
-    pub fn should_refresh_git_stats(&self) -> bool {
-        self.last_git_refresh.elapsed() >= std::time::Duration::from_secs(5)
+    /// The toast to show, unless it has expired
+    pub fn visible_toast(&self) -> Option<&Toast> {
+        self.toast
+            .as_ref()
+            .filter(|toast| std::time::Instant::now() < toast.until)
     }
 
-    /// Mark that git stats were just refreshed
-    pub fn mark_git_refreshed(&mut self) {
+    /// Apply the settings read from the config file. Called at startup and
+    /// whenever the file changes; the worktree directory, default agent and
+    /// git limits are only read at startup.
+    pub fn apply_config(&mut self, config: Config) {
+        self.reply_templates = config.reply_templates();
+        self.row_fields = config.row_fields();
+        self.verify_command = config.verify_command;
+        self.summary_command = config.summary_command;
+        self.allowed_write_dirs = config
+            .allowed_write_dirs
+            .iter()
+            .map(|dir| scope::expand_home(dir))
+            .collect();
+        self.diff_warnings = config.diff_warnings;
+        self.otlp = config.otlp.as_ref().and_then(otlp::Exporter::new);
+        // Keep the running reporter (and its run's numbers) while the endpoint stays
+        let telemetry = config.telemetry.as_ref().filter(|t| t.enabled);
+        if telemetry.map(|t| t.endpoint.as_str()) != self.telemetry.as_ref().map(|r| r.url.as_str())
+        {
+            self.telemetry = telemetry.and_then(telemetry::Reporter::new);
+        }
+        telemetry::set_crash_reports(self.telemetry.is_some());
+        self.alerts = config.alerts;
+        self.row_fade = config.row_fade;
+        self.usage_limits = config.usage_limits;
+        if !self.usage_limits.is_set() {
+            // Limits removed: stop showing usage nothing measures anymore
+            self.usage = None;
+            self.usage_progress = None;
+        }
+        self.redactor = Redactor::new(&config.redaction);
+        self.snapshot = config.snapshot;
+        self.excludes = Excludes::new(&config.exclude);
+        self.frames
+            .set_max_fps(config.max_fps.unwrap_or(frame::DEFAULT_MAX_FPS));
+        self.memory_budget = config
+            .memory_budget_mb
+            .unwrap_or(DEFAULT_MEMORY_BUDGET_MB)
+            .max(1)
+            << 20;
+        self.long_command_after = std::time::Duration::from_secs(
+            config
+                .long_command_minutes
+                .unwrap_or(DEFAULT_LONG_COMMAND_MINUTES)
+                .max(1)
+                * 60,
+        );
+        self.idle_kill_after = std::time::Duration::from_secs(
+            config
+                .idle_kill_minutes
+                .unwrap_or(DEFAULT_IDLE_KILL_MINUTES)
+                .max(1)
+                * 60,
+        );
+        self.stall_after = config
+            .stall_secs
+            .map_or(api_status::STALL_AFTER, std::time::Duration::from_secs);
+        self.projects = config.projects;
+        self.project_thresholds.clear();
+        if config.api_status.is_none() {
+            self.api_status = None;
+        }
+        self.api_status_config = config.api_status;
+        // New sessions get the new servers; running agents keep theirs
+        self.mcp_servers = config.mcp_servers;
+        self.notifications.set_config(config.notifications.into());
+    }
+
+    /// Approximate memory held by the output of all sessions, in bytes
+    pub fn memory_usage(&self) -> usize {
+        self.sessions
+            .sessions()
+            .iter()
+            .map(|s| s.output_bytes())
+            .sum()
+    }
+
+    /// Keep session output within the memory budget: once over it, move the
+    /// oldest output of idle sessions to disk, least recently viewed first.
+    /// The selected session, working agents and sessions scrolled back (whose
+    /// place would move) are never trimmed.
+    pub fn enforce_memory_budget(&mut self) {
+        let now = std::time::Instant::now();
+        let selected_id = self.sessions.selected_session().map(|s| s.id.clone());
+        if let Some(id) = &selected_id {
+            self.viewed_at.insert(id.clone(), now);
+        }
+        if self.last_memory_check.elapsed() < MEMORY_CHECK_INTERVAL {
+            return;
+        }
+        self.last_memory_check = now;
+
+        let mut usage = self.memory_usage();
+        if usage <= self.memory_budget {
+            return;
+        }
+        let mut candidates: Vec<(Option<std::time::Instant>, String)> = self
+            .sessions
+            .sessions()
+            .iter()
+            .filter(|s| {
+                Some(&s.id) != selected_id.as_ref()
+                    && !s.state.is_active()
+                    && s.is_following()
+                    && s.output.len() > MIN_KEPT_LINES
+            })
+            .map(|s| (self.viewed_at.get(&s.id).copied(), s.id.clone()))
+            .collect();
+        // Never viewed (None) sorts first
+        candidates.sort();
+
+        for (_, id) in candidates {
+            if usage <= self.memory_budget {
+                break;
+            }
+            if let Some(session) = self.sessions.get_by_id_mut(&id) {
+                let before = session.output_bytes();
+                match session.trim_output(MIN_KEPT_LINES) {
+                    Ok(moved) => log::log(&format!(
+                        "Moved {} output lines of {} to disk to stay within the memory budget",
+                        moved, session.name
+                    )),
+                    Err(e) => log::log(&format!(
+                        "Failed to move output of {} to disk: {}",
+                        session.name, e
+                    )),
+                }
+                usage -= before - session.output_bytes();
+            }
+        }
+    }
+
+    /// Select the session a focus link asked for (see [`focus`])
+    pub fn check_focus_request(&mut self) {
+        if self.view_only || self.last_focus_check.elapsed() < FOCUS_CHECK_INTERVAL {
+            return;
+        }
+        self.last_focus_check = std::time::Instant::now();
+        let Some(name) = focus::take_request() else {
+            return;
+        };
+        match self.sessions.sessions().iter().position(|s| s.name == name) {
+            Some(index) => {
+                self.select_session(index);
+                self.show_toast(format!("Jumped to {}", name), false);
+            }
+            None => self.show_toast(format!("No session named {}", name), true),
+        }
+    }
+
+    /// Flag agents whose Bash command has been running longer than their
+    /// project's `long_command_after` without a result, notifying once per command
+    pub fn check_long_commands(&mut self) {
+        let thresholds: Vec<std::time::Duration> = self
+            .sessions
+            .sessions()
+            .iter()
+            .map(|s| self.long_command_after_for(s))
+            .collect();
+        for (session, threshold) in self.sessions.sessions_mut().iter_mut().zip(thresholds) {
+            if session.long_command.is_some() {
+                continue;
+            }
+            let Some((command, elapsed)) = session.running_command() else {
+                continue;
+            };
+            if elapsed < threshold {
+                continue;
+            }
+            log::log(&format!(
+                "{}: `{}` running for {}m without a result",
+                session.name,
+                command,
+                elapsed.as_secs() / 60
+            ));
+            self.notifications
+                .notify_long_command(&session.name, &command, elapsed);
+            session.long_command = Some(command);
+        }
+    }
+
+    /// Look up the threshold overrides of session directories not seen yet
+    pub fn load_project_thresholds(&mut self) {
+        for session in self.sessions.sessions() {
+            if !self.project_thresholds.contains_key(&session.cwd) {
+                let thresholds = project_thresholds(&self.projects, &session.cwd);
+                self.project_thresholds
+                    .insert(session.cwd.clone(), thresholds);
+            }
+        }
+    }
+
+    /// Threshold overrides of the project a session works in
+    fn thresholds_for(&self, session: &Session) -> Thresholds {
+        self.project_thresholds
+            .get(&session.cwd)
+            .copied()
+            .unwrap_or_default()
+    }
+
+    /// How long a session's Bash command runs before it's flagged
+    pub fn long_command_after_for(&self, session: &Session) -> std::time::Duration {
+        self.thresholds_for(session)
+            .long_command_minutes
+            .map_or(self.long_command_after, |minutes| {
+                std::time::Duration::from_secs(minutes.max(1) * 60)
+            })
+    }
+
+    /// How long a session is idle before 'Z' kills its agent
+    pub fn idle_kill_after_for(&self, session: &Session) -> std::time::Duration {
+        self.thresholds_for(session)
+            .idle_kill_minutes
+            .map_or(self.idle_kill_after, |minutes| {
+                std::time::Duration::from_secs(minutes.max(1) * 60)
+            })
+    }
+
+    /// How long a session's agent works without output before it counts as stalled
+    pub fn stall_after_for(&self, session: &Session) -> std::time::Duration {
+        self.thresholds_for(session)
+            .stall_secs
+            .map_or(self.stall_after, std::time::Duration::from_secs)
+    }
+
+    /// Whether a session's agent is working but has gone without output for
+    /// longer than its stall threshold
+    pub fn is_stalled(&self, session: &Session) -> bool {
+        session.state == SessionState::Prompting
+            && session
+                .last_activity
+                .is_some_and(|at| at.elapsed() >= self.stall_after_for(session))
+    }
+
+    /// Write the snapshot file if it's enabled and due
+    pub fn write_snapshot_if_due(&mut self) {
+        if !self.snapshot || self.view_only || self.last_snapshot.elapsed() < SNAPSHOT_INTERVAL {
+            return;
+        }
+        self.last_snapshot = std::time::Instant::now();
+        let snapshot = snapshot::Snapshot::from_sessions(self.sessions.sessions());
+        if let Err(e) = snapshot::write(&snapshot::snapshot_path(), &snapshot) {
+            log::log(&format!("Failed to write session snapshot: {}", e));
+        }
+    }
+
+    /// Reload the config file if it changed since it was last applied. A
+    /// file that fails to parse or validate leaves the running settings alone.
+    pub fn check_config_reload(&mut self) {
+        if self.last_config_check.elapsed() < CONFIG_CHECK_INTERVAL {
+            return;
+        }
+        self.last_config_check = std::time::Instant::now();
+        let modified = config_modified();
+        if modified == self.config_modified {
+            return;
+        }
+        self.config_modified = modified;
+        match Config::reload() {
+            Ok(config) => {
+                self.apply_config(config);
+                log::log("Config reloaded");
+                self.show_toast("Config reloaded", false);
+            }
+            Err(e) => {
+                log::log(&format!("Config not reloaded: {:#}", e));
+                // Parse errors span several lines (with the offending snippet)
+                let reason = e.to_string().lines().next().unwrap_or_default().to_string();
+                self.show_toast(format!("Config not reloaded: {}", reason), true);
+            }
+        }
+    }
+
+    /// Whether a visual alert is showing
+    pub fn is_flashing(&self) -> bool {
+        self.flash_until
+            .is_some_and(|until| std::time::Instant::now() < until)
+    }
+
+    /// Advance spinner animation (every other tick to slow it down)
+    pub fn tick_spinner(&mut self) {
+        self.spinner_tick += 1;
+        if self.spinner_tick.is_multiple_of(2) {
+            self.spinner_frame = (self.spinner_frame + 1) % SPINNER_FRAMES.len();
+        }
+    }
+
+    /// Get current spinner character
+    pub fn spinner(&self) -> &'static str {
+        SPINNER_FRAMES[self.spinner_frame]
+    }
+
+    /// Check if git diff stats should be refreshed (every 5 seconds, paused while unfocused).
+    /// Refreshes that come due while one is running are coalesced into one after it.
+    pub fn should_refresh_git_stats(&self) -> bool {
+        self.focused
+            && !self.git_refresh_running
+            && (self.git_refresh_due
+                || self.last_git_refresh.elapsed() >= std::time::Duration::from_secs(5))
+    }
+
+    /// Mark that git stats were just refreshed
+    pub fn mark_git_refreshed(&mut self) {
         self.last_git_refresh = std::time::Instant::now();
+        self.git_refresh_due = false;
+    }
+
+    /// Start a background git refresh of all sessions; returns its generation
+    pub fn start_git_refresh(&mut self) -> u64 {
+        self.mark_git_refreshed();
+        self.git_refresh_running = true;
+        self.next_git_generation()
+    }
+
+    /// A background git refresh of all sessions finished
+    pub fn finish_git_refresh(&mut self) {
+        self.git_refresh_running = false;
+    }
+
+    /// Take the sessions whose agent wrote files since their last git refresh,
+    /// as (id, cwd, branch, created_at), at most once a second. Only these
+    /// sessions are refreshed, leaving the rest of the list alone.
+    pub fn take_dirty_git_sessions(
+        &mut self,
+    ) -> Vec<(String, PathBuf, String, std::time::SystemTime)> {
+        if !self.focused
+            || self.last_dirty_git_refresh.elapsed() < std::time::Duration::from_secs(1)
+        {
+            return vec![];
+        }
+        let mut dirty = vec![];
+        for session in self.sessions.sessions_mut() {
+            if session.git_dirty && !session.git_branch.is_empty() {
+                session.git_dirty = false;
+                dirty.push((
+                    session.id.clone(),
+                    session.cwd.clone(),
+                    session.git_branch.clone(),
+                    session.created_at,
+                ));
+            }
+        }
+        if !dirty.is_empty() {
+            self.last_dirty_git_refresh = std::time::Instant::now();
+        }
+        dirty
+    }
+
+    /// Start a background usage scan if one is due (every minute, paused while
+    /// unfocused, and never without usage limits to show it against)
+    pub fn start_usage_refresh(&mut self) -> bool {
+        let due = self
+            .last_usage_refresh
+            .is_none_or(|last| last.elapsed() >= std::time::Duration::from_secs(60));
+        if !self.usage_limits.is_set() || !self.focused || self.usage_refresh_running || !due {
+            return false;
+        }
+        self.last_usage_refresh = Some(std::time::Instant::now());
+        self.usage_refresh_running = true;
+        true
+    }
+
+    /// PIDs of the working agents to sample for network connections, if a
+    /// sample is due (every 3 seconds while any agent is working)
+    pub fn start_network_sample(&mut self) -> Option<Vec<u32>> {
+        let due = self
+            .last_network_sample
+            .is_none_or(|last| last.elapsed() >= std::time::Duration::from_secs(3));
+        if self.network_sample_running || !due {
+            return None;
+        }
+        let pids: Vec<u32> = self
+            .sessions
+            .sessions()
+            .iter()
+            .filter(|s| s.state == SessionState::Prompting)
+            .filter_map(|s| s.agent_pid)
+            .collect();
+        if pids.is_empty() {
+            for session in self.sessions.sessions_mut() {
+                session.streaming = false;
+            }
+            return None;
+        }
+        self.last_network_sample = Some(std::time::Instant::now());
+        self.network_sample_running = true;
+        Some(pids)
+    }
+
+    /// A network sample finished: the agents (by PID) with a connection open
+    pub fn update_network_sample(&mut self, connected: std::collections::HashSet<u32>) {
+        self.network_sample_running = false;
+        for session in self.sessions.sessions_mut() {
+            session.streaming = session.state == SessionState::Prompting
+                && session
+                    .agent_pid
+                    .is_some_and(|pid| connected.contains(&pid));
+        }
+    }
+
+    /// Note the number of open sessions for telemetry
+    pub fn observe_session_count(&mut self) {
+        let open = self.sessions.sessions().len();
+        if let Some(telemetry) = &mut self.telemetry {
+            telemetry.observe_sessions(open);
+        }
+    }
+
+    /// Start polling the API status page if it's enabled and due; returns its URL
+    pub fn start_api_status_check(&mut self) -> Option<String> {
+        let config = self.api_status_config.as_ref()?;
+        let due = self
+            .last_api_status_check
+            .is_none_or(|last| last.elapsed() >= config.interval());
+        if self.api_status_running || !due {
+            return None;
+        }
+        self.last_api_status_check = Some(std::time::Instant::now());
+        self.api_status_running = true;
+        Some(config.url.clone())
+    }
+
+    /// An API status poll finished
+    pub fn update_api_status(&mut self, result: Result<ApiStatus, String>) {
+        self.api_status_running = false;
+        if let Err(e) = &result {
+            log::log(&format!("API status check failed: {}", e));
+        }
+        // Settings may have been reloaded without a status page meanwhile
+        if self.api_status_config.is_some() {
+            self.api_status = Some(result);
+        }
+    }
+
+    /// Number of agents working on a turn without output for a while
+    pub fn stalled_session_count(&self) -> usize {
+        self.sessions
+            .sessions()
+            .iter()
+            .filter(|s| self.is_stalled(s))
+            .count()
+    }
+
+    /// When queued prompts may be dispatched again, while the usage quota holds
+    /// them back (`dispatch_max_percent` in `[usage_limits]`)
+    pub fn dispatch_held_until(&self) -> Option<chrono::DateTime<chrono::Local>> {
+        let limit = self.usage_limits.five_hour_tokens?;
+        let max_percent = self.usage_limits.dispatch_max_percent?;
+        let windows = self.usage.as_ref()?;
+        usage::dispatch_hold(windows, limit, max_percent, chrono::Utc::now())
+            .map(|at| at.with_timezone(&chrono::Local))
+    }
+
+    /// Read work orders enqueued from the command line; returns whether
+    /// idle agents should be checked for orders (every QUEUE_CHECK_INTERVAL)
+    pub fn check_work_queue(&mut self) -> bool {
+        if self.view_only || self.last_queue_check.elapsed() < QUEUE_CHECK_INTERVAL {
+            return false;
+        }
+        self.last_queue_check = std::time::Instant::now();
+        self.work_queue.reload();
+        !self.work_queue.orders().is_empty()
+    }
+
+    /// Oldest work order a session takes once idle. Agents waiting on an
+    /// answer, interrupted or read-only sessions and the read-only view take none.
+    pub fn work_order_for(&self, session: &Session) -> Option<&WorkOrder> {
+        if self.view_only || session.read_only || session.needs_input || session.interrupted {
+            return None;
+        }
+        self.work_queue.next_for(&session.cwd)
+    }
+
+    /// Take the next prompt for an idle session: its own queued prompts
+    /// first, then its project's oldest work order. Dispatches are logged
+    /// in the event log.
+    pub fn next_queued_prompt(&mut self, session_id: &str) -> Option<String> {
+        let session = self.sessions.get_by_id_mut(session_id)?;
+        if session.state != SessionState::Idle {
+            return None;
+        }
+        if let Some(text) = session.queued_prompts.pop_front() {
+            log::log_event(&format!(
+                "Dispatching queued prompt to {} ({} remaining)",
+                session.name,
+                session.queued_prompts.len()
+            ));
+            return Some(text);
+        }
+
+        let session = self.sessions.get_by_id(session_id)?;
+        self.work_order_for(session)?;
+        let (name, cwd) = (session.name.clone(), session.cwd.clone());
+        match self.work_queue.take_for(&cwd) {
+            Ok(Some(order)) => {
+                log::log_event(&format!(
+                    "Dispatching work order for {} to {}: {}",
+                    order.project.display(),
+                    name,
+                    order.text
+                ));
+                Some(order.text)
+            }
+            Ok(None) => None,
+            Err(e) => {
+                log::log(&format!("Failed to update the work queue: {}", e));
+                None
+            }
+        }
+    }
+
+    /// A background usage scan reported its totals. The first scan's running
+    /// totals are shown as they come in; later scans replace the totals when done.
+    pub fn update_usage_refresh(&mut self, usage: UsageWindows, progress: ScanProgress) {
+        if progress.is_done() {
+            if let Some(telemetry) = &self.telemetry
+                && let Some(started) = self.last_usage_refresh
+            {
+                telemetry.usage_scanned(started.elapsed(), &progress);
+            }
+            self.usage = Some(usage);
+            self.usage_progress = None;
+            self.usage_truncated = progress.truncated;
+            self.usage_refresh_running = false;
+        } else if self.usage.is_none() || self.usage_progress.is_some() {
+            self.usage = Some(usage);
+            self.usage_progress = Some(progress);
+        }
+    }
+
+    /// Generation for a new git refresh. Results are applied to a session only
+    /// if it hasn't seen a newer generation, so slow refreshes can't overwrite
+    /// fresher results that landed first.
+    pub fn next_git_generation(&mut self) -> u64 {
+        self.git_generation += 1;
+        self.git_generation
+    }
+
+    /// Track terminal focus; regaining it refreshes right away
+    pub fn set_focused(&mut self, focused: bool) {
+        if focused && !self.focused {
+            self.git_refresh_due = true;
+        }
+        self.focused = focused;
     }
 
     /// Open the folder picker starting at the given directory
@@ -937,7 +2335,8 @@ impl App {
     }
 
     /// Update folder picker entries (called after scanning directory)
-    pub fn set_folder_entries(&mut self, entries: Vec<FolderEntry>) {
+    pub fn set_folder_entries(&mut self, mut entries: Vec<FolderEntry>) {
+        entries.retain(|e| e.is_current || e.is_parent || !self.excludes.is_excluded(&e.path));
         if let Some(picker) = &mut self.folder_picker {
             picker.all_entries = entries;
             picker.query.clear();
@@ -1002,6 +2401,139 @@ impl App {
         self.input_mode = InputMode::Normal;
     }
 
+    /// Open the recently written files browser for the selected session
+    pub fn open_recent_files(&mut self) {
+        if let Some(session) = self.sessions.selected_session() {
+            self.recent_files = Some(RecentFilesState::new(session.recent_files.clone()));
+            self.input_mode = InputMode::RecentFiles;
+        }
+    }
+
+    /// Close the recently written files browser
+    pub fn close_recent_files(&mut self) {
+        self.recent_files = None;
+        self.input_mode = InputMode::Normal;
+    }
+
+    /// Open the process tree below the selected session's agent
+    pub fn open_process_tree(&mut self) {
+        let Some(session) = self.sessions.selected_session() else {
+            return;
+        };
+        match session.agent_pid {
+            Some(pid) => {
+                self.process_tree = Some(ProcessTreeState::new(pid));
+                self.input_mode = InputMode::ProcessTree;
+            }
+            None => self.show_toast("No agent process", true),
+        }
+    }
+
+    /// Close the process tree
+    pub fn close_process_tree(&mut self) {
+        self.process_tree = None;
+        self.input_mode = InputMode::Normal;
+    }
+
+    /// Collapse or expand the children of the selected process
+    pub fn toggle_process_collapsed(&mut self) {
+        if let Some(tree) = &mut self.process_tree
+            && let Some(row) = tree.selected_item()
+            && row.children > 0
+        {
+            let pid = row.process.pid;
+            if !tree.collapsed.remove(&pid) {
+                tree.collapsed.insert(pid);
+            }
+            tree.rebuild();
+        }
+    }
+
+    /// Start listing processes in the background if the process tree is open
+    /// and due (every 2 seconds)
+    pub fn start_process_refresh(&mut self) -> bool {
+        let Some(tree) = &mut self.process_tree else {
+            return false;
+        };
+        let due = tree
+            .last_refresh
+            .is_none_or(|last| last.elapsed() >= std::time::Duration::from_secs(2));
+        if tree.refresh_running || !due {
+            return false;
+        }
+        tree.last_refresh = Some(std::time::Instant::now());
+        tree.refresh_running = true;
+        true
+    }
+
+    /// A background process listing finished
+    pub fn update_processes(&mut self, processes: Result<Vec<Process>, String>) {
+        if let Some(tree) = &mut self.process_tree {
+            tree.processes = Some(processes);
+            tree.refresh_running = false;
+            tree.rebuild();
+        }
+    }
+
+    /// Open the work tree snapshots of the selected session for diffing
+    pub fn open_workspace_diff(&mut self) {
+        if let Some(session) = self.sessions.selected_session() {
+            self.workspace_diff =
+                Some(WorkspaceDiffState::new(session.workspace_snapshots.clone()));
+            self.input_mode = InputMode::WorkspaceDiff;
+        }
+    }
+
+    /// Close the snapshot diff view
+    pub fn close_workspace_diff(&mut self) {
+        self.workspace_diff = None;
+        self.input_mode = InputMode::Normal;
+    }
+
+    /// Open the quick reply menu for the selected session
+    pub fn open_reply_menu(&mut self) {
+        // Transcripts opened with `amux view` have no agent to reply to
+        if self
+            .sessions
+            .selected_session()
+            .is_some_and(|s| !s.read_only)
+            && !self.reply_templates.is_empty()
+        {
+            self.reply_menu = Some(ReplyTemplatesState {
+                templates: self.reply_templates.clone(),
+                selected: 0,
+            });
+            self.input_mode = InputMode::ReplyTemplates;
+        }
+    }
+
+    /// Close the quick reply menu
+    pub fn close_reply_menu(&mut self) {
+        self.reply_menu = None;
+        self.input_mode = InputMode::Normal;
+    }
+
+    /// Close the quick reply menu, returning the chosen template (by number if
+    /// given, otherwise the selected one)
+    pub fn take_reply_template(&mut self, number: Option<usize>) -> Option<String> {
+        let menu = self.reply_menu.take()?;
+        self.input_mode = InputMode::Normal;
+        match number {
+            Some(n) => menu.templates.get(n.checked_sub(1)?).cloned(),
+            None => menu.selected_item().cloned(),
+        }
+    }
+
+    /// Put the selected template into the prompt at the cursor for editing
+    pub fn draft_reply_template(&mut self) {
+        if let Some(template) = self.take_reply_template(None) {
+            self.input_buffer
+                .insert_str(self.cursor_position, &template);
+            self.cursor_position += template.len();
+            self.enter_insert_mode();
+        }
+    }
+
     /// Open the worktree picker with existing worktrees
     pub fn open_worktree_picker(&mut self, entries: Vec<WorktreeEntry>) {
         self.worktree_picker = Some(WorktreePickerState::new(entries));
@@ -1052,6 +2584,600 @@ impl App {
         self.input_mode = InputMode::Normal;
     }
 
+    /// Open the conversation statistics popup for the selected session
+    pub fn open_stats(&mut self) {
+        if self.sessions.selected_session().is_some() {
+            self.stats_tokens_for = None;
+            self.stats_tokens = None;
+            self.input_mode = InputMode::Stats;
+        }
+    }
+
+    /// Session file of the session in the statistics popup, if its tokens
+    /// haven't been counted yet
+    pub fn start_token_count(&mut self) -> Option<(String, PathBuf)> {
+        if self.input_mode != InputMode::Stats || self.stats_tokens_for.is_some() {
+            return None;
+        }
+        let session = self.sessions.selected_session()?;
+        self.stats_tokens_for = Some(session.id.clone());
+        let session_id = session.acp_session_id.as_deref()?;
+        let path = serve::find_session_file(&usage::projects_dir()?, session_id)?;
+        Some((session.id.clone(), path))
+    }
+
+    /// Tokens of a session were counted for the statistics popup
+    pub fn update_stats_tokens(&mut self, session_id: &str, tokens: usage::SessionTokens) {
+        if self.stats_tokens_for.as_deref() == Some(session_id) {
+            self.stats_tokens = Some(tokens);
+        }
+    }
+
+    /// Close the conversation statistics popup
+    pub fn close_stats(&mut self) {
+        self.input_mode = InputMode::Normal;
+    }
+
+    /// Edit the selected session's scratchpad notes
+    pub fn open_notes(&mut self) {
+        if let Some(session) = self.sessions.selected_session() {
+            self.notes_editor = Some(TextEditState::new(session.notes.clone()));
+            self.input_mode = InputMode::Notes;
+        }
+    }
+
+    /// Close the notes editor, keeping the edited notes. They're saved to
+    /// disk once the agent-side session ID is known.
+    pub fn close_notes(&mut self) {
+        self.input_mode = InputMode::Normal;
+        let Some(editor) = self.notes_editor.take() else {
+            return;
+        };
+        let Some(session) = self.sessions.selected_session_mut() else {
+            return;
+        };
+        session.notes = editor.text.trim_end().to_string();
+        if let Some(id) = &session.acp_session_id
+            && let Err(e) = notes::save(id, &session.notes)
+        {
+            log::log(&format!("Failed to save notes: {}", e));
+        }
+    }
+
+    /// Names of the epics sessions are linked to, sorted
+    pub fn epics(&self) -> Vec<String> {
+        let mut epics: Vec<String> = self
+            .sessions
+            .sessions()
+            .iter()
+            .filter_map(|s| s.epic.clone())
+            .collect();
+        epics.sort();
+        epics.dedup();
+        epics
+    }
+
+    /// Link the selected session to an epic, starting from its current one
+    pub fn open_epic_input(&mut self) {
+        if let Some(session) = self.sessions.selected_session() {
+            let epic = session.epic.clone().unwrap_or_default();
+            self.epic_input = Some(TextEditState::new(epic));
+            self.input_mode = InputMode::EpicInput;
+        }
+    }
+
+    pub fn close_epic_input(&mut self) {
+        self.epic_input = None;
+        self.input_mode = InputMode::Normal;
+    }
+
+    /// Complete the epic name to the first existing epic it's a prefix of
+    pub fn complete_epic_input(&mut self) {
+        let epics = self.epics();
+        if let Some(input) = &mut self.epic_input
+            && let Some(epic) = epics.iter().find(|e| e.starts_with(input.text.trim()))
+        {
+            *input = TextEditState::new(epic.clone());
+        }
+    }
+
+    /// Link the selected session to the entered epic (an empty name unlinks it)
+    pub fn submit_epic_input(&mut self) {
+        let Some(input) = self.epic_input.take() else {
+            return;
+        };
+        self.input_mode = InputMode::Normal;
+        let name = input.text.trim();
+        if let Some(session) = self.sessions.selected_session_mut() {
+            session.epic = (!name.is_empty()).then(|| name.to_string());
+        }
+    }
+
+    /// Open the audit log, reading the most recent entries from disk
+    pub fn open_audit_log(&mut self) {
+        self.audit_entries = audit::recent(AUDIT_LOG_ENTRIES);
+        self.input_mode = InputMode::AuditLog;
+    }
+
+    /// Close the audit log
+    pub fn close_audit_log(&mut self) {
+        self.audit_entries.clear();
+        self.input_mode = InputMode::Normal;
+    }
+
+    /// Open the plan history timeline for the selected session
+    pub fn open_plan_history(&mut self) {
+        if self.sessions.selected_session().is_some() {
+            self.input_mode = InputMode::PlanHistory;
+        }
+    }
+
+    /// Close the plan history timeline
+    pub fn close_plan_history(&mut self) {
+        self.input_mode = InputMode::Normal;
+    }
+
+    /// Open the verify output of the selected session, following its end
+    pub fn open_verify_output(&mut self) {
+        if self.sessions.selected_session().is_some() {
+            self.verify_scroll = 0;
+            self.input_mode = InputMode::VerifyOutput;
+        }
+    }
+
+    /// Close the verify output
+    pub fn close_verify_output(&mut self) {
+        self.input_mode = InputMode::Normal;
+    }
+
+    /// Scroll the verify output by `delta` lines (positive scrolls back)
+    pub fn scroll_verify_output(&mut self, delta: isize) {
+        let lines = self
+            .sessions
+            .selected_session()
+            .map_or(0, |s| s.verify_output.len());
+        self.verify_scroll = self
+            .verify_scroll
+            .saturating_add_signed(delta)
+            .min(lines.saturating_sub(1));
+    }
+
+    /// Prompts queued per session, then work orders oldest first
+    pub fn queue_items(&self) -> Vec<QueueItem> {
+        let prompts = self.sessions.sessions().iter().flat_map(|session| {
+            session
+                .queued_prompts
+                .iter()
+                .enumerate()
+                .map(|(index, text)| QueueItem::Prompt {
+                    session_id: session.id.clone(),
+                    index,
+                    text: text.clone(),
+                })
+        });
+        let orders = self
+            .work_queue
+            .orders()
+            .iter()
+            .cloned()
+            .map(QueueItem::Order);
+        prompts.chain(orders).collect()
+    }
+
+    /// Open the queue view
+    pub fn open_queue_view(&mut self) {
+        self.work_queue.reload();
+        self.queue_view = Some(QueueViewState::default());
+        self.input_mode = InputMode::Queue;
+    }
+
+    pub fn close_queue_view(&mut self) {
+        self.queue_view = None;
+        self.input_mode = InputMode::Normal;
+    }
+
+    /// Move the queue view's selection by `delta` items
+    pub fn move_queue_selection(&mut self, delta: isize) {
+        let count = self.queue_items().len();
+        if let Some(view) = &mut self.queue_view {
+            view.selected = view
+                .selected
+                .saturating_add_signed(delta)
+                .min(count.saturating_sub(1));
+        }
+    }
+
+    fn selected_queue_item(&self) -> Option<QueueItem> {
+        let selected = self.queue_view.as_ref()?.selected;
+        self.queue_items().into_iter().nth(selected)
+    }
+
+    /// Cancel the selected prompt or work order
+    pub fn cancel_queue_item(&mut self) {
+        let Some(item) = self.selected_queue_item() else {
+            return;
+        };
+        let removed = match &item {
+            QueueItem::Prompt {
+                session_id,
+                index,
+                text,
+            } => self
+                .sessions
+                .get_by_id_mut(session_id)
+                .filter(|s| s.queued_prompts.get(*index) == Some(text))
+                .and_then(|s| s.queued_prompts.remove(*index))
+                .is_some(),
+            QueueItem::Order(order) => self.work_queue.remove(order).unwrap_or(false),
+        };
+        if removed {
+            log::log_event(&format!("Cancelled queued work: {}", item.text()));
+        } else {
+            self.show_toast("Already dispatched", true);
+        }
+        self.move_queue_selection(0);
+    }
+
+    /// Edit the selected prompt or work order
+    pub fn edit_queue_item(&mut self) {
+        let Some(item) = self.selected_queue_item() else {
+            return;
+        };
+        let input = TextEditState::new(item.text().to_string());
+        if let Some(view) = &mut self.queue_view {
+            view.edit = Some((QueueEditTarget::Item(item), input));
+        }
+    }
+
+    /// Add a work order for the selected session's project
+    pub fn add_work_order(&mut self) {
+        let project = self
+            .sessions
+            .selected_session()
+            .map_or_else(|| self.start_dir.clone(), |s| s.cwd.clone());
+        if let Some(view) = &mut self.queue_view {
+            view.edit = Some((
+                QueueEditTarget::NewOrder(project),
+                TextEditState::new(String::new()),
+            ));
+        }
+    }
+
+    /// Stop editing without saving
+    pub fn close_queue_edit(&mut self) {
+        if let Some(view) = &mut self.queue_view {
+            view.edit = None;
+        }
+    }
+
+    /// Save the edited prompt or work order, or add the new work order
+    pub fn submit_queue_edit(&mut self) {
+        let Some((target, input)) = self.queue_view.as_mut().and_then(|v| v.edit.take()) else {
+            return;
+        };
+        let text = input.text.trim();
+        if text.is_empty() {
+            return;
+        }
+        let saved = match &target {
+            QueueEditTarget::Item(QueueItem::Prompt {
+                session_id,
+                index,
+                text: old,
+            }) => match self.sessions.get_by_id_mut(session_id) {
+                Some(session) if session.queued_prompts.get(*index) == Some(old) => {
+                    session.queued_prompts[*index] = text.to_string();
+                    Ok(true)
+                }
+                _ => Ok(false),
+            },
+            QueueEditTarget::Item(QueueItem::Order(order)) => self.work_queue.replace(order, text),
+            QueueEditTarget::NewOrder(project) => {
+                self.work_queue.push(project, text).map(|()| true)
+            }
+        };
+        match saved {
+            Ok(true) => {}
+            Ok(false) => self.show_toast("Already dispatched", true),
+            Err(e) => self.show_toast(format!("Failed to save the queue: {}", e), true),
+        }
+    }
+
+    /// Open the archive search screen
+    pub fn open_search(&mut self) {
+        if self.search_view.is_none() {
+            self.search_view = Some(SearchViewState::new());
+        }
+        self.input_mode = InputMode::Search;
+    }
+
+    /// Close the opened message, or the search screen (keeping the results
+    /// for when it's opened again)
+    pub fn close_search(&mut self) {
+        match &mut self.search_view {
+            Some(view) if view.preview.is_some() => view.preview = None,
+            _ => self.input_mode = InputMode::Normal,
+        }
+    }
+
+    /// Search for the query typed, or open the selected result if it was
+    /// already searched for
+    pub fn submit_search(&mut self) {
+        let Some(view) = &mut self.search_view else {
+            return;
+        };
+        let input = view.input.text.trim().to_string();
+        if view.searched.as_deref() == Some(input.as_str()) {
+            if !view.searching {
+                self.open_search_result();
+            }
+            return;
+        }
+        match archive::parse_query(&input) {
+            Ok((terms, _)) if terms.is_empty() => {
+                view.error = Some("Type words to search for".to_string());
+            }
+            Ok((terms, filter)) => {
+                view.pending = Some((input.clone(), terms, filter));
+                view.searched = Some(input);
+                view.searching = true;
+                view.hits.clear();
+                view.selected = 0;
+                view.error = None;
+            }
+            Err(e) => view.error = Some(e),
+        }
+    }
+
+    /// Take the search the search screen is waiting for, to run in the
+    /// background (query, terms, filter)
+    pub fn start_search(&mut self) -> Option<(String, String, SearchFilter)> {
+        self.search_view.as_mut()?.pending.take()
+    }
+
+    /// A background archive search finished
+    pub fn update_search(&mut self, query: &str, hits: Vec<SearchHit>) {
+        // Results of a search since replaced by another are dropped
+        if let Some(view) = &mut self.search_view
+            && view.searched.as_deref() == Some(query)
+        {
+            view.hits = hits;
+            view.searching = false;
+        }
+    }
+
+    /// Move the search screen's selection by `delta` matching lines
+    pub fn move_search_selection(&mut self, delta: isize) {
+        if let Some(view) = &mut self.search_view {
+            let count = view.results().len();
+            view.selected = view
+                .selected
+                .saturating_add_signed(delta)
+                .min(count.saturating_sub(1));
+        }
+    }
+
+    /// Jump to the message of the selected result: in its session if that is
+    /// still open with the message in memory, otherwise show it from the archive
+    pub fn open_search_result(&mut self) {
+        let Some(view) = &self.search_view else {
+            return;
+        };
+        let Some((hit, snippet)) = view.results().get(view.selected).copied() else {
+            return;
+        };
+        let (entry, snippet) = (hit.entry.clone(), snippet.clone());
+
+        if let Some(message) = snippet.message
+            && let Some(session_id) = &entry.session_id
+            && let Some((index, output_index)) = self
+                .sessions
+                .sessions()
+                .iter()
+                .enumerate()
+                .find_map(|(i, s)| {
+                    (s.acp_session_id.as_ref() == Some(session_id))
+                        .then(|| permalink::message_index(s, message))
+                        .flatten()
+                        .map(|output_index| (i, output_index))
+                })
+        {
+            // The tagging cursor keeps the message in view
+            self.select_session(index);
+            self.tag_cursor = Some(output_index);
+            self.input_mode = InputMode::Tagging;
+            return;
+        }
+
+        let text = match archive::read_transcript(&entry) {
+            Ok(transcript) => snippet
+                .message
+                .and_then(|message| permalink::find_in_transcript(&transcript, message))
+                // Lines outside messages (plan history) are shown alone
+                .unwrap_or(snippet.text),
+            Err(e) => {
+                self.show_toast(format!("Failed to read {}: {}", entry.file, e), true);
+                return;
+            }
+        };
+        let title = match snippet.message {
+            Some(message) => format!("{} - message {}", entry.name, message),
+            None => format!("{} - line {}", entry.name, snippet.line),
+        };
+        if let Some(view) = &mut self.search_view {
+            view.preview = Some((title, text));
+        }
+    }
+
+    /// Enter tagging mode with the cursor on the latest message
+    pub fn open_tagging(&mut self) {
+        let Some(session) = self.sessions.selected_session() else {
+            return;
+        };
+        if let Some(index) = session.adjacent_taggable(session.output.len(), false) {
+            self.tag_cursor = Some(index);
+            self.input_mode = InputMode::Tagging;
+        }
+    }
+
+    /// Leave tagging mode
+    pub fn close_tagging(&mut self) {
+        self.tag_cursor = None;
+        self.input_mode = InputMode::Normal;
+    }
+
+    /// Move the tagging cursor to the next or previous message
+    pub fn move_tag_cursor(&mut self, forward: bool) {
+        if let Some(cursor) = self.tag_cursor
+            && let Some(session) = self.sessions.selected_session()
+            && let Some(index) = session.adjacent_taggable(cursor, forward)
+        {
+            self.tag_cursor = Some(index);
+        }
+    }
+
+    /// Toggle a tag on the message under the tagging cursor
+    pub fn tag_message(&mut self, tag: MessageTag) {
+        if let Some(cursor) = self.tag_cursor
+            && let Some(session) = self.sessions.selected_session_mut()
+        {
+            session.toggle_tag(cursor, tag);
+        }
+    }
+
+    /// Toggle ANSI colors in the tool results after the message under the
+    /// tagging cursor
+    pub fn toggle_ansi(&mut self) {
+        if let Some(cursor) = self.tag_cursor
+            && let Some(session) = self.sessions.selected_session_mut()
+        {
+            session.toggle_ansi(cursor);
+        }
+    }
+
+    /// Copy the permalink of the message under the tagging cursor
+    pub fn copy_permalink(&mut self) {
+        let Some(cursor) = self.tag_cursor else {
+            return;
+        };
+        let Some(session) = self.sessions.selected_session_mut() else {
+            return;
+        };
+        let Some(link) = Permalink::for_message(session, cursor) else {
+            session.add_output(
+                "No permalink: the agent session has not started".to_string(),
+                OutputType::Error,
+            );
+            return;
+        };
+        match clipboard::write_text(&link.to_string()) {
+            Ok(()) => session.add_output(
+                format!("Copied {} (open with `amux open` once archived)", link),
+                OutputType::SystemMessage,
+            ),
+            Err(e) => session.add_output(
+                format!("Failed to copy permalink {}: {}", link, e),
+                OutputType::Error,
+            ),
+        }
+    }
+
+    /// Open the files referenced by the prompt under the tagging cursor, if
+    /// they still exist
+    pub fn open_attachments(&mut self) {
+        let Some(cursor) = self.tag_cursor else {
+            return;
+        };
+        let Some(session) = self.sessions.selected_session_mut() else {
+            return;
+        };
+        let Some(line) = session
+            .output
+            .get(cursor)
+            .filter(|line| line.line_type == OutputType::UserInput)
+        else {
+            return;
+        };
+        let references = attachments::references(&line.content);
+        if references.is_empty() {
+            session.add_output(
+                "No files referenced by this message".to_string(),
+                OutputType::SystemMessage,
+            );
+            return;
+        }
+
+        for reference in references {
+            let path = attachments::resolve(&reference, &session.cwd);
+            if !path.exists() {
+                session.add_output(
+                    format!("{} no longer exists", path.display()),
+                    OutputType::Error,
+                );
+            } else if let Err(e) = attachments::open(&path) {
+                session.add_output(
+                    format!("Failed to open {}: {}", path.display(), e),
+                    OutputType::Error,
+                );
+            }
+        }
+    }
+
+    /// Export tagged messages from all sessions as a redacted digest
+    pub fn export_tagged_messages(&mut self) {
+        let result = transcript::export_tagged(self.sessions.sessions(), &self.redactor);
+
+        if let Some(session) = self.sessions.selected_session_mut() {
+            match result {
+                Ok(path) => session.add_output(
+                    format!("Tagged messages exported to {} (redacted)", path.display()),
+                    OutputType::SystemMessage,
+                ),
+                Err(e) => session.add_output(
+                    format!("Failed to export tagged messages: {}", e),
+                    OutputType::Error,
+                ),
+            }
+        }
+    }
+
+    /// Append tagged turns from all sessions to the fine-tuning dataset
+    pub fn export_dataset(&mut self, filter: Option<MessageTag>) {
+        // Include the output moved to disk for the memory budget, which holds
+        // the prompts of tagged turns near the start of a trimmed session
+        let result = self
+            .sessions
+            .sessions()
+            .iter()
+            .filter(|session| !session.message_tags.is_empty())
+            .map(Session::with_spilled_output)
+            .collect::<std::io::Result<Vec<_>>>()
+            .and_then(|sessions| dataset::export(&sessions, filter, &self.redactor));
+        let which = filter.map_or("tagged", |tag| tag.label());
+
+        if let Some(session) = self.sessions.selected_session_mut() {
+            match result {
+                Ok((_, 0)) => session.add_output(
+                    format!("No {} turns to export", which),
+                    OutputType::SystemMessage,
+                ),
+                Ok((path, count)) => session.add_output(
+                    format!(
+                        "Appended {} {} turn(s) to {} (redacted)",
+                        count,
+                        which,
+                        path.display()
+                    ),
+                    OutputType::SystemMessage,
+                ),
+                Err(e) => session.add_output(
+                    format!("Failed to export dataset: {}", e),
+                    OutputType::Error,
+                ),
+            }
+        }
+    }
+
     /// Open the bug report dialog
     pub fn open_bug_report(&mut self) {
         let log_path = self.log_path.clone().unwrap_or_default();
@@ -1075,6 +3201,34 @@ impl App {
         self.input_mode = InputMode::Normal;
     }
 
+    /// Sessions whose agent has been idle for its project's `idle_kill_after` or longer
+    pub fn idle_sessions(&self) -> Vec<&Session> {
+        self.sessions
+            .sessions()
+            .iter()
+            .filter(|s| {
+                s.state == SessionState::Idle
+                    && !s.read_only
+                    && s.last_activity
+                        .is_some_and(|at| at.elapsed() >= self.idle_kill_after_for(s))
+            })
+            .collect()
+    }
+
+    /// Open the confirmation to kill all idle sessions (when there are any)
+    pub fn open_kill_idle_confirm(&mut self) {
+        if self.idle_sessions().is_empty() {
+            self.show_toast("No idle agents to kill", false);
+            return;
+        }
+        self.input_mode = InputMode::KillIdleConfirm;
+    }
+
+    /// Close the confirmation to kill all idle sessions
+    pub fn close_kill_idle_confirm(&mut self) {
+        self.input_mode = InputMode::Normal;
+    }
+
     /// Scroll current session up
     pub fn scroll_up(&mut self, n: usize) {
         let viewport = self.viewport_height;
@@ -1095,6 +3249,16 @@ impl App {
         }
     }
 
+    /// Scroll by lines (negative is up), accelerating while the key is held
+    pub fn scroll_lines(&mut self, delta: i32) {
+        let delta = self.scroll_accel.step(delta);
+        if delta < 0 {
+            self.scroll_up(delta.unsigned_abs() as usize);
+        } else {
+            self.scroll_down(delta as usize);
+        }
+    }
+
     /// Scroll to top of output
     pub fn scroll_to_top(&mut self) {
         if let Some(session) = self.sessions.selected_session_mut() {
@@ -1130,13 +3294,25 @@ impl App {
     pub fn next_session(&mut self) {
         self.save_input_to_session();
         self.sessions.select_next();
+        self.skip_hidden_sessions(true);
         self.restore_input_from_session();
+        self.selection_moved_at = Some(std::time::Instant::now());
     }
 
     pub fn prev_session(&mut self) {
         self.save_input_to_session();
         self.sessions.select_prev();
+        self.skip_hidden_sessions(false);
         self.restore_input_from_session();
+        self.selection_moved_at = Some(std::time::Instant::now());
+    }
+
+    /// Whether the selection moved too recently to lay out the selected
+    /// session's conversation. The conversation view shows a placeholder
+    /// instead, and lays it out in full once the selection has settled.
+    pub fn detail_settling(&self) -> bool {
+        self.selection_moved_at
+            .is_some_and(|moved| moved.elapsed() < DETAIL_DEBOUNCE)
     }
 
     /// Select session by index, saving/restoring input buffers
@@ -1150,6 +3326,45 @@ impl App {
         self.sessions.selected_session()
     }
 
+    /// Open the selected session as a tab, or close its tab if it has one
+    pub fn toggle_tab(&mut self) {
+        let Some(id) = self.selected_session().map(|s| s.id.clone()) else {
+            return;
+        };
+        if let Some(pos) = self.tabs.iter().position(|tab| *tab == id) {
+            self.tabs.remove(pos);
+        } else {
+            self.tabs.push(id);
+        }
+    }
+
+    /// Switch to the next (or previous) tab. Scroll position and follow state
+    /// live on each session, so switching back resumes where the tab was left.
+    pub fn cycle_tab(&mut self, forward: bool) {
+        // Drop tabs of sessions that were killed since
+        let sessions = self.sessions.sessions();
+        self.tabs
+            .retain(|id| sessions.iter().any(|session| session.id == *id));
+        if self.tabs.is_empty() {
+            return;
+        }
+
+        let len = self.tabs.len();
+        let current = self
+            .selected_session()
+            .and_then(|selected| self.tabs.iter().position(|id| *id == selected.id));
+        let next = match (current, forward) {
+            (Some(pos), true) => (pos + 1) % len,
+            (Some(pos), false) => (pos + len - 1) % len,
+            (None, true) => 0,
+            (None, false) => len - 1,
+        };
+        let id = &self.tabs[next];
+        if let Some(index) = self.sessions.sessions().iter().position(|s| s.id == *id) {
+            self.select_session(index);
+        }
+    }
+
     /// Spawn a new session and return its unique ID
     pub fn spawn_session(
         &mut self,
@@ -1165,7 +3380,12 @@ impl App {
 
         let id = format!("session_{}", self.next_session_id);
         self.next_session_id += 1;
-        let session = Session::new(id.clone(), name, agent_type, cwd, is_worktree);
+        let mut session = Session::new(id.clone(), name, agent_type, cwd, is_worktree);
+
+        // Badge the mode the project's Claude settings start the agent in
+        if agent_type == AgentType::ClaudeCode {
+            session.project_mode = default_permission_mode(&session.cwd);
+        }
 
         // Save current session's input before switching to the new session
         self.save_input_to_session();
@@ -1174,18 +3394,187 @@ impl App {
         id
     }
 
+    /// Open a JSONL transcript as a read-only session (`amux view`); returns
+    /// the number of messages loaded
+    pub fn open_transcript(&mut self, name: String, text: &str) -> usize {
+        let id = format!("session_{}", self.next_session_id);
+        self.next_session_id += 1;
+        let mut session = Session::new(
+            id,
+            name,
+            AgentType::ClaudeCode,
+            self.start_dir.clone(),
+            false,
+        );
+        let messages = load_jsonl(&mut session, text);
+        session.state = SessionState::Idle;
+        session.read_only = true;
+
+        self.save_input_to_session();
+        self.sessions.add_session(session);
+        messages
+    }
+
+    /// Open a transcript file as a read-only session, read in the background
+    /// once the session is selected (`amux view <FILE>`)
+    pub fn open_transcript_file(&mut self, transcript: serve::SharedTranscript) {
+        let metadata = std::fs::metadata(&transcript.path).ok();
+        self.open_transcript(transcript.name.clone(), "");
+        let Some(session) = self.sessions.sessions_mut().last_mut() else {
+            return;
+        };
+        // Shown until the read finishes; large files on a network home take a while
+        let size = metadata.as_ref().map_or(0, |m| m.len());
+        session.add_output(
+            format!(
+                "Loading transcript ({:.1} MB)…",
+                size as f64 / (1024.0 * 1024.0)
+            ),
+            OutputType::SystemMessage,
+        );
+        self.shared.push(SharedSession {
+            id: session.id.clone(),
+            transcript,
+            modified: metadata.and_then(|m| m.modified().ok()),
+            text: String::new(),
+            len: 0,
+            stale: true,
+            loading: false,
+        });
+    }
+
+    /// In the read-only view, open the transcripts of agents that appeared
+    /// and mark the ones their agents wrote to since. Only file metadata is
+    /// looked at here: transcripts are read as their sessions are selected.
+    pub fn refresh_shared(&mut self) {
+        if !self.view_only
+            || self
+                .last_shared_refresh
+                .is_some_and(|at| at.elapsed() < SHARED_REFRESH_INTERVAL)
+        {
+            return;
+        }
+        self.last_shared_refresh = Some(std::time::Instant::now());
+        let (Some(snapshot), Some(projects)) = (snapshot::read(), usage::projects_dir()) else {
+            return;
+        };
+
+        for transcript in serve::shared_transcripts(&snapshot, &projects) {
+            let known = self
+                .shared
+                .iter_mut()
+                .find(|shared| shared.transcript.session_id == transcript.session_id);
+            match known {
+                Some(shared) => {
+                    let modified = std::fs::metadata(&shared.transcript.path)
+                        .and_then(|m| m.modified())
+                        .ok();
+                    if modified != shared.modified {
+                        shared.modified = modified;
+                        shared.stale = true;
+                    }
+                }
+                None => self.open_transcript_file(transcript),
+            }
+        }
+    }
+
+    /// The selected transcript file if it changed since it was read: app
+    /// session ID, file and bytes already read
+    pub fn start_shared_load(&mut self) -> Option<(String, PathBuf, u64)> {
+        let selected = self.sessions.selected_session()?.id.clone();
+        let shared = self
+            .shared
+            .iter_mut()
+            .find(|shared| shared.id == selected && shared.stale && !shared.loading)?;
+        shared.stale = false;
+        shared.loading = true;
+        Some((selected, shared.transcript.path.clone(), shared.len))
+    }
+
+    /// Lines appended to a transcript file were read: rebuild its session
+    pub fn update_shared(&mut self, id: &str, result: std::io::Result<serve::Appended>) {
+        let Some(shared) = self.shared.iter_mut().find(|shared| shared.id == id) else {
+            return;
+        };
+        shared.loading = false;
+        let appended = match result {
+            Ok(appended) => appended,
+            Err(e) => {
+                log::log(&format!(
+                    "Failed to read {}: {}",
+                    shared.transcript.path.display(),
+                    e
+                ));
+                return;
+            }
+        };
+        if appended.from == 0 {
+            shared.text.clear();
+        }
+        // Teammates see everything the agents read and wrote: redact
+        // secrets the same way as exports
+        if self.view_only {
+            shared.text.push_str(&self.redactor.redact(&appended.text));
+        } else {
+            shared.text.push_str(&appended.text);
+        }
+        shared.len = appended.len;
+
+        // Reload in place, keeping where the viewer scrolled to
+        let mut session = Session::new(
+            shared.id.clone(),
+            shared.transcript.name.clone(),
+            AgentType::ClaudeCode,
+            self.start_dir.clone(),
+            false,
+        );
+        load_jsonl(&mut session, &shared.text);
+        session.state = SessionState::Idle;
+        session.read_only = true;
+        if let Some(existing) = self.sessions.get_by_id_mut(id) {
+            session.scroll_offset = existing.scroll_offset;
+            *existing = session;
+        }
+    }
+
     /// Kill the currently selected session
     pub fn kill_selected_session(&mut self) {
         // Clear current input (it belongs to the session being killed)
         self.input_buffer.clear();
         self.cursor_position = 0;
+        if let Some(session) = self.sessions.selected_session_mut() {
+            session.remove_spill_file();
+        }
         self.sessions.remove_selected();
         // Restore input from the newly selected session
         self.restore_input_from_session();
     }
 
+    /// Kill the sessions with the given IDs, keeping the selection (and its
+    /// input) on the selected session if it survives
+    pub fn kill_sessions(&mut self, ids: &[String]) {
+        if self.selected_session().is_some_and(|s| ids.contains(&s.id)) {
+            self.input_buffer.clear();
+            self.cursor_position = 0;
+        } else {
+            self.save_input_to_session();
+        }
+        for session in self.sessions.sessions_mut() {
+            if ids.contains(&session.id) {
+                session.remove_spill_file();
+            }
+        }
+        self.sessions.remove_by_ids(ids);
+        self.restore_input_from_session();
+    }
+
     /// Enter insert mode
     pub fn enter_insert_mode(&mut self) {
+        // Transcripts opened with `amux view` have no agent behind them
+        if self.selected_session().is_some_and(|s| s.read_only) {
+            return;
+        }
         self.input_mode = InputMode::Insert;
     }
 
@@ -0,0 +1,322 @@
+//! Session archive.
+//!
+//! Archived sessions are stored under `~/.amux/archive/` so their history
+//! survives the agents' own cleanup. Each session's markdown transcript
+//! (including plan history) is written gzip-compressed, and its metadata is
+//! appended to `index.jsonl` for listing and search.
+
+use std::fs::OpenOptions;
+use std::io::{BufRead, BufReader, Read, Write};
+use std::path::PathBuf;
+
+use chrono::{DateTime, Local, NaiveDate};
+use flate2::Compression;
+use flate2::read::GzDecoder;
+use flate2::write::GzEncoder;
+use serde::{Deserialize, Serialize};
+
+use crate::permalink;
+use crate::session::{OutputType, Session};
+use crate::transcript;
+
+/// Maximum snippets shown per search hit
+const MAX_SNIPPETS: usize = 3;
+
+/// Maximum snippet length in characters
+const SNIPPET_LEN: usize = 100;
+
+/// Metadata of an archived session (one line in `index.jsonl`)
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct ArchiveEntry {
+    /// Agent-side session ID, if the session was started
+    pub session_id: Option<String>,
+    pub name: String,
+    pub agent: String,
+    pub cwd: PathBuf,
+    pub branch: String,
+    /// RFC 3339 timestamp of when the session was archived
+    pub archived_at: String,
+    /// First prompt sent in the session
+    pub first_prompt: Option<String>,
+    /// Compressed transcript file name, relative to the archive directory
+    pub file: String,
+}
+
+/// Directory holding archived transcripts and the index
+pub fn archive_dir() -> PathBuf {
+    dirs::home_dir()
+        .unwrap_or_else(|| PathBuf::from("."))
+        .join(".amux")
+        .join("archive")
+}
+
+/// Archive a session, returning the compressed transcript path
+pub fn archive(session: &Session) -> std::io::Result<PathBuf> {
+    let dir = archive_dir();
+    std::fs::create_dir_all(&dir)?;
+
+    let now = Local::now();
+    let name: String = session
+        .name
+        .chars()
+        .map(|c| {
+            if c.is_alphanumeric() || c == '-' {
+                c
+            } else {
+                '_'
+            }
+        })
+        .collect();
+    let file = format!("{}_{}.md.gz", name, now.format("%Y%m%d_%H%M%S"));
+    let path = dir.join(&file);
+
+    let mut encoder = GzEncoder::new(std::fs::File::create(&path)?, Compression::default());
+    encoder.write_all(transcript::to_markdown(session).as_bytes())?;
+    encoder.finish()?;
+
+    let entry = ArchiveEntry {
+        session_id: session.acp_session_id.clone(),
+        name: session.name.clone(),
+        agent: session.agent_type.display_name().to_string(),
+        cwd: session.cwd.clone(),
+        branch: session.git_branch.clone(),
+        archived_at: now.to_rfc3339(),
+        first_prompt: session
+            .output
+            .iter()
+            .find(|line| line.line_type == OutputType::UserInput)
+            .map(|line| line.content.clone()),
+        file,
+    };
+    let mut index = OpenOptions::new()
+        .create(true)
+        .append(true)
+        .open(dir.join("index.jsonl"))?;
+    writeln!(index, "{}", serde_json::to_string(&entry)?)?;
+
+    Ok(path)
+}
+
+/// Read all archive entries, oldest first (unreadable lines are skipped)
+pub fn entries() -> Vec<ArchiveEntry> {
+    let Ok(index) = std::fs::File::open(archive_dir().join("index.jsonl")) else {
+        return vec![];
+    };
+    BufReader::new(index)
+        .lines()
+        .map_while(Result::ok)
+        .filter_map(|line| serde_json::from_str(&line).ok())
+        .collect()
+}
+
+/// Read an archived transcript back as text
+pub fn read_transcript(entry: &ArchiveEntry) -> std::io::Result<String> {
+    let file = std::fs::File::open(archive_dir().join(&entry.file))?;
+    let mut text = String::new();
+    GzDecoder::new(file).read_to_string(&mut text)?;
+    Ok(text)
+}
+
+/// Restrictions applied to archive search results
+#[derive(Debug, Clone, Default)]
+pub struct SearchFilter {
+    /// Only sessions whose directory contains this text
+    pub project: Option<String>,
+    /// Only sessions archived on or after this day
+    pub since: Option<NaiveDate>,
+}
+
+/// Parse a `--since` date (YYYY-MM-DD)
+pub fn parse_since(text: &str) -> Result<NaiveDate, String> {
+    NaiveDate::parse_from_str(text, "%Y-%m-%d")
+        .map_err(|_| format!("Invalid date \"{}\" (expected YYYY-MM-DD)", text))
+}
+
+/// Split a search screen query into its terms and the `project:<TEXT>` and
+/// `since:<YYYY-MM-DD>` filters in it
+pub fn parse_query(input: &str) -> Result<(String, SearchFilter), String> {
+    let mut filter = SearchFilter::default();
+    let mut terms = vec![];
+    for word in input.split_whitespace() {
+        if let Some(project) = word.strip_prefix("project:") {
+            filter.project = Some(project.to_string());
+        } else if let Some(since) = word.strip_prefix("since:") {
+            filter.since = Some(parse_since(since)?);
+        } else {
+            terms.push(word);
+        }
+    }
+    Ok((terms.join(" "), filter))
+}
+
+/// A matching transcript line
+#[derive(Debug, Clone, PartialEq)]
+pub struct Snippet {
+    /// Line number in the transcript, counting from 1
+    pub line: usize,
+    /// Number of the message the line belongs to (see [`permalink`])
+    pub message: Option<usize>,
+    pub text: String,
+}
+
+/// An archived session matching a search, with matching lines
+#[derive(Debug, Clone)]
+pub struct SearchHit {
+    pub entry: ArchiveEntry,
+    pub score: usize,
+    /// Best matching transcript lines
+    pub snippets: Vec<Snippet>,
+}
+
+/// Search archived transcripts, best matches first
+pub fn search(query: &str, filter: &SearchFilter) -> Vec<SearchHit> {
+    let terms = query_terms(query);
+    if terms.is_empty() {
+        return vec![];
+    }
+
+    let mut hits: Vec<SearchHit> = entries()
+        .into_iter()
+        .filter(|entry| {
+            filter
+                .project
+                .as_ref()
+                .is_none_or(|p| entry.cwd.to_string_lossy().contains(p.as_str()))
+        })
+        .filter(|entry| {
+            filter.since.is_none_or(|since| {
+                DateTime::parse_from_rfc3339(&entry.archived_at)
+                    .is_ok_and(|at| at.date_naive() >= since)
+            })
+        })
+        .filter_map(|entry| {
+            let text = read_transcript(&entry).ok()?;
+            let (score, snippets) = score_text(&text, &terms);
+            (score > 0).then_some(SearchHit {
+                entry,
+                score,
+                snippets,
+            })
+        })
+        .collect();
+
+    hits.sort_by(|a, b| {
+        b.score
+            .cmp(&a.score)
+            .then_with(|| b.entry.archived_at.cmp(&a.entry.archived_at))
+    });
+    hits
+}
+
+/// Lowercased search terms
+fn query_terms(query: &str) -> Vec<String> {
+    query.split_whitespace().map(str::to_lowercase).collect()
+}
+
+/// Score text by term occurrences, weighting lines that contain every term.
+/// Returns the score and the best matching lines.
+fn score_text(text: &str, terms: &[String]) -> (usize, Vec<Snippet>) {
+    let mut score = 0;
+    let mut matches: Vec<(usize, usize, Option<usize>, &str)> = vec![];
+    // Message the current line belongs to, from the anchors before it
+    let mut message = None;
+
+    for (index, line) in text.lines().enumerate() {
+        if line.starts_with("## ") {
+            message = None;
+        } else if let Some(n) = permalink::parse_anchor(line) {
+            message = Some(n);
+            continue;
+        }
+        let lower = line.to_lowercase();
+        let counts: Vec<usize> = terms
+            .iter()
+            .map(|t| lower.matches(t.as_str()).count())
+            .collect();
+        let line_score: usize = counts.iter().sum();
+        if line_score == 0 {
+            continue;
+        }
+        // Lines containing the whole query are worth much more than scattered terms
+        let line_score = if counts.iter().all(|&c| c > 0) {
+            line_score * 5
+        } else {
+            line_score
+        };
+        score += line_score;
+        matches.push((line_score, index + 1, message, line.trim()));
+    }
+
+    // A session must mention every term somewhere
+    let lower = text.to_lowercase();
+    if !terms.iter().all(|t| lower.contains(t.as_str())) {
+        return (0, vec![]);
+    }
+
+    matches.sort_by(|a, b| b.0.cmp(&a.0).then(a.1.cmp(&b.1)));
+    let snippets = matches
+        .into_iter()
+        .take(MAX_SNIPPETS)
+        .map(|(_, line_number, message, line)| {
+            let text = if line.chars().count() > SNIPPET_LEN {
+                let cut: String = line.chars().take(SNIPPET_LEN - 1).collect();
+                format!("{}…", cut)
+            } else {
+                line.to_string()
+            };
+            Snippet {
+                line: line_number,
+                message,
+                text,
+            }
+        })
+        .collect();
+
+    (score, snippets)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_score_text_ranks_full_matches() {
+        let terms = query_terms("Migration script");
+        let (score, snippets) = score_text(
+            "> write a migration script\nrunning migration\nthe script is done",
+            &terms,
+        );
+        assert_eq!(score, 10 + 1 + 1);
+        assert_eq!(snippets[0].line, 1);
+        assert_eq!(snippets[0].text, "> write a migration script");
+        assert_eq!(snippets.len(), 3);
+    }
+
+    #[test]
+    fn test_score_text_finds_messages() {
+        let terms = query_terms("migration");
+        let transcript = format!(
+            "# s (Claude Code)\n{}\n> write a migration\n{}\nDone, migration added.\n\n## Plan history\n- migration",
+            permalink::anchor(1),
+            permalink::anchor(2)
+        );
+        let (_, snippets) = score_text(&transcript, &terms);
+        let messages: Vec<_> = snippets.iter().map(|s| s.message).collect();
+        assert_eq!(messages, [Some(1), Some(2), None]);
+    }
+
+    #[test]
+    fn test_parse_query() {
+        let (query, filter) = parse_query("migration project:api since:2026-03-01 script").unwrap();
+        assert_eq!(query, "migration script");
+        assert_eq!(filter.project.as_deref(), Some("api"));
+        assert_eq!(filter.since, NaiveDate::from_ymd_opt(2026, 3, 1));
+        assert!(parse_query("since:March").is_err());
+    }
+
+    #[test]
+    fn test_score_text_requires_all_terms() {
+        let terms = query_terms("migration rollback");
+        assert_eq!(score_text("running migration", &terms).0, 0);
+    }
+}
@@ -0,0 +1,105 @@
+//! Files referenced by prompts.
+//!
+//! Prompts mention files with `@src/app.rs` (as in Claude Code) and list
+//! pasted images as a `[+screenshot.png, …]` suffix. The conversation view
+//! shows them as chips under the prompt, and Enter in tagging mode opens the
+//! files of the prompt under the cursor with the system's default app.
+
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
+
+/// Characters ending a sentence that aren't part of a mentioned path
+const TRAILING_PUNCTUATION: &[char] = &['.', ',', ';', ':', '!', '?', ')', '"', '\''];
+
+/// Files a prompt references, in order: `@` mentions, then pasted images
+pub fn references(prompt: &str) -> Vec<String> {
+    let (text, images) = split_images(prompt);
+    let mut refs: Vec<String> = vec![];
+    for word in text.split_whitespace() {
+        let Some(path) = word.strip_prefix('@') else {
+            continue;
+        };
+        let path = path.trim_end_matches(TRAILING_PUNCTUATION);
+        // "@alice" is a person; paths have a directory or an extension
+        if path.contains(['/', '.']) && !refs.iter().any(|r| r == path) {
+            refs.push(path.to_string());
+        }
+    }
+    refs.extend(images.into_iter().map(str::to_string));
+    refs
+}
+
+/// Split the pasted images suffix (`text [+a.png, b.png]`) off a prompt
+fn split_images(prompt: &str) -> (&str, Vec<&str>) {
+    let trimmed = prompt.trim_end();
+    if let Some(rest) = trimmed.strip_suffix(']')
+        && let Some(start) = rest.rfind(" [+")
+    {
+        let names = rest[start + 3..]
+            .split(", ")
+            .filter(|name| !name.is_empty())
+            .collect();
+        return (&rest[..start], names);
+    }
+    (prompt, vec![])
+}
+
+/// Path a reference points to, relative to the session's directory
+pub fn resolve(reference: &str, cwd: &Path) -> PathBuf {
+    match reference.strip_prefix("~/") {
+        Some(rest) => dirs::home_dir().unwrap_or_default().join(rest),
+        None => cwd.join(reference),
+    }
+}
+
+/// Open a file with the system's default app, without waiting for it
+pub fn open(path: &Path) -> std::io::Result<()> {
+    let opener = if cfg!(target_os = "macos") {
+        "open"
+    } else {
+        "xdg-open"
+    };
+    Command::new(opener)
+        .arg(path)
+        .stdin(Stdio::null())
+        .stdout(Stdio::null())
+        .stderr(Stdio::null())
+        .spawn()
+        .map(|_| ())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_mentions() {
+        assert_eq!(
+            references("> compare @src/app.rs and @README.md, then ask @alice"),
+            ["src/app.rs", "README.md"]
+        );
+        assert_eq!(references("> email me at x@y.com"), Vec::<String>::new());
+        assert_eq!(references("> @a.rs @a.rs"), ["a.rs"]);
+    }
+
+    #[test]
+    fn test_pasted_images() {
+        assert_eq!(
+            references("> what is wrong here @ui.rs [+shot.png, clipboard.png]"),
+            ["ui.rs", "shot.png", "clipboard.png"]
+        );
+        assert_eq!(references("> see [link]"), Vec::<String>::new());
+    }
+
+    #[test]
+    fn test_resolve() {
+        assert_eq!(
+            resolve("src/app.rs", Path::new("/srv/api")),
+            PathBuf::from("/srv/api/src/app.rs")
+        );
+        assert_eq!(
+            resolve("/etc/hosts", Path::new("/srv/api")),
+            PathBuf::from("/etc/hosts")
+        );
+    }
+}
@@ -0,0 +1,167 @@
+//! Detecting when an agent ends its turn by asking the user something, or by
+//! wrapping up its work.
+//!
+//! Agents often stop with a question in plain text ("Should I also update
+//! the migration?") instead of the ask_user tool. Such sessions are idle,
+//! but not done: they're flagged as needing input. Turns that end with a
+//! summary of the work ("## Summary", "All tests pass") are done, so idle
+//! sessions that merely stopped can be told apart from finished ones.
+
+/// Openings of a sentence that asks the user for a decision
+const ASKING_PHRASES: &[&str] = &[
+    "should i",
+    "shall i",
+    "would you like",
+    "do you want",
+    "which approach",
+    "which option",
+    "let me know",
+    "please confirm",
+    "please let me know",
+];
+
+/// Phrases of a message that wraps up finished work, matched as whole words
+/// ("summary" alone is left to headings: prose mentions it too often)
+const SUMMARY_PHRASES: &[&str] = &[
+    "all tests pass",
+    "all done",
+    "is complete",
+    "are complete",
+    "has been completed",
+    "have been completed",
+    "i've completed",
+    "i have completed",
+    "i've finished",
+    "changes made",
+    "successfully implemented",
+];
+
+/// Lines at the end of a message that are checked
+const TAIL_LINES: usize = 3;
+
+/// Lines of prose outside code blocks, trimmed
+fn prose_lines(message: &str) -> Vec<&str> {
+    let mut in_code = false;
+    let mut prose: Vec<&str> = vec![];
+    for line in message.lines() {
+        let line = line.trim();
+        if line.starts_with("```") {
+            in_code = !in_code;
+            continue;
+        }
+        if !in_code && !line.is_empty() {
+            prose.push(line);
+        }
+    }
+    prose
+}
+
+/// Whether `phrase` occurs in `text` as whole words, e.g. "is complete" in
+/// "the port is complete." but not in "the port is completely broken"
+fn contains_words(text: &str, phrase: &str) -> bool {
+    let is_word_char = |c: Option<char>| c.is_some_and(char::is_alphanumeric);
+    text.match_indices(phrase).any(|(start, _)| {
+        let before = text[..start].chars().next_back();
+        let after = text[start + phrase.len()..].chars().next();
+        !is_word_char(before) && !is_word_char(after)
+    })
+}
+
+/// Whether a message asks the user a question or for a decision, judged by
+/// its last few lines outside code blocks
+pub fn asks_user(message: &str) -> bool {
+    prose_lines(message)
+        .iter()
+        .rev()
+        .take(TAIL_LINES)
+        .any(|line| {
+            let line = line.trim_end_matches(['*', '_', ')', '"']);
+            let lower = line.to_lowercase();
+            // Asking phrases count at the start of any sentence on the line
+            let asks = lower.split(['.', '!', ':']).any(|sentence| {
+                let sentence = sentence.trim_start_matches(['-', '*', '>', ' ']);
+                ASKING_PHRASES.iter().any(|p| sentence.starts_with(p))
+            });
+            line.ends_with('?') || asks
+        })
+}
+
+/// Whether a message wraps up finished work: a summary heading anywhere, or a
+/// wrap-up phrase in its first or last few lines
+pub fn is_final_summary(message: &str) -> bool {
+    let prose = prose_lines(message);
+    let is_summary_heading = |line: &&str| {
+        let heading = line
+            .trim_start_matches('#')
+            .trim_matches(['*', '_', ':', ' '])
+            .to_lowercase();
+        (line.starts_with('#') && heading.contains("summary")) || line.ends_with("ummary:")
+    };
+    let wraps_up = |line: &&str| {
+        let lower = line.to_lowercase();
+        SUMMARY_PHRASES.iter().any(|p| contains_words(&lower, p))
+    };
+    prose.iter().any(is_summary_heading)
+        || prose.iter().take(1).any(wraps_up)
+        || prose.iter().rev().take(TAIL_LINES).any(wraps_up)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_asks_user() {
+        assert!(asks_user(
+            "I fixed the parser.\n\nShould I also update the migration?"
+        ));
+        assert!(asks_user(
+            "Two options:\n1. Cache it\n2. Recompute\n\n**Which approach do you prefer?**"
+        ));
+        assert!(asks_user(
+            "Done with the refactor. Let me know if the naming works for you."
+        ));
+        assert!(!asks_user("All tests pass. The fix is committed."));
+        // Questions inside code or early in a long message don't count
+        assert!(!asks_user(
+            "Why did it fail?\nThe lock was held.\nI released it.\nTests pass.\nDone."
+        ));
+        assert!(!asks_user("Added:\n```rust\n// is this needed?\n```"));
+    }
+
+    #[test]
+    fn test_is_final_summary() {
+        assert!(is_final_summary(
+            "## Summary\n\n- Fixed the parser\n- Added tests"
+        ));
+        assert!(is_final_summary("Changes:\n- parser.rs\n\nAll tests pass."));
+        assert!(is_final_summary(
+            "I've completed the migration.\n\nDetails follow."
+        ));
+        assert!(!is_final_summary("Let me read the parser first."));
+        assert!(!is_final_summary(
+            "```\n## Summary\n```\nStill looking into the lock."
+        ));
+        // Phrases only count as whole words, and "summary" only as a heading
+        assert!(!is_final_summary("The refactor is completely broken."));
+        assert!(!is_final_summary(
+            "Let me print a summary of the failing tests first."
+        ));
+        assert!(!is_final_summary("Not all tests passed yet."));
+        assert!(!is_final_summary(
+            "The key exchanges made earlier time out."
+        ));
+    }
+
+    #[test]
+    fn test_contains_words() {
+        assert!(contains_words("the port is complete.", "is complete"));
+        assert!(contains_words("is complete", "is complete"));
+        assert!(!contains_words("this complete", "is complete"));
+        assert!(!contains_words("it is completely broken", "is complete"));
+        assert!(contains_words(
+            "it is completely broken, now it is complete",
+            "is complete"
+        ));
+    }
+}
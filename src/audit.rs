@@ -0,0 +1,154 @@
+//! Audit log of destructive actions.
+//!
+//! Killing, restarting, clearing or cancelling an agent and running external
+//! commands (verify, summary) or worktree deletion are appended to
+//! `~/.amux/audit.jsonl`, one JSON object per line, so it's clear afterwards
+//! who stopped what on a shared machine. The log is only ever appended to.
+
+use std::fs::OpenOptions;
+use std::io::{BufRead, BufReader, Write};
+use std::path::{Path, PathBuf};
+
+use chrono::Local;
+use serde::{Deserialize, Serialize};
+
+use crate::log;
+use crate::session::Session;
+
+/// A recorded action (one line in `audit.jsonl`)
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct AuditEntry {
+    /// RFC 3339 timestamp of the action
+    pub at: String,
+    /// What was done: kill, restart, clear, cancel, verify, summary, delete-worktree
+    pub action: String,
+    /// Session name, for actions on a session
+    pub session: Option<String>,
+    pub agent: Option<String>,
+    /// PID of the agent process the action affected
+    pub pid: Option<u32>,
+    pub cwd: PathBuf,
+    /// Whether the user confirmed the action in a dialog first
+    pub confirmed: bool,
+    /// Command run or other specifics
+    pub detail: Option<String>,
+}
+
+impl AuditEntry {
+    /// Entry for an action on a session
+    pub fn for_session(action: &str, session: &Session, confirmed: bool) -> Self {
+        Self {
+            at: Local::now().to_rfc3339(),
+            action: action.to_string(),
+            session: Some(session.name.clone()),
+            agent: Some(session.agent_type.display_name().to_string()),
+            pid: session.agent_pid,
+            cwd: session.cwd.clone(),
+            confirmed,
+            detail: None,
+        }
+    }
+
+    /// Entry for an action on a directory, not tied to a session
+    pub fn for_dir(action: &str, cwd: &Path, confirmed: bool) -> Self {
+        Self {
+            at: Local::now().to_rfc3339(),
+            action: action.to_string(),
+            session: None,
+            agent: None,
+            pid: None,
+            cwd: cwd.to_path_buf(),
+            confirmed,
+            detail: None,
+        }
+    }
+
+    pub fn with_detail(mut self, detail: impl Into<String>) -> Self {
+        self.detail = Some(detail.into());
+        self
+    }
+
+    /// One-line description for the audit log popup
+    pub fn summary(&self) -> String {
+        let mut text = self.action.clone();
+        match &self.session {
+            Some(session) => text.push_str(&format!(" {}", session)),
+            None => text.push_str(&format!(" {}", self.cwd.display())),
+        }
+        if let Some(agent) = &self.agent {
+            text.push_str(&format!(" ({})", agent));
+        }
+        if let Some(pid) = self.pid {
+            text.push_str(&format!(" pid {}", pid));
+        }
+        if let Some(detail) = &self.detail {
+            text.push_str(&format!(": {}", detail));
+        }
+        if self.confirmed {
+            text.push_str(" [confirmed]");
+        }
+        text
+    }
+}
+
+/// Path of the audit log
+pub fn audit_path() -> PathBuf {
+    dirs::home_dir()
+        .unwrap_or_else(|| PathBuf::from("."))
+        .join(".amux")
+        .join("audit.jsonl")
+}
+
+/// Append an entry to the audit log; failures are logged, never fatal
+pub fn record(entry: &AuditEntry) {
+    log::log_event(&format!("Audit: {}", entry.summary()));
+    if let Err(e) = append(&audit_path(), entry) {
+        log::log(&format!("Failed to write audit log: {}", e));
+    }
+}
+
+fn append(path: &Path, entry: &AuditEntry) -> std::io::Result<()> {
+    if let Some(dir) = path.parent() {
+        std::fs::create_dir_all(dir)?;
+    }
+    let mut file = OpenOptions::new().create(true).append(true).open(path)?;
+    writeln!(file, "{}", serde_json::to_string(entry)?)
+}
+
+/// The most recent entries, newest first (unreadable lines are skipped)
+pub fn recent(limit: usize) -> Vec<AuditEntry> {
+    let Ok(file) = std::fs::File::open(audit_path()) else {
+        return vec![];
+    };
+    let entries: Vec<AuditEntry> = BufReader::new(file)
+        .lines()
+        .map_while(Result::ok)
+        .filter_map(|line| serde_json::from_str(&line).ok())
+        .collect();
+    entries.into_iter().rev().take(limit).collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_summary() {
+        let entry = AuditEntry::for_dir("delete-worktree", Path::new("/wt/fix"), true)
+            .with_detail("branch fix deleted too");
+        assert_eq!(
+            entry.summary(),
+            "delete-worktree /wt/fix: branch fix deleted too [confirmed]"
+        );
+
+        let mut entry = AuditEntry::for_dir("verify", Path::new("/wt/fix"), false);
+        entry.session = Some("api".to_string());
+        entry.agent = Some("Claude Code".to_string());
+        entry.pid = Some(4242);
+        let entry = entry.with_detail("cargo test");
+        assert_eq!(
+            entry.summary(),
+            "verify api (Claude Code) pid 4242: cargo test"
+        );
+    }
+}
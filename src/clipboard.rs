@@ -19,6 +19,12 @@ pub enum ClipboardContent {
     None,
 }
 
+/// Put text on the system clipboard
+pub fn write_text(text: &str) -> Result<()> {
+    Clipboard::new()?.set_text(text)?;
+    Ok(())
+}
+
 /// Read content from the system clipboard
 /// Prioritizes images over text
 pub fn read_clipboard() -> Result<ClipboardContent> {
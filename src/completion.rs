@@ -0,0 +1,213 @@
+//! Shell completion scripts for the amux CLI.
+//!
+//! Generated with `amux completion <bash|zsh|fish>`. The start directory
+//! argument completes to directories. Project names (`search --project`) and
+//! session IDs (`open amux://<session>/`) come from the running amux's
+//! snapshot and the archive: the scripts ask the hidden `amux __complete
+//! <projects|sessions>` for them on each completion.
+
+use std::path::Path;
+
+use crate::{archive, snapshot};
+
+/// Get the completion script for a shell, or None if the shell is unsupported
+pub fn script(shell: &str) -> Option<&'static str> {
+    match shell {
+        "bash" => Some(BASH),
+        "zsh" => Some(ZSH),
+        "fish" => Some(FISH),
+        _ => None,
+    }
+}
+
+/// Values `amux __complete <kind>` prints, or None for an unknown kind
+pub fn candidates(kind: &str) -> Option<Vec<String>> {
+    let live = snapshot::read().map(|s| s.agents).unwrap_or_default();
+    let archived = archive::entries();
+    match kind {
+        "projects" => Some(project_names(
+            live.iter()
+                .map(|a| a.cwd.as_path())
+                .chain(archived.iter().map(|e| e.cwd.as_path())),
+        )),
+        "sessions" => Some(unique(
+            live.into_iter()
+                .filter_map(|a| a.session_id)
+                .chain(archived.into_iter().filter_map(|e| e.session_id)),
+        )),
+        _ => None,
+    }
+}
+
+/// Directory names of the projects, each once
+fn project_names<'a>(cwds: impl Iterator<Item = &'a Path>) -> Vec<String> {
+    unique(cwds.filter_map(|cwd| Some(cwd.file_name()?.to_string_lossy().into_owned())))
+}
+
+/// `values` without repeats, in first-seen order
+fn unique(values: impl Iterator<Item = String>) -> Vec<String> {
+    let mut seen = std::collections::HashSet::new();
+    values.filter(|v| seen.insert(v.clone())).collect()
+}
+
+const BASH: &str = r#"# amux bash completion
+# Install: amux completion bash > /etc/bash_completion.d/amux
+_amux() {
+    local cur prev
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    case "$prev" in
+        -w|--worktree-dir)
+            COMPREPLY=($(compgen -d -- "$cur"))
+            return
+            ;;
+        completion)
+            COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+            return
+            ;;
+        config)
+            COMPREPLY=($(compgen -W "export import" -- "$cur"))
+            return
+            ;;
+        --project)
+            if [[ "${COMP_WORDS[1]}" == search ]]; then
+                COMPREPLY=($(compgen -W "$(amux __complete projects 2>/dev/null)" -- "$cur"))
+            else
+                COMPREPLY=($(compgen -d -- "$cur"))
+            fi
+            return
+            ;;
+        view)
+            COMPREPLY=($(compgen -f -- "$cur"))
+            return
+            ;;
+    esac
+
+    # Bash splits amux://<session>/ at the colon: complete the part after it
+    if [[ "${COMP_WORDS[1]}" == open ]]; then
+        if [[ "$prev" == : ]]; then
+            COMPREPLY=($(compgen -W "$(amux __complete sessions 2>/dev/null | sed 's|.*|//&/|')" -- "$cur"))
+        elif [[ $COMP_CWORD -eq 2 ]]; then
+            COMPREPLY=($(compgen -W "amux:// --register" -- "$cur"))
+        fi
+        return
+    fi
+
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W "-w --worktree-dir --no-color --screen-reader -V --version -h --help" -- "$cur"))
+    elif [[ $COMP_CWORD -eq 1 ]]; then
+        COMPREPLY=($(compgen -W "completion enqueue search open config digest doctor serve tmux-status view" -- "$cur") $(compgen -d -- "$cur"))
+    else
+        COMPREPLY=($(compgen -d -- "$cur"))
+    fi
+}
+complete -o filenames -F _amux amux
+"#;
+
+const ZSH: &str = r#"#compdef amux
+# amux zsh completion
+# Install: amux completion zsh > "${fpath[1]}/_amux"
+_amux() {
+    if (( CURRENT == 3 )) && [[ "${words[2]}" == completion ]]; then
+        _values 'shell' bash zsh fish
+        return
+    fi
+    if (( CURRENT == 3 )) && [[ "${words[2]}" == config ]]; then
+        _values 'action' export import
+        return
+    fi
+    if (( CURRENT == 3 )) && [[ "${words[2]}" == open ]]; then
+        compadd -S '' -- ${(f)"$(amux __complete sessions 2>/dev/null | sed 's|.*|amux://&/|')"}
+        return
+    fi
+    if [[ "${words[2]}" == search && "${words[CURRENT-1]}" == --project ]]; then
+        compadd -- ${(f)"$(amux __complete projects 2>/dev/null)"}
+        return
+    fi
+    if (( CURRENT == 3 )) && [[ "${words[2]}" == view ]]; then
+        _files -g '*.jsonl'
+        return
+    fi
+
+    _arguments \
+        '(-w --worktree-dir)'{-w,--worktree-dir}'[Directory for git worktrees]:path:_directories' \
+        '--no-color[Disable colors and use ASCII glyphs]' \
+        '--screen-reader[Linear layout for screen readers]' \
+        '(- *)'{-V,--version}'[Print version information]' \
+        '(- *)'{-h,--help}'[Print help message]' \
+        '1: :->first'
+
+    case "$state" in
+        first)
+            _alternative \
+                'commands:command:((completion\:"Generate shell completions" enqueue\:"Queue a task for the next idle agent in a project" search\:"Search archived sessions" open\:"Print a message by permalink or focus a session" config\:"Export or import the configuration" digest\:"Summarize the last week of sessions" doctor\:"Check the environment" serve\:"Read-only fleet view over SSH" tmux-status\:"Fleet state for the tmux status bar" view\:"Open a JSONL transcript"))' \
+                'directories:directory:_directories'
+            ;;
+    esac
+}
+_amux "$@"
+"#;
+
+const FISH: &str = r#"# amux fish completion
+# Install: amux completion fish > ~/.config/fish/completions/amux.fish
+complete -c amux -f
+complete -c amux -s w -l worktree-dir -r -a '(__fish_complete_directories)' -d 'Directory for git worktrees'
+complete -c amux -l no-color -d 'Disable colors and use ASCII glyphs'
+complete -c amux -l screen-reader -d 'Linear layout for screen readers'
+complete -c amux -s V -l version -d 'Print version information'
+complete -c amux -s h -l help -d 'Print help message'
+complete -c amux -n '__fish_use_subcommand' -a completion -d 'Generate shell completions'
+complete -c amux -n '__fish_use_subcommand' -a enqueue -d 'Queue a task for the next idle agent in a project'
+complete -c amux -n '__fish_use_subcommand' -a search -d 'Search archived sessions'
+complete -c amux -n '__fish_use_subcommand' -a open -d 'Print a message by permalink or focus a session'
+complete -c amux -n '__fish_use_subcommand' -a config -d 'Export or import the configuration'
+complete -c amux -n '__fish_use_subcommand' -a digest -d 'Summarize the last week of sessions'
+complete -c amux -n '__fish_use_subcommand' -a doctor -d 'Check the environment'
+complete -c amux -n '__fish_use_subcommand' -a serve -d 'Read-only fleet view over SSH'
+complete -c amux -n '__fish_use_subcommand' -a tmux-status -d 'Fleet state for the tmux status bar'
+complete -c amux -n '__fish_use_subcommand' -a view -d 'Open a JSONL transcript'
+complete -c amux -n '__fish_seen_subcommand_from config' -a 'export import'
+complete -c amux -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'
+complete -c amux -n '__fish_seen_subcommand_from serve' -a '--tui'
+complete -c amux -n '__fish_seen_subcommand_from view' -F
+complete -c amux -n '__fish_seen_subcommand_from open' -a '(amux __complete sessions 2>/dev/null | string replace -r "(.+)" "amux://\$1/")' -d 'Session'
+complete -c amux -n '__fish_seen_subcommand_from search' -l project -r -a '(amux __complete projects 2>/dev/null)' -d 'Project'
+complete -c amux -n '__fish_seen_subcommand_from enqueue' -l project -r -a '(__fish_complete_directories)' -d 'Project directory'
+complete -c amux -n 'not __fish_seen_subcommand_from completion enqueue search open config digest doctor serve tmux-status view' -a '(__fish_complete_directories)'
+"#;
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_supported_shells() {
+        assert!(
+            script("bash")
+                .unwrap()
+                .contains("complete -o filenames -F _amux amux")
+        );
+        assert!(script("zsh").unwrap().starts_with("#compdef amux"));
+        assert!(script("fish").unwrap().contains("complete -c amux"));
+        assert!(script("powershell").is_none());
+        for shell in ["bash", "zsh", "fish"] {
+            assert!(script(shell).unwrap().contains("amux __complete sessions"));
+        }
+    }
+
+    #[test]
+    fn test_candidates_are_unique() {
+        let cwds = [
+            Path::new("/srv/api"),
+            Path::new("/srv/web"),
+            Path::new("/home/me/api"),
+            Path::new("/"),
+        ];
+        assert_eq!(
+            project_names(cwds.into_iter()),
+            vec!["api".to_string(), "web".to_string()]
+        );
+        assert!(candidates("branches").is_none());
+    }
+}
@@ -13,6 +13,48 @@
 //! worktree_dir = "~/.amux/worktrees"
 //! default_agent = "ClaudeCode"
 //! theme = "dark"
+//! verify_command = "make test"
+//! summary_command = "claude -p 'Summarize this session transcript in a few bullet points'"
+//! allowed_write_dirs = ["/tmp", "~/.cache"]
+//!
+//! # Redaction applied to exported transcripts
+//! [redaction]
+//! builtin = true
+//! rules = [{ pattern = "[a-z0-9-]+\\.corp\\.example\\.com", replacement = "[HOST]" }]
+//!
+//! [notifications]
+//! quiet_hours = "22:00-08:00"
+//! quiet_weekends = true
+//!
+//! # Flag large diffs for review (doubling a threshold escalates the warning)
+//! [diff_warnings]
+//! files = 20
+//! lines = 500
+//!
+//! # Dim idle session rows a step further after each threshold (minutes)
+//! [row_fade]
+//! steps = [5, 15, 60]
+//!
+//! # Terminal bell / screen flash per event: "bell", "flash", "both" or "none"
+//! [alerts]
+//! permission = "bell"
+//! error = "flash"
+//!
+//! # Export a trace span per agent turn (child spans per tool call)
+//! [otlp]
+//! endpoint = "http://localhost:4318"
+//!
+//! # Thresholds for the agents working in one project (a `.amux.toml` in the
+//! # project can set them too)
+//! [[projects]]
+//! path = "~/work/monorepo"
+//! long_command_minutes = 30
+//! stall_secs = 600
+//!
+//! # Opt-in, anonymized performance metrics and crash reports (off by default)
+//! [telemetry]
+//! enabled = true
+//! endpoint = "http://telemetry.example.com/amux"
 //!
 //! # MCP servers available to all sessions
 //! [[mcp_servers]]
@@ -30,11 +72,16 @@
 #![allow(dead_code)]
 
 use std::collections::HashMap;
-use std::path::PathBuf;
+use std::path::{Path, PathBuf};
+use std::time::Duration;
 
 use serde::Deserialize;
 
-use crate::notification::NotificationConfig;
+use crate::git::{DiffSeverity, DiffStats, QueryLimits};
+use crate::log;
+use crate::notification::{NotificationConfig, QuietHours};
+use crate::pricing::{Price, Pricing};
+use crate::scope::expand_home;
 use crate::session::AgentType;
 
 /// Main configuration structure.
@@ -50,6 +97,15 @@ pub struct Config {
     /// Theme name to use (reserved for future use)
     pub theme: Option<String>,
 
+    /// Shell command run in a session's directory to verify the agent's work (e.g. "make test")
+    pub verify_command: Option<String>,
+
+    /// Command that reads a transcript on stdin and prints a summary (e.g. `claude -p "summarize"`)
+    pub summary_command: Option<String>,
+
+    /// Directories outside a session's project where agent writes are expected (e.g. "/tmp")
+    pub allowed_write_dirs: Vec<PathBuf>,
+
     /// Keybinding customization (reserved for future use)
     #[serde(default)]
     pub keybindings: KeyBindings,
@@ -61,6 +117,497 @@ pub struct Config {
     /// Desktop notification settings
     #[serde(default)]
     pub notifications: NotificationConfigFile,
+
+    /// Redaction rules applied to exported transcripts
+    #[serde(default)]
+    pub redaction: RedactionConfig,
+
+    /// Diff sizes at which a session's diff stats are flagged for review
+    #[serde(default)]
+    pub diff_warnings: DiffWarningConfig,
+
+    /// OpenTelemetry collector receiving a span per agent turn (disabled when unset)
+    pub otlp: Option<OtlpConfig>,
+
+    /// Anonymized performance metrics and crash reports (off unless enabled)
+    pub telemetry: Option<TelemetryConfig>,
+
+    /// Status page polled for Claude API health (disabled when unset)
+    pub api_status: Option<ApiStatusConfig>,
+
+    /// Terminal bell / screen flash per event type
+    #[serde(default)]
+    pub alerts: AlertConfig,
+
+    /// Dimming of session rows by time since the agent was last active
+    #[serde(default)]
+    pub row_fade: RowFadeConfig,
+
+    /// Follow-up prompts offered in the quick reply menu ('r'); built-in ones when empty
+    pub reply_templates: Vec<String>,
+
+    /// Token limits of Claude's subscription windows, to show usage as a percentage
+    #[serde(default)]
+    pub usage_limits: UsageLimitsConfig,
+
+    /// Limits on the git queries background refreshes run
+    #[serde(default)]
+    pub git: GitConfig,
+
+    /// Settings of `amux digest`
+    #[serde(default)]
+    pub digest: DigestConfig,
+
+    /// API prices to value subscription usage at in `amux digest`
+    #[serde(default)]
+    pub pricing: PricingConfig,
+
+    /// Write the session list to ~/.local/state/amux/agents.json every few seconds
+    pub snapshot: bool,
+
+    /// Globs of project directories left out of the folder picker and digest (e.g. "~/tmp/**")
+    pub exclude: Vec<String>,
+
+    /// Repaints per second while agents stream output (default 10); input repaints immediately
+    pub max_fps: Option<u32>,
+
+    /// Memory for session output in MB (default 512); beyond it, the oldest output of
+    /// sessions not viewed recently is moved to disk
+    pub memory_budget_mb: Option<usize>,
+
+    /// Minutes a Bash command may run without a result before the agent is flagged
+    /// and a notification is sent (default 5)
+    pub long_command_minutes: Option<u64>,
+
+    /// Minutes a session must have been idle for 'Z' to kill its agent (default 60)
+    pub idle_kill_minutes: Option<u64>,
+
+    /// Seconds a working agent may go without output before its row is marked stalled (default 90)
+    pub stall_secs: Option<u64>,
+
+    /// Per-project overrides of the thresholds above
+    pub projects: Vec<ProjectConfig>,
+
+    /// Fields shown for each session in the sidebar, in order (built-in set when empty)
+    pub row_fields: Vec<RowField>,
+}
+
+/// Quick replies offered when none are configured
+const DEFAULT_REPLY_TEMPLATES: &[&str] = &[
+    "continue",
+    "write tests for the change",
+    "explain your last change",
+    "run the tests and fix any failures",
+    "commit the change",
+];
+
+/// Row fields shown when none are configured
+const DEFAULT_ROW_FIELDS: &[RowField] = &[
+    RowField::Branch,
+    RowField::Diff,
+    RowField::Plan,
+    RowField::Procs,
+    RowField::Verify,
+    RowField::Mode,
+    RowField::Task,
+    RowField::Notes,
+    RowField::Commits,
+];
+
+/// A field of a session's row in the sidebar. Branch through mode share the
+/// line below the path; message, task, notes and commits get lines of their own.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum RowField {
+    /// Git branch and worktree marker
+    Branch,
+    /// Lines added and removed
+    Diff,
+    /// Plan progress and time to completion
+    Plan,
+    /// Running terminal commands
+    Procs,
+    /// Verify command result
+    Verify,
+    /// Agent mode
+    Mode,
+    /// The agent's latest message (also toggled with 'P')
+    Message,
+    /// Plan entry in progress
+    Task,
+    /// Scratchpad notes
+    Notes,
+    /// Commits made this session
+    Commits,
+}
+
+/// Terminal cue for an event: ring the bell, flash the screen, both, or nothing.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum AlertCue {
+    #[default]
+    None,
+    Bell,
+    Flash,
+    Both,
+}
+
+impl AlertCue {
+    pub fn bell(self) -> bool {
+        matches!(self, AlertCue::Bell | AlertCue::Both)
+    }
+
+    pub fn flash(self) -> bool {
+        matches!(self, AlertCue::Flash | AlertCue::Both)
+    }
+}
+
+/// Events that can trigger a terminal cue
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum AlertEvent {
+    Permission,
+    Question,
+    Complete,
+    Error,
+}
+
+/// Terminal cues per event type (all off by default).
+#[derive(Debug, Clone, Default, Deserialize)]
+#[serde(default)]
+pub struct AlertConfig {
+    /// Agent is waiting for a permission approval
+    pub permission: AlertCue,
+    /// Agent asked a clarifying question
+    pub question: AlertCue,
+    /// Agent finished its turn
+    pub complete: AlertCue,
+    /// Agent reported an error
+    pub error: AlertCue,
+}
+
+impl AlertConfig {
+    pub fn cue(&self, event: AlertEvent) -> AlertCue {
+        match event {
+            AlertEvent::Permission => self.permission,
+            AlertEvent::Question => self.question,
+            AlertEvent::Complete => self.complete,
+            AlertEvent::Error => self.error,
+        }
+    }
+}
+
+/// Dimming of idle session rows: fresh rows are bright, each threshold
+/// passed dims a row one step further, the last step is fully dim.
+#[derive(Debug, Clone, Deserialize)]
+#[serde(default)]
+pub struct RowFadeConfig {
+    /// Minutes of inactivity at which a row dims another step (empty disables fading)
+    pub steps: Vec<u64>,
+}
+
+impl Default for RowFadeConfig {
+    fn default() -> Self {
+        Self {
+            steps: vec![5, 15, 60],
+        }
+    }
+}
+
+impl RowFadeConfig {
+    /// How far a row has faded after `idle` without activity, from 0.0 (fresh) to 1.0 (dim)
+    pub fn fade(&self, idle: Duration) -> f32 {
+        if self.steps.is_empty() {
+            return 0.0;
+        }
+        let minutes = idle.as_secs() / 60;
+        let passed = self.steps.iter().filter(|&&step| minutes >= step).count();
+        passed as f32 / self.steps.len() as f32
+    }
+}
+
+/// File in a project directory that overrides thresholds for the agents working in it
+pub const PROJECT_FILE: &str = ".amux.toml";
+
+/// Idle and stall thresholds a project can override; unset ones fall back
+/// to the global settings.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Deserialize)]
+#[serde(default)]
+pub struct Thresholds {
+    pub long_command_minutes: Option<u64>,
+    pub idle_kill_minutes: Option<u64>,
+    pub stall_secs: Option<u64>,
+}
+
+impl Thresholds {
+    /// These thresholds, with unset ones taken from `other`
+    pub fn or(self, other: Thresholds) -> Thresholds {
+        Thresholds {
+            long_command_minutes: self.long_command_minutes.or(other.long_command_minutes),
+            idle_kill_minutes: self.idle_kill_minutes.or(other.idle_kill_minutes),
+            stall_secs: self.stall_secs.or(other.stall_secs),
+        }
+    }
+}
+
+/// Thresholds for the sessions in a project directory (`[[projects]]`)
+#[derive(Debug, Clone, Deserialize)]
+pub struct ProjectConfig {
+    /// Project directory; sessions in it or below it use the thresholds
+    pub path: PathBuf,
+    #[serde(flatten)]
+    pub thresholds: Thresholds,
+}
+
+/// Threshold overrides for sessions working in `cwd`. The `[[projects]]`
+/// entry with the longest path containing `cwd` comes first, then the
+/// `.amux.toml` in `cwd` or the nearest directory above it that has one.
+pub fn project_thresholds(projects: &[ProjectConfig], cwd: &Path) -> Thresholds {
+    let configured = projects
+        .iter()
+        .map(|project| (expand_home(&project.path), project.thresholds))
+        .filter(|(path, _)| cwd.starts_with(path))
+        .max_by_key(|(path, _)| path.components().count())
+        .map(|(_, thresholds)| thresholds)
+        .unwrap_or_default();
+    configured.or(read_project_file(cwd))
+}
+
+/// Thresholds from the nearest `.amux.toml` at or above `cwd`; one that
+/// fails to parse is logged and ignored
+fn read_project_file(cwd: &Path) -> Thresholds {
+    let Some((path, contents)) = cwd.ancestors().find_map(|dir| {
+        let path = dir.join(PROJECT_FILE);
+        std::fs::read_to_string(&path).ok().map(|c| (path, c))
+    }) else {
+        return Thresholds::default();
+    };
+    toml::from_str(&contents).unwrap_or_else(|e| {
+        log::log(&format!("Ignoring {}: {}", path.display(), e));
+        Thresholds::default()
+    })
+}
+
+/// Token limits of Claude's 5-hour and weekly usage windows. The real limits
+/// aren't published; set them from experience with your plan.
+#[derive(Debug, Clone, Copy, Default, Deserialize)]
+#[serde(default)]
+pub struct UsageLimitsConfig {
+    pub five_hour_tokens: Option<u64>,
+    pub weekly_tokens: Option<u64>,
+    /// Hold queued prompts while the 5-hour window, projected at the current
+    /// pace, would pass this percentage of `five_hour_tokens`
+    pub dispatch_max_percent: Option<u64>,
+}
+
+impl UsageLimitsConfig {
+    /// Whether anything uses the subscription window usage (without limits,
+    /// Claude's session files aren't scanned)
+    pub fn is_set(&self) -> bool {
+        self.five_hour_tokens.is_some()
+            || self.weekly_tokens.is_some()
+            || self.dispatch_max_percent.is_some()
+    }
+}
+
+/// Settings of `amux digest`.
+#[derive(Debug, Clone, Default, Deserialize)]
+#[serde(default)]
+pub struct DigestConfig {
+    /// Regex finding the ticket in a branch name, to total tokens per ticket
+    /// (default: JIRA-style keys like "PROJ-123")
+    pub ticket_pattern: Option<String>,
+}
+
+/// API prices to value subscription usage at. Built-in list prices cover
+/// Claude's models; entries here override them.
+#[derive(Debug, Clone, Default, Deserialize)]
+#[serde(default)]
+pub struct PricingConfig {
+    /// What the subscription costs per month (e.g. 200 for Max 20x), shown
+    /// next to the API equivalent
+    pub plan_usd_per_month: Option<f64>,
+    pub models: Vec<ModelPriceConfig>,
+}
+
+/// Prices in USD per million tokens for models whose name contains `model`
+#[derive(Debug, Clone, Deserialize)]
+pub struct ModelPriceConfig {
+    pub model: String,
+    pub input: f64,
+    pub output: f64,
+    /// Defaults to 1.25 times `input`
+    pub cache_write: Option<f64>,
+    /// Defaults to a tenth of `input`
+    pub cache_read: Option<f64>,
+}
+
+impl PricingConfig {
+    pub fn pricing(&self) -> Pricing {
+        let models = self
+            .models
+            .iter()
+            .map(|m| {
+                let mut price = Price::new(m.input, m.output);
+                price.cache_write = m.cache_write.unwrap_or(price.cache_write);
+                price.cache_read = m.cache_read.unwrap_or(price.cache_read);
+                (m.model.clone(), price)
+            })
+            .collect();
+        Pricing {
+            models,
+            plan_usd_per_month: self.plan_usd_per_month,
+        }
+    }
+}
+
+/// Limits on read-only git queries (diff stats, changed files, commits).
+#[derive(Debug, Clone, Deserialize)]
+#[serde(default)]
+pub struct GitConfig {
+    /// Queries running at once
+    pub max_concurrent: usize,
+    /// Seconds after which a query is killed (e.g. git hanging on a network mount)
+    pub timeout_secs: u64,
+}
+
+impl Default for GitConfig {
+    fn default() -> Self {
+        let limits = QueryLimits::default();
+        Self {
+            max_concurrent: limits.max_concurrent,
+            timeout_secs: limits.timeout.as_secs(),
+        }
+    }
+}
+
+impl GitConfig {
+    pub fn query_limits(&self) -> QueryLimits {
+        QueryLimits {
+            max_concurrent: self.max_concurrent.max(1),
+            timeout: Duration::from_secs(self.timeout_secs.max(1)),
+        }
+    }
+}
+
+/// OpenTelemetry trace export settings.
+#[derive(Debug, Clone, Deserialize)]
+pub struct OtlpConfig {
+    /// OTLP/HTTP collector URL, e.g. "http://localhost:4318"
+    pub endpoint: String,
+
+    /// `service.name` resource attribute on exported spans
+    #[serde(default = "default_otlp_service_name")]
+    pub service_name: String,
+}
+
+fn default_otlp_service_name() -> String {
+    "amux".to_string()
+}
+
+/// Opt-in telemetry settings. Nothing is sent unless `enabled` is set.
+#[derive(Debug, Clone, Deserialize)]
+pub struct TelemetryConfig {
+    /// Send events; off unless set
+    #[serde(default)]
+    pub enabled: bool,
+
+    /// http(s) endpoint events are posted to as JSON, e.g. a self-hosted collector
+    pub endpoint: String,
+}
+
+/// Claude API status polling settings.
+#[derive(Debug, Clone, Deserialize)]
+#[serde(default)]
+pub struct ApiStatusConfig {
+    /// Statuspage `status.json` endpoint
+    pub url: String,
+    /// Seconds between polls
+    pub interval_secs: u64,
+}
+
+impl Default for ApiStatusConfig {
+    fn default() -> Self {
+        Self {
+            url: "https://status.anthropic.com/api/v2/status.json".to_string(),
+            interval_secs: 120,
+        }
+    }
+}
+
+impl ApiStatusConfig {
+    pub fn interval(&self) -> Duration {
+        Duration::from_secs(self.interval_secs.max(30))
+    }
+}
+
+/// Thresholds for flagging large uncommitted diffs.
+#[derive(Debug, Clone, Deserialize)]
+#[serde(default)]
+pub struct DiffWarningConfig {
+    /// Number of changed files at which a diff counts as large
+    pub files: usize,
+    /// Number of changed lines (insertions + deletions) at which a diff counts as large
+    pub lines: usize,
+}
+
+impl Default for DiffWarningConfig {
+    fn default() -> Self {
+        Self {
+            files: 20,
+            lines: 500,
+        }
+    }
+}
+
+impl DiffWarningConfig {
+    /// Classify a diff: large over a threshold, huge over twice a threshold
+    pub fn severity(&self, stats: &DiffStats) -> DiffSeverity {
+        let lines = stats.insertions + stats.deletions;
+        let over = |factor: usize| {
+            stats.files_changed >= self.files * factor || lines >= self.lines * factor
+        };
+        if over(2) {
+            DiffSeverity::Huge
+        } else if over(1) {
+            DiffSeverity::Large
+        } else {
+            DiffSeverity::Normal
+        }
+    }
+}
+
+/// Redaction configuration for transcript exports.
+#[derive(Debug, Clone, Deserialize)]
+#[serde(default)]
+pub struct RedactionConfig {
+    /// Whether the built-in secret detectors (API keys, tokens, emails) are enabled
+    pub builtin: bool,
+    /// Additional regex rules
+    pub rules: Vec<RedactionRule>,
+}
+
+impl Default for RedactionConfig {
+    fn default() -> Self {
+        Self {
+            builtin: true,
+            rules: vec![],
+        }
+    }
+}
+
+/// A custom redaction rule
+#[derive(Debug, Clone, Deserialize)]
+pub struct RedactionRule {
+    /// Regex matched against each transcript line
+    pub pattern: String,
+
+    /// Placeholder that replaces each match
+    #[serde(default = "default_redaction_replacement")]
+    pub replacement: String,
+}
+
+fn default_redaction_replacement() -> String {
+    "[REDACTED]".to_string()
 }
 
 /// Notification configuration from config file.
@@ -73,6 +620,10 @@ pub struct NotificationConfigFile {
     pub idle_delay_secs: u64,
     /// Minimum seconds between same notification type (prevents spam)
     pub dedupe_interval_secs: u64,
+    /// Daily window without notifications, e.g. "22:00-08:00"
+    pub quiet_hours: Option<String>,
+    /// Suppress notifications all day on Saturdays and Sundays
+    pub quiet_weekends: bool,
 }
 
 impl Default for NotificationConfigFile {
@@ -81,16 +632,34 @@ impl Default for NotificationConfigFile {
             enabled: true,
             idle_delay_secs: 5,
             dedupe_interval_secs: 30,
+            quiet_hours: None,
+            quiet_weekends: false,
         }
     }
 }
 
 impl From<NotificationConfigFile> for NotificationConfig {
     fn from(file: NotificationConfigFile) -> Self {
+        let window = file.quiet_hours.as_deref().and_then(|spec| {
+            let window = QuietHours::parse_window(spec);
+            // Config::validate reports this; the TUI may be running, so
+            // don't write to the terminal
+            if window.is_none() {
+                log::log(&format!(
+                    "Invalid quiet_hours \"{}\" (expected HH:MM-HH:MM)",
+                    spec
+                ));
+            }
+            window
+        });
         Self {
             enabled: file.enabled,
             idle_delay_secs: file.idle_delay_secs,
             dedupe_interval_secs: file.dedupe_interval_secs,
+            quiet_hours: QuietHours {
+                window,
+                weekends: file.quiet_weekends,
+            },
         }
     }
 }
@@ -148,6 +717,35 @@ impl Config {
         }
     }
 
+    /// Read the config file again while amux is running. Unlike
+    /// [`Config::load`], a file that fails to parse or validate is an error
+    /// instead of the defaults, so the running settings can be kept.
+    pub fn reload() -> anyhow::Result<Self> {
+        let config_path = Self::config_path();
+        if !config_path.exists() {
+            return Ok(Self::default());
+        }
+        let contents = std::fs::read_to_string(&config_path)?;
+        let config: Config = toml::from_str(&contents)?;
+        config.validate()?;
+        Ok(config)
+    }
+
+    /// Check values that parse but can't be used
+    pub fn validate(&self) -> anyhow::Result<()> {
+        if let Some(spec) = &self.notifications.quiet_hours
+            && QuietHours::parse_window(spec).is_none()
+        {
+            anyhow::bail!("invalid quiet_hours \"{}\" (expected HH:MM-HH:MM)", spec);
+        }
+        for rule in &self.redaction.rules {
+            if let Err(e) = regex::Regex::new(&rule.pattern) {
+                anyhow::bail!("invalid redaction pattern {:?}: {}", rule.pattern, e);
+            }
+        }
+        Ok(())
+    }
+
     /// Get the default configuration file path.
     pub fn config_path() -> PathBuf {
         dirs::config_dir()
@@ -156,6 +754,70 @@ impl Config {
             .join("config.toml")
     }
 
+    /// Contents of the config file for `amux config export`, with a header
+    /// noting where it came from, and the state files amux keeps across runs
+    /// (hidden sessions and notes) bundled as comments at the end
+    pub fn export() -> std::io::Result<String> {
+        let config_path = Self::config_path();
+        let contents = if config_path.exists() {
+            std::fs::read_to_string(&config_path)?
+        } else {
+            String::new()
+        };
+        let mut state = vec![];
+        for (name, path) in state_files() {
+            if path.exists() {
+                state.push((name.to_string(), std::fs::read_to_string(&path)?));
+            }
+        }
+        Ok(format!(
+            "# amux configuration exported {} (amux {})\n{}",
+            chrono::Local::now().format("%Y-%m-%d %H:%M"),
+            env!("CARGO_PKG_VERSION"),
+            bundle_state(&contents, &state)
+        ))
+    }
+
+    /// Install an exported config for `amux config import`, with the state
+    /// files bundled into it. Each file replaced is backed up next to it
+    /// (`config.toml.bak`, `hidden.json.bak`). Returns the files written and
+    /// their backups. Invalid configs and state are rejected before anything
+    /// is written.
+    pub fn import(contents: &str) -> anyhow::Result<Vec<(PathBuf, Option<PathBuf>)>> {
+        let (contents, state) = split_state(contents);
+        toml::from_str::<Config>(&contents)
+            .map_err(|e| anyhow::anyhow!("not a valid amux config: {}", e))?;
+        let known = state_files();
+        let mut files = vec![(Self::config_path(), contents)];
+        for (name, text) in state {
+            let Some((_, path)) = known.iter().find(|(known, _)| *known == name) else {
+                anyhow::bail!("unknown state file {}", name);
+            };
+            serde_json::from_str::<serde_json::Value>(&text)
+                .map_err(|e| anyhow::anyhow!("{} is not valid JSON: {}", name, e))?;
+            files.push((path.clone(), text));
+        }
+
+        let mut written = vec![];
+        for (path, text) in files {
+            if let Some(dir) = path.parent() {
+                std::fs::create_dir_all(dir)?;
+            }
+            let backup = if path.exists() {
+                let mut backup = path.clone().into_os_string();
+                backup.push(".bak");
+                let backup = PathBuf::from(backup);
+                std::fs::copy(&path, &backup)?;
+                Some(backup)
+            } else {
+                None
+            };
+            std::fs::write(&path, text)?;
+            written.push((path, backup));
+        }
+        Ok(written)
+    }
+
     /// Get the configuration directory path.
     pub fn config_dir() -> PathBuf {
         dirs::config_dir()
@@ -196,12 +858,115 @@ impl Config {
     pub fn default_agent(&self) -> AgentType {
         self.default_agent.unwrap_or(AgentType::ClaudeCode)
     }
+
+    /// Get the sidebar row fields, falling back to the built-in ones.
+    pub fn row_fields(&self) -> Vec<RowField> {
+        if self.row_fields.is_empty() {
+            DEFAULT_ROW_FIELDS.to_vec()
+        } else {
+            self.row_fields.clone()
+        }
+    }
+
+    /// Get the quick reply templates, falling back to the built-in ones.
+    pub fn reply_templates(&self) -> Vec<String> {
+        if self.reply_templates.is_empty() {
+            DEFAULT_REPLY_TEMPLATES
+                .iter()
+                .map(|t| t.to_string())
+                .collect()
+        } else {
+            self.reply_templates.clone()
+        }
+    }
+}
+
+/// State files amux keeps across runs that `amux config export` bundles with
+/// the config. Work orders stay behind: the amux on this machine dispatches
+/// them.
+fn state_files() -> Vec<(&'static str, PathBuf)> {
+    vec![
+        ("hidden.json", crate::hidden::hidden_path()),
+        ("notes.json", crate::notes::notes_path()),
+        (
+            "plan_history.json",
+            crate::plan_history::plan_history_path(),
+        ),
+    ]
+}
+
+/// Line starting a state file bundled into an export
+const STATE_HEADER: &str = "# amux state: ";
+
+/// Prefix of the lines of a bundled state file. They are comments, so an
+/// export is still a valid config.toml.
+const STATE_LINE: &str = "#|";
+
+/// Append state files (name, contents) to a config as comments
+fn bundle_state(config: &str, state: &[(String, String)]) -> String {
+    let mut out = config.to_string();
+    for (name, text) in state {
+        if !out.is_empty() && !out.ends_with('\n') {
+            out.push('\n');
+        }
+        out.push_str(&format!("\n{}{}\n", STATE_HEADER, name));
+        for line in text.lines() {
+            out.push_str(&format!("{} {}\n", STATE_LINE, line));
+        }
+    }
+    out
+}
+
+/// Split an export into the config and the state files bundled into it
+fn split_state(contents: &str) -> (String, Vec<(String, String)>) {
+    let mut config = String::new();
+    let mut state: Vec<(String, String)> = vec![];
+    for line in contents.lines() {
+        if let Some(name) = line.strip_prefix(STATE_HEADER) {
+            state.push((name.trim().to_string(), String::new()));
+        } else if let Some(line) = line.strip_prefix(STATE_LINE)
+            && let Some((_, text)) = state.last_mut()
+        {
+            text.push_str(line.strip_prefix(' ').unwrap_or(line));
+            text.push('\n');
+        } else {
+            config.push_str(line);
+            config.push('\n');
+        }
+    }
+    (config, state)
 }
 
 #[cfg(test)]
 mod tests {
     use super::*;
 
+    #[test]
+    fn test_bundle_state() {
+        let config = "snapshot = true\n";
+        let state = vec![
+            ("hidden.json".to_string(), "[\n  \"abc\"\n]".to_string()),
+            (
+                "notes.json".to_string(),
+                "{\"abc\": \"#| not a marker\"}".to_string(),
+            ),
+        ];
+        let bundle = bundle_state(config, &state);
+        // Still a valid config
+        assert!(toml::from_str::<Config>(&bundle).unwrap().snapshot);
+
+        let (config, restored) = split_state(&bundle);
+        assert_eq!(config.trim(), "snapshot = true");
+        assert_eq!(
+            restored[0],
+            ("hidden.json".to_string(), "[\n  \"abc\"\n]\n".to_string())
+        );
+        assert_eq!(restored[1].1, "{\"abc\": \"#| not a marker\"}\n");
+
+        // Exports from before state was bundled are all config
+        assert!(split_state(config.as_str()).1.is_empty());
+    }
+
     #[test]
     fn test_default_config() {
         let config = Config::default();
@@ -216,11 +981,152 @@ mod tests {
             worktree_dir = "/tmp/worktrees"
             default_agent = "ClaudeCode"
             theme = "dark"
+            verify_command = "make test"
+            allowed_write_dirs = ["/tmp"]
         "#;
 
         let config: Config = toml::from_str(toml).unwrap();
         assert_eq!(config.worktree_dir, Some(PathBuf::from("/tmp/worktrees")));
         assert_eq!(config.default_agent, Some(AgentType::ClaudeCode));
         assert_eq!(config.theme, Some("dark".to_string()));
+        assert_eq!(config.verify_command, Some("make test".to_string()));
+        assert_eq!(config.allowed_write_dirs, vec![PathBuf::from("/tmp")]);
+    }
+
+    #[test]
+    fn test_validate() {
+        assert!(Config::default().validate().is_ok());
+
+        let config: Config = toml::from_str("[notifications]\nquiet_hours = \"late\"").unwrap();
+        assert!(config.validate().is_err());
+
+        let config: Config = toml::from_str("[redaction]\nrules = [{ pattern = \"(\" }]").unwrap();
+        assert!(config.validate().is_err());
+    }
+
+    #[test]
+    fn test_git_query_limits() {
+        let limits = Config::default().git.query_limits();
+        assert_eq!(limits.max_concurrent, 4);
+        assert_eq!(limits.timeout, Duration::from_secs(10));
+
+        let config: Config =
+            toml::from_str("[git]\nmax_concurrent = 0\ntimeout_secs = 30").unwrap();
+        let limits = config.git.query_limits();
+        assert_eq!(limits.max_concurrent, 1);
+        assert_eq!(limits.timeout, Duration::from_secs(30));
+    }
+
+    #[test]
+    fn test_reply_templates() {
+        assert_eq!(Config::default().reply_templates()[0], "continue");
+
+        let config: Config = toml::from_str(r#"reply_templates = ["ship it"]"#).unwrap();
+        assert_eq!(config.reply_templates(), vec!["ship it".to_string()]);
+    }
+
+    #[test]
+    fn test_row_fields() {
+        assert_eq!(Config::default().row_fields()[0], RowField::Branch);
+
+        let config: Config = toml::from_str(r#"row_fields = ["message", "branch"]"#).unwrap();
+        assert_eq!(
+            config.row_fields(),
+            vec![RowField::Message, RowField::Branch]
+        );
+        assert!(toml::from_str::<Config>(r#"row_fields = ["cpu"]"#).is_err());
+    }
+
+    #[test]
+    fn test_parse_alert_config() {
+        let toml = r#"
+            [alerts]
+            permission = "bell"
+            error = "both"
+        "#;
+
+        let config: Config = toml::from_str(toml).unwrap();
+        assert!(config.alerts.cue(AlertEvent::Permission).bell());
+        assert!(!config.alerts.cue(AlertEvent::Permission).flash());
+        assert!(config.alerts.cue(AlertEvent::Error).flash());
+        assert_eq!(config.alerts.cue(AlertEvent::Complete), AlertCue::None);
+    }
+
+    #[test]
+    fn test_project_thresholds() {
+        let dir = std::env::temp_dir().join(format!("amux-project-{}", std::process::id()));
+        let cwd = dir.join("api").join("src");
+        std::fs::create_dir_all(&cwd).unwrap();
+        std::fs::write(
+            dir.join("api").join(PROJECT_FILE),
+            "long_command_minutes = 30\nstall_secs = 120",
+        )
+        .unwrap();
+
+        let config: Config = toml::from_str(&format!(
+            "[[projects]]\npath = {:?}\nstall_secs = 600\nidle_kill_minutes = 10\n\n\
+             [[projects]]\npath = {:?}\nidle_kill_minutes = 5",
+            dir.to_str().unwrap(),
+            dir.join("api").to_str().unwrap()
+        ))
+        .unwrap();
+        let thresholds = project_thresholds(&config.projects, &cwd);
+        std::fs::remove_dir_all(&dir).unwrap();
+
+        assert_eq!(
+            thresholds,
+            Thresholds {
+                long_command_minutes: Some(30),
+                idle_kill_minutes: Some(5),
+                stall_secs: Some(120),
+            }
+        );
+    }
+
+    #[test]
+    fn test_row_fade_steps() {
+        let fade = RowFadeConfig::default();
+        assert_eq!(fade.fade(Duration::from_secs(60)), 0.0);
+        assert!((fade.fade(Duration::from_secs(20 * 60)) - 2.0 / 3.0).abs() < f32::EPSILON);
+        assert_eq!(fade.fade(Duration::from_secs(2 * 3600)), 1.0);
+
+        let config: Config = toml::from_str("[row_fade]\nsteps = []").unwrap();
+        assert_eq!(config.row_fade.fade(Duration::from_secs(2 * 3600)), 0.0);
+    }
+
+    #[test]
+    fn test_parse_redaction_config() {
+        let toml = r#"
+            [redaction]
+            builtin = false
+            rules = [
+                { pattern = "internal\\.example\\.com", replacement = "[HOST]" },
+                { pattern = "secret-\\d+" },
+            ]
+        "#;
+
+        let config: Config = toml::from_str(toml).unwrap();
+        assert!(!config.redaction.builtin);
+        assert_eq!(config.redaction.rules.len(), 2);
+        assert_eq!(config.redaction.rules[0].replacement, "[HOST]");
+        assert_eq!(config.redaction.rules[1].replacement, "[REDACTED]");
+        assert!(Config::default().redaction.builtin);
+    }
+
+    #[test]
+    fn test_diff_warning_severity() {
+        let config: Config = toml::from_str("[diff_warnings]\nlines = 100").unwrap();
+        let warnings = config.diff_warnings;
+        assert_eq!(warnings.files, 20);
+
+        let stats = |files_changed, insertions| DiffStats {
+            files_changed,
+            insertions,
+            deletions: 0,
+        };
+        assert_eq!(warnings.severity(&stats(3, 50)), DiffSeverity::Normal);
+        assert_eq!(warnings.severity(&stats(3, 100)), DiffSeverity::Large);
+        assert_eq!(warnings.severity(&stats(25, 10)), DiffSeverity::Large);
+        assert_eq!(warnings.severity(&stats(3, 250)), DiffSeverity::Huge);
     }
 }
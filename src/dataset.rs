@@ -0,0 +1,250 @@
+//! Fine-tuning dataset export of tagged turns.
+//!
+//! Each turn (a prompt and everything the agent did until the next prompt)
+//! that contains a tagged message becomes one line of
+//! `~/.amux/exports/dataset.jsonl` in the common chat schema: a `messages`
+//! array of `user`, `assistant` and `tool` messages, with tool calls as
+//! OpenAI-style `tool_calls` whose arguments are the tool's JSON input. The
+//! file is only ever appended to, so harvesting good sessions over time
+//! builds one dataset. Everything written passes through the redactor.
+
+use std::io::Write;
+use std::path::PathBuf;
+
+use serde_json::{Value, json};
+
+use crate::redact::Redactor;
+use crate::session::{MessageTag, OutputType, Session};
+use crate::transcript;
+
+/// Turns of a session with a tag matching `filter` (any tag when None), one
+/// dataset record each
+pub fn records(session: &Session, filter: Option<MessageTag>) -> Vec<Value> {
+    // Turns start at each run of prompt lines
+    let mut starts: Vec<usize> = vec![];
+    for (index, line) in session.output.iter().enumerate() {
+        let after_prompt =
+            index > 0 && session.output[index - 1].line_type == OutputType::UserInput;
+        if line.line_type == OutputType::UserInput && !after_prompt {
+            starts.push(index);
+        }
+    }
+
+    let mut records = vec![];
+    for (i, &start) in starts.iter().enumerate() {
+        let end = starts.get(i + 1).copied().unwrap_or(session.output.len());
+        let tags: Vec<MessageTag> = session
+            .message_tags
+            .range(start..end)
+            .map(|(_, tag)| *tag)
+            .filter(|tag| filter.is_none_or(|wanted| *tag == wanted))
+            .collect();
+        if tags.is_empty() {
+            continue;
+        }
+        let mut labels: Vec<&str> = tags.iter().map(MessageTag::label).collect();
+        labels.sort_unstable();
+        labels.dedup();
+
+        records.push(json!({
+            "messages": turn_messages(session, start, end),
+            "metadata": {
+                "session": session.name,
+                "agent": session.agent_type.display_name(),
+                "tags": labels,
+            },
+        }));
+    }
+    records
+}
+
+/// Chat messages of the output lines in `start..end`
+fn turn_messages(session: &Session, start: usize, end: usize) -> Vec<Value> {
+    let mut messages: Vec<Value> = vec![];
+    let mut tool_call_id: Option<String> = None;
+
+    for line in &session.output[start..end] {
+        let (role, content) = match &line.line_type {
+            OutputType::UserInput => ("user", line.content.clone()),
+            OutputType::Text if line.content.trim().is_empty() => continue,
+            OutputType::Text => ("assistant", line.content.clone()),
+            OutputType::ToolCall {
+                tool_call_id: id,
+                name,
+                raw_json,
+                ..
+            } => {
+                let call = json!({
+                    "id": id,
+                    "type": "function",
+                    "function": {
+                        "name": name,
+                        "arguments": tool_arguments(raw_json).to_string(),
+                    },
+                });
+                // Calls attach to the assistant message they follow
+                match messages.last_mut() {
+                    Some(last) if last["role"] == "assistant" => {
+                        match last["tool_calls"].as_array_mut() {
+                            Some(calls) => calls.push(call),
+                            None => last["tool_calls"] = json!([call]),
+                        }
+                    }
+                    _ => messages.push(json!({
+                        "role": "assistant",
+                        "content": "",
+                        "tool_calls": [call],
+                    })),
+                }
+                tool_call_id = Some(id.clone());
+                continue;
+            }
+            OutputType::ToolOutput | OutputType::WebResult { .. } => ("tool", line.content.clone()),
+            OutputType::DiffAdd => ("tool", format!("+{}", line.content)),
+            OutputType::DiffRemove => ("tool", format!("-{}", line.content)),
+            OutputType::DiffContext => ("tool", format!(" {}", line.content)),
+            OutputType::DiffHeader => ("tool", line.content.clone()),
+            // Thinking, the user's own shell commands and amux's messages
+            // aren't part of the conversation with the model
+            OutputType::Thought
+            | OutputType::Error
+            | OutputType::BashCommand
+            | OutputType::BashOutput
+            | OutputType::SystemMessage
+            | OutputType::RawJson => continue,
+        };
+
+        // Consecutive lines of the same speaker form one message; an
+        // assistant message with tool calls is followed by their results
+        let continues = messages.last().is_some_and(|last| {
+            last["role"] == role && (role != "assistant" || last.get("tool_calls").is_none())
+        });
+        if continues {
+            let last = messages.last_mut().expect("checked above");
+            let text = format!("{}\n{}", last["content"].as_str().unwrap_or(""), content);
+            last["content"] = Value::String(text);
+        } else if role == "tool" {
+            messages.push(json!({
+                "role": "tool",
+                "tool_call_id": tool_call_id.clone().unwrap_or_default(),
+                "content": content,
+            }));
+        } else {
+            messages.push(json!({ "role": role, "content": content }));
+        }
+    }
+    messages
+}
+
+/// JSON input of a tool call, from its ACP request (empty object if unknown)
+fn tool_arguments(raw_json: &[String]) -> Value {
+    raw_json
+        .iter()
+        .filter_map(|json| serde_json::from_str::<Value>(json).ok())
+        .map(|value| value.get("rawInput").cloned().unwrap_or(value))
+        .find(|input| input.is_object())
+        .unwrap_or_else(|| json!({}))
+}
+
+/// Append the tagged turns of all sessions to the dataset file, returning its
+/// path and the number of records written
+pub fn export(
+    sessions: &[Session],
+    filter: Option<MessageTag>,
+    redactor: &Redactor,
+) -> std::io::Result<(PathBuf, usize)> {
+    let path = transcript::export_dir()?.join("dataset.jsonl");
+    let mut out = String::new();
+    let mut count = 0;
+    for session in sessions {
+        for record in records(session, filter) {
+            out.push_str(&redactor.redact(&record.to_string()));
+            out.push('\n');
+            count += 1;
+        }
+    }
+
+    if count > 0 {
+        let mut file = std::fs::OpenOptions::new()
+            .create(true)
+            .append(true)
+            .open(&path)?;
+        file.write_all(out.as_bytes())?;
+    }
+    Ok((path, count))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::session::AgentType;
+
+    fn session() -> Session {
+        let mut session = Session::mock("s1", "api", AgentType::ClaudeCode, "main");
+        session.add_output("fix the login bug".to_string(), OutputType::UserInput);
+        session.add_output("Let me look.".to_string(), OutputType::Text);
+        session.add_tool_call(
+            "t1".to_string(),
+            "Read auth.rs".to_string(),
+            None,
+            Some(r#"{"rawInput":{"file_path":"auth.rs"}}"#.to_string()),
+        );
+        session.add_output("fn login() {}".to_string(), OutputType::ToolOutput);
+        session.add_output("Fixed it.".to_string(), OutputType::Text);
+        session.add_output("now add a test".to_string(), OutputType::UserInput);
+        session.add_output("Done.".to_string(), OutputType::Text);
+        session
+    }
+
+    #[test]
+    fn test_only_tagged_turns() {
+        let mut session = session();
+        assert!(records(&session, None).is_empty());
+
+        // Tag "Fixed it." in the first turn
+        let fixed = session
+            .output
+            .iter()
+            .position(|l| l.content == "Fixed it.")
+            .unwrap();
+        session.message_tags.insert(fixed, MessageTag::Decision);
+
+        let records = records(&session, None);
+        assert_eq!(records.len(), 1);
+        assert_eq!(records[0]["metadata"]["tags"], json!(["decision"]));
+        assert!(super::records(&session, Some(MessageTag::Bug)).is_empty());
+        assert_eq!(
+            super::records(&session, Some(MessageTag::Decision)).len(),
+            1
+        );
+    }
+
+    #[test]
+    fn test_tool_calls_normalized() {
+        let mut session = session();
+        let last = session.output.len() - 1;
+        session.message_tags.insert(last, MessageTag::Todo);
+        let first = session
+            .output
+            .iter()
+            .position(|l| l.content == "Let me look.")
+            .unwrap();
+        session.message_tags.insert(first, MessageTag::Todo);
+
+        let records = records(&session, Some(MessageTag::Todo));
+        assert_eq!(records.len(), 2);
+        let messages = records[0]["messages"].as_array().unwrap();
+        let roles: Vec<&str> = messages
+            .iter()
+            .map(|m| m["role"].as_str().unwrap())
+            .collect();
+        assert_eq!(roles, ["user", "assistant", "tool", "assistant"]);
+        assert_eq!(messages[1]["content"], "Let me look.");
+        let call = &messages[1]["tool_calls"][0];
+        assert_eq!(call["id"], "t1");
+        assert_eq!(call["function"]["name"], "Read auth.rs");
+        assert_eq!(call["function"]["arguments"], r#"{"file_path":"auth.rs"}"#);
+        assert_eq!(messages[2]["tool_call_id"], "t1");
+        assert_eq!(messages[2]["content"], "fn login() {}");
+    }
+}
@@ -0,0 +1,711 @@
+//! Weekly digest of agent work (`amux digest --week`).
+//!
+//! Built from Claude Code's session files (`~/.claude/projects/*/*.jsonl`),
+//! which record every session's directory, timestamps, token usage, todo
+//! lists and failed tool calls. The digest is Markdown meant to be pasted
+//! into a weekly report: sessions per project, tasks completed, tokens used,
+//! the longest sessions and the errors that came up most. Subagents record
+//! their work in transcripts of their own, which are rolled up into the
+//! session that started them, so a session's tokens and cost include its
+//! subagents' (listed separately too).
+//!
+//! For chargeback, tokens are also totaled per git branch (each line of a
+//! session file records the branch checked out) and per ticket, found in the
+//! branch name with a regex (`[digest] ticket_pattern`, JIRA-style keys like
+//! `PROJ-123` by default).
+//!
+//! Subscription users also see what the period's usage would have cost at
+//! API prices (see [`pricing`](crate::pricing)), next to what their plan cost.
+
+use std::collections::{BTreeMap, HashSet};
+use std::path::PathBuf;
+
+use chrono::{DateTime, Duration, Local, Utc};
+use regex::Regex;
+use serde_json::Value;
+
+use crate::exclude::Excludes;
+use crate::pricing::{Pricing, TokenCounts};
+use crate::usage;
+
+/// Completed tasks listed at most
+const MAX_TASKS: usize = 20;
+
+/// Longest sessions listed
+const MAX_LONGEST: usize = 5;
+
+/// Distinct errors listed at most
+const MAX_ERRORS: usize = 10;
+
+/// Titles and error messages are cut to this many characters
+const LINE_LEN: usize = 100;
+
+/// Branches and tickets listed at most
+const MAX_BRANCHES: usize = 20;
+
+/// Ticket keys found in branch names unless the config sets a pattern
+pub const DEFAULT_TICKET_PATTERN: &str = r"[A-Z][A-Z0-9]+-\d+";
+
+/// Tokens used outside a git repository or on a detached HEAD
+const NO_BRANCH: &str = "(no branch)";
+
+/// What one session did within the digest period
+#[derive(Debug, Clone, Default, PartialEq)]
+pub struct SessionDigest {
+    /// Name of the session's directory
+    pub project: String,
+    /// The session's directory
+    pub cwd: Option<PathBuf>,
+    /// Summary Claude Code generated for the session, or its first prompt
+    pub title: Option<String>,
+    pub started: Option<DateTime<Utc>>,
+    pub ended: Option<DateTime<Utc>>,
+    /// Claude Code's session ID; a subagent's is that of its session
+    pub session_id: Option<String>,
+    /// A subagent's transcript rather than a session's
+    pub is_subagent: bool,
+    pub tokens: u64,
+    /// Cost recorded in the session file (only some Claude Code versions do)
+    pub cost_usd: f64,
+    /// Subagents rolled up into the session
+    pub subagents: usize,
+    /// Tokens used by the rolled up subagents
+    pub subagent_tokens: u64,
+    /// Cost recorded by the rolled up subagents
+    pub subagent_cost_usd: f64,
+    /// Tokens (subagents' included) by the git branch checked out when used
+    pub branch_tokens: BTreeMap<String, u64>,
+    /// Tokens (subagents' included) by model, to price them
+    pub model_tokens: BTreeMap<String, TokenCounts>,
+    /// Todo items marked completed, in the order they were finished
+    pub completed_tasks: Vec<String>,
+    /// First line of every failed tool call and API error
+    pub errors: Vec<String>,
+}
+
+impl SessionDigest {
+    /// Time between the first and the last message in the period
+    pub fn duration(&self) -> Duration {
+        match (self.started, self.ended) {
+            (Some(started), Some(ended)) => ended - started,
+            _ => Duration::zero(),
+        }
+    }
+
+    /// Tokens used by the session and its subagents
+    pub fn total_tokens(&self) -> u64 {
+        self.tokens + self.subagent_tokens
+    }
+
+    /// Cost recorded by the session and its subagents
+    pub fn total_cost_usd(&self) -> f64 {
+        self.cost_usd + self.subagent_cost_usd
+    }
+}
+
+/// Summarize the part of a session file written since `since`; None if the
+/// session had no activity in that time
+pub fn summarize(text: &str, since: DateTime<Utc>) -> Option<SessionDigest> {
+    let mut digest = SessionDigest::default();
+    let mut seen_messages = HashSet::new();
+    let mut summary = None;
+    let mut first_prompt = None;
+
+    for line in text.lines() {
+        let Ok(entry) = serde_json::from_str::<Value>(line) else {
+            continue;
+        };
+        if entry.get("type").and_then(Value::as_str) == Some("summary") {
+            summary = entry
+                .get("summary")
+                .and_then(Value::as_str)
+                .map(str::to_string);
+            continue;
+        }
+        let Some(at) = entry
+            .get("timestamp")
+            .and_then(Value::as_str)
+            .and_then(|t| DateTime::parse_from_rfc3339(t).ok())
+            .map(|t| t.with_timezone(&Utc))
+        else {
+            continue;
+        };
+        if at < since {
+            continue;
+        }
+        digest.started.get_or_insert(at);
+        digest.ended = Some(at);
+        if digest.project.is_empty()
+            && let Some(cwd) = entry.get("cwd").and_then(Value::as_str)
+        {
+            digest.project = project_name(cwd);
+            digest.cwd = Some(PathBuf::from(cwd));
+        }
+        if digest.session_id.is_none()
+            && let Some(id) = entry.get("sessionId").and_then(Value::as_str)
+        {
+            digest.session_id = Some(id.to_string());
+            digest.is_subagent = entry.get("isSidechain").and_then(Value::as_bool) == Some(true);
+        }
+        if let Some(cost) = entry.get("costUSD").and_then(Value::as_f64) {
+            digest.cost_usd += cost;
+        }
+
+        // A response spanning several lines repeats its usage on each
+        if let Some(usage) = entry.pointer("/message/usage") {
+            let id = entry.pointer("/message/id").and_then(Value::as_str);
+            if id.is_none_or(|id| seen_messages.insert(id.to_string())) {
+                let tokens = usage::billed_tokens(usage);
+                digest.tokens += tokens;
+                let branch = entry
+                    .get("gitBranch")
+                    .and_then(Value::as_str)
+                    .filter(|b| !b.is_empty() && *b != "HEAD")
+                    .unwrap_or(NO_BRANCH);
+                *digest.branch_tokens.entry(branch.to_string()).or_default() += tokens;
+                let model = entry
+                    .pointer("/message/model")
+                    .and_then(Value::as_str)
+                    .unwrap_or_default();
+                digest
+                    .model_tokens
+                    .entry(model.to_string())
+                    .or_default()
+                    .add(&TokenCounts::from_usage(usage));
+            }
+        }
+
+        let Some(content) = entry.pointer("/message/content") else {
+            continue;
+        };
+        if entry.get("isApiErrorMessage").and_then(Value::as_bool) == Some(true) {
+            digest.errors.push(first_line(&content_text(content)));
+            continue;
+        }
+        match entry.get("type").and_then(Value::as_str) {
+            Some("user") => {
+                if first_prompt.is_none()
+                    && entry.get("isMeta").and_then(Value::as_bool) != Some(true)
+                    && let Some(prompt) = content.as_str()
+                {
+                    first_prompt = Some(prompt.to_string());
+                }
+                for block in content.as_array().into_iter().flatten() {
+                    if block.get("type").and_then(Value::as_str) == Some("tool_result")
+                        && block.get("is_error").and_then(Value::as_bool) == Some(true)
+                    {
+                        let text = block.get("content").map(content_text).unwrap_or_default();
+                        digest.errors.push(first_line(&text));
+                    }
+                }
+            }
+            Some("assistant") => {
+                for block in content.as_array().into_iter().flatten() {
+                    if block.get("name").and_then(Value::as_str) == Some("TodoWrite") {
+                        add_completed_tasks(&mut digest.completed_tasks, block);
+                    }
+                }
+            }
+            _ => {}
+        }
+    }
+
+    digest.started?;
+    digest.title = summary.or(first_prompt).map(|title| first_line(&title));
+    Some(digest)
+}
+
+/// Fold subagent transcripts into the session that started them. Subagents
+/// whose session isn't among `digests` are kept as sessions of their own.
+fn roll_up_subagents(digests: Vec<SessionDigest>) -> Vec<SessionDigest> {
+    let (subagents, mut sessions): (Vec<_>, Vec<_>) =
+        digests.into_iter().partition(|digest| digest.is_subagent);
+    for subagent in subagents {
+        let parent = sessions.iter().position(|session| {
+            !session.is_subagent
+                && session.session_id.is_some()
+                && session.session_id == subagent.session_id
+        });
+        let Some(parent) = parent else {
+            sessions.push(subagent);
+            continue;
+        };
+        let parent = &mut sessions[parent];
+        parent.subagents += 1;
+        parent.subagent_tokens += subagent.total_tokens();
+        parent.subagent_cost_usd += subagent.total_cost_usd();
+        for (branch, tokens) in subagent.branch_tokens {
+            *parent.branch_tokens.entry(branch).or_default() += tokens;
+        }
+        for (model, tokens) in subagent.model_tokens {
+            parent.model_tokens.entry(model).or_default().add(&tokens);
+        }
+        parent.errors.extend(subagent.errors);
+    }
+    sessions
+}
+
+/// Record the todos a TodoWrite call marks completed. Every call repeats the
+/// whole list, so tasks already recorded are skipped.
+fn add_completed_tasks(tasks: &mut Vec<String>, block: &Value) {
+    let todos = block.pointer("/input/todos").and_then(Value::as_array);
+    for todo in todos.into_iter().flatten() {
+        if todo.get("status").and_then(Value::as_str) != Some("completed") {
+            continue;
+        }
+        if let Some(task) = todo.get("content").and_then(Value::as_str)
+            && !tasks.iter().any(|t| t == task)
+        {
+            tasks.push(task.to_string());
+        }
+    }
+}
+
+/// Message content is either a string or a list of text blocks
+fn content_text(content: &Value) -> String {
+    match content {
+        Value::String(text) => text.clone(),
+        Value::Array(parts) => parts
+            .iter()
+            .filter_map(|part| part.get("text").and_then(Value::as_str))
+            .collect::<Vec<_>>()
+            .join("\n"),
+        _ => String::new(),
+    }
+}
+
+/// First non-empty line, cut to LINE_LEN characters
+fn first_line(text: &str) -> String {
+    let line = text
+        .lines()
+        .map(str::trim)
+        .find(|line| !line.is_empty())
+        .unwrap_or_default();
+    if line.chars().count() > LINE_LEN {
+        let cut: String = line.chars().take(LINE_LEN - 1).collect();
+        format!("{}…", cut)
+    } else {
+        line.to_string()
+    }
+}
+
+fn project_name(cwd: &str) -> String {
+    std::path::Path::new(cwd)
+        .file_name()
+        .and_then(|name| name.to_str())
+        .unwrap_or(cwd)
+        .to_string()
+}
+
+/// Ticket a branch works on: the first match of `pattern` in its name
+fn ticket<'a>(branch: &'a str, pattern: &Regex) -> Option<&'a str> {
+    pattern.find(branch).map(|m| m.as_str())
+}
+
+/// All sessions active in a period
+#[derive(Debug, Clone)]
+pub struct Digest {
+    pub since: DateTime<Utc>,
+    pub until: DateTime<Utc>,
+    pub sessions: Vec<SessionDigest>,
+    /// Finds the ticket in a branch name
+    pub ticket_pattern: Regex,
+    /// Prices the usage is valued at
+    pub pricing: Pricing,
+}
+
+/// Collect the sessions active in the last `days` days from Claude Code's
+/// session files, leaving out excluded projects. Blocking.
+pub fn collect(days: i64, excludes: &Excludes, ticket_pattern: Regex, pricing: Pricing) -> Digest {
+    let until = Utc::now();
+    let since = until - Duration::days(days);
+    let cutoff = std::time::SystemTime::from(since);
+    let mut sessions = vec![];
+
+    let dirs = usage::projects_dir().and_then(|projects| std::fs::read_dir(projects).ok());
+    for dir in dirs.into_iter().flatten().flatten() {
+        let Ok(files) = std::fs::read_dir(dir.path()) else {
+            continue;
+        };
+        let paths = files
+            .flatten()
+            .map(|file| file.path())
+            .filter(|path| path.extension().and_then(|e| e.to_str()) == Some("jsonl"))
+            .flat_map(|path| {
+                let subagents = usage::subagent_files(&path);
+                std::iter::once(path).chain(subagents)
+            });
+        for path in paths {
+            // Files not written to since the start of the period can't have activity in it
+            if !std::fs::metadata(&path)
+                .and_then(|m| m.modified())
+                .is_ok_and(|modified| modified >= cutoff)
+            {
+                continue;
+            }
+            if let Ok(text) = std::fs::read_to_string(&path)
+                && let Some(session) = summarize(&text, since)
+                && !session
+                    .cwd
+                    .as_ref()
+                    .is_some_and(|cwd| excludes.is_excluded(cwd))
+            {
+                sessions.push(session);
+            }
+        }
+    }
+    let mut sessions = roll_up_subagents(sessions);
+
+    // Newest first
+    sessions.sort_by(|a, b| b.ended.cmp(&a.ended));
+    Digest {
+        since,
+        until,
+        sessions,
+        ticket_pattern,
+        pricing,
+    }
+}
+
+impl Digest {
+    /// Render the digest as Markdown
+    pub fn to_markdown(&self) -> String {
+        let mut md = format!(
+            "# Agent work {} to {}\n\n",
+            self.since.with_timezone(&Local).format("%Y-%m-%d"),
+            self.until.with_timezone(&Local).format("%Y-%m-%d")
+        );
+        if self.sessions.is_empty() {
+            md.push_str("No agent sessions in this period.\n");
+            return md;
+        }
+
+        // Per project: sessions, time, tokens, of which by subagents
+        let mut projects: BTreeMap<&str, (usize, Duration, u64, u64)> = BTreeMap::new();
+        for session in &self.sessions {
+            let project =
+                projects
+                    .entry(session.project.as_str())
+                    .or_insert((0, Duration::zero(), 0, 0));
+            project.0 += 1;
+            project.1 += session.duration();
+            project.2 += session.total_tokens();
+            project.3 += session.subagent_tokens;
+        }
+        let tokens: u64 = self.sessions.iter().map(|s| s.total_tokens()).sum();
+        let subagent_tokens: u64 = self.sessions.iter().map(|s| s.subagent_tokens).sum();
+        let cost: f64 = self.sessions.iter().map(|s| s.total_cost_usd()).sum();
+        md.push_str(&format!(
+            "{} sessions in {} projects, {} tokens",
+            self.sessions.len(),
+            projects.len(),
+            usage::format_tokens(tokens)
+        ));
+        if subagent_tokens > 0 {
+            md.push_str(&format!(
+                " ({} by subagents)",
+                usage::format_tokens(subagent_tokens)
+            ));
+        }
+        if cost > 0.0 {
+            md.push_str(&format!(", ${:.2}", cost));
+        }
+        md.push_str(".\n\n");
+
+        // What the usage would have cost at API prices, for subscription users
+        let mut model_tokens: BTreeMap<String, TokenCounts> = BTreeMap::new();
+        for session in &self.sessions {
+            for (model, tokens) in &session.model_tokens {
+                model_tokens.entry(model.clone()).or_default().add(tokens);
+            }
+        }
+        let (api_cost, unpriced) = self.pricing.cost(&model_tokens);
+        if api_cost > 0.0 {
+            md.push_str(&format!("${:.2} equivalent at API prices", api_cost));
+            let days = (self.until - self.since).num_days().max(1);
+            if let Some(plan) = self.pricing.plan_cost(days) {
+                md.push_str(&format!(" (the plan cost ${:.2} for the period)", plan));
+            }
+            if unpriced > 0 {
+                md.push_str(&format!(
+                    "; {} tokens of models without a known price left out",
+                    usage::format_tokens(unpriced)
+                ));
+            }
+            md.push_str(".\n\n");
+        }
+
+        md.push_str("## Sessions per project\n\n");
+        md.push_str("| Project | Sessions | Time | Tokens | Subagents |\n");
+        md.push_str("|---------|----------|------|--------|-----------|\n");
+        let mut by_sessions: Vec<_> = projects.into_iter().collect();
+        by_sessions.sort_by_key(|(_, (count, _, _, _))| std::cmp::Reverse(*count));
+        for (project, (count, time, tokens, subagent_tokens)) in by_sessions {
+            md.push_str(&format!(
+                "| {} | {} | {} | {} | {} |\n",
+                project,
+                count,
+                format_duration(time),
+                usage::format_tokens(tokens),
+                usage::format_tokens(subagent_tokens)
+            ));
+        }
+
+        let tasks: Vec<(&str, &String)> = self
+            .sessions
+            .iter()
+            .flat_map(|s| {
+                s.completed_tasks
+                    .iter()
+                    .map(move |t| (s.project.as_str(), t))
+            })
+            .collect();
+        if !tasks.is_empty() {
+            md.push_str("\n## Tasks completed\n\n");
+            for (project, task) in tasks.iter().take(MAX_TASKS) {
+                md.push_str(&format!("- {}: {}\n", project, task));
+            }
+            if tasks.len() > MAX_TASKS {
+                md.push_str(&format!("- …and {} more\n", tasks.len() - MAX_TASKS));
+            }
+        }
+
+        md.push_str("\n## Longest sessions\n\n");
+        let mut longest: Vec<&SessionDigest> = self.sessions.iter().collect();
+        longest.sort_by_key(|s| std::cmp::Reverse(s.duration()));
+        for (i, session) in longest.iter().take(MAX_LONGEST).enumerate() {
+            let subagents = match session.subagents {
+                0 => String::new(),
+                count => format!(
+                    ", {} by {} subagent{}",
+                    usage::format_tokens(session.subagent_tokens),
+                    count,
+                    if count == 1 { "" } else { "s" }
+                ),
+            };
+            md.push_str(&format!(
+                "{}. {}: {} ({}, {} tokens{})\n",
+                i + 1,
+                session.project,
+                session.title.as_deref().unwrap_or("(untitled)"),
+                format_duration(session.duration()),
+                usage::format_tokens(session.total_tokens()),
+                subagents
+            ));
+        }
+
+        // Chargeback: tokens per branch and per ticket in the branch name
+        let mut branches: BTreeMap<(&str, &str), u64> = BTreeMap::new();
+        for session in &self.sessions {
+            for (branch, tokens) in &session.branch_tokens {
+                *branches
+                    .entry((session.project.as_str(), branch.as_str()))
+                    .or_default() += tokens;
+            }
+        }
+        if branches.keys().any(|(_, branch)| *branch != NO_BRANCH) {
+            let mut tickets: BTreeMap<&str, u64> = BTreeMap::new();
+            md.push_str("\n## Tokens per branch\n\n");
+            md.push_str("| Project | Branch | Ticket | Tokens |\n");
+            md.push_str("|---------|--------|--------|--------|\n");
+            let mut branches: Vec<_> = branches.into_iter().collect();
+            branches.sort_by_key(|(_, tokens)| std::cmp::Reverse(*tokens));
+            for (i, ((project, branch), tokens)) in branches.iter().enumerate() {
+                let ticket = ticket(branch, &self.ticket_pattern);
+                *tickets.entry(ticket.unwrap_or("(no ticket)")).or_default() += tokens;
+                if i < MAX_BRANCHES {
+                    md.push_str(&format!(
+                        "| {} | {} | {} | {} |\n",
+                        project,
+                        branch,
+                        ticket.unwrap_or("-"),
+                        usage::format_tokens(*tokens)
+                    ));
+                }
+            }
+            if branches.len() > MAX_BRANCHES {
+                md.push_str(&format!(
+                    "\n…and {} more branches.\n",
+                    branches.len() - MAX_BRANCHES
+                ));
+            }
+
+            md.push_str("\n## Tokens per ticket\n\n");
+            let mut tickets: Vec<_> = tickets.into_iter().collect();
+            tickets.sort_by_key(|(_, tokens)| std::cmp::Reverse(*tokens));
+            for (ticket, tokens) in tickets.into_iter().take(MAX_BRANCHES) {
+                md.push_str(&format!(
+                    "- {}: {} tokens\n",
+                    ticket,
+                    usage::format_tokens(tokens)
+                ));
+            }
+        }
+
+        // Errors grouped by project and message, most frequent first
+        let mut errors: BTreeMap<(&str, &str), usize> = BTreeMap::new();
+        for session in &self.sessions {
+            for error in session.errors.iter().filter(|e| !e.is_empty()) {
+                *errors
+                    .entry((session.project.as_str(), error.as_str()))
+                    .or_default() += 1;
+            }
+        }
+        if !errors.is_empty() {
+            md.push_str("\n## Notable errors\n\n");
+            let mut errors: Vec<_> = errors.into_iter().collect();
+            errors.sort_by_key(|(_, count)| std::cmp::Reverse(*count));
+            for ((project, error), count) in errors.into_iter().take(MAX_ERRORS) {
+                md.push_str(&format!("- {}× {}: {}\n", count, project, error));
+            }
+        }
+        md
+    }
+}
+
+/// Hours and minutes: 3h 12m, 45m
+fn format_duration(duration: Duration) -> String {
+    let minutes = duration.num_minutes();
+    if minutes >= 60 {
+        format!("{}h {}m", minutes / 60, minutes % 60)
+    } else {
+        format!("{}m", minutes)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn tickets() -> Regex {
+        Regex::new(DEFAULT_TICKET_PATTERN).unwrap()
+    }
+
+    fn at(time: &str) -> DateTime<Utc> {
+        DateTime::parse_from_rfc3339(time)
+            .unwrap()
+            .with_timezone(&Utc)
+    }
+
+    const SESSION: &str = r#"{"type":"summary","summary":"Fix the flaky tests"}
+{"type":"user","timestamp":"2025-05-01T09:00:00Z","cwd":"/work/old","message":{"role":"user","content":"before the week"}}
+{"type":"user","timestamp":"2025-06-02T09:00:00Z","cwd":"/work/api","message":{"role":"user","content":"fix the tests"}}
+{"type":"assistant","timestamp":"2025-06-02T09:01:00Z","message":{"id":"m1","usage":{"input_tokens":100,"output_tokens":50},"content":[{"type":"tool_use","id":"t1","name":"TodoWrite","input":{"todos":[{"content":"Reproduce","status":"completed"},{"content":"Fix","status":"in_progress"}]}}]}}
+{"type":"assistant","timestamp":"2025-06-02T09:01:00Z","message":{"id":"m1","usage":{"input_tokens":100,"output_tokens":50},"content":[{"type":"tool_use","id":"t2","name":"Bash","input":{"command":"cargo test"}}]}}
+{"type":"user","timestamp":"2025-06-02T09:02:00Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","is_error":true,"content":"Exit code 101\nthread panicked"}]}}
+{"type":"assistant","timestamp":"2025-06-02T10:30:00Z","message":{"id":"m2","usage":{"input_tokens":10,"output_tokens":5},"content":[{"type":"tool_use","id":"t3","name":"TodoWrite","input":{"todos":[{"content":"Reproduce","status":"completed"},{"content":"Fix","status":"completed"}]}}]}}
+"#;
+
+    #[test]
+    fn test_summarize() {
+        let digest = summarize(SESSION, at("2025-06-01T00:00:00Z")).unwrap();
+        assert_eq!(digest.project, "api");
+        assert_eq!(digest.cwd, Some(PathBuf::from("/work/api")));
+        assert_eq!(digest.title.as_deref(), Some("Fix the flaky tests"));
+        assert_eq!(digest.tokens, 165);
+        assert_eq!(digest.completed_tasks, vec!["Reproduce", "Fix"]);
+        assert_eq!(digest.errors, vec!["Exit code 101"]);
+        assert_eq!(digest.duration(), Duration::minutes(90));
+
+        // No activity in the period
+        assert!(summarize(SESSION, at("2025-07-01T00:00:00Z")).is_none());
+    }
+
+    const SUBAGENT: &str = r#"{"type":"user","timestamp":"2025-06-02T09:05:00Z","sessionId":"s1","isSidechain":true,"cwd":"/work/api","message":{"role":"user","content":"find the flaky test"}}
+{"type":"assistant","timestamp":"2025-06-02T09:06:00Z","sessionId":"s1","isSidechain":true,"message":{"id":"m9","usage":{"input_tokens":300,"output_tokens":35},"content":[]},"costUSD":0.25}
+"#;
+
+    #[test]
+    fn test_roll_up_subagents() {
+        let since = at("2025-06-01T00:00:00Z");
+        let mut session = summarize(SESSION, since).unwrap();
+        session.session_id = Some("s1".to_string());
+        let subagent = summarize(SUBAGENT, since).unwrap();
+        assert!(subagent.is_subagent);
+        assert_eq!(subagent.session_id.as_deref(), Some("s1"));
+        let mut orphan = subagent.clone();
+        orphan.session_id = Some("gone".to_string());
+
+        let sessions = roll_up_subagents(vec![subagent, session, orphan]);
+        assert_eq!(sessions.len(), 2);
+        assert_eq!(sessions[0].subagents, 1);
+        assert_eq!(sessions[0].total_tokens(), 500);
+        assert_eq!(sessions[0].total_cost_usd(), 0.25);
+        assert_eq!(sessions[1].session_id.as_deref(), Some("gone"));
+
+        let digest = Digest {
+            since,
+            until: at("2025-06-08T12:00:00Z"),
+            sessions: sessions[..1].to_vec(),
+            ticket_pattern: tickets(),
+            pricing: Pricing::default(),
+        };
+        let md = digest.to_markdown();
+        assert!(md.contains("1 sessions in 1 projects, 500 tokens (335 by subagents), $0.25.\n"));
+        assert!(md.contains("| api | 1 | 1h 30m | 500 | 335 |\n"));
+        assert!(md.contains("(1h 30m, 500 tokens, 335 by 1 subagent)\n"));
+    }
+
+    #[test]
+    fn test_to_markdown() {
+        let session = summarize(SESSION, at("2025-06-01T00:00:00Z")).unwrap();
+        let digest = Digest {
+            since: at("2025-06-01T12:00:00Z"),
+            until: at("2025-06-08T12:00:00Z"),
+            sessions: vec![session],
+            ticket_pattern: tickets(),
+            pricing: Pricing::default(),
+        };
+        let md = digest.to_markdown();
+        assert!(md.contains("1 sessions in 1 projects, 165 tokens.\n"));
+        assert!(md.contains("| api | 1 | 1h 30m | 165 | 0 |\n"));
+        assert!(md.contains("- api: Fix\n"));
+        assert!(md.contains("1. api: Fix the flaky tests (1h 30m, 165 tokens)\n"));
+        assert!(md.contains("- 1× api: Exit code 101\n"));
+        // Without branches recorded there's nothing to charge back
+        assert!(!md.contains("## Tokens per branch"));
+
+        let empty = Digest {
+            sessions: vec![],
+            ..digest
+        };
+        assert!(empty.to_markdown().contains("No agent sessions"));
+    }
+
+    const BRANCHES: &str = r#"{"type":"assistant","timestamp":"2025-06-02T09:01:00Z","cwd":"/work/api","gitBranch":"feature/PAY-42-refunds","message":{"id":"m1","model":"claude-sonnet-4-20250514","usage":{"input_tokens":100,"output_tokens":50},"content":[]}}
+{"type":"assistant","timestamp":"2025-06-02T09:02:00Z","gitBranch":"PAY-42-followup","message":{"id":"m2","usage":{"input_tokens":20,"output_tokens":10},"content":[]}}
+{"type":"assistant","timestamp":"2025-06-02T09:03:00Z","gitBranch":"main","message":{"id":"m3","usage":{"input_tokens":5,"output_tokens":5},"content":[]}}
+{"type":"assistant","timestamp":"2025-06-02T09:04:00Z","gitBranch":"HEAD","message":{"id":"m4","usage":{"input_tokens":1,"output_tokens":1},"content":[]}}
+"#;
+
+    #[test]
+    fn test_tokens_per_branch() {
+        let since = at("2025-06-01T00:00:00Z");
+        let session = summarize(BRANCHES, since).unwrap();
+        assert_eq!(session.branch_tokens["feature/PAY-42-refunds"], 150);
+        assert_eq!(session.branch_tokens["main"], 10);
+        assert_eq!(session.branch_tokens[NO_BRANCH], 2);
+        assert_eq!(ticket("feature/PAY-42-refunds", &tickets()), Some("PAY-42"));
+        assert_eq!(ticket("main", &tickets()), None);
+
+        let digest = Digest {
+            since,
+            until: at("2025-06-08T12:00:00Z"),
+            sessions: vec![session],
+            ticket_pattern: tickets(),
+            pricing: Pricing {
+                plan_usd_per_month: Some(200.0),
+                ..Pricing::default()
+            },
+        };
+        let md = digest.to_markdown();
+        assert!(md.contains(
+            "$0.00 equivalent at API prices (the plan cost $46.03 for the period); \
+             12 tokens of models without a known price left out.\n"
+        ));
+        assert!(md.contains("| api | feature/PAY-42-refunds | PAY-42 | 150 |\n"));
+        assert!(md.contains("| api | main | - | 10 |\n"));
+        assert!(md.contains("- PAY-42: 180 tokens\n"));
+        assert!(md.contains("- (no ticket): 12 tokens\n"));
+    }
+}
@@ -0,0 +1,286 @@
+//! `amux doctor`: environment checks for first runs.
+//!
+//! A missing agent or an unwritable `~/.amux` otherwise shows up as an
+//! empty session list or a silent failure inside the TUI. Each check says
+//! what it found and, when something is off, what to do about it.
+
+use std::path::Path;
+
+use crate::config::Config;
+use crate::session::{AgentType, check_all_agents, command_exists};
+
+/// Outcome of a single check
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum Status {
+    Ok,
+    /// Works, but some feature is degraded
+    Warn,
+    /// amux cannot work properly until this is fixed
+    Fail,
+}
+
+/// A single environment check
+#[derive(Debug, Clone)]
+pub struct Check {
+    pub name: &'static str,
+    pub status: Status,
+    /// What was found
+    pub detail: String,
+    /// How to fix it, for warnings and failures
+    pub fix: Option<String>,
+}
+
+impl Check {
+    fn ok(name: &'static str, detail: impl Into<String>) -> Self {
+        Self {
+            name,
+            status: Status::Ok,
+            detail: detail.into(),
+            fix: None,
+        }
+    }
+
+    fn problem(
+        name: &'static str,
+        status: Status,
+        detail: impl Into<String>,
+        fix: impl Into<String>,
+    ) -> Self {
+        Self {
+            name,
+            status,
+            detail: detail.into(),
+            fix: Some(fix.into()),
+        }
+    }
+}
+
+/// Run all checks
+pub fn run_checks() -> Vec<Check> {
+    let mut checks = agent_checks();
+    checks.push(git_check());
+    let (config_check, config) = config_check();
+    checks.push(config_check);
+    checks.extend(dir_checks(&config));
+    checks.push(telemetry_check(&config));
+    checks.extend(terminal_checks(
+        std::env::var("TERM").ok().as_deref(),
+        std::env::var("COLORTERM").ok().as_deref(),
+        std::env::var("NO_COLOR").is_ok_and(|v| !v.is_empty()),
+    ));
+    checks
+}
+
+/// Install hint for an agent, matching the README requirements
+fn install_hint(agent_type: AgentType) -> &'static str {
+    match agent_type {
+        AgentType::ClaudeCode => {
+            "install Node.js (for npx) or put claude-code-acp on PATH: npx @anthropic-ai/claude-code-acp"
+        }
+        AgentType::GeminiCli => "npm install -g @google/gemini-cli",
+    }
+}
+
+/// One check per agent; missing agents only fail when none is available
+fn agent_checks() -> Vec<Check> {
+    let agents = check_all_agents();
+    let any_available = agents.iter().any(|a| a.is_available());
+    agents
+        .into_iter()
+        .map(|agent| {
+            let name = agent.agent_type.display_name();
+            if agent.is_available() {
+                return Check::ok(name, "found");
+            }
+            let missing: Vec<&str> = agent
+                .preconditions
+                .iter()
+                .filter(|p| !p.satisfied)
+                .map(|p| p.description)
+                .collect();
+            let status = if any_available {
+                Status::Warn
+            } else {
+                Status::Fail
+            };
+            Check::problem(
+                name,
+                status,
+                format!("missing {}", missing.join(", ")),
+                install_hint(agent.agent_type),
+            )
+        })
+        .collect()
+}
+
+fn git_check() -> Check {
+    if command_exists("git") {
+        Check::ok("git", "found")
+    } else {
+        Check::problem(
+            "git",
+            Status::Warn,
+            "not found: worktrees, branches and diff stats are unavailable",
+            "install git and make sure it is on PATH",
+        )
+    }
+}
+
+/// Check that the config file parses; returns the config to use for later checks
+fn config_check() -> (Check, Config) {
+    let path = Config::config_path();
+    if !path.exists() {
+        return (
+            Check::ok(
+                "config",
+                format!("{} (not present, defaults)", path.display()),
+            ),
+            Config::default(),
+        );
+    }
+    let parsed = std::fs::read_to_string(&path)
+        .map_err(|e| e.to_string())
+        .and_then(|contents| toml::from_str::<Config>(&contents).map_err(|e| e.to_string()));
+    match parsed {
+        Ok(config) => (Check::ok("config", path.display().to_string()), config),
+        Err(e) => (
+            Check::problem(
+                "config",
+                Status::Fail,
+                format!("{}: {}", path.display(), e.trim()),
+                "fix the file or move it aside; amux falls back to defaults while it is invalid",
+            ),
+            Config::default(),
+        ),
+    }
+}
+
+/// Whether a file can be created in `dir`, creating the directory if needed
+fn is_writable(dir: &Path) -> std::io::Result<()> {
+    std::fs::create_dir_all(dir)?;
+    let probe = dir.join(".amux-doctor");
+    std::fs::write(&probe, b"")?;
+    std::fs::remove_file(&probe)
+}
+
+/// Directories amux writes logs, exports, archives and worktrees to
+fn dir_checks(config: &Config) -> Vec<Check> {
+    let amux_dir = dirs::home_dir()
+        .unwrap_or_else(|| std::path::PathBuf::from("."))
+        .join(".amux");
+    [
+        ("logs", amux_dir.join("logs")),
+        ("exports", amux_dir.join("exports")),
+        ("archive", amux_dir.join("archive")),
+        ("worktrees", config.worktree_dir()),
+    ]
+    .into_iter()
+    .map(|(name, dir)| match is_writable(&dir) {
+        Ok(()) => Check::ok(name, dir.display().to_string()),
+        Err(e) => Check::problem(
+            name,
+            Status::Fail,
+            format!("{} is not writable: {}", dir.display(), e),
+            format!("check the permissions of {}", dir.display()),
+        ),
+    })
+    .collect()
+}
+
+/// Whether anything is sent to a telemetry endpoint
+fn telemetry_check(config: &Config) -> Check {
+    match config.telemetry.as_ref().filter(|t| t.enabled) {
+        Some(telemetry) => Check::ok(
+            "telemetry",
+            format!(
+                "on: anonymized metrics and crash reports go to {}",
+                telemetry.endpoint
+            ),
+        ),
+        None => Check::ok("telemetry", "off"),
+    }
+}
+
+/// Terminal capability checks from TERM, COLORTERM and NO_COLOR
+fn terminal_checks(term: Option<&str>, colorterm: Option<&str>, no_color: bool) -> Vec<Check> {
+    let mut checks = vec![];
+
+    checks.push(match term {
+        None | Some("") | Some("dumb") => Check::problem(
+            "terminal",
+            Status::Warn,
+            format!(
+                "TERM is {}",
+                term.filter(|t| !t.is_empty()).unwrap_or("unset")
+            ),
+            "run amux in a terminal emulator, or use --no-color for plain output",
+        ),
+        Some(term) => Check::ok("terminal", format!("TERM={}", term)),
+    });
+
+    if no_color {
+        checks.push(Check::ok("colors", "disabled (NO_COLOR)"));
+    } else if matches!(colorterm, Some("truecolor") | Some("24bit")) {
+        checks.push(Check::ok("colors", "truecolor"));
+    } else {
+        checks.push(Check::problem(
+            "colors",
+            Status::Warn,
+            "COLORTERM does not advertise truecolor; theme colors may look off",
+            "use a truecolor terminal (or set COLORTERM=truecolor if it is one), or run with --no-color",
+        ));
+    }
+
+    checks
+}
+
+/// Print the checks and return whether none failed
+pub fn print_report(checks: &[Check]) -> bool {
+    for check in checks {
+        let mark = match check.status {
+            Status::Ok => "ok  ",
+            Status::Warn => "warn",
+            Status::Fail => "FAIL",
+        };
+        println!("[{}] {:<12} {}", mark, check.name, check.detail);
+        if let Some(fix) = &check.fix {
+            println!("       {:<12} fix: {}", "", fix);
+        }
+    }
+
+    let failed = checks.iter().filter(|c| c.status == Status::Fail).count();
+    let warned = checks.iter().filter(|c| c.status == Status::Warn).count();
+    println!();
+    if failed == 0 && warned == 0 {
+        println!("Everything looks good.");
+    } else {
+        println!("{} problem(s), {} warning(s)", failed, warned);
+    }
+    failed == 0
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_terminal_checks() {
+        let checks = terminal_checks(Some("xterm-256color"), Some("truecolor"), false);
+        assert!(checks.iter().all(|c| c.status == Status::Ok));
+
+        let checks = terminal_checks(Some("dumb"), None, false);
+        assert!(checks.iter().all(|c| c.status == Status::Warn));
+        assert!(checks.iter().all(|c| c.fix.is_some()));
+
+        let checks = terminal_checks(Some("xterm"), None, true);
+        assert_eq!(checks[1].status, Status::Ok);
+    }
+
+    #[test]
+    fn test_telemetry_check() {
+        assert_eq!(telemetry_check(&Config::default()).detail, "off");
+        let config: Config =
+            toml::from_str("[telemetry]\nenabled = true\nendpoint = \"http://t.example\"").unwrap();
+        assert!(telemetry_check(&config).detail.starts_with("on: "));
+    }
+}
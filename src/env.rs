@@ -0,0 +1,127 @@
+//! Environment snapshot per session.
+//!
+//! Agents behave differently depending on the environment their commands
+//! run in (a `NODE_ENV`, an active virtualenv, which API endpoint a `.env`
+//! points at). When a session starts, the relevant variables the agent
+//! inherits from amux and those set in the project's `.env` are recorded so
+//! they can be compared between sessions.
+
+use std::path::Path;
+
+/// Variables that are relevant by name
+const RELEVANT_NAMES: &[&str] = &[
+    "NODE_ENV",
+    "RAILS_ENV",
+    "RACK_ENV",
+    "APP_ENV",
+    "ENVIRONMENT",
+    "VIRTUAL_ENV",
+    "CONDA_DEFAULT_ENV",
+    "AWS_PROFILE",
+    "AWS_REGION",
+    "KUBECONFIG",
+];
+
+/// Variables that are relevant by prefix (agent settings)
+const RELEVANT_PREFIXES: &[&str] = &["CLAUDE_CODE_"];
+
+/// Variables that are relevant by suffix (endpoints and hosts)
+const RELEVANT_SUFFIXES: &[&str] = &["_URL", "_ENDPOINT", "_HOST"];
+
+/// Where a variable came from
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum EnvSource {
+    /// Inherited by the agent process from amux
+    Process,
+    /// Set in the project's `.env` file
+    DotEnv,
+}
+
+/// A recorded environment variable
+#[derive(Debug, Clone, PartialEq)]
+pub struct EnvVar {
+    pub name: String,
+    pub value: String,
+    pub source: EnvSource,
+}
+
+/// Whether a variable is worth showing in the snapshot
+pub fn is_relevant(name: &str) -> bool {
+    RELEVANT_NAMES.contains(&name)
+        || RELEVANT_PREFIXES.iter().any(|p| name.starts_with(p))
+        || RELEVANT_SUFFIXES.iter().any(|s| name.ends_with(s))
+}
+
+/// Parse `KEY=value` lines of a `.env` file (with optional `export` and quotes)
+pub fn parse_dotenv(text: &str) -> Vec<(String, String)> {
+    text.lines()
+        .map(str::trim)
+        .filter(|line| !line.is_empty() && !line.starts_with('#'))
+        .filter_map(|line| {
+            let line = line.strip_prefix("export ").unwrap_or(line);
+            let (name, value) = line.split_once('=')?;
+            let value = value.trim();
+            let value = value
+                .strip_prefix('"')
+                .and_then(|v| v.strip_suffix('"'))
+                .or_else(|| value.strip_prefix('\'').and_then(|v| v.strip_suffix('\'')))
+                .unwrap_or(value);
+            Some((name.trim().to_string(), value.to_string()))
+        })
+        .collect()
+}
+
+/// Relevant variables for a session started in `cwd`, sorted by name
+pub fn capture(cwd: &Path) -> Vec<EnvVar> {
+    let mut vars: Vec<EnvVar> = std::env::vars()
+        .filter(|(name, _)| is_relevant(name))
+        .map(|(name, value)| EnvVar {
+            name,
+            value,
+            source: EnvSource::Process,
+        })
+        .collect();
+
+    if let Ok(text) = std::fs::read_to_string(cwd.join(".env")) {
+        vars.extend(
+            parse_dotenv(&text)
+                .into_iter()
+                .filter(|(name, _)| is_relevant(name))
+                .map(|(name, value)| EnvVar {
+                    name,
+                    value,
+                    source: EnvSource::DotEnv,
+                }),
+        );
+    }
+
+    vars.sort_by(|a, b| a.name.cmp(&b.name));
+    vars
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_is_relevant() {
+        assert!(is_relevant("NODE_ENV"));
+        assert!(is_relevant("PAYMENTS_API_URL"));
+        assert!(is_relevant("CLAUDE_CODE_USE_BEDROCK"));
+        assert!(!is_relevant("HOME"));
+        assert!(!is_relevant("TERM"));
+    }
+
+    #[test]
+    fn test_parse_dotenv() {
+        let text = "# local\nexport NODE_ENV=development\nAPI_URL=\"http://localhost:8080\"\n\nSECRET='x y'\nbroken\n";
+        assert_eq!(
+            parse_dotenv(text),
+            vec![
+                ("NODE_ENV".to_string(), "development".to_string()),
+                ("API_URL".to_string(), "http://localhost:8080".to_string()),
+                ("SECRET".to_string(), "x y".to_string()),
+            ]
+        );
+    }
+}
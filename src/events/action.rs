@@ -7,7 +7,7 @@
 use std::path::PathBuf;
 
 use crate::acp::PermissionOptionId;
-use crate::session::AgentType;
+use crate::session::{AgentType, MessageTag};
 
 /// Actions that can be dispatched from event handlers.
 ///
@@ -29,6 +29,136 @@ pub enum Action {
     OpenHelp,
     /// Close help popup
     CloseHelp,
+    /// Open conversation statistics popup
+    OpenStats,
+    /// Close conversation statistics popup
+    CloseStats,
+    /// Open the audit log of destructive actions
+    OpenAuditLog,
+    /// Close the audit log
+    CloseAuditLog,
+    /// Open the quick reply menu
+    OpenReplyMenu,
+    /// Close the quick reply menu
+    CloseReplyMenu,
+    /// Move up in the quick reply menu
+    ReplyMenuUp,
+    /// Move down in the quick reply menu
+    ReplyMenuDown,
+    /// Send a quick reply: the numbered one, or the selected one
+    SendReplyTemplate(Option<usize>),
+    /// Put the selected quick reply into the prompt for editing
+    DraftReplyTemplate,
+    /// Link the selected session to an epic
+    OpenEpicInput,
+    /// Close the epic input without changing the session
+    CloseEpicInput,
+    /// Link the session to the entered epic
+    SubmitEpicInput,
+    /// Complete the epic name to an existing epic
+    EpicInputComplete,
+    /// Type a character into the epic name
+    EpicInputChar(char),
+    /// Delete the character before the cursor in the epic name
+    EpicInputBackspace,
+    /// Move the epic name cursor left
+    EpicInputLeft,
+    /// Move the epic name cursor right
+    EpicInputRight,
+    /// Edit the selected session's scratchpad notes
+    OpenNotes,
+    /// Close the notes editor, keeping the notes
+    CloseNotes,
+    /// Type a character (or newline) into the notes
+    NotesInputChar(char),
+    /// Delete the character before the cursor in the notes
+    NotesInputBackspace,
+    /// Delete the character at the cursor in the notes
+    NotesInputDelete,
+    /// Move the notes cursor left
+    NotesInputLeft,
+    /// Move the notes cursor right
+    NotesInputRight,
+    /// Move the notes cursor to the start of the line
+    NotesInputHome,
+    /// Move the notes cursor to the end of the line
+    NotesInputEnd,
+    /// Open plan history timeline
+    OpenPlanHistory,
+    /// Close plan history timeline
+    ClosePlanHistory,
+
+    // === Queue ===
+    /// Open the queue of prompts and work orders
+    OpenQueue,
+    /// Close the queue view
+    CloseQueue,
+    /// Move up in the queue view
+    QueueUp,
+    /// Move down in the queue view
+    QueueDown,
+    /// Cancel the selected prompt or work order
+    CancelQueueItem,
+    /// Edit the selected prompt or work order
+    EditQueueItem,
+    /// Add a work order for the selected session's project
+    AddWorkOrder,
+    /// Save the edited prompt or work order
+    SubmitQueueEdit,
+    /// Stop editing without saving
+    CloseQueueEdit,
+    /// Type a character into the edited prompt or work order
+    QueueEditChar(char),
+    /// Delete the character before the cursor in the edited text
+    QueueEditBackspace,
+    /// Move the edit cursor left
+    QueueEditLeft,
+    /// Move the edit cursor right
+    QueueEditRight,
+
+    // === Archive search ===
+    /// Open the archive search screen
+    OpenSearch,
+    /// Close the opened message, or the search screen
+    CloseSearch,
+    /// Search for the query typed, or open the selected result
+    SubmitSearch,
+    /// Select the previous matching line
+    SearchUp,
+    /// Select the next matching line
+    SearchDown,
+    /// Type a character into the search query
+    SearchInputChar(char),
+    /// Delete the character before the cursor in the search query
+    SearchInputBackspace,
+    /// Move the search cursor left
+    SearchInputLeft,
+    /// Move the search cursor right
+    SearchInputRight,
+
+    // === Message tagging ===
+    /// Enter tagging mode on the latest message
+    OpenTagging,
+    /// Leave tagging mode
+    CloseTagging,
+    /// Move the tagging cursor to the previous message
+    TagCursorUp,
+    /// Move the tagging cursor to the next message
+    TagCursorDown,
+    /// Toggle a tag on the message under the cursor
+    TagMessage(MessageTag),
+    /// Export tagged messages from all sessions
+    ExportTaggedMessages,
+    /// Append tagged turns from all sessions to the fine-tuning dataset
+    /// (only turns with the given tag when set)
+    ExportDataset(Option<MessageTag>),
+    /// Copy the permalink of the message under the cursor
+    CopyPermalink,
+    /// Open the files referenced by the prompt under the cursor
+    OpenAttachments,
+    /// Show the ANSI colors in the tool results after the message under the
+    /// cursor, or strip them again
+    ToggleAnsi,
 
     // === Session navigation ===
     /// Select next session in list
@@ -37,6 +167,12 @@ pub enum Action {
     PrevSession,
     /// Select session by index (1-9)
     SelectSession(usize),
+    /// Open the selected session as a tab, or close its tab
+    ToggleTab,
+    /// Switch to the next tab
+    NextTab,
+    /// Switch to the previous tab
+    PrevTab,
 
     // === Session management ===
     /// Open folder picker starting at path
@@ -67,6 +203,24 @@ pub enum Action {
     CloseClearConfirm,
     /// Kill selected session
     KillSession,
+    /// Ask to kill all sessions idle for longer than the configured time
+    OpenKillIdleConfirm,
+    /// Dismiss the kill idle sessions confirmation
+    CloseKillIdleConfirm,
+    /// Kill all sessions idle for longer than the configured time
+    KillIdleSessions,
+    /// Kill the selected session's agent process and resume its session in a new one
+    RestartSession,
+    /// Run the configured verify command in the selected session's directory
+    RunVerify,
+    /// Show the selected session's verify output, running verify if it never ran
+    OpenVerifyOutput,
+    /// Close the verify output
+    CloseVerifyOutput,
+    /// Scroll the verify output by lines (positive scrolls back)
+    ScrollVerifyOutput(isize),
+    /// Summarize the selected session's transcript with the configured summary command
+    SummarizeSession,
 
     // === Input handling ===
     /// Add character to input buffer
@@ -107,6 +261,8 @@ pub enum Action {
     ScrollUp(usize),
     /// Scroll down by n lines
     ScrollDown(usize),
+    /// Scroll by lines (negative is up), accelerating while held
+    ScrollLines(i32),
     /// Scroll to top
     ScrollToTop,
     /// Scroll to bottom
@@ -245,6 +401,34 @@ pub enum Action {
     /// Resume selected session
     SessionPickerSelect,
 
+    // === Recent files ===
+    /// Close recent files browser
+    CloseRecentFiles,
+    /// Navigate recent files up
+    RecentFilesUp,
+    /// Navigate recent files down
+    RecentFilesDown,
+
+    // === Process tree ===
+    /// Close the process tree
+    CloseProcessTree,
+    /// Navigate the process tree up
+    ProcessTreeUp,
+    /// Navigate the process tree down
+    ProcessTreeDown,
+    /// Collapse or expand the selected process's children
+    ProcessTreeToggle,
+
+    // === Workspace diff ===
+    /// Close the snapshot diff (or go back from a diff to the snapshot list)
+    CloseWorkspaceDiff,
+    /// Select the previous snapshot, or scroll the diff up
+    WorkspaceDiffUp,
+    /// Select the next snapshot, or scroll the diff down
+    WorkspaceDiffDown,
+    /// Mark the selected snapshot as one end of the range to diff
+    WorkspaceDiffMark,
+
     // === Worktree cleanup ===
     /// Close worktree cleanup
     CloseWorktreeCleanup,
@@ -321,8 +505,73 @@ pub enum Action {
     // === Debug ===
     /// Toggle debug mode for tool JSON display
     ToggleDebugToolJson,
+    /// Toggle display of agent thinking blocks
+    ToggleThinking,
+    /// Toggle showing only errors, failed tools and warnings (with context)
+    ToggleErrorsOnly,
+    /// Toggle display of full web tool results (fetched pages, search results)
+    ToggleWebBodies,
+    /// Toggle previews of each agent's latest message in the session list
+    TogglePreviews,
+    /// Export the selected session's transcript with secrets redacted
+    ExportTranscript,
+    /// Archive the selected session's transcript and metadata
+    ArchiveSession,
+    /// Browse files recently written by the selected session's agent
+    OpenRecentFiles,
+    /// Show the processes running under the selected session's agent
+    OpenProcessTree,
+    /// Diff the selected session's work tree between two points in time
+    OpenWorkspaceDiff,
+
+    // === Hidden sessions ===
+    /// Hide or unhide the selected session
+    ToggleHideSession,
+    /// Show or hide hidden sessions in the list
+    ToggleShowHidden,
+    /// Leave headless (Agent SDK / CI) sessions out of the list, or bring them back
+    ToggleHideHeadless,
+    /// Mute or unmute notifications for the selected session
+    ToggleMuteSession,
 
     // === No-op ===
     /// No action to take
     None,
 }
+
+impl Action {
+    /// Whether the action only changes what's shown, so it's allowed in the
+    /// read-only view (`amux serve --tui`)
+    pub fn is_view_only(&self) -> bool {
+        matches!(
+            self,
+            Action::Quit
+                | Action::OpenHelp
+                | Action::CloseHelp
+                | Action::OpenStats
+                | Action::CloseStats
+                | Action::OpenPlanHistory
+                | Action::ClosePlanHistory
+                | Action::NextSession
+                | Action::PrevSession
+                | Action::SelectSession(_)
+                | Action::ToggleTab
+                | Action::NextTab
+                | Action::PrevTab
+                | Action::ScrollUp(_)
+                | Action::ScrollDown(_)
+                | Action::ScrollLines(_)
+                | Action::ScrollToTop
+                | Action::ScrollToBottom
+                | Action::CycleSortMode
+                | Action::ToggleDebugToolJson
+                | Action::ToggleThinking
+                | Action::ToggleErrorsOnly
+                | Action::ToggleWebBodies
+                | Action::TogglePreviews
+                | Action::ToggleShowHidden
+                | Action::ToggleHideHeadless
+                | Action::None
+        )
+    }
+}
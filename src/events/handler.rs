@@ -2,13 +2,13 @@
 
 #![allow(dead_code)]
 
-use crossterm::event::{Event, KeyEventKind};
+use crossterm::event::Event;
 
 use crate::acp::PermissionOptionId;
 use crate::app::App;
 
 use super::Action;
-use super::keyboard::handle_key_event;
+use super::keyboard::{handle_key_event, is_press, normalize_key};
 use super::mouse::handle_mouse_event;
 
 /// Result of handling an agent event - may contain a command to send back
@@ -30,7 +30,7 @@ impl EventHandler {
     /// Handle a crossterm event (keyboard, mouse, paste) and return an action.
     pub fn handle_event(app: &App, event: &Event) -> Action {
         match event {
-            Event::Key(key) if key.kind == KeyEventKind::Press => handle_key_event(app, *key),
+            Event::Key(key) if is_press(key) => handle_key_event(app, normalize_key(*key)),
             Event::Mouse(mouse) => handle_mouse_event(app, *mouse),
             Event::Paste(_) => Action::None, // Paste is handled specially in main.rs
             _ => Action::None,
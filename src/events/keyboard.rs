@@ -2,13 +2,69 @@
 
 #![allow(dead_code)]
 
-use crossterm::event::{KeyCode, KeyEvent, KeyModifiers};
+use crossterm::event::{KeyCode, KeyEvent, KeyEventKind, KeyModifiers};
 
 use crate::app::{App, InputMode};
-use crate::session::SessionState;
+use crate::session::{MessageTag, SessionState};
 
 use super::Action;
 
+/// Whether a key event is a press. Windows terminals and the kitty keyboard
+/// protocol report held keys as repeats, not presses; repeats count only for
+/// keys that move or scroll (and delete text), so holding a key that acts,
+/// like `x`, can't fire it over and over.
+pub fn is_press(key: &KeyEvent) -> bool {
+    match key.kind {
+        KeyEventKind::Press => true,
+        KeyEventKind::Repeat => is_repeatable(&normalize_key(*key)),
+        KeyEventKind::Release => false,
+    }
+}
+
+/// Keys that may repeat while held: arrows, paging, j/k and the Ctrl scroll
+/// keys, and Backspace/Delete
+fn is_repeatable(key: &KeyEvent) -> bool {
+    let ctrl = key.modifiers.contains(KeyModifiers::CONTROL);
+    match key.code {
+        KeyCode::Up
+        | KeyCode::Down
+        | KeyCode::Left
+        | KeyCode::Right
+        | KeyCode::PageUp
+        | KeyCode::PageDown
+        | KeyCode::Backspace
+        | KeyCode::Delete => true,
+        KeyCode::Char('j' | 'k') => !ctrl,
+        KeyCode::Char('u' | 'd' | 'b' | 'f' | 'y' | 'e') => ctrl,
+        _ => false,
+    }
+}
+
+/// Normalize keys that terminals report differently, so bindings match the
+/// same way everywhere (Windows Terminal/ConPTY, kitty keyboard protocol):
+/// - a shifted letter reported as the lowercase letter with SHIFT becomes the
+///   uppercase letter
+/// - a raw control character becomes its key: Ctrl+letter, Tab, Enter or
+///   Backspace
+pub fn normalize_key(mut key: KeyEvent) -> KeyEvent {
+    match key.code {
+        KeyCode::Char(c)
+            if key.modifiers.contains(KeyModifiers::SHIFT) && c.is_ascii_lowercase() =>
+        {
+            key.code = KeyCode::Char(c.to_ascii_uppercase());
+        }
+        KeyCode::Char('\t') => key.code = KeyCode::Tab,
+        KeyCode::Char('\r' | '\n') => key.code = KeyCode::Enter,
+        KeyCode::Char('\u{8}' | '\u{7f}') => key.code = KeyCode::Backspace,
+        KeyCode::Char(c @ '\u{1}'..='\u{1a}') => {
+            key.code = KeyCode::Char((b'a' + c as u8 - 1) as char);
+            key.modifiers |= KeyModifiers::CONTROL;
+        }
+        _ => {}
+    }
+    key
+}
+
 /// Handle keyboard events and return the appropriate action.
 pub fn handle_key_event(app: &App, key: KeyEvent) -> Action {
     match app.input_mode {
@@ -25,6 +81,20 @@ pub fn handle_key_event(app: &App, key: KeyEvent) -> Action {
         InputMode::Help => handle_help_mode(key),
         InputMode::BugReport => handle_bug_report_mode(key),
         InputMode::ClearConfirm => handle_clear_confirm_mode(key),
+        InputMode::KillIdleConfirm => handle_kill_idle_confirm_mode(key),
+        InputMode::RecentFiles => handle_recent_files_mode(key),
+        InputMode::ProcessTree => handle_process_tree_mode(key),
+        InputMode::WorkspaceDiff => handle_workspace_diff_mode(key),
+        InputMode::Stats => handle_stats_mode(key),
+        InputMode::AuditLog => handle_audit_log_mode(key),
+        InputMode::ReplyTemplates => handle_reply_templates_mode(key),
+        InputMode::Notes => handle_notes_mode(key),
+        InputMode::EpicInput => handle_epic_input_mode(key),
+        InputMode::PlanHistory => handle_plan_history_mode(key),
+        InputMode::Tagging => handle_tagging_mode(key),
+        InputMode::Queue => handle_queue_mode(app, key),
+        InputMode::Search => handle_search_mode(app, key),
+        InputMode::VerifyOutput => handle_verify_output_mode(key),
     }
 }
 
@@ -87,6 +157,11 @@ fn handle_normal_mode(app: &App, key: KeyEvent) -> Action {
         KeyCode::Char('j') | KeyCode::Down => Action::NextSession,
         KeyCode::Char('k') | KeyCode::Up => Action::PrevSession,
 
+        // Transcript tabs
+        KeyCode::Char('O') => Action::ToggleTab,
+        KeyCode::Char(']') => Action::NextTab,
+        KeyCode::Char('[') => Action::PrevTab,
+
         // Enter insert mode
         KeyCode::Char('i') | KeyCode::Enter => {
             if app.sessions.selected_session().is_some() {
@@ -105,6 +180,18 @@ fn handle_normal_mode(app: &App, key: KeyEvent) -> Action {
         // Kill session
         KeyCode::Char('x') => Action::KillSession,
 
+        // Kill all sessions idle for longer than the configured time
+        KeyCode::Char('Z') => Action::OpenKillIdleConfirm,
+
+        // Restart agent (kill process and resume session)
+        KeyCode::Char('R') => Action::RestartSession,
+
+        // Show verify command (e.g. tests) output, running it the first time
+        KeyCode::Char('V') => Action::OpenVerifyOutput,
+
+        // Summarize the transcript with the configured summary command
+        KeyCode::Char('S') => Action::SummarizeSession,
+
         // Duplicate session
         KeyCode::Char('d') if !key.modifiers.contains(KeyModifiers::CONTROL) => {
             Action::DuplicateSession
@@ -119,6 +206,72 @@ fn handle_normal_mode(app: &App, key: KeyEvent) -> Action {
         // Toggle debug tool JSON display
         KeyCode::Char('t') => Action::ToggleDebugToolJson,
 
+        // Toggle thinking blocks
+        KeyCode::Char('T') => Action::ToggleThinking,
+
+        // Show only errors, failed tools and warnings
+        KeyCode::Char('F') => Action::ToggleErrorsOnly,
+
+        // Expand/collapse web tool results
+        KeyCode::Char('W') => Action::ToggleWebBodies,
+
+        // Preview each agent's latest message in the session list
+        KeyCode::Char('P') => Action::TogglePreviews,
+
+        // Export redacted transcript
+        KeyCode::Char('e') if !key.modifiers.contains(KeyModifiers::CONTROL) => {
+            Action::ExportTranscript
+        }
+
+        // Archive session transcript
+        KeyCode::Char('A') => Action::ArchiveSession,
+
+        // Browse recently written files
+        KeyCode::Char('o') => Action::OpenRecentFiles,
+
+        // Processes running under the agent
+        KeyCode::Char('X') => Action::OpenProcessTree,
+
+        // Diff the work tree between two points in the conversation
+        KeyCode::Char('C') => Action::OpenWorkspaceDiff,
+
+        // Conversation statistics
+        KeyCode::Char('s') => Action::OpenStats,
+
+        // Plan history timeline
+        KeyCode::Char('p') => Action::OpenPlanHistory,
+
+        // Audit log of kills, restarts and commands run
+        KeyCode::Char('L') => Action::OpenAuditLog,
+
+        // Quick reply templates
+        KeyCode::Char('r') => Action::OpenReplyMenu,
+
+        // Scratchpad notes on the session
+        KeyCode::Char('N') => Action::OpenNotes,
+
+        // Link the session to an epic
+        KeyCode::Char('E') => Action::OpenEpicInput,
+
+        // Queued prompts and work orders
+        KeyCode::Char('Q') => Action::OpenQueue,
+
+        // Search archived sessions
+        KeyCode::Char('/') => Action::OpenSearch,
+
+        // Tag messages for later extraction
+        KeyCode::Char('a') => Action::OpenTagging,
+
+        // Hide selected session / reveal hidden sessions
+        KeyCode::Char('D') => Action::ToggleHideSession,
+        KeyCode::Char('H') => Action::ToggleShowHidden,
+
+        // Interactive sessions only (hide Agent SDK / CI sessions)
+        KeyCode::Char('I') => Action::ToggleHideHeadless,
+
+        // Mute notifications for selected session
+        KeyCode::Char('M') => Action::ToggleMuteSession,
+
         // Scroll - vim style
         KeyCode::Char('u') if key.modifiers.contains(KeyModifiers::CONTROL) => {
             let half_page = app.viewport_height / 2;
@@ -134,6 +287,12 @@ fn handle_normal_mode(app: &App, key: KeyEvent) -> Action {
         KeyCode::Char('f') if key.modifiers.contains(KeyModifiers::CONTROL) => {
             Action::ScrollDown(app.viewport_height)
         }
+        KeyCode::Char('y') if key.modifiers.contains(KeyModifiers::CONTROL) => {
+            Action::ScrollLines(-1)
+        }
+        KeyCode::Char('e') if key.modifiers.contains(KeyModifiers::CONTROL) => {
+            Action::ScrollLines(1)
+        }
         KeyCode::PageUp => Action::ScrollUp(app.viewport_height),
         KeyCode::PageDown => Action::ScrollDown(app.viewport_height),
         KeyCode::Char('g') => Action::ScrollToTop,
@@ -412,6 +571,35 @@ pub fn handle_session_picker_mode(key: KeyEvent) -> Action {
     }
 }
 
+pub fn handle_recent_files_mode(key: KeyEvent) -> Action {
+    match key.code {
+        KeyCode::Esc | KeyCode::Char('q') | KeyCode::Enter => Action::CloseRecentFiles,
+        KeyCode::Char('j') | KeyCode::Down => Action::RecentFilesDown,
+        KeyCode::Char('k') | KeyCode::Up => Action::RecentFilesUp,
+        _ => Action::None,
+    }
+}
+
+pub fn handle_process_tree_mode(key: KeyEvent) -> Action {
+    match key.code {
+        KeyCode::Esc | KeyCode::Char('q') | KeyCode::Char('X') => Action::CloseProcessTree,
+        KeyCode::Char('j') | KeyCode::Down => Action::ProcessTreeDown,
+        KeyCode::Char('k') | KeyCode::Up => Action::ProcessTreeUp,
+        KeyCode::Enter | KeyCode::Char(' ') => Action::ProcessTreeToggle,
+        _ => Action::None,
+    }
+}
+
+pub fn handle_workspace_diff_mode(key: KeyEvent) -> Action {
+    match key.code {
+        KeyCode::Esc | KeyCode::Char('q') => Action::CloseWorkspaceDiff,
+        KeyCode::Char('j') | KeyCode::Down => Action::WorkspaceDiffDown,
+        KeyCode::Char('k') | KeyCode::Up => Action::WorkspaceDiffUp,
+        KeyCode::Enter | KeyCode::Char(' ') => Action::WorkspaceDiffMark,
+        _ => Action::None,
+    }
+}
+
 pub fn handle_worktree_cleanup_mode(key: KeyEvent) -> Action {
     match key.code {
         KeyCode::Esc | KeyCode::Char('q') => Action::CloseWorktreeCleanup,
@@ -454,6 +642,152 @@ pub fn handle_help_mode(key: KeyEvent) -> Action {
     }
 }
 
+pub fn handle_stats_mode(key: KeyEvent) -> Action {
+    match key.code {
+        KeyCode::Esc | KeyCode::Char('s') | KeyCode::Char('q') => Action::CloseStats,
+        _ => Action::None,
+    }
+}
+
+pub fn handle_audit_log_mode(key: KeyEvent) -> Action {
+    match key.code {
+        KeyCode::Esc | KeyCode::Char('L') | KeyCode::Char('q') => Action::CloseAuditLog,
+        _ => Action::None,
+    }
+}
+
+pub fn handle_reply_templates_mode(key: KeyEvent) -> Action {
+    match key.code {
+        KeyCode::Esc | KeyCode::Char('q') | KeyCode::Char('r') => Action::CloseReplyMenu,
+        KeyCode::Char('j') | KeyCode::Down => Action::ReplyMenuDown,
+        KeyCode::Char('k') | KeyCode::Up => Action::ReplyMenuUp,
+        KeyCode::Enter => Action::SendReplyTemplate(None),
+        KeyCode::Char(c @ '1'..='9') => {
+            Action::SendReplyTemplate(c.to_digit(10).map(|n| n as usize))
+        }
+        KeyCode::Tab | KeyCode::Char('e') => Action::DraftReplyTemplate,
+        _ => Action::None,
+    }
+}
+
+pub fn handle_notes_mode(key: KeyEvent) -> Action {
+    match key.code {
+        KeyCode::Esc => Action::CloseNotes,
+        KeyCode::Enter => Action::NotesInputChar('\n'),
+        KeyCode::Char(c) => Action::NotesInputChar(c),
+        KeyCode::Backspace => Action::NotesInputBackspace,
+        KeyCode::Delete => Action::NotesInputDelete,
+        KeyCode::Left => Action::NotesInputLeft,
+        KeyCode::Right => Action::NotesInputRight,
+        KeyCode::Home => Action::NotesInputHome,
+        KeyCode::End => Action::NotesInputEnd,
+        _ => Action::None,
+    }
+}
+
+pub fn handle_epic_input_mode(key: KeyEvent) -> Action {
+    match key.code {
+        KeyCode::Esc => Action::CloseEpicInput,
+        KeyCode::Enter => Action::SubmitEpicInput,
+        KeyCode::Tab => Action::EpicInputComplete,
+        KeyCode::Char(c) => Action::EpicInputChar(c),
+        KeyCode::Backspace => Action::EpicInputBackspace,
+        KeyCode::Left => Action::EpicInputLeft,
+        KeyCode::Right => Action::EpicInputRight,
+        _ => Action::None,
+    }
+}
+
+pub fn handle_plan_history_mode(key: KeyEvent) -> Action {
+    match key.code {
+        KeyCode::Esc | KeyCode::Char('p') | KeyCode::Char('q') => Action::ClosePlanHistory,
+        _ => Action::None,
+    }
+}
+
+pub fn handle_verify_output_mode(key: KeyEvent) -> Action {
+    match key.code {
+        KeyCode::Esc | KeyCode::Char('V') | KeyCode::Char('q') => Action::CloseVerifyOutput,
+        KeyCode::Char('r') => Action::RunVerify,
+        KeyCode::Char('k') | KeyCode::Up => Action::ScrollVerifyOutput(1),
+        KeyCode::Char('j') | KeyCode::Down => Action::ScrollVerifyOutput(-1),
+        KeyCode::PageUp => Action::ScrollVerifyOutput(10),
+        KeyCode::PageDown => Action::ScrollVerifyOutput(-10),
+        _ => Action::None,
+    }
+}
+
+pub fn handle_queue_mode(app: &App, key: KeyEvent) -> Action {
+    let editing = app.queue_view.as_ref().is_some_and(|v| v.edit.is_some());
+    if editing {
+        return match key.code {
+            KeyCode::Esc => Action::CloseQueueEdit,
+            KeyCode::Enter => Action::SubmitQueueEdit,
+            KeyCode::Char(c) => Action::QueueEditChar(c),
+            KeyCode::Backspace => Action::QueueEditBackspace,
+            KeyCode::Left => Action::QueueEditLeft,
+            KeyCode::Right => Action::QueueEditRight,
+            _ => Action::None,
+        };
+    }
+    match key.code {
+        KeyCode::Esc | KeyCode::Char('Q') | KeyCode::Char('q') => Action::CloseQueue,
+        KeyCode::Char('j') | KeyCode::Down => Action::QueueDown,
+        KeyCode::Char('k') | KeyCode::Up => Action::QueueUp,
+        KeyCode::Char('x') | KeyCode::Delete => Action::CancelQueueItem,
+        KeyCode::Char('e') | KeyCode::Enter => Action::EditQueueItem,
+        KeyCode::Char('a') => Action::AddWorkOrder,
+        _ => Action::None,
+    }
+}
+
+pub fn handle_search_mode(app: &App, key: KeyEvent) -> Action {
+    // An opened message only closes
+    if app
+        .search_view
+        .as_ref()
+        .is_some_and(|v| v.preview.is_some())
+    {
+        return match key.code {
+            KeyCode::Esc | KeyCode::Enter | KeyCode::Char('q') => Action::CloseSearch,
+            _ => Action::None,
+        };
+    }
+    match key.code {
+        KeyCode::Esc => Action::CloseSearch,
+        KeyCode::Enter => Action::SubmitSearch,
+        KeyCode::Down => Action::SearchDown,
+        KeyCode::Up => Action::SearchUp,
+        KeyCode::Char('n') if key.modifiers.contains(KeyModifiers::CONTROL) => Action::SearchDown,
+        KeyCode::Char('p') if key.modifiers.contains(KeyModifiers::CONTROL) => Action::SearchUp,
+        KeyCode::Char(c) => Action::SearchInputChar(c),
+        KeyCode::Backspace => Action::SearchInputBackspace,
+        KeyCode::Left => Action::SearchInputLeft,
+        KeyCode::Right => Action::SearchInputRight,
+        _ => Action::None,
+    }
+}
+
+pub fn handle_tagging_mode(key: KeyEvent) -> Action {
+    match key.code {
+        KeyCode::Esc | KeyCode::Char('a') | KeyCode::Char('q') => Action::CloseTagging,
+        KeyCode::Char('j') | KeyCode::Down => Action::TagCursorDown,
+        KeyCode::Char('k') | KeyCode::Up => Action::TagCursorUp,
+        KeyCode::Char('d') => Action::TagMessage(MessageTag::Decision),
+        KeyCode::Char('b') => Action::TagMessage(MessageTag::Bug),
+        KeyCode::Char('t') => Action::TagMessage(MessageTag::Todo),
+        KeyCode::Char('x') => Action::ExportTaggedMessages,
+        KeyCode::Char('e') => Action::ExportDataset(None),
+        KeyCode::Char('D') => Action::ExportDataset(Some(MessageTag::Decision)),
+        KeyCode::Char('B') => Action::ExportDataset(Some(MessageTag::Bug)),
+        KeyCode::Char('T') => Action::ExportDataset(Some(MessageTag::Todo)),
+        KeyCode::Char('y') => Action::CopyPermalink,
+        KeyCode::Enter => Action::OpenAttachments,
+        KeyCode::Char('c') => Action::ToggleAnsi,
+        _ => Action::None,
+    }
+}
+
 pub fn handle_clear_confirm_mode(key: KeyEvent) -> Action {
     match key.code {
         KeyCode::Char('y') | KeyCode::Enter => Action::ClearSession,
@@ -462,6 +796,14 @@ pub fn handle_clear_confirm_mode(key: KeyEvent) -> Action {
     }
 }
 
+pub fn handle_kill_idle_confirm_mode(key: KeyEvent) -> Action {
+    match key.code {
+        KeyCode::Char('y') | KeyCode::Enter => Action::KillIdleSessions,
+        KeyCode::Char('n') | KeyCode::Esc => Action::CloseKillIdleConfirm,
+        _ => Action::None,
+    }
+}
+
 pub fn handle_bug_report_mode(key: KeyEvent) -> Action {
     match key.code {
         KeyCode::Esc => Action::CloseBugReport,
@@ -0,0 +1,130 @@
+//! Project directories excluded from amux's scans.
+//!
+//! `exclude` in the config lists globs like `~/tmp/**` or `~/clients/acme`.
+//! As in `.gitignore`, a pattern matching a directory excludes everything
+//! under it. `*` and `?` match within one path component and `**` matches any
+//! number of components.
+
+use std::path::{Component, Path};
+
+use crate::scope;
+
+/// Compiled exclude patterns
+#[derive(Debug, Clone, Default)]
+pub struct Excludes {
+    /// Components of each pattern, with `~` expanded
+    patterns: Vec<Vec<String>>,
+}
+
+impl Excludes {
+    pub fn new(patterns: &[String]) -> Self {
+        Self {
+            patterns: patterns
+                .iter()
+                .map(|pattern| components(&scope::expand_home(Path::new(pattern.trim()))))
+                .filter(|parts| !parts.is_empty())
+                .collect(),
+        }
+    }
+
+    pub fn is_empty(&self) -> bool {
+        self.patterns.is_empty()
+    }
+
+    /// Whether `path` or one of its parent directories matches a pattern
+    pub fn is_excluded(&self, path: &Path) -> bool {
+        let parts = components(&scope::expand_home(path));
+        self.patterns
+            .iter()
+            .any(|pattern| (1..=parts.len()).any(|len| match_components(pattern, &parts[..len])))
+    }
+}
+
+/// Normal components of a path, with the root as "/"
+fn components(path: &Path) -> Vec<String> {
+    path.components()
+        .filter_map(|component| match component {
+            Component::RootDir => Some("/".to_string()),
+            Component::Normal(part) => Some(part.to_string_lossy().to_string()),
+            _ => None,
+        })
+        .collect()
+}
+
+/// Match path components against pattern components, `**` spanning any number
+fn match_components(pattern: &[String], parts: &[String]) -> bool {
+    match pattern.split_first() {
+        None => parts.is_empty(),
+        Some((first, rest)) if first == "**" => {
+            (0..=parts.len()).any(|skip| match_components(rest, &parts[skip..]))
+        }
+        Some((first, rest)) => parts.split_first().is_some_and(|(part, parts)| {
+            let pattern: Vec<char> = first.chars().collect();
+            let text: Vec<char> = part.chars().collect();
+            match_glob(&pattern, &text) && match_components(rest, parts)
+        }),
+    }
+}
+
+/// Match one component against a glob with `*` and `?`
+fn match_glob(pattern: &[char], text: &[char]) -> bool {
+    match pattern.split_first() {
+        None => text.is_empty(),
+        Some(('*', rest)) => (0..=text.len()).any(|skip| match_glob(rest, &text[skip..])),
+        Some(('?', rest)) => !text.is_empty() && match_glob(rest, &text[1..]),
+        Some((c, rest)) => text.first() == Some(c) && match_glob(rest, &text[1..]),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn excludes(patterns: &[&str]) -> Excludes {
+        Excludes::new(&patterns.iter().map(|p| p.to_string()).collect::<Vec<_>>())
+    }
+
+    #[test]
+    fn test_directory_excludes_subdirectories() {
+        let ex = excludes(&["/home/me/tmp"]);
+        assert!(ex.is_excluded(Path::new("/home/me/tmp")));
+        assert!(ex.is_excluded(Path::new("/home/me/tmp/experiment/src")));
+        assert!(!ex.is_excluded(Path::new("/home/me/tmpfiles")));
+        assert!(!ex.is_excluded(Path::new("/home/me")));
+    }
+
+    #[test]
+    fn test_wildcards() {
+        let ex = excludes(&["/home/*/clients/acme-?", "**/scratch-*"]);
+        assert!(ex.is_excluded(Path::new("/home/me/clients/acme-1/app")));
+        assert!(!ex.is_excluded(Path::new("/home/me/clients/acme-12")));
+        assert!(ex.is_excluded(Path::new("/srv/code/scratch-parser")));
+        assert!(!ex.is_excluded(Path::new("/srv/code/parser")));
+    }
+
+    #[test]
+    fn test_double_star_in_middle() {
+        let ex = excludes(&["/work/**/vendor"]);
+        assert!(ex.is_excluded(Path::new("/work/vendor")));
+        assert!(ex.is_excluded(Path::new("/work/a/b/vendor/lib")));
+        assert!(!ex.is_excluded(Path::new("/other/vendor")));
+    }
+
+    #[test]
+    fn test_home_expansion() {
+        let Some(home) = dirs::home_dir() else {
+            return;
+        };
+        let ex = excludes(&["~/tmp/**"]);
+        assert!(ex.is_excluded(&home.join("tmp").join("try")));
+        assert!(ex.is_excluded(Path::new("~/tmp/try")));
+        assert!(!ex.is_excluded(&home.join("code")));
+    }
+
+    #[test]
+    fn test_empty() {
+        let ex = excludes(&["", "  "]);
+        assert!(ex.is_empty());
+        assert!(!ex.is_excluded(Path::new("/anything")));
+    }
+}
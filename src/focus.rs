@@ -0,0 +1,185 @@
+//! Jumping to an agent from outside amux.
+//!
+//! `amux://focus/<session>` names an agent by its session name. Opening it
+//! (`amux open amux://focus/api`, a desktop notification's "Open" action, or
+//! any link once `amux open --register` made amux the `amux://` handler)
+//! leaves a request in amux's state directory. The running amux picks it up
+//! within a fraction of a second and selects the session; inside tmux, the
+//! client is also switched to the pane amux runs in (found by its process ID)
+//! so the terminal comes forward.
+
+use std::path::{Path, PathBuf};
+use std::process::Command;
+
+use crate::snapshot;
+
+/// URI prefix of focus links
+const PREFIX: &str = "amux://focus/";
+
+/// Link that focuses the session named `session_name`
+pub fn uri(session_name: &str) -> String {
+    format!("{}{}", PREFIX, session_name)
+}
+
+/// Session name of a focus link
+pub fn parse(text: &str) -> Option<String> {
+    let name = text.trim().strip_prefix(PREFIX)?.trim_end_matches('/');
+    (!name.is_empty()).then(|| name.to_string())
+}
+
+/// File the request to focus a session is left in
+fn request_path() -> PathBuf {
+    snapshot::state_dir().join("focus")
+}
+
+/// Ask the running amux to select `session_name`, and bring its tmux pane
+/// forward when there is one. `amux_pid` is the running amux's process ID;
+/// without it, the one recorded in the snapshot is used.
+pub fn request(session_name: &str, amux_pid: Option<u32>) -> std::io::Result<()> {
+    let path = request_path();
+    if let Some(dir) = path.parent() {
+        std::fs::create_dir_all(dir)?;
+    }
+    std::fs::write(&path, session_name)?;
+    if let Some(pid) = amux_pid.or_else(|| snapshot::read()?.amux_pid) {
+        switch_tmux_pane(pid);
+    }
+    Ok(())
+}
+
+/// Take the pending focus request, if any
+pub fn take_request() -> Option<String> {
+    let path = request_path();
+    let name = std::fs::read_to_string(&path).ok()?;
+    let _ = std::fs::remove_file(&path);
+    let name = name.trim();
+    (!name.is_empty()).then(|| name.to_string())
+}
+
+/// Switch the tmux client to the pane amux (process `amux_pid`) runs in.
+/// Best effort: does nothing outside tmux or when amux runs in no pane.
+fn switch_tmux_pane(amux_pid: u32) {
+    let Ok(output) = Command::new("tmux")
+        .args(["list-panes", "-a", "-F", "#{pane_id} #{pane_pid}"])
+        .output()
+    else {
+        return;
+    };
+    let panes = String::from_utf8_lossy(&output.stdout);
+    let Some(pane) = amux_pane(&panes, &ancestors(amux_pid)) else {
+        return;
+    };
+    for args in [
+        ["switch-client", "-t", pane],
+        ["select-window", "-t", pane],
+        ["select-pane", "-t", pane],
+    ] {
+        let _ = Command::new("tmux").args(args).status();
+    }
+}
+
+/// `pid` followed by its parent, grandparent and so on
+fn ancestors(pid: u32) -> Vec<u32> {
+    let mut pids = vec![pid];
+    // A pane's process is rarely more than a few levels up (shell, wrapper)
+    while pids.len() < 16 {
+        let Ok(output) = Command::new("ps")
+            .args(["-o", "ppid=", "-p", &pids[pids.len() - 1].to_string()])
+            .output()
+        else {
+            break;
+        };
+        match String::from_utf8_lossy(&output.stdout).trim().parse() {
+            Ok(parent) if parent > 1 && !pids.contains(&parent) => pids.push(parent),
+            _ => break,
+        }
+    }
+    pids
+}
+
+/// Pane in `tmux list-panes` output whose process is one of `pids` (amux
+/// and its ancestors, nearest first)
+fn amux_pane<'a>(panes: &'a str, pids: &[u32]) -> Option<&'a str> {
+    let panes: Vec<(&str, u32)> = panes
+        .lines()
+        .filter_map(|line| {
+            let (pane, pid) = line.split_once(' ')?;
+            Some((pane, pid.trim().parse().ok()?))
+        })
+        .collect();
+    pids.iter()
+        .find_map(|pid| panes.iter().find(|(_, p)| p == pid).map(|(pane, _)| *pane))
+}
+
+/// Desktop entry that opens `amux://` links with `amux open`
+fn desktop_entry(amux: &Path) -> String {
+    format!(
+        "[Desktop Entry]\n\
+         Type=Application\n\
+         Name=amux\n\
+         Exec={} open %u\n\
+         NoDisplay=true\n\
+         Terminal=false\n\
+         MimeType=x-scheme-handler/amux;\n",
+        amux.display()
+    )
+}
+
+/// Run `amux open --register`: make amux the handler of `amux://` links
+/// (freedesktop systems). Returns an error message on failure.
+pub fn register() -> Result<(), String> {
+    if cfg!(target_os = "macos") {
+        return Err("registering URL handlers needs an app bundle on macOS; \
+             run `amux open amux://focus/<session>` from a hotkey tool instead"
+            .to_string());
+    }
+    let amux = std::env::current_exe().unwrap_or_else(|_| PathBuf::from("amux"));
+    let dir = dirs::data_dir()
+        .ok_or("no data directory")?
+        .join("applications");
+    std::fs::create_dir_all(&dir).map_err(|e| format!("{}: {}", dir.display(), e))?;
+    let path = dir.join("amux-url.desktop");
+    std::fs::write(&path, desktop_entry(&amux))
+        .map_err(|e| format!("{}: {}", path.display(), e))?;
+    println!("Wrote {}", path.display());
+
+    let status = Command::new("xdg-mime")
+        .args(["default", "amux-url.desktop", "x-scheme-handler/amux"])
+        .status()
+        .map_err(|e| format!("failed to run xdg-mime: {}", e))?;
+    if !status.success() {
+        return Err("xdg-mime default failed".to_string());
+    }
+    println!("amux:// links now open with amux");
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_uri_round_trip() {
+        assert_eq!(uri("api"), "amux://focus/api");
+        assert_eq!(parse("amux://focus/api"), Some("api".to_string()));
+        assert_eq!(parse(" amux://focus/api/ "), Some("api".to_string()));
+        assert_eq!(parse("amux://focus/"), None);
+        assert_eq!(parse("amux://abc-123/4"), None);
+    }
+
+    #[test]
+    fn test_amux_pane() {
+        // amux (42) runs in a shell (30); another pane runs amux too
+        let panes = "%0 10\n%3 30\n%4 50\n";
+        assert_eq!(amux_pane(panes, &[42, 30, 1]), Some("%3"));
+        assert_eq!(amux_pane(panes, &[50]), Some("%4"));
+        assert_eq!(amux_pane(panes, &[42]), None);
+    }
+
+    #[test]
+    fn test_desktop_entry() {
+        let entry = desktop_entry(Path::new("/usr/bin/amux"));
+        assert!(entry.contains("Exec=/usr/bin/amux open %u\n"));
+        assert!(entry.contains("MimeType=x-scheme-handler/amux;\n"));
+    }
+}
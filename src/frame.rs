@@ -0,0 +1,114 @@
+//! Repaint rate limiting.
+//!
+//! Agents streaming large outputs send dozens of updates a second, and
+//! repainting after each one keeps amux busy redrawing frames nobody can read.
+//! [`FrameLimiter`] coalesces those updates into at most `max_fps` repaints a
+//! second. Input from the user still repaints right away, so typing and
+//! navigation never wait for the next frame.
+
+use std::time::{Duration, Instant};
+
+/// Repaints per second while agents stream output, unless configured
+pub const DEFAULT_MAX_FPS: u32 = 10;
+
+/// Decides when the screen is repainted
+#[derive(Debug, Clone)]
+pub struct FrameLimiter {
+    /// Minimum time between coalesced repaints
+    interval: Duration,
+    /// When the screen was last repainted
+    last_frame: Option<Instant>,
+    /// Something changed since the last repaint
+    pending: bool,
+    /// The next repaint shouldn't wait for the interval
+    urgent: bool,
+}
+
+impl Default for FrameLimiter {
+    fn default() -> Self {
+        Self::new(DEFAULT_MAX_FPS)
+    }
+}
+
+impl FrameLimiter {
+    pub fn new(max_fps: u32) -> Self {
+        Self {
+            interval: Duration::from_secs(1) / max_fps.clamp(1, 60),
+            last_frame: None,
+            pending: true,
+            urgent: false,
+        }
+    }
+
+    /// Change the frame rate, keeping any pending repaint
+    pub fn set_max_fps(&mut self, max_fps: u32) {
+        self.interval = Duration::from_secs(1) / max_fps.clamp(1, 60);
+    }
+
+    /// Note a change to repaint within the frame rate
+    pub fn request(&mut self) {
+        self.pending = true;
+    }
+
+    /// Note a change to repaint right away (user input)
+    pub fn request_now(&mut self) {
+        self.pending = true;
+        self.urgent = true;
+    }
+
+    /// Whether to repaint at `now`; when it returns true the frame counts as drawn
+    pub fn should_draw(&mut self, now: Instant) -> bool {
+        let due = self
+            .last_frame
+            .is_none_or(|last| now.duration_since(last) >= self.interval);
+        if !self.pending || !(self.urgent || due) {
+            return false;
+        }
+        self.last_frame = Some(now);
+        self.pending = false;
+        self.urgent = false;
+        true
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_coalesces_updates_within_interval() {
+        let mut frames = FrameLimiter::new(10);
+        let start = Instant::now();
+        assert!(frames.should_draw(start));
+
+        // A burst of updates within 100ms repaints once, when the interval is up
+        for ms in [10, 20, 50, 90] {
+            frames.request();
+            assert!(!frames.should_draw(start + Duration::from_millis(ms)));
+        }
+        assert!(frames.should_draw(start + Duration::from_millis(100)));
+        assert!(!frames.should_draw(start + Duration::from_millis(300)));
+    }
+
+    #[test]
+    fn test_user_input_repaints_immediately() {
+        let mut frames = FrameLimiter::new(10);
+        let start = Instant::now();
+        assert!(frames.should_draw(start));
+
+        frames.request_now();
+        assert!(frames.should_draw(start + Duration::from_millis(5)));
+        frames.request();
+        assert!(!frames.should_draw(start + Duration::from_millis(10)));
+    }
+
+    #[test]
+    fn test_fps_clamped() {
+        let mut frames = FrameLimiter::new(0);
+        let start = Instant::now();
+        assert!(frames.should_draw(start));
+        frames.request();
+        assert!(!frames.should_draw(start + Duration::from_millis(999)));
+        assert!(frames.should_draw(start + Duration::from_secs(1)));
+    }
+}
@@ -1,12 +1,73 @@
 use anyhow::{Result, bail};
+use chrono::{DateTime, Local};
 use std::path::Path;
+use std::process::Output;
+use std::sync::OnceLock;
+use std::sync::atomic::{AtomicUsize, Ordering};
+use std::time::{Duration, SystemTime};
+use tokio::sync::Semaphore;
+
+/// Limits on read-only git queries (status, diffs, logs). Background refreshes
+/// run many of them; the limits keep a slow repo or a hung network mount from
+/// piling up processes or stalling refreshes.
+#[derive(Debug, Clone, Copy)]
+pub struct QueryLimits {
+    /// Queries running at once; others wait for a slot
+    pub max_concurrent: usize,
+    /// Queries running longer are killed and fail
+    pub timeout: Duration,
+}
+
+impl Default for QueryLimits {
+    fn default() -> Self {
+        Self {
+            max_concurrent: 4,
+            timeout: Duration::from_secs(10),
+        }
+    }
+}
+
+static QUERY_LIMITS: OnceLock<QueryLimits> = OnceLock::new();
+static QUERY_SLOTS: OnceLock<Semaphore> = OnceLock::new();
+
+/// Set the limits for git queries; must be called before the first query to take effect
+pub fn set_query_limits(limits: QueryLimits) {
+    let _ = QUERY_LIMITS.set(limits);
+}
+
+/// Running read-only git commands within the query limits
+pub trait GitQuery {
+    /// Like `output()`, but waits for a query slot and fails with `TimedOut`
+    /// (killing the process) when the timeout passes
+    fn query_output(&mut self) -> impl Future<Output = std::io::Result<Output>> + Send;
+}
+
+impl GitQuery for tokio::process::Command {
+    fn query_output(&mut self) -> impl Future<Output = std::io::Result<Output>> + Send {
+        async move {
+            let limits = *QUERY_LIMITS.get_or_init(QueryLimits::default);
+            let slots = QUERY_SLOTS.get_or_init(|| Semaphore::new(limits.max_concurrent.max(1)));
+            let _slot = slots.acquire().await.map_err(std::io::Error::other)?;
+
+            self.kill_on_drop(true);
+            tokio::time::timeout(limits.timeout, self.output())
+                .await
+                .map_err(|_| {
+                    std::io::Error::new(
+                        std::io::ErrorKind::TimedOut,
+                        format!("git timed out after {}s", limits.timeout.as_secs()),
+                    )
+                })?
+        }
+    }
+}
 
 /// Get the git remote origin URL for a repository, normalized for grouping
 pub async fn get_origin_url(repo_path: &Path) -> Option<String> {
     let output = tokio::process::Command::new("git")
         .args(["config", "--get", "remote.origin.url"])
         .current_dir(repo_path)
-        .output()
+        .query_output()
         .await
         .ok()?;
 
@@ -59,7 +120,7 @@ pub async fn branch_exists(repo_path: &Path, branch_name: &str) -> Result<bool>
             &format!("refs/heads/{}", branch_name),
         ])
         .current_dir(repo_path)
-        .output()
+        .query_output()
         .await?;
 
     Ok(output.status.success())
@@ -76,7 +137,7 @@ pub async fn remote_branch_exists(repo_path: &Path, branch_name: &str) -> Result
                 &format!("refs/remotes/{}/{}", remote, branch_name),
             ])
             .current_dir(repo_path)
-            .output()
+            .query_output()
             .await?;
 
         if output.status.success() {
@@ -140,7 +201,7 @@ pub async fn is_worktree_clean(worktree_path: &Path) -> Result<bool> {
     let output = tokio::process::Command::new("git")
         .args(["status", "--porcelain"])
         .current_dir(worktree_path)
-        .output()
+        .query_output()
         .await?;
 
     if !output.status.success() {
@@ -167,7 +228,7 @@ pub async fn is_branch_merged(repo_path: &Path, branch_name: &str) -> Result<boo
             &format!("origin/{}", default_branch),
         ])
         .current_dir(repo_path)
-        .output()
+        .query_output()
         .await?;
 
     // Exit code 0 = is ancestor (merged), 1 = not ancestor, other = error
@@ -180,7 +241,7 @@ pub async fn get_default_branch(repo_path: &Path) -> Result<String> {
     let output = tokio::process::Command::new("git")
         .args(["symbolic-ref", "refs/remotes/origin/HEAD"])
         .current_dir(repo_path)
-        .output()
+        .query_output()
         .await?;
 
     if output.status.success() {
@@ -195,7 +256,7 @@ pub async fn get_default_branch(repo_path: &Path) -> Result<String> {
         let output = tokio::process::Command::new("git")
             .args(["rev-parse", "--verify", &format!("refs/heads/{}", branch)])
             .current_dir(repo_path)
-            .output()
+            .query_output()
             .await?;
 
         if output.status.success() {
@@ -273,6 +334,39 @@ pub struct DiffStats {
     pub deletions: usize,
 }
 
+/// How large a diff is relative to the configured review thresholds
+#[derive(Debug, Clone, Copy, Default, PartialEq)]
+pub enum DiffSeverity {
+    #[default]
+    Normal,
+    /// Over a threshold: worth reviewing soon
+    Large,
+    /// Over twice a threshold: hard to review already
+    Huge,
+}
+
+/// Git state of a session's working tree, gathered off the event loop.
+/// Fields are None when the corresponding git command failed.
+#[derive(Debug, Default)]
+pub struct WorkTreeStatus {
+    pub diff_stats: Option<DiffStats>,
+    pub changed_files: Option<Vec<String>>,
+    pub commits: Option<Vec<String>>,
+}
+
+/// Gather diff stats and changed files against the base branch, and commits made since `since`
+pub async fn get_work_tree_status(
+    repo_path: &Path,
+    current_branch: &str,
+    since: SystemTime,
+) -> WorkTreeStatus {
+    WorkTreeStatus {
+        diff_stats: get_diff_stats(repo_path, current_branch).await.ok(),
+        changed_files: get_changed_files(repo_path, current_branch).await.ok(),
+        commits: get_commits_since(repo_path, since).await.ok(),
+    }
+}
+
 /// Get git diff statistics between current branch and base branch (usually origin/main)
 pub async fn get_diff_stats(repo_path: &Path, current_branch: &str) -> Result<DiffStats> {
     // Get the default branch
@@ -283,7 +377,7 @@ pub async fn get_diff_stats(repo_path: &Path, current_branch: &str) -> Result<Di
         let output = tokio::process::Command::new("git")
             .args(["diff", "--shortstat", "HEAD"])
             .current_dir(repo_path)
-            .output()
+            .query_output()
             .await?;
 
         if output.status.success() {
@@ -300,7 +394,7 @@ pub async fn get_diff_stats(repo_path: &Path, current_branch: &str) -> Result<Di
     let output = tokio::process::Command::new("git")
         .args(["diff", "--shortstat", &compare_ref])
         .current_dir(repo_path)
-        .output()
+        .query_output()
         .await?;
 
     if !output.status.success() {
@@ -310,7 +404,7 @@ pub async fn get_diff_stats(repo_path: &Path, current_branch: &str) -> Result<Di
         let output = tokio::process::Command::new("git")
             .args(["diff", "--shortstat", &local_compare])
             .current_dir(repo_path)
-            .output()
+            .query_output()
             .await?;
 
         if !output.status.success() {
@@ -324,6 +418,187 @@ pub async fn get_diff_stats(repo_path: &Path, current_branch: &str) -> Result<Di
     parse_diff_stats(&String::from_utf8_lossy(&output.stdout))
 }
 
+/// List files changed on the current branch compared to the base branch,
+/// including uncommitted changes and untracked (not ignored) files in the
+/// working directory
+pub async fn get_changed_files(repo_path: &Path, current_branch: &str) -> Result<Vec<String>> {
+    let base_branch = get_default_branch(repo_path).await?;
+
+    // Diff the working directory against the point where the branch forked
+    // from the base branch (or HEAD when on the base branch itself)
+    let mut diff_base = "HEAD".to_string();
+    if current_branch != base_branch {
+        for base_ref in [format!("origin/{}", base_branch), base_branch.clone()] {
+            let output = tokio::process::Command::new("git")
+                .args(["merge-base", &base_ref, "HEAD"])
+                .current_dir(repo_path)
+                .query_output()
+                .await?;
+
+            if output.status.success() {
+                diff_base = String::from_utf8_lossy(&output.stdout).trim().to_string();
+                break;
+            }
+        }
+    }
+
+    let output = tokio::process::Command::new("git")
+        .args(["diff", "--name-only", &diff_base])
+        .current_dir(repo_path)
+        .query_output()
+        .await?;
+
+    if !output.status.success() {
+        return Ok(vec![]);
+    }
+
+    let mut files: Vec<String> = String::from_utf8_lossy(&output.stdout)
+        .lines()
+        .filter(|line| !line.is_empty())
+        .map(|line| line.to_string())
+        .collect();
+
+    // New files the agent hasn't added yet don't show up in the diff
+    let output = tokio::process::Command::new("git")
+        .args(["ls-files", "--others", "--exclude-standard"])
+        .current_dir(repo_path)
+        .query_output()
+        .await?;
+
+    if output.status.success() {
+        for line in String::from_utf8_lossy(&output.stdout).lines() {
+            if !line.is_empty() && !files.iter().any(|file| file == line) {
+                files.push(line.to_string());
+            }
+        }
+    }
+
+    Ok(files)
+}
+
+/// List subjects of commits on HEAD made since the given time, newest first
+pub async fn get_commits_since(repo_path: &Path, since: SystemTime) -> Result<Vec<String>> {
+    let since = DateTime::<Local>::from(since).to_rfc3339();
+    let output = tokio::process::Command::new("git")
+        .args(["log", &format!("--since={}", since), "--format=%s", "HEAD"])
+        .current_dir(repo_path)
+        .query_output()
+        .await?;
+
+    if !output.status.success() {
+        return Ok(vec![]);
+    }
+
+    Ok(String::from_utf8_lossy(&output.stdout)
+        .lines()
+        .filter(|line| !line.is_empty())
+        .map(|line| line.to_string())
+        .collect())
+}
+
+/// Work tree snapshots taking longer are killed and fail. Snapshots hash and
+/// write every changed file, so they get more time than read-only queries.
+const SNAPSHOT_TIMEOUT: Duration = Duration::from_secs(60);
+
+/// Untracked files larger than this in total (build output that isn't
+/// ignored, datasets) skip the snapshot rather than filling the object store
+const SNAPSHOT_MAX_UNTRACKED_BYTES: u64 = 50 * 1024 * 1024;
+
+/// Snapshots run one at a time, apart from the read-only query slots, so
+/// they neither queue behind status refreshes nor hold them up
+static SNAPSHOT_SLOT: Semaphore = Semaphore::const_new(1);
+
+/// Record the work tree as a tree object, untracked files included (ignored
+/// ones aren't), without touching the work tree, index or stash list: the
+/// files are staged into a copy of the index. The same work tree always
+/// records as the same tree. Trees are unreferenced, so `git gc` prunes them
+/// eventually. Fails without writing anything when the untracked files are
+/// too large to copy into the object store.
+pub async fn snapshot_work_tree(repo_path: &Path) -> Result<String> {
+    static SNAPSHOTS: AtomicUsize = AtomicUsize::new(0);
+
+    let _slot = SNAPSHOT_SLOT.acquire().await?;
+    let untracked = run_snapshot_git(
+        repo_path,
+        None,
+        &["ls-files", "--others", "--exclude-standard", "-z"],
+    )
+    .await?;
+    let mut untracked_bytes = 0;
+    for file in untracked.split('\0').filter(|f| !f.is_empty()) {
+        if let Ok(metadata) = tokio::fs::metadata(repo_path.join(file)).await {
+            untracked_bytes += metadata.len();
+        }
+    }
+    if untracked_bytes > SNAPSHOT_MAX_UNTRACKED_BYTES {
+        bail!(
+            "untracked files take {} MB, over the {} MB snapshot limit",
+            untracked_bytes / (1024 * 1024),
+            SNAPSHOT_MAX_UNTRACKED_BYTES / (1024 * 1024)
+        );
+    }
+
+    let index = run_snapshot_git(repo_path, None, &["rev-parse", "--git-path", "index"]).await?;
+    let temp_index = std::env::temp_dir().join(format!(
+        "amux-index-{}-{}",
+        std::process::id(),
+        SNAPSHOTS.fetch_add(1, Ordering::Relaxed)
+    ));
+    // Starting from the real index lets git skip hashing unchanged files
+    if let Err(e) = tokio::fs::copy(repo_path.join(index), &temp_index).await
+        && e.kind() != std::io::ErrorKind::NotFound
+    {
+        bail!("Failed to snapshot work tree: {}", e);
+    }
+
+    let tree = match run_snapshot_git(repo_path, Some(&temp_index), &["add", "--all"]).await {
+        Ok(_) => run_snapshot_git(repo_path, Some(&temp_index), &["write-tree"]).await,
+        Err(e) => Err(e),
+    };
+    let _ = tokio::fs::remove_file(&temp_index).await;
+    tree
+}
+
+/// Run a git command of a work tree snapshot, optionally against another
+/// index file; its trimmed output
+async fn run_snapshot_git(repo_path: &Path, index: Option<&Path>, args: &[&str]) -> Result<String> {
+    let mut command = tokio::process::Command::new("git");
+    command.args(args).current_dir(repo_path).kill_on_drop(true);
+    if let Some(index) = index {
+        command.env("GIT_INDEX_FILE", index);
+    }
+    let Ok(output) = tokio::time::timeout(SNAPSHOT_TIMEOUT, command.output()).await else {
+        bail!(
+            "Failed to snapshot work tree: git {} timed out after {}s",
+            args[0],
+            SNAPSHOT_TIMEOUT.as_secs()
+        );
+    };
+    let output = output?;
+
+    if !output.status.success() {
+        let stderr = String::from_utf8_lossy(&output.stderr);
+        bail!("Failed to snapshot work tree: {}", stderr.trim());
+    }
+    Ok(String::from_utf8_lossy(&output.stdout).trim().to_string())
+}
+
+/// Unified diff between two work tree snapshots
+pub async fn diff_snapshots(repo_path: &Path, from: &str, to: &str) -> Result<String> {
+    let output = tokio::process::Command::new("git")
+        .args(["diff", from, to])
+        .current_dir(repo_path)
+        .query_output()
+        .await?;
+
+    if !output.status.success() {
+        let stderr = String::from_utf8_lossy(&output.stderr);
+        bail!("Failed to diff snapshots: {}", stderr.trim());
+    }
+
+    Ok(String::from_utf8_lossy(&output.stdout).to_string())
+}
+
 /// Parse git diff --shortstat output
 /// Example: " 3 files changed, 45 insertions(+), 12 deletions(-)"
 fn parse_diff_stats(output: &str) -> Result<DiffStats> {
@@ -359,3 +634,97 @@ fn parse_diff_stats(output: &str) -> Result<DiffStats> {
 
     Ok(stats)
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn git(repo: &Path, args: &[&str]) {
+        let status = std::process::Command::new("git")
+            .args(args)
+            .current_dir(repo)
+            .stdout(std::process::Stdio::null())
+            .status()
+            .unwrap();
+        assert!(status.success(), "git {:?} failed", args);
+    }
+
+    #[tokio::test]
+    async fn test_snapshot_work_tree() {
+        let repo = std::env::temp_dir().join(format!("amux-git-{}", std::process::id()));
+        std::fs::create_dir_all(&repo).unwrap();
+        git(&repo, &["init", "-q"]);
+        std::fs::write(repo.join(".gitignore"), "target/\n").unwrap();
+        std::fs::write(repo.join("lib.rs"), "fn a() {}\n").unwrap();
+        git(&repo, &["add", "."]);
+        git(
+            &repo,
+            &[
+                "-c",
+                "user.name=t",
+                "-c",
+                "user.email=t@t",
+                "commit",
+                "-qm",
+                "init",
+            ],
+        );
+
+        // An unchanged work tree records as the same tree
+        let clean = snapshot_work_tree(&repo).await.unwrap();
+        assert_eq!(snapshot_work_tree(&repo).await.unwrap(), clean);
+
+        // Untracked files are recorded, ignored ones aren't, and the index
+        // is left alone
+        std::fs::write(repo.join("lib.rs"), "fn b() {}\n").unwrap();
+        std::fs::write(repo.join("new.rs"), "fn c() {}\n").unwrap();
+        std::fs::create_dir_all(repo.join("target")).unwrap();
+        std::fs::write(repo.join("target").join("out"), "binary").unwrap();
+        let changed = snapshot_work_tree(&repo).await.unwrap();
+        assert_ne!(changed, clean);
+        assert_eq!(snapshot_work_tree(&repo).await.unwrap(), changed);
+
+        let diff = diff_snapshots(&repo, &clean, &changed).await.unwrap();
+        let staged = std::process::Command::new("git")
+            .args(["diff", "--cached", "--name-only"])
+            .current_dir(&repo)
+            .output()
+            .unwrap();
+        let _ = std::fs::remove_dir_all(&repo);
+        assert!(diff.contains("+fn b() {}"));
+        assert!(diff.contains("+++ b/new.rs"));
+        assert!(!diff.contains("target/out"));
+        assert!(staged.stdout.is_empty());
+    }
+
+    #[tokio::test]
+    async fn test_get_changed_files() {
+        let repo = std::env::temp_dir().join(format!("amux-changed-{}", std::process::id()));
+        std::fs::create_dir_all(&repo).unwrap();
+        git(&repo, &["init", "-q", "-b", "main"]);
+        std::fs::write(repo.join(".gitignore"), "target/\n").unwrap();
+        std::fs::write(repo.join("lib.rs"), "fn a() {}\n").unwrap();
+        git(&repo, &["add", "."]);
+        git(
+            &repo,
+            &[
+                "-c",
+                "user.name=t",
+                "-c",
+                "user.email=t@t",
+                "commit",
+                "-qm",
+                "init",
+            ],
+        );
+
+        // Modified and untracked files are listed, ignored ones aren't
+        std::fs::write(repo.join("lib.rs"), "fn b() {}\n").unwrap();
+        std::fs::write(repo.join("new.rs"), "fn c() {}\n").unwrap();
+        std::fs::create_dir_all(repo.join("target")).unwrap();
+        std::fs::write(repo.join("target").join("out"), "binary").unwrap();
+        let files = get_changed_files(&repo, "main").await;
+        let _ = std::fs::remove_dir_all(&repo);
+        assert_eq!(files.unwrap(), ["lib.rs", "new.rs"]);
+    }
+}
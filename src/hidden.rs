@@ -0,0 +1,83 @@
+//! Sessions hidden from the session list.
+//!
+//! Hiding a session with `D` is remembered across restarts in
+//! `~/.amux/hidden.json`, keyed by the agent-side session ID, so a resumed
+//! session stays hidden.
+
+use std::collections::BTreeSet;
+use std::path::{Path, PathBuf};
+
+/// Path of the hidden sessions file
+pub fn hidden_path() -> PathBuf {
+    dirs::home_dir()
+        .unwrap_or_else(|| PathBuf::from("."))
+        .join(".amux")
+        .join("hidden.json")
+}
+
+fn read_all(path: &Path) -> BTreeSet<String> {
+    std::fs::read_to_string(path)
+        .ok()
+        .and_then(|text| serde_json::from_str(&text).ok())
+        .unwrap_or_default()
+}
+
+/// Whether an agent session was hidden
+pub fn is_hidden(session_id: &str) -> bool {
+    read_all(&hidden_path()).contains(session_id)
+}
+
+/// Remember or forget that an agent session is hidden
+pub fn set_hidden(session_id: &str, hidden: bool) -> std::io::Result<()> {
+    set_hidden_in(&hidden_path(), session_id, hidden)
+}
+
+fn set_hidden_in(path: &Path, session_id: &str, hidden: bool) -> std::io::Result<()> {
+    let mut all = read_all(path);
+    let changed = if hidden {
+        all.insert(session_id.to_string())
+    } else {
+        all.remove(session_id)
+    };
+    if !changed {
+        return Ok(());
+    }
+    if let Some(dir) = path.parent() {
+        std::fs::create_dir_all(dir)?;
+    }
+    std::fs::write(path, serde_json::to_string_pretty(&all)?)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_hide_and_unhide() {
+        let dir = std::env::temp_dir().join(format!("amux-hidden-{}", std::process::id()));
+        let path = dir.join("hidden.json");
+
+        // Nothing is hidden without a file
+        assert!(!read_all(&path).contains("abc"));
+
+        set_hidden_in(&path, "abc", true).unwrap();
+        set_hidden_in(&path, "def", true).unwrap();
+        assert_eq!(
+            read_all(&path),
+            BTreeSet::from(["abc".to_string(), "def".to_string()])
+        );
+
+        // Hiding twice keeps one entry
+        set_hidden_in(&path, "abc", true).unwrap();
+        assert_eq!(read_all(&path).len(), 2);
+
+        set_hidden_in(&path, "abc", false).unwrap();
+        assert_eq!(read_all(&path), BTreeSet::from(["def".to_string()]));
+
+        // Unhiding a session that isn't hidden is a no-op
+        set_hidden_in(&path, "xyz", false).unwrap();
+        assert_eq!(read_all(&path).len(), 1);
+
+        std::fs::remove_dir_all(&dir).unwrap();
+    }
+}
@@ -0,0 +1,97 @@
+//! HTTP requests for status polls and exports.
+//!
+//! Requests are made with `curl`, which handles HTTPS and proxies, so amux
+//! needs no TLS stack of its own. Every request has a timeout, and the curl
+//! process is killed if the caller stops waiting.
+
+use std::process::Stdio;
+use std::time::Duration;
+
+use tokio::io::AsyncWriteExt;
+
+/// How long a request may take unless the caller says otherwise
+pub const TIMEOUT: Duration = Duration::from_secs(10);
+
+/// Check that `url` is an `http://` or `https://` URL with a host
+pub fn is_http_url(url: &str) -> bool {
+    let url = url.trim();
+    url.strip_prefix("http://")
+        .or_else(|| url.strip_prefix("https://"))
+        .is_some_and(|rest| !rest.is_empty() && !rest.starts_with('/'))
+}
+
+/// Run curl with `args` (and `body` on stdin), returning its stdout
+async fn curl(args: &[&str], body: Option<&str>, timeout: Duration) -> Result<String, String> {
+    let mut child = tokio::process::Command::new("curl")
+        .args(["-fsS", "--max-time", &timeout.as_secs_f64().to_string()])
+        .args(args)
+        .stdin(if body.is_some() {
+            Stdio::piped()
+        } else {
+            Stdio::null()
+        })
+        .stdout(Stdio::piped())
+        .stderr(Stdio::piped())
+        .kill_on_drop(true)
+        .spawn()
+        .map_err(|e| format!("failed to run curl: {}", e))?;
+
+    if let Some(body) = body
+        && let Some(mut stdin) = child.stdin.take()
+    {
+        stdin
+            .write_all(body.as_bytes())
+            .await
+            .map_err(|e| format!("failed to send the request body: {}", e))?;
+    }
+
+    let output = child
+        .wait_with_output()
+        .await
+        .map_err(|e| format!("failed to run curl: {}", e))?;
+    if !output.status.success() {
+        return Err(String::from_utf8_lossy(&output.stderr).trim().to_string());
+    }
+    Ok(String::from_utf8_lossy(&output.stdout).into_owned())
+}
+
+/// GET `url` (following redirects), returning the body
+pub async fn get(url: &str, timeout: Duration) -> Result<String, String> {
+    curl(&["-L", url], None, timeout).await
+}
+
+/// POST a JSON body to `url`; fails unless the response status is 2xx
+pub async fn post_json(url: &str, body: &str, timeout: Duration) -> Result<(), String> {
+    curl(
+        &[
+            "-X",
+            "POST",
+            "-H",
+            "Content-Type: application/json",
+            "--data-binary",
+            "@-",
+            "-o",
+            "/dev/null",
+            url,
+        ],
+        Some(body),
+        timeout,
+    )
+    .await
+    .map(|_| ())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_is_http_url() {
+        assert!(is_http_url("http://localhost:4318"));
+        assert!(is_http_url("https://collector.example.com/v1/traces"));
+        assert!(!is_http_url("https://"));
+        assert!(!is_http_url("http:///v1/traces"));
+        assert!(!is_http_url("localhost:4318"));
+        assert!(!is_http_url("ftp://collector"));
+    }
+}
@@ -0,0 +1,95 @@
+//! amux as a library.
+//!
+//! The `amux` binary (`main.rs`) holds the event loop, key dispatch and the
+//! agent event handling; everything else lives in this crate, so bots and
+//! dashboards can reuse the session logic without forking the TUI. The
+//! supported API is:
+//!
+//! - [`session`]: [`session::Session`] and its output, transcript loading
+//!   ([`session::load_jsonl`]), and conversation statistics
+//!   ([`session::Session::conversation_stats`])
+//! - [`archive`]: listing and searching archived sessions
+//! - [`transcript`]: Markdown rendering of a session
+//! - [`redact`]: secret redaction for anything leaving the machine
+//! - [`git`]: diff stats, changed files and commits of a work tree
+//! - [`acp`]: the Agent Client Protocol client the sessions are driven by
+//! - [`config`]: reading `~/.config/amux/config.toml`
+//!
+//! The hidden modules make up the TUI and change with it; they are public
+//! only because the binary uses them. Modules only the crate itself uses
+//! are private.
+
+pub mod acp;
+pub mod archive;
+pub mod config;
+pub mod git;
+pub mod redact;
+pub mod session;
+pub mod transcript;
+
+#[doc(hidden)]
+pub mod api_status;
+#[doc(hidden)]
+pub mod app;
+#[doc(hidden)]
+pub mod attention;
+#[doc(hidden)]
+pub mod audit;
+#[doc(hidden)]
+pub mod clipboard;
+#[doc(hidden)]
+pub mod completion;
+#[doc(hidden)]
+pub mod digest;
+#[doc(hidden)]
+pub mod doctor;
+#[doc(hidden)]
+pub mod env;
+#[doc(hidden)]
+pub mod events;
+#[doc(hidden)]
+pub mod exclude;
+#[doc(hidden)]
+pub mod focus;
+#[doc(hidden)]
+pub mod hidden;
+#[doc(hidden)]
+pub mod log;
+#[doc(hidden)]
+pub mod notes;
+#[doc(hidden)]
+pub mod notification;
+#[doc(hidden)]
+pub mod permalink;
+#[doc(hidden)]
+pub mod picker;
+#[doc(hidden)]
+pub mod plan_history;
+#[doc(hidden)]
+pub mod pricing;
+#[doc(hidden)]
+pub mod procs;
+#[doc(hidden)]
+pub mod queue;
+#[doc(hidden)]
+pub mod scope;
+#[doc(hidden)]
+pub mod serve;
+#[doc(hidden)]
+pub mod snapshot;
+#[doc(hidden)]
+pub mod tmux;
+#[doc(hidden)]
+pub mod tui;
+#[doc(hidden)]
+pub mod usage;
+#[doc(hidden)]
+pub mod web;
+
+mod attachments;
+mod dataset;
+mod frame;
+mod http;
+mod otlp;
+mod scroll;
+mod telemetry;
@@ -47,6 +47,7 @@ pub fn install_panic_hook() {
         };
 
         log(&format!("[PANIC] {} at {}", msg, location));
+        crate::telemetry::record_crash(&msg, &location);
 
         // Also call the default hook to print to stderr
         default_hook(panic_info);
@@ -1,21 +1,16 @@
-mod acp;
-mod app;
-mod clipboard;
-mod config;
-mod events;
-mod git;
-mod log;
-mod notification;
-mod picker;
-mod scroll;
-mod session;
-mod tui;
+use amux::{
+    acp, api_status, app, archive, attention, audit, clipboard, completion, config, digest, doctor,
+    env, events, exclude, focus, git, hidden, log, notes, notification, permalink, picker,
+    plan_history, procs, queue, scope, serve, session, snapshot, tmux, transcript, tui, usage, web,
+};
 
 use anyhow::Result;
 use crossterm::{
     event::{
-        DisableBracketedPaste, DisableMouseCapture, EnableBracketedPaste, EnableMouseCapture,
-        Event, EventStream, KeyCode, KeyEventKind, KeyModifiers, MouseEventKind,
+        DisableBracketedPaste, DisableFocusChange, DisableMouseCapture, EnableBracketedPaste,
+        EnableFocusChange, EnableMouseCapture, Event, EventStream, KeyCode, KeyModifiers,
+        KeyboardEnhancementFlags, MouseEventKind, PopKeyboardEnhancementFlags,
+        PushKeyboardEnhancementFlags,
     },
     execute,
     terminal::{EnterAlternateScreen, LeaveAlternateScreen, disable_raw_mode, enable_raw_mode},
@@ -25,27 +20,36 @@ use ratatui::prelude::*;
 use std::collections::HashMap;
 use std::io::stdout;
 use std::path::PathBuf;
+use std::sync::atomic::{AtomicBool, Ordering};
 use std::time::Duration;
 use tokio::sync::mpsc;
 
 use acp::{
     AgentConnection, AgentEvent, AskUserResponse, ContentBlock, PermissionOptionId, SessionUpdate,
+    StopReason,
 };
 use app::{
     App, CleanupEntry, FolderEntry, ImageAttachment, InputMode, WorktreeConfig, WorktreeEntry,
 };
 use clipboard::ClipboardContent;
+use config::AlertEvent;
 use events::Action;
 use events::keyboard::{
-    handle_agent_picker_mode, handle_branch_input_mode, handle_bug_report_mode,
-    handle_clear_confirm_mode, handle_folder_picker_mode, handle_help_mode, handle_insert_mode,
-    handle_session_picker_mode, handle_worktree_cleanup_mode,
-    handle_worktree_cleanup_repo_picker_mode, handle_worktree_folder_picker_mode,
-    handle_worktree_picker_mode,
+    handle_agent_picker_mode, handle_audit_log_mode, handle_branch_input_mode,
+    handle_bug_report_mode, handle_clear_confirm_mode, handle_epic_input_mode,
+    handle_folder_picker_mode, handle_help_mode, handle_insert_mode, handle_key_event,
+    handle_kill_idle_confirm_mode, handle_notes_mode, handle_plan_history_mode,
+    handle_process_tree_mode, handle_queue_mode, handle_recent_files_mode,
+    handle_reply_templates_mode, handle_search_mode, handle_session_picker_mode, handle_stats_mode,
+    handle_tagging_mode, handle_verify_output_mode, handle_workspace_diff_mode,
+    handle_worktree_cleanup_mode, handle_worktree_cleanup_repo_picker_mode,
+    handle_worktree_folder_picker_mode, handle_worktree_picker_mode, is_press, normalize_key,
 };
+use git::GitQuery;
 use picker::Picker;
 use session::{
-    AgentType, OutputType, PendingPermission, PendingQuestion, SessionState, check_all_agents,
+    AgentType, OutputType, PendingPermission, PendingQuestion, SessionState, SessionSummary,
+    VerifyStatus, check_all_agents,
 };
 
 /// Internal app events for async operations
@@ -62,6 +66,59 @@ enum AppEvent {
         output: String,
         success: bool,
     },
+    /// A line of output from a running verify command
+    VerifyOutput { session_id: String, line: String },
+    /// A verify command finished (session_id, whether it exited successfully)
+    VerifyCompleted { session_id: String, success: bool },
+    /// A summary command finished (summary text or error message)
+    SummaryCompleted {
+        session_id: String,
+        output_len: usize,
+        result: Result<String, String>,
+    },
+    /// Git state of one session from a refresh started at `generation`
+    GitStatusRefreshed {
+        session_id: String,
+        generation: u64,
+        status: git::WorkTreeStatus,
+    },
+    /// A git refresh of all sessions finished
+    GitRefreshFinished,
+    /// Running totals of a Claude subscription window usage scan
+    UsageScanned {
+        usage: usage::UsageWindows,
+        progress: usage::ScanProgress,
+    },
+    /// An archive search for the search screen finished
+    SearchCompleted {
+        query: String,
+        hits: Vec<archive::SearchHit>,
+    },
+    /// A listing of processes for the process tree (or error message)
+    ProcessesListed(Result<Vec<procs::Process>, String>),
+    /// Agents (by PID) found with a connection to their API open
+    NetworkSampled(std::collections::HashSet<u32>),
+    /// Tokens used by a session and its subagents, for the statistics popup
+    SessionTokensCounted {
+        session_id: String,
+        tokens: usage::SessionTokens,
+    },
+    /// A work tree snapshot of a session was taken
+    WorkspaceSnapshotTaken {
+        session_id: String,
+        snapshot: session::WorkspaceSnapshot,
+    },
+    /// The diff between two work tree snapshots (diff text or error message)
+    WorkspaceDiffLoaded(Result<String, String>),
+    /// An API status poll finished (status or error message)
+    ApiStatusChecked(Result<api_status::ApiStatus, String>),
+    /// Lines appended to a read-only transcript file were read
+    SharedTranscriptRead {
+        session_id: String,
+        result: std::io::Result<serve::Appended>,
+    },
+    /// A signal asked amux to quit (signal name)
+    Shutdown(&'static str),
 }
 
 /// Get the current git branch for a directory
@@ -69,7 +126,7 @@ async fn get_git_branch(cwd: &std::path::Path) -> String {
     match tokio::process::Command::new("git")
         .args(["rev-parse", "--abbrev-ref", "HEAD"])
         .current_dir(cwd)
-        .output()
+        .query_output()
         .await
     {
         Ok(output) if output.status.success() => {
@@ -289,7 +346,7 @@ async fn get_worktree_parent_repo(worktree_path: &std::path::Path) -> Option<std
     let gitdir_output = tokio::process::Command::new("git")
         .args(["rev-parse", "--git-common-dir"])
         .current_dir(worktree_path)
-        .output()
+        .query_output()
         .await;
 
     match gitdir_output {
@@ -308,7 +365,7 @@ async fn get_worktree_merged_status(worktree_path: &std::path::Path) -> bool {
     let branch_output = tokio::process::Command::new("git")
         .args(["rev-parse", "--abbrev-ref", "HEAD"])
         .current_dir(worktree_path)
-        .output()
+        .query_output()
         .await;
 
     let branch = match branch_output {
@@ -322,7 +379,7 @@ async fn get_worktree_merged_status(worktree_path: &std::path::Path) -> bool {
     let gitdir_output = tokio::process::Command::new("git")
         .args(["rev-parse", "--git-common-dir"])
         .current_dir(worktree_path)
-        .output()
+        .query_output()
         .await;
 
     let common_dir = match gitdir_output {
@@ -364,6 +421,8 @@ enum AgentCommand {
         model_id: String,
     },
     CancelPrompt,
+    /// Kill the agent process (waits for it to exit) and stop the command loop
+    Kill,
 }
 
 /// Info for resuming a session
@@ -375,26 +434,445 @@ fn print_help() {
 
 USAGE:
     amux [OPTIONS] [DIRECTORY]
+    amux completion <bash|zsh|fish>
+    amux enqueue <TASK> [--project <PATH>]
+    amux search <QUERY> [--project <TEXT>] [--since <YYYY-MM-DD>]
+    amux open <PERMALINK|amux://focus/<SESSION>|--register>
+    amux config <export [FILE]|import <FILE>>
+    amux digest [--week|--days <N>]
+    amux doctor
+    amux serve --tui
+    amux tmux-status [--install]
+    amux view <FILE|->
 
 ARGS:
     [DIRECTORY]    Start directory for new sessions (default: current directory)
 
+COMMANDS:
+    completion <SHELL>    Print a shell completion script (bash, zsh, fish)
+    enqueue <TASK>        Queue a task for the next idle agent in a project (--project <PATH>, default: .)
+    search <QUERY>        Search archived sessions (--project <TEXT>, --since <YYYY-MM-DD>)
+    open <PERMALINK>      Print a message from an archived session (amux://<session>/<n>)
+    open <FOCUS LINK>     Select a session in the running amux (amux://focus/<session>)
+    open --register       Make amux the handler of amux:// links (freedesktop)
+    config export [FILE]  Write the config, hidden sessions and notes to FILE (default: stdout)
+    config import <FILE>  Install an export (replaced files are kept as .bak)
+    digest                Print a Markdown digest of the last week's agent sessions (--days <N>)
+    doctor                Check agents, git, config, data directories and the terminal
+    serve --tui           Read-only view of this machine's fleet (as an SSH forced command)
+    tmux-status           Print fleet state for the tmux status bar (--install adds it to status-right)
+    view <FILE|->         Open a Claude Code JSONL transcript read-only (- reads stdin)
+
 OPTIONS:
     -w, --worktree-dir <PATH>    Directory for git worktrees
+        --no-color               Disable colors and use ASCII glyphs (also: NO_COLOR)
+        --screen-reader          Linear layout without boxes or animation, for screen readers
     -V, --version                Print version information
     -h, --help                   Print this help message
 "
     );
 }
 
+/// Run `amux digest`: print a Markdown summary of recent agent sessions
+fn run_digest(args: &[String]) {
+    let mut days = 7;
+    let mut i = 0;
+    while i < args.len() {
+        match args[i].as_str() {
+            "--week" => days = 7,
+            "--days" if i + 1 < args.len() => {
+                match args[i + 1].parse::<i64>() {
+                    Ok(n) if n > 0 => days = n,
+                    _ => {
+                        eprintln!("--days needs a positive number of days");
+                        std::process::exit(1);
+                    }
+                }
+                i += 1;
+            }
+            _ => {
+                eprintln!("Usage: amux digest [--week|--days <N>]");
+                std::process::exit(1);
+            }
+        }
+        i += 1;
+    }
+    let config = config::Config::load();
+    let excludes = exclude::Excludes::new(&config.exclude);
+    let pattern = config
+        .digest
+        .ticket_pattern
+        .as_deref()
+        .unwrap_or(digest::DEFAULT_TICKET_PATTERN);
+    let ticket_pattern = match regex::Regex::new(pattern) {
+        Ok(regex) => regex,
+        Err(e) => {
+            eprintln!("Invalid [digest] ticket_pattern {:?}: {}", pattern, e);
+            std::process::exit(1);
+        }
+    };
+    print!(
+        "{}",
+        digest::collect(days, &excludes, ticket_pattern, config.pricing.pricing()).to_markdown()
+    );
+}
+
+/// Run `amux search`: print archived sessions matching a query, best first
+fn run_search(args: &[String]) {
+    let mut filter = archive::SearchFilter::default();
+    let mut terms = vec![];
+    let mut i = 0;
+    while i < args.len() {
+        match args[i].as_str() {
+            "--project" if i + 1 < args.len() => {
+                filter.project = Some(args[i + 1].clone());
+                i += 1;
+            }
+            "--since" if i + 1 < args.len() => {
+                match archive::parse_since(&args[i + 1]) {
+                    Ok(since) => filter.since = Some(since),
+                    Err(e) => {
+                        eprintln!("{}", e);
+                        std::process::exit(1);
+                    }
+                }
+                i += 1;
+            }
+            arg => terms.push(arg.to_string()),
+        }
+        i += 1;
+    }
+
+    let query = terms.join(" ");
+    if query.trim().is_empty() {
+        eprintln!("Usage: amux search <QUERY> [--project <TEXT>] [--since <YYYY-MM-DD>]");
+        std::process::exit(1);
+    }
+
+    let hits = archive::search(&query, &filter);
+    if hits.is_empty() {
+        println!("No archived sessions match \"{}\"", query);
+        return;
+    }
+    for hit in hits {
+        let date = hit
+            .entry
+            .archived_at
+            .get(..10)
+            .unwrap_or(&hit.entry.archived_at);
+        println!(
+            "{}  {} ({}, {})",
+            date,
+            hit.entry.name,
+            hit.entry.cwd.display(),
+            hit.entry.branch
+        );
+        for snippet in &hit.snippets {
+            println!("    {:>5}: {}", snippet.line, snippet.text);
+        }
+        println!(
+            "    {}",
+            archive::archive_dir().join(&hit.entry.file).display()
+        );
+        println!();
+    }
+}
+
+/// Run `amux enqueue`: leave a work order for the next idle agent in a project
+fn run_enqueue(args: &[String]) {
+    let mut project = None;
+    let mut terms = vec![];
+    let mut i = 0;
+    while i < args.len() {
+        match args[i].as_str() {
+            "--project" if i + 1 < args.len() => {
+                project = Some(PathBuf::from(&args[i + 1]));
+                i += 1;
+            }
+            arg => terms.push(arg.to_string()),
+        }
+        i += 1;
+    }
+
+    let text = terms.join(" ");
+    if text.trim().is_empty() {
+        eprintln!("Usage: amux enqueue <TASK> [--project <PATH>]");
+        std::process::exit(1);
+    }
+    let project = project.unwrap_or_else(|| std::env::current_dir().unwrap_or_default());
+    if !project.is_dir() {
+        eprintln!("{} is not a directory", project.display());
+        std::process::exit(1);
+    }
+    if let Err(e) = queue::enqueue(&project, text.trim()) {
+        eprintln!("Failed to enqueue: {}", e);
+        std::process::exit(1);
+    }
+    println!(
+        "Queued for the next idle agent in {}",
+        project.canonicalize().unwrap_or(project).display()
+    );
+}
+
+/// Run `amux config export|import`: move the configuration between machines
+fn run_config(args: &[String]) {
+    let usage = || {
+        eprintln!("Usage: amux config export [FILE] | amux config import <FILE>");
+        std::process::exit(1);
+    };
+    match (args.first().map(String::as_str), args.get(1)) {
+        (Some("export"), file) => {
+            let contents = config::Config::export().unwrap_or_else(|e| {
+                eprintln!("Failed to read config: {}", e);
+                std::process::exit(1);
+            });
+            match file {
+                Some(file) => {
+                    if let Err(e) = std::fs::write(file, contents) {
+                        eprintln!("Failed to write {}: {}", file, e);
+                        std::process::exit(1);
+                    }
+                    println!(
+                        "Exported {} and session state to {}",
+                        config::Config::config_path().display(),
+                        file
+                    );
+                }
+                None => print!("{}", contents),
+            }
+        }
+        (Some("import"), Some(file)) => {
+            let result = std::fs::read_to_string(file)
+                .map_err(anyhow::Error::from)
+                .and_then(|contents| config::Config::import(&contents));
+            match result {
+                Ok(written) => {
+                    println!("Imported {}", file);
+                    for (path, backup) in written {
+                        match backup {
+                            Some(backup) => println!(
+                                "  {} (previous one saved as {})",
+                                path.display(),
+                                backup.display()
+                            ),
+                            None => println!("  {}", path.display()),
+                        }
+                    }
+                }
+                Err(e) => {
+                    eprintln!("Failed to import {}: {}", file, e);
+                    std::process::exit(1);
+                }
+            }
+        }
+        _ => usage(),
+    }
+}
+
+/// Run `amux open`: print the message a permalink points to from the archive
+fn run_open(args: &[String]) {
+    if args.first().map(String::as_str) == Some("--register") {
+        if let Err(e) = focus::register() {
+            eprintln!("Failed to register the amux:// handler: {}", e);
+            std::process::exit(1);
+        }
+        return;
+    }
+    if let Some(session_name) = args.first().and_then(|arg| focus::parse(arg)) {
+        if let Err(e) = focus::request(&session_name, None) {
+            eprintln!("Failed to focus {}: {}", session_name, e);
+            std::process::exit(1);
+        }
+        return;
+    }
+
+    let Some(link) = args
+        .first()
+        .and_then(|arg| permalink::Permalink::parse(arg))
+    else {
+        eprintln!(
+            "Usage: amux open <amux://<session-id>/<message>|amux://focus/<session>|--register>"
+        );
+        std::process::exit(1);
+    };
+
+    // The most recent archive of the session has every message older ones have
+    let Some(entry) = archive::entries()
+        .into_iter()
+        .rev()
+        .find(|entry| entry.session_id.as_deref() == Some(link.session_id.as_str()))
+    else {
+        eprintln!(
+            "Session {} is not archived (archive it with A in amux first)",
+            link.session_id
+        );
+        std::process::exit(1);
+    };
+
+    let message = archive::read_transcript(&entry)
+        .ok()
+        .and_then(|text| permalink::find_in_transcript(&text, link.message));
+    let Some(message) = message else {
+        eprintln!("Message {} not found in {}", link.message, entry.name);
+        std::process::exit(1);
+    };
+    println!(
+        "{} ({}, {}) message {}\n",
+        entry.name,
+        entry.cwd.display(),
+        entry.branch,
+        link.message
+    );
+    println!("{}", message);
+}
+
+/// Transcript `amux view` opens
+enum ViewTranscript {
+    /// Read from stdin before the TUI starts (session name, text)
+    Stdin(String),
+    /// A file, read in the background once the TUI is up
+    File(serve::SharedTranscript),
+}
+
+/// Find the transcript for `amux view`: a file, or stdin for "-"
+fn read_view_transcript(arg: Option<&String>) -> ViewTranscript {
+    let Some(arg) = arg else {
+        eprintln!("Usage: amux view <FILE|->");
+        std::process::exit(1);
+    };
+    if arg == "-" {
+        let mut text = String::new();
+        if let Err(e) = std::io::Read::read_to_string(&mut std::io::stdin(), &mut text) {
+            eprintln!("Failed to read stdin: {}", e);
+            std::process::exit(1);
+        }
+        return ViewTranscript::Stdin(text);
+    }
+    // Only check the file is there: a large one is read with the TUI up
+    let path = PathBuf::from(arg);
+    if let Err(e) = std::fs::File::open(&path) {
+        eprintln!("Failed to read {}: {}", arg, e);
+        std::process::exit(1);
+    }
+    let name = path
+        .file_stem()
+        .and_then(|stem| stem.to_str())
+        .unwrap_or(arg)
+        .to_string();
+    ViewTranscript::File(serve::SharedTranscript {
+        session_id: String::new(),
+        name,
+        path,
+    })
+}
+
 #[tokio::main]
 async fn main() -> Result<()> {
     // Parse CLI arguments first (before initializing terminal)
     let args: Vec<String> = std::env::args().collect();
     let mut start_dir = std::env::current_dir().unwrap_or_default();
     let mut worktree_dir_override: Option<std::path::PathBuf> = None;
+    // https://no-color.org: any non-empty NO_COLOR value disables color
+    let mut no_color = std::env::var("NO_COLOR").is_ok_and(|v| !v.is_empty());
+    let mut screen_reader = false;
+
+    // Subcommands (handled before option parsing)
+    if args.get(1).map(String::as_str) == Some("completion") {
+        let shell = args.get(2).map(String::as_str).unwrap_or_default();
+        match completion::script(shell) {
+            Some(script) => {
+                print!("{script}");
+                return Ok(());
+            }
+            None => {
+                eprintln!("Usage: amux completion <bash|zsh|fish>");
+                std::process::exit(1);
+            }
+        }
+    }
+
+    // Dynamic values for the completion scripts (not in the usage text)
+    if args.get(1).map(String::as_str) == Some("__complete") {
+        let kind = args.get(2).map(String::as_str).unwrap_or_default();
+        let Some(values) = completion::candidates(kind) else {
+            std::process::exit(1);
+        };
+        for value in values {
+            println!("{}", value);
+        }
+        return Ok(());
+    }
+
+    if args.get(1).map(String::as_str) == Some("search") {
+        run_search(&args[2..]);
+        return Ok(());
+    }
 
-    let mut i = 1;
+    if args.get(1).map(String::as_str) == Some("enqueue") {
+        run_enqueue(&args[2..]);
+        return Ok(());
+    }
+
+    if args.get(1).map(String::as_str) == Some("open") {
+        run_open(&args[2..]);
+        return Ok(());
+    }
+
+    if args.get(1).map(String::as_str) == Some("config") {
+        run_config(&args[2..]);
+        return Ok(());
+    }
+
+    if args.get(1).map(String::as_str) == Some("digest") {
+        run_digest(&args[2..]);
+        return Ok(());
+    }
+
+    if args.get(1).map(String::as_str) == Some("tmux-status") {
+        if args.get(2).map(String::as_str) == Some("--install") {
+            if let Err(e) = tmux::install(config::Config::load().snapshot) {
+                eprintln!("Failed to install the tmux status: {}", e);
+                std::process::exit(1);
+            }
+        } else {
+            tmux::print_status();
+        }
+        return Ok(());
+    }
+
+    if args.get(1).map(String::as_str) == Some("doctor") {
+        if !doctor::print_report(&doctor::run_checks()) {
+            std::process::exit(1);
+        }
+        return Ok(());
+    }
+
+    // `amux view <FILE|->` opens a JSONL transcript in the viewer instead of
+    // starting with the folder picker
+    let mut view_transcript: Option<ViewTranscript> = None;
+    if args.get(1).map(String::as_str) == Some("view") {
+        view_transcript = Some(read_view_transcript(args.get(2)));
+    }
+
+    // `amux serve --tui` shows the fleet of the amux running on this machine
+    // read-only, for teammates connecting over SSH
+    let serve_tui = args.get(1).map(String::as_str) == Some("serve");
+    if serve_tui {
+        if args.get(2).map(String::as_str) != Some("--tui") {
+            eprintln!("Usage: amux serve --tui");
+            std::process::exit(1);
+        }
+        if snapshot::read().is_none() {
+            eprintln!("No fleet to show: amux isn't running with `snapshot = true` in its config");
+            std::process::exit(1);
+        }
+    }
+
+    let mut i = if view_transcript.is_some() || serve_tui {
+        3
+    } else {
+        1
+    };
     while i < args.len() {
         match args[i].as_str() {
             "--version" | "-V" => {
@@ -405,6 +883,12 @@ async fn main() -> Result<()> {
                 print_help();
                 return Ok(());
             }
+            "--no-color" => {
+                no_color = true;
+            }
+            "--screen-reader" => {
+                screen_reader = true;
+            }
             "--worktree-dir" | "-w" => {
                 if i + 1 < args.len() {
                     let path = std::path::PathBuf::from(&args[i + 1]);
@@ -422,7 +906,7 @@ async fn main() -> Result<()> {
                 }
             }
             arg if !arg.starts_with('-') => {
-                let path = std::path::PathBuf::from(arg);
+                let path = PathBuf::from(arg);
                 if path.is_dir() {
                     start_dir = path.canonicalize().unwrap_or(path);
                 } else {
@@ -450,6 +934,7 @@ async fn main() -> Result<()> {
 
     // Load config
     let config = config::Config::load();
+    git::set_query_limits(config.git.query_limits());
 
     // Load worktree config with precedence: CLI > env var > config file > default
     let worktree_config =
@@ -462,36 +947,134 @@ async fn main() -> Result<()> {
         stdout,
         EnterAlternateScreen,
         EnableBracketedPaste,
-        EnableMouseCapture
+        EnableMouseCapture,
+        EnableFocusChange
     )?;
+    // Unambiguous Esc and Ctrl combinations where the terminal speaks the kitty
+    // keyboard protocol
+    if matches!(
+        crossterm::terminal::supports_keyboard_enhancement(),
+        Ok(true)
+    ) {
+        execute!(
+            stdout,
+            PushKeyboardEnhancementFlags(KeyboardEnhancementFlags::DISAMBIGUATE_ESCAPE_CODES)
+        )?;
+        KEYBOARD_ENHANCED.store(true, Ordering::Relaxed);
+    }
     let backend = CrosstermBackend::new(stdout);
     let mut terminal = Terminal::new(backend)?;
+    install_terminal_restore_hook();
 
     // Create app state
-    let notification_config = config.notifications.into();
-    let mut app = App::new(
-        start_dir,
-        worktree_config,
-        config.mcp_servers,
-        notification_config,
-    );
+    let mut app = App::new(start_dir, worktree_config);
     app.log_path = log_path;
     app.session_id = session_id;
+    app.apply_config(config);
+    app.plain_mode = no_color || screen_reader;
+    app.screen_reader = screen_reader;
+    match view_transcript {
+        Some(ViewTranscript::Stdin(text)) => {
+            app.open_transcript("stdin".to_string(), &text);
+        }
+        Some(ViewTranscript::File(transcript)) => app.open_transcript_file(transcript),
+        None => {}
+    }
+    if serve_tui {
+        app.view_only = true;
+        app.refresh_shared();
+    }
 
     // Run the app
     let result = run_app(&mut terminal, &mut app).await;
 
+    // Output moved to disk for the memory budget only lives for the run
+    session::remove_spill_dir();
+
     // Restore terminal
+    restore_terminal()?;
+    terminal.show_cursor()?;
+
+    // Report the run when telemetry is enabled
+    if let Some(telemetry) = &app.telemetry {
+        telemetry.run_finished(app.sessions.sessions().len()).await;
+    }
+
+    result
+}
+
+/// Whether the kitty keyboard protocol was enabled, to turn it off on exit
+static KEYBOARD_ENHANCED: AtomicBool = AtomicBool::new(false);
+
+/// Leave raw mode and the alternate screen, undoing the terminal setup
+fn restore_terminal() -> std::io::Result<()> {
+    if KEYBOARD_ENHANCED.swap(false, Ordering::Relaxed) {
+        execute!(stdout(), PopKeyboardEnhancementFlags)?;
+    }
     disable_raw_mode()?;
     execute!(
-        terminal.backend_mut(),
+        stdout(),
+        DisableFocusChange,
         DisableMouseCapture,
         DisableBracketedPaste,
-        LeaveAlternateScreen
-    )?;
-    terminal.show_cursor()?;
+        LeaveAlternateScreen,
+        crossterm::cursor::Show
+    )
+}
 
-    result
+/// Restore the terminal before a panic message is printed, so it's readable
+/// and the shell isn't left in raw mode, and delete the run's spill files.
+/// Only panics on the main thread end the app; a panicking background task
+/// leaves the TUI running.
+fn install_terminal_restore_hook() {
+    let previous_hook = std::panic::take_hook();
+    std::panic::set_hook(Box::new(move |panic_info| {
+        if std::thread::current().name() == Some("main") {
+            let _ = restore_terminal();
+            session::remove_spill_dir();
+        }
+        previous_hook(panic_info);
+    }));
+}
+
+/// Forward SIGTERM and SIGHUP (terminal closed) as a shutdown event, so
+/// agents are stopped the same way as when quitting with `q`
+fn forward_shutdown_signals(tx: mpsc::Sender<AppEvent>) {
+    #[cfg(unix)]
+    tokio::spawn(async move {
+        use tokio::signal::unix::{SignalKind, signal};
+        let (Ok(mut term), Ok(mut hangup)) = (
+            signal(SignalKind::terminate()),
+            signal(SignalKind::hangup()),
+        ) else {
+            return;
+        };
+        let name = tokio::select! {
+            _ = term.recv() => "SIGTERM",
+            _ = hangup.recv() => "SIGHUP",
+        };
+        let _ = tx.send(AppEvent::Shutdown(name)).await;
+    });
+    #[cfg(not(unix))]
+    drop(tx);
+}
+
+/// Kill every agent process and give their command loops a moment to exit,
+/// so no agent outlives amux. Agents that don't respond in time are still
+/// killed when their connection is dropped.
+async fn shutdown_agents(agent_commands: &mut HashMap<String, mpsc::Sender<AgentCommand>>) {
+    let senders: Vec<_> = agent_commands.drain().map(|(_, tx)| tx).collect();
+    for tx in &senders {
+        // Don't wait on a command loop that is busy with a prompt
+        let _ = tx.try_send(AgentCommand::Kill);
+    }
+    let all_closed = futures::future::join_all(senders.iter().map(|tx| tx.closed()));
+    if tokio::time::timeout(Duration::from_secs(2), all_closed)
+        .await
+        .is_err()
+    {
+        log::log("Timed out waiting for agents to exit");
+    }
 }
 
 async fn run_app<B: Backend>(terminal: &mut Terminal<B>, app: &mut App) -> Result<()>
@@ -510,15 +1093,22 @@ where
     // Event stream for keyboard
     let mut event_stream = EventStream::new();
 
-    // Open folder picker on startup
-    let start = app.start_dir.clone();
-    app.open_folder_picker(start.clone());
-    let entries = scan_folder_entries(&start).await;
-    app.set_folder_entries(entries);
+    // Open folder picker on startup, unless a transcript was opened with `amux view`
+    // (or this is the read-only view of another amux)
+    if app.sessions.sessions().is_empty() && !app.view_only {
+        let start = app.start_dir.clone();
+        app.open_folder_picker(start.clone());
+        let entries = scan_folder_entries(&start).await;
+        app.set_folder_entries(entries);
+    }
 
-    loop {
-        // Render
-        terminal.draw(|frame| tui::ui::render(frame, app))?;
+    forward_shutdown_signals(app_event_tx.clone());
+
+    'app: loop {
+        // Render, coalescing streamed updates to the configured frame rate
+        if app.frames.should_draw(std::time::Instant::now()) {
+            terminal.draw(|frame| tui::ui::render(frame, app))?;
+        }
 
         // Handle events with timeout for responsiveness
         // Use biased select to prioritize keyboard input over agent events
@@ -526,9 +1116,19 @@ where
             biased;
             // Terminal events (keyboard, paste, etc.)
             maybe_event = event_stream.next() => {
+                app.frames.request_now();
                 if let Some(Ok(event)) = maybe_event {
+                    // Pause background refreshes while the terminal window is unfocused
+                    if matches!(event, Event::FocusGained | Event::FocusLost) {
+                        app.set_focused(matches!(event, Event::FocusGained));
+                        continue;
+                    }
+
                     // Handle paste events (from drag & drop or Cmd+V in some terminals)
                     if let Event::Paste(text) = &event {
+                        if app.view_only {
+                            continue;
+                        }
                         // Auto-switch to insert mode if in normal mode with a session selected
                         if app.input_mode == InputMode::Normal && app.sessions.selected_session().is_some() {
                             app.enter_insert_mode();
@@ -586,15 +1186,19 @@ where
                             }
                             _ => Action::None,
                         };
+                        if app.view_only && !action.is_view_only() {
+                            continue;
+                        }
 
                         // Handle the action
                         match action {
+                            // Wheel scrolling accelerates on fast, continuous flicks
                             Action::ScrollUp(n) => {
-                                app.scroll_up(n);
+                                app.scroll_lines(-(n as i32));
                                 continue;
                             }
                             Action::ScrollDown(n) => {
-                                app.scroll_down(n);
+                                app.scroll_lines(n as i32);
                                 continue;
                             }
                             Action::EnterInsertMode => {
@@ -662,6 +1266,9 @@ where
                                     if let Some(cmd_tx) = agent_commands.get(&session_id) {
                                         let _ = cmd_tx.send(AgentCommand::CancelPrompt).await;
                                     }
+                                    audit::record(&audit::AuditEntry::for_session(
+                                        "cancel", session, false,
+                                    ));
                                     session.state = SessionState::Idle;
                                     session.add_output(
                                         "Cancelled".to_string(),
@@ -679,7 +1286,12 @@ where
 
                     // Handle key events
                     if let Event::Key(key) = event
-                    && key.kind == KeyEventKind::Press {
+                    && is_press(&key) {
+                        let key = normalize_key(key);
+                        // The read-only view only takes keys that change what's shown
+                        if app.view_only && !handle_key_event(app, key).is_view_only() {
+                            continue;
+                        }
                         match app.input_mode {
                             InputMode::Normal => {
                                 // Check if there's a pending permission request
@@ -870,136 +1482,14 @@ where
                                         _ => {}
                                     }
                                 } else {
-                                    // Normal mode keys
-                                    match key.code {
-                                        KeyCode::Char('q') => return Ok(()),
-                                        KeyCode::Esc => {
-                                            // Cancel running prompt
-                                            if let Some(session) = app.sessions.selected_session_mut()
-                                                && session.state == SessionState::Prompting
-                                            {
-                                                let session_id = session.id.clone();
-                                                if let Some(cmd_tx) = agent_commands.get(&session_id) {
-                                                    let _ = cmd_tx.send(AgentCommand::CancelPrompt).await;
-                                                }
-                                                session.state = SessionState::Idle;
-                                                session.add_output(
-                                                    "Cancelled".to_string(),
-                                                    OutputType::SystemMessage,
-                                                );
-                                            }
-                                        }
-                                        KeyCode::Char('?') => {
-                                            app.open_help();
-                                        }
-                                        KeyCode::Char('B') => {
-                                            app.open_bug_report();
-                                        }
-
-                                        KeyCode::Tab => {
-                                            // Cycle permission mode for selected session
-                                            if let Some(session) = app.sessions.selected_session_mut() {
-                                                session.cycle_permission_mode();
-                                            }
-                                        }
-                                        KeyCode::Char('m') => {
-                                            // Cycle model for selected session
-                                            if let Some(session) = app.sessions.selected_session_mut()
-                                                && let Some(model_id) = session.cycle_model() {
-                                                    let local_id = session.id.clone();
-                                                    let acp_session_id = session.acp_session_id.clone().unwrap_or_default();
-                                                    if let Some(cmd_tx) = agent_commands.get(&local_id) {
-                                                        let _ = cmd_tx.send(AgentCommand::SetModel {
-                                                            session_id: acp_session_id,
-                                                            model_id,
-                                                        }).await;
-                                                    }
-                                                }
-                                        }
-                                        // Number keys to select session directly (using display order)
-                                        KeyCode::Char(c @ '1'..='9') => {
-                                            let display_idx = (c as usize) - ('1' as usize);
-                                            // Convert display index to internal index
-                                            if let Some(internal_idx) = app.internal_index_for_display(display_idx) {
-                                                app.select_session(internal_idx);
-                                            }
-                                        }
-                                        KeyCode::Char('j') | KeyCode::Down => app.next_session(),
-                                        KeyCode::Char('k') | KeyCode::Up => app.prev_session(),
-                                        KeyCode::Char('i') | KeyCode::Enter => {
-                                            if app.sessions.selected_session().is_some() {
-                                                app.enter_insert_mode();
-                                            }
-                                        }
-                                        KeyCode::Char('n') => {
-                                            // Open folder picker starting from configured directory
-                                            let start = app.start_dir.clone();
-                                            app.open_folder_picker(start.clone());
-                                            let entries = scan_folder_entries(&start).await;
-                                            app.set_folder_entries(entries);
-                                        }
-                                        KeyCode::Char('w') => {
-                                            // Open worktree picker (existing worktrees or create new)
-                                            // Don't fetch here - only fetch when opening cleanup view
-                                            let worktree_dir = app.worktree_config.worktree_dir.clone();
-                                            let entries = scan_worktrees(&worktree_dir, false).await;
-                                            app.open_worktree_picker(entries);
-                                        }
-                                        KeyCode::Char('x') => {
-                                            if let Some(session) = app.sessions.selected_session() {
-                                                let session_id = session.id.clone();
-                                                agent_commands.remove(&session_id);
-                                            }
-                                            app.kill_selected_session();
-                                        }
-                                        KeyCode::Char('d') if !key.modifiers.contains(KeyModifiers::CONTROL) => {
-                                            // Duplicate current session (same folder, same agent)
-                                            if let Some(session) = app.sessions.selected_session() {
-                                                let agent_type = session.agent_type;
-                                                let cwd = session.cwd.clone();
-                                                let is_worktree = session.is_worktree;
-                                                spawn_agent_in_dir(app, &agent_tx, &mut agent_commands, agent_type, cwd, is_worktree).await?;
-                                            }
-                                        }
-                                        KeyCode::Char('c') => {
-                                            // Clear session (with confirmation)
-                                            if app.sessions.selected_session().is_some() {
-                                                app.open_clear_confirm();
-                                            }
-                                        }
-                                        KeyCode::Char('v') => {
-                                            // Cycle through sort modes
-                                            app.cycle_sort_mode();
-                                        }
-                                        KeyCode::Char('t') => {
-                                            // Toggle debug tool JSON display
-                                            app.toggle_debug_tool_json();
-                                        }
-
-                                        // Scroll output - vim style
-                                        KeyCode::Char('u') if key.modifiers.contains(KeyModifiers::CONTROL) => {
-                                            // Ctrl+u: half page up
-                                            let half_page = app.viewport_height / 2;
-                                            app.scroll_up(half_page);
-                                        }
-                                        KeyCode::Char('d') if key.modifiers.contains(KeyModifiers::CONTROL) => {
-                                            // Ctrl+d: half page down
-                                            let half_page = app.viewport_height / 2;
-                                            app.scroll_down(half_page);
-                                        }
-                                        KeyCode::Char('b') if key.modifiers.contains(KeyModifiers::CONTROL) => {
-                                            // Ctrl+b: full page up (back)
-                                            app.scroll_up(app.viewport_height);
-                                        }
-                                        KeyCode::Char('f') if key.modifiers.contains(KeyModifiers::CONTROL) => {
-                                            // Ctrl+f: full page down (forward)
-                                            app.scroll_down(app.viewport_height);
-                                        }
-                                        KeyCode::PageUp => app.scroll_up(app.viewport_height),
-                                        KeyCode::PageDown => app.scroll_down(app.viewport_height),
-                                        KeyCode::Char('g') => app.scroll_to_top(),
-                                        KeyCode::Char('G') => app.scroll_to_bottom(),
-                                        _ => {}
+                                    // Normal mode keys go through the same mapping as every
+                                    // other mode, so keyboard.rs is the one place to bind them
+                                    let action = handle_key_event(app, key);
+                                    if matches!(action, Action::Quit) {
+                                        break 'app;
+                                    }
+                                    if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                        handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
                                     }
                                 }
                             }
@@ -1051,6 +1541,30 @@ where
                                     handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
                                 }
                             }
+                            InputMode::KillIdleConfirm => {
+                                let action = handle_kill_idle_confirm_mode(key);
+                                if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                    handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
+                                }
+                            }
+                            InputMode::RecentFiles => {
+                                let action = handle_recent_files_mode(key);
+                                if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                    handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
+                                }
+                            }
+                            InputMode::ProcessTree => {
+                                let action = handle_process_tree_mode(key);
+                                if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                    handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
+                                }
+                            }
+                            InputMode::WorkspaceDiff => {
+                                let action = handle_workspace_diff_mode(key);
+                                if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                    handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
+                                }
+                            }
                             InputMode::BugReport => {
                                 let action = handle_bug_report_mode(key);
                                 if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
@@ -1063,6 +1577,66 @@ where
                                     handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
                                 }
                             }
+                            InputMode::Stats => {
+                                let action = handle_stats_mode(key);
+                                if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                    handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
+                                }
+                            }
+                            InputMode::ReplyTemplates => {
+                                let action = handle_reply_templates_mode(key);
+                                if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                    handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
+                                }
+                            }
+                            InputMode::EpicInput => {
+                                let action = handle_epic_input_mode(key);
+                                if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                    handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
+                                }
+                            }
+                            InputMode::Notes => {
+                                let action = handle_notes_mode(key);
+                                if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                    handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
+                                }
+                            }
+                            InputMode::AuditLog => {
+                                let action = handle_audit_log_mode(key);
+                                if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                    handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
+                                }
+                            }
+                            InputMode::PlanHistory => {
+                                let action = handle_plan_history_mode(key);
+                                if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                    handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
+                                }
+                            }
+                            InputMode::Queue => {
+                                let action = handle_queue_mode(app, key);
+                                if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                    handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
+                                }
+                            }
+                            InputMode::Search => {
+                                let action = handle_search_mode(app, key);
+                                if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                    handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
+                                }
+                            }
+                            InputMode::VerifyOutput => {
+                                let action = handle_verify_output_mode(key);
+                                if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                    handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
+                                }
+                            }
+                            InputMode::Tagging => {
+                                let action = handle_tagging_mode(key);
+                                if let Some(async_action) = process_action(app, action, &agent_commands, &app_event_tx).await {
+                                    handle_async_in_loop(app, async_action, &agent_tx, &mut agent_commands, &app_event_tx).await?;
+                                }
+                            }
                             InputMode::Insert => {
                                 // Use the new Action-based system
                                 let action = handle_insert_mode(app, key);
@@ -1081,6 +1655,10 @@ where
                 // one event per loop iteration to avoid losing events
                 if app.input_mode == InputMode::Insert {
                     while let Some(Some(Ok(event))) = event_stream.next().now_or_never() {
+                        if matches!(event, Event::FocusGained | Event::FocusLost) {
+                            app.set_focused(matches!(event, Event::FocusGained));
+                            continue;
+                        }
                         // Handle paste events
                         if let Event::Paste(text) = &event {
                             if let Some(path) = clipboard::try_parse_image_path(text) {
@@ -1097,7 +1675,8 @@ where
 
                         // Handle key events in drain loop
                         if let Event::Key(key) = event
-                        && key.kind == KeyEventKind::Press {
+                        && is_press(&key) {
+                            let key = normalize_key(key);
                             match key.code {
                                 KeyCode::Char(c) => {
                                     if key.modifiers.is_empty() || key.modifiers == KeyModifiers::SHIFT {
@@ -1118,7 +1697,17 @@ where
 
             // Agent events
             Some((session_id, event)) = agent_rx.recv() => {
+                app.frames.request();
+                // Agent is ready for the next prompt after these events
+                let dispatch_queued = matches!(
+                    event,
+                    AgentEvent::PromptComplete { .. } | AgentEvent::SessionCreated { .. }
+                );
+                let is_error = matches!(event, AgentEvent::Error { .. });
                 let result = handle_agent_event(app, &session_id, event);
+                if is_error {
+                    app.alert(AlertEvent::Error);
+                }
 
                 // Process the result
                 match result {
@@ -1132,6 +1721,7 @@ where
                         }
                     }
                     EventResult::Notification(notification) => {
+                        app.alert(notification.alert_event());
                         process_notification(&mut app.notifications, notification);
                     }
                     EventResult::AutoAcceptWithNotification { request_id, option_id, notification } => {
@@ -1144,10 +1734,17 @@ where
                         process_notification(&mut app.notifications, notification);
                     }
                 }
+
+                if dispatch_queued {
+                    // Snapshot the work tree at session start and after each turn
+                    snapshot_workspace(app, &session_id, &app_event_tx);
+                    dispatch_queued_prompt(app, &agent_commands, &session_id).await;
+                }
             }
 
             // Internal app events (worktree deletion, etc.)
             Some(event) = app_event_rx.recv() => {
+                app.frames.request();
                 match event {
                     AppEvent::WorktreeDeleted(path) => {
                         // Kill any sessions running in the deleted worktree
@@ -1205,38 +1802,264 @@ where
                             session.add_output(String::new(), OutputType::Text);
                             // Scroll to bottom to show the output
                             session.scroll_to_bottom();
+                            // The command may have changed files or made commits
+                            session.git_dirty = true;
+                        }
+                    }
+                    AppEvent::VerifyOutput { session_id, line } => {
+                        if let Some(session) = app.sessions.get_by_id_mut(&session_id) {
+                            session.add_verify_output(line);
+                        }
+                    }
+                    AppEvent::VerifyCompleted { session_id, success } => {
+                        if let Some(session) = app.sessions.get_by_id_mut(&session_id) {
+                            let (status, result) = if success {
+                                (VerifyStatus::Passed, "passed")
+                            } else {
+                                (VerifyStatus::Failed, "failed")
+                            };
+                            session.verify_status = Some(status);
+                            session.add_verify_output(format!("Verify {}", result));
+                            let message = format!("Verify {} for {}", result, session.name);
+                            log::log_event(&message);
+                            // The popup shows the result already
+                            let showing = app.input_mode == InputMode::VerifyOutput
+                                && app.sessions.selected_session().is_some_and(|s| s.id == session_id);
+                            if !showing {
+                                app.show_toast(format!("{} (V shows the output)", message), !success);
+                            }
+                        }
+                    }
+                    AppEvent::SummaryCompleted { session_id, output_len, result } => {
+                        if let Some(session) = app.sessions.get_by_id_mut(&session_id) {
+                            session.summarizing = false;
+                            match result {
+                                Ok(text) => {
+                                    session.summary = Some(SessionSummary {
+                                        text,
+                                        output_len,
+                                        at: chrono::Local::now(),
+                                    });
+                                }
+                                Err(e) => {
+                                    session.add_output(format!("Summary failed: {}", e), OutputType::Error);
+                                }
+                            }
+                        }
+                    }
+                    AppEvent::GitStatusRefreshed { session_id, generation, status } => {
+                        let diff_warnings = app.diff_warnings.clone();
+                        if let Some(session) = app.sessions.get_by_id_mut(&session_id)
+                            && generation > session.git_generation
+                        {
+                            session.git_generation = generation;
+                            if let Some(stats) = status.diff_stats {
+                                session.diff_severity = diff_warnings.severity(&stats);
+                                session.diff_stats = Some(stats);
+                            }
+                            if let Some(changed_files) = status.changed_files {
+                                session.changed_files = changed_files;
+                            }
+                            if let Some(commits) = status.commits {
+                                session.session_commits = commits;
+                            }
+                        }
+                        // Warn about agents editing the same files in different worktrees
+                        app.sessions.update_file_conflicts();
+                    }
+                    AppEvent::GitRefreshFinished => {
+                        app.finish_git_refresh();
+                    }
+                    AppEvent::UsageScanned { usage, progress } => {
+                        app.update_usage_refresh(usage, progress);
+                    }
+                    AppEvent::SearchCompleted { query, hits } => {
+                        app.update_search(&query, hits);
+                    }
+                    AppEvent::ProcessesListed(result) => {
+                        app.update_processes(result);
+                    }
+                    AppEvent::NetworkSampled(connected) => {
+                        app.update_network_sample(connected);
+                    }
+                    AppEvent::SessionTokensCounted { session_id, tokens } => {
+                        app.update_stats_tokens(&session_id, tokens);
+                    }
+                    AppEvent::WorkspaceSnapshotTaken { session_id, snapshot } => {
+                        if let Some(session) = app.sessions.get_by_id_mut(&session_id) {
+                            session.add_workspace_snapshot(snapshot);
                         }
                     }
+                    AppEvent::WorkspaceDiffLoaded(result) => {
+                        if let Some(state) = &mut app.workspace_diff {
+                            state.diff = Some(result.map(|diff| diff.lines().map(str::to_string).collect()));
+                        }
+                    }
+                    AppEvent::ApiStatusChecked(result) => {
+                        app.update_api_status(result);
+                    }
+                    AppEvent::SharedTranscriptRead { session_id, result } => {
+                        app.update_shared(&session_id, result);
+                    }
+                    AppEvent::Shutdown(signal) => {
+                        log::log(&format!("Received {}, shutting down", signal));
+                        break 'app;
+                    }
                 }
             }
 
             // Timeout to keep UI responsive and tick spinner (16ms = ~60 FPS)
             _ = tokio::time::sleep(Duration::from_millis(16)) => {
+                app.frames.request();
                 app.tick_spinner();
 
-                // Refresh git diff stats periodically (every 5 seconds)
+                // Apply edits to the config file without a restart
+                app.check_config_reload();
+
+                // Share fleet state with statuslines and prompts
+                app.write_snapshot_if_due();
+
+                // Drop old output of sessions not viewed lately once over the memory budget
+                app.enforce_memory_budget();
+
+                // Pick up per-project thresholds for new session directories
+                app.load_project_thresholds();
+
+                // Flag Bash commands running for minutes without a result
+                app.check_long_commands();
+
+                // Select the session an amux://focus link asked for
+                app.check_focus_request();
+
+                // Track the most sessions open at once for telemetry
+                app.observe_session_count();
+
+                // Follow the shared fleet in the read-only view
+                app.refresh_shared();
+
+                // Dispatch prompts the usage quota held back once it allows, and
+                // work orders from `amux enqueue` to idle agents in their project
+                let check_orders = app.check_work_queue();
+                if app.dispatch_held_until().is_none() {
+                    let held: Vec<String> = app.sessions.sessions()
+                        .iter()
+                        .filter(|s| s.state == SessionState::Idle
+                            && (!s.queued_prompts.is_empty()
+                                || (check_orders && app.work_order_for(s).is_some())))
+                        .map(|s| s.id.clone())
+                        .collect();
+                    for session_id in held {
+                        dispatch_queued_prompt(app, &agent_commands, &session_id).await;
+                    }
+                }
+
+                // Refresh git diff stats periodically (every 5 seconds) in the
+                // background, so slow git commands don't stall input
                 if app.should_refresh_git_stats() {
-                    app.mark_git_refreshed();
+                    let generation = app.start_git_refresh();
 
                     // Collect sessions to refresh
                     let sessions_to_refresh: Vec<_> = app.sessions.sessions()
                         .iter()
-                        .map(|s| (s.id.clone(), s.cwd.clone(), s.git_branch.clone()))
+                        .filter(|s| !s.git_branch.is_empty())
+                        .map(|s| (s.id.clone(), s.cwd.clone(), s.git_branch.clone(), s.created_at))
                         .collect();
 
-                    // Refresh each session's diff stats
-                    for (session_id, cwd, branch) in sessions_to_refresh {
-                        if !branch.is_empty()
-                            && let Ok(stats) = git::get_diff_stats(&cwd, &branch).await
-                            && let Some(session) = app.sessions.get_by_id_mut(&session_id)
-                        {
-                            session.diff_stats = Some(stats);
+                    // Refresh each session's diff stats, changed files and commits
+                    let tx = app_event_tx.clone();
+                    tokio::spawn(async move {
+                        for (session_id, cwd, branch, created_at) in sessions_to_refresh {
+                            let status = git::get_work_tree_status(&cwd, &branch, created_at).await;
+                            let _ = tx
+                                .send(AppEvent::GitStatusRefreshed { session_id, generation, status })
+                                .await;
                         }
-                    }
+                        let _ = tx.send(AppEvent::GitRefreshFinished).await;
+                    });
+                }
+
+                // Poll the Claude API status page
+                if let Some(url) = app.start_api_status_check() {
+                    let tx = app_event_tx.clone();
+                    tokio::spawn(async move {
+                        let result = api_status::fetch(&url).await;
+                        let _ = tx.send(AppEvent::ApiStatusChecked(result)).await;
+                    });
+                }
+
+                // Rescan subscription window usage from Claude's session files
+                if app.start_usage_refresh() {
+                    let tx = app_event_tx.clone();
+                    let cache = app.usage_cache.clone();
+                    tokio::task::spawn_blocking(move || {
+                        // Only one scan runs at a time; a panicked one leaves a usable cache
+                        let mut cache = cache.lock().unwrap_or_else(|e| e.into_inner());
+                        usage::scan(&mut cache, |usage, progress| {
+                            let _ = tx.blocking_send(AppEvent::UsageScanned { usage, progress });
+                        });
+                    });
+                }
+
+                // Read the selected read-only transcript, or what was appended to it
+                if let Some((session_id, path, from)) = app.start_shared_load() {
+                    let tx = app_event_tx.clone();
+                    tokio::task::spawn_blocking(move || {
+                        let result = serve::read_appended(&path, from);
+                        let _ = tx.blocking_send(AppEvent::SharedTranscriptRead { session_id, result });
+                    });
+                }
+
+                // Search the archive for the search screen
+                if let Some((query, terms, filter)) = app.start_search() {
+                    let tx = app_event_tx.clone();
+                    tokio::task::spawn_blocking(move || {
+                        let hits = archive::search(&terms, &filter);
+                        let _ = tx.blocking_send(AppEvent::SearchCompleted { query, hits });
+                    });
+                }
+
+                // List processes while the process tree is open
+                if app.start_process_refresh() {
+                    let tx = app_event_tx.clone();
+                    tokio::task::spawn_blocking(move || {
+                        let _ = tx.blocking_send(AppEvent::ProcessesListed(procs::list()));
+                    });
+                }
+
+                // Count the tokens of the session in the statistics popup
+                if let Some((session_id, path)) = app.start_token_count() {
+                    let tx = app_event_tx.clone();
+                    tokio::task::spawn_blocking(move || {
+                        let tokens = usage::session_tokens(&path);
+                        let _ = tx.blocking_send(AppEvent::SessionTokensCounted { session_id, tokens });
+                    });
+                }
+
+                // Check which working agents are talking to their API
+                if let Some(pids) = app.start_network_sample() {
+                    let tx = app_event_tx.clone();
+                    tokio::task::spawn_blocking(move || {
+                        let _ = tx.blocking_send(AppEvent::NetworkSampled(procs::networking(&pids)));
+                    });
+                }
+
+                // Refresh sessions the agent just wrote files in right away, on their own
+                for (session_id, cwd, branch, created_at) in app.take_dirty_git_sessions() {
+                    let generation = app.next_git_generation();
+                    let tx = app_event_tx.clone();
+                    tokio::spawn(async move {
+                        let status = git::get_work_tree_status(&cwd, &branch, created_at).await;
+                        let _ = tx
+                            .send(AppEvent::GitStatusRefreshed { session_id, generation, status })
+                            .await;
+                    });
                 }
             }
         }
     }
+
+    shutdown_agents(&mut agent_commands).await;
+    Ok(())
 }
 
 async fn spawn_agent_in_dir(
@@ -1264,12 +2087,39 @@ async fn spawn_agent_in_dir(
         session.git_branch = branch;
         session.git_origin = origin;
         session.diff_stats = diff_stats;
+        session.env_snapshot = env::capture(&cwd);
     }
 
     // Convert MCP servers from config format to protocol format
     let mcp_servers: Vec<acp::McpServer> =
         app.mcp_servers.iter().map(acp::McpServer::from).collect();
 
+    start_agent(
+        agent_tx,
+        agent_commands,
+        session_id,
+        agent_type,
+        cwd,
+        mcp_servers,
+        None,
+    );
+
+    Ok(())
+}
+
+/// Spawn the agent process for a session and run its command loop.
+///
+/// When `resume_session_id` is set, the existing ACP session is loaded instead of
+/// creating a new one.
+fn start_agent(
+    agent_tx: &mpsc::Sender<(String, AgentEvent)>,
+    agent_commands: &mut HashMap<String, mpsc::Sender<AgentCommand>>,
+    session_id: String,
+    agent_type: AgentType,
+    cwd: std::path::PathBuf,
+    mcp_servers: Vec<acp::McpServer>,
+    resume_session_id: Option<String>,
+) {
     // Channel for commands to this agent
     let (cmd_tx, mut cmd_rx) = mpsc::channel::<AgentCommand>(32);
     agent_commands.insert(session_id.clone(), cmd_tx.clone());
@@ -1297,6 +2147,8 @@ async fn spawn_agent_in_dir(
     tokio::spawn(async move {
         match AgentConnection::spawn(agent_type, &cwd_clone, event_tx.clone()).await {
             Ok(mut conn) => {
+                let _ = event_tx.send(AgentEvent::Spawned { pid: conn.pid() }).await;
+
                 // Initialize
                 if let Err(e) = conn.initialize().await {
                     let _ = event_tx
@@ -1307,11 +2159,13 @@ async fn spawn_agent_in_dir(
                     return;
                 }
 
-                // Create session with MCP servers
-                if let Err(e) = conn
-                    .new_session(cwd_clone.to_str().unwrap_or("."), mcp_servers)
-                    .await
-                {
+                // Resume the previous session, or create a new one with MCP servers
+                let cwd_str = cwd_clone.to_str().unwrap_or(".");
+                let result = match &resume_session_id {
+                    Some(id) => conn.load_session(id, cwd_str, mcp_servers).await,
+                    None => conn.new_session(cwd_str, mcp_servers).await,
+                };
+                if let Err(e) = result {
                     let _ = event_tx
                         .send(AgentEvent::Error {
                             message: format!("Session failed: {}", e),
@@ -1387,6 +2241,12 @@ async fn spawn_agent_in_dir(
                                     .await;
                             }
                         }
+                        AgentCommand::Kill => {
+                            if let Err(e) = conn.kill().await {
+                                log::log(&format!("Failed to kill agent: {}", e));
+                            }
+                            break;
+                        }
                     }
                 }
             }
@@ -1399,8 +2259,6 @@ async fn spawn_agent_in_dir(
             }
         }
     });
-
-    Ok(())
 }
 
 /// Process an action and apply it to the app state.
@@ -1426,17 +2284,151 @@ async fn process_action(
                 app.enter_insert_mode();
             }
         }
-        ExitInsertMode => {
-            app.exit_insert_mode();
+        ExitInsertMode => {
+            app.exit_insert_mode();
+        }
+        ExitBashMode => {
+            app.exit_bash_mode();
+        }
+        OpenHelp => {
+            app.open_help();
+        }
+        CloseHelp => {
+            app.close_help();
+        }
+        OpenStats => {
+            app.open_stats();
+        }
+        CloseStats => {
+            app.close_stats();
+        }
+        OpenAuditLog => {
+            app.open_audit_log();
+        }
+        CloseAuditLog => {
+            app.close_audit_log();
+        }
+        OpenPlanHistory => {
+            app.open_plan_history();
+        }
+        ClosePlanHistory => {
+            app.close_plan_history();
+        }
+        OpenQueue => {
+            app.open_queue_view();
+        }
+        CloseQueue => {
+            app.close_queue_view();
+        }
+        QueueUp => {
+            app.move_queue_selection(-1);
+        }
+        QueueDown => {
+            app.move_queue_selection(1);
+        }
+        CancelQueueItem => {
+            app.cancel_queue_item();
+        }
+        EditQueueItem => {
+            app.edit_queue_item();
+        }
+        AddWorkOrder => {
+            app.add_work_order();
+        }
+        SubmitQueueEdit => {
+            app.submit_queue_edit();
+        }
+        CloseQueueEdit => {
+            app.close_queue_edit();
+        }
+        QueueEditChar(c) => {
+            if let Some((_, input)) = app.queue_view.as_mut().and_then(|v| v.edit.as_mut()) {
+                input.input_char(c);
+            }
+        }
+        QueueEditBackspace => {
+            if let Some((_, input)) = app.queue_view.as_mut().and_then(|v| v.edit.as_mut()) {
+                input.input_backspace();
+            }
+        }
+        QueueEditLeft => {
+            if let Some((_, input)) = app.queue_view.as_mut().and_then(|v| v.edit.as_mut()) {
+                input.input_left();
+            }
+        }
+        QueueEditRight => {
+            if let Some((_, input)) = app.queue_view.as_mut().and_then(|v| v.edit.as_mut()) {
+                input.input_right();
+            }
+        }
+
+        // === Archive search ===
+        OpenSearch => {
+            app.open_search();
+        }
+        CloseSearch => {
+            app.close_search();
+        }
+        SubmitSearch => {
+            app.submit_search();
+        }
+        SearchUp => {
+            app.move_search_selection(-1);
+        }
+        SearchDown => {
+            app.move_search_selection(1);
+        }
+        SearchInputChar(c) => {
+            if let Some(view) = &mut app.search_view {
+                view.input.input_char(c);
+            }
+        }
+        SearchInputBackspace => {
+            if let Some(view) = &mut app.search_view {
+                view.input.input_backspace();
+            }
+        }
+        SearchInputLeft => {
+            if let Some(view) = &mut app.search_view {
+                view.input.input_left();
+            }
+        }
+        SearchInputRight => {
+            if let Some(view) = &mut app.search_view {
+                view.input.input_right();
+            }
+        }
+
+        // === Message tagging ===
+        OpenTagging => {
+            app.open_tagging();
+        }
+        CloseTagging => {
+            app.close_tagging();
+        }
+        TagCursorUp => {
+            app.move_tag_cursor(false);
         }
-        ExitBashMode => {
-            app.exit_bash_mode();
+        TagCursorDown => {
+            app.move_tag_cursor(true);
         }
-        OpenHelp => {
-            app.open_help();
+        TagMessage(tag) => {
+            app.tag_message(tag);
         }
-        CloseHelp => {
-            app.close_help();
+        ExportTaggedMessages => {
+            app.export_tagged_messages();
+        }
+        ExportDataset(filter) => {
+            app.export_dataset(filter);
+        }
+        CopyPermalink => {
+            app.copy_permalink();
+        }
+        OpenAttachments => {
+            app.open_attachments();
+        }
+        ToggleAnsi => {
+            app.toggle_ansi();
         }
 
         // === Session navigation ===
@@ -1449,6 +2441,15 @@ async fn process_action(
         SelectSession(idx) => {
             app.select_session(idx);
         }
+        ToggleTab => {
+            app.toggle_tab();
+        }
+        NextTab => {
+            app.cycle_tab(true);
+        }
+        PrevTab => {
+            app.cycle_tab(false);
+        }
 
         // === Input handling ===
         InputChar(c) => {
@@ -1528,6 +2529,9 @@ async fn process_action(
         ScrollDown(n) => {
             app.scroll_down(n);
         }
+        ScrollLines(delta) => {
+            app.scroll_lines(delta);
+        }
         ScrollToTop => {
             app.scroll_to_top();
         }
@@ -1656,6 +2660,7 @@ async fn process_action(
                 if let Some(cmd_tx) = agent_commands.get(&session_id) {
                     let _ = cmd_tx.send(AgentCommand::CancelPrompt).await;
                 }
+                audit::record(&audit::AuditEntry::for_session("cancel", session, false));
                 session.state = SessionState::Idle;
                 session.add_output("Cancelled".to_string(), OutputType::SystemMessage);
             }
@@ -1850,6 +2855,47 @@ async fn process_action(
         ToggleDebugToolJson => {
             app.toggle_debug_tool_json();
         }
+        ToggleThinking => {
+            app.toggle_show_thinking();
+        }
+        ToggleErrorsOnly => {
+            app.toggle_errors_only();
+        }
+        ToggleWebBodies => {
+            app.toggle_show_web_bodies();
+        }
+        TogglePreviews => {
+            app.toggle_show_previews();
+        }
+        ExportTranscript => {
+            app.export_selected_transcript();
+        }
+        ArchiveSession => {
+            app.archive_selected();
+        }
+        OpenRecentFiles => {
+            app.open_recent_files();
+        }
+        OpenProcessTree => {
+            app.open_process_tree();
+        }
+        OpenWorkspaceDiff => {
+            app.open_workspace_diff();
+        }
+
+        // === Hidden sessions ===
+        ToggleHideSession => {
+            app.toggle_hide_selected();
+        }
+        ToggleShowHidden => {
+            app.toggle_show_hidden();
+        }
+        ToggleHideHeadless => {
+            app.toggle_hide_headless();
+        }
+        ToggleMuteSession => {
+            app.toggle_mute_selected();
+        }
 
         // === Folder picker ===
         OpenFolderPicker(path) => {
@@ -2015,6 +3061,181 @@ async fn process_action(
             return Some(AsyncAction::SessionPickerSelect);
         }
 
+        // === Recent files ===
+        CloseRecentFiles => {
+            app.close_recent_files();
+        }
+
+        // === Process tree ===
+        CloseProcessTree => {
+            app.close_process_tree();
+        }
+        ProcessTreeToggle => {
+            app.toggle_process_collapsed();
+        }
+
+        // === Quick replies ===
+        OpenReplyMenu => {
+            app.open_reply_menu();
+        }
+        CloseReplyMenu => {
+            app.close_reply_menu();
+        }
+        ReplyMenuDown => {
+            if let Some(menu) = &mut app.reply_menu {
+                menu.select_next();
+            }
+        }
+        ReplyMenuUp => {
+            if let Some(menu) = &mut app.reply_menu {
+                menu.select_prev();
+            }
+        }
+        SendReplyTemplate(number) => {
+            if let Some(text) = app.take_reply_template(number) {
+                submit_prompt(app, agent_commands, text).await;
+            }
+        }
+        DraftReplyTemplate => {
+            app.draft_reply_template();
+        }
+        OpenEpicInput => {
+            app.open_epic_input();
+        }
+        CloseEpicInput => {
+            app.close_epic_input();
+        }
+        SubmitEpicInput => {
+            app.submit_epic_input();
+        }
+        EpicInputComplete => {
+            app.complete_epic_input();
+        }
+        EpicInputChar(c) => {
+            if let Some(input) = &mut app.epic_input {
+                input.input_char(c);
+            }
+        }
+        EpicInputBackspace => {
+            if let Some(input) = &mut app.epic_input {
+                input.input_backspace();
+            }
+        }
+        EpicInputLeft => {
+            if let Some(input) = &mut app.epic_input {
+                input.input_left();
+            }
+        }
+        EpicInputRight => {
+            if let Some(input) = &mut app.epic_input {
+                input.input_right();
+            }
+        }
+        OpenNotes => {
+            app.open_notes();
+        }
+        CloseNotes => {
+            app.close_notes();
+        }
+        NotesInputChar(c) => {
+            if let Some(notes) = &mut app.notes_editor {
+                notes.input_char(c);
+            }
+        }
+        NotesInputBackspace => {
+            if let Some(notes) = &mut app.notes_editor {
+                notes.input_backspace();
+            }
+        }
+        NotesInputDelete => {
+            if let Some(notes) = &mut app.notes_editor {
+                notes.input_delete();
+            }
+        }
+        NotesInputLeft => {
+            if let Some(notes) = &mut app.notes_editor {
+                notes.input_left();
+            }
+        }
+        NotesInputRight => {
+            if let Some(notes) = &mut app.notes_editor {
+                notes.input_right();
+            }
+        }
+        NotesInputHome => {
+            if let Some(notes) = &mut app.notes_editor {
+                notes.input_home();
+            }
+        }
+        NotesInputEnd => {
+            if let Some(notes) = &mut app.notes_editor {
+                notes.input_end();
+            }
+        }
+        RecentFilesDown => {
+            if let Some(recent) = &mut app.recent_files {
+                recent.select_next();
+                recent.load_preview();
+            }
+        }
+        RecentFilesUp => {
+            if let Some(recent) = &mut app.recent_files {
+                recent.select_prev();
+                recent.load_preview();
+            }
+        }
+        ProcessTreeDown => {
+            if let Some(tree) = &mut app.process_tree {
+                tree.select_next();
+            }
+        }
+        ProcessTreeUp => {
+            if let Some(tree) = &mut app.process_tree {
+                tree.select_prev();
+            }
+        }
+
+        // === Workspace diff ===
+        CloseWorkspaceDiff => {
+            // From a diff (or a half-marked range) back to the snapshot list first
+            match &mut app.workspace_diff {
+                Some(state) if state.diff.is_some() || state.from.is_some() => {
+                    state.diff = None;
+                    state.from = None;
+                }
+                _ => app.close_workspace_diff(),
+            }
+        }
+        WorkspaceDiffDown => {
+            if let Some(state) = &mut app.workspace_diff {
+                match &state.diff {
+                    Some(Ok(lines)) => {
+                        state.diff_scroll =
+                            (state.diff_scroll + 1).min(lines.len().saturating_sub(1));
+                    }
+                    Some(Err(_)) => {}
+                    None => state.select_next(),
+                }
+            }
+        }
+        WorkspaceDiffUp => {
+            if let Some(state) = &mut app.workspace_diff {
+                match &state.diff {
+                    Some(_) => state.diff_scroll = state.diff_scroll.saturating_sub(1),
+                    None => state.select_prev(),
+                }
+            }
+        }
+        WorkspaceDiffMark => {
+            if let Some(state) = &mut app.workspace_diff
+                && state.diff.is_none()
+                && !state.snapshots.is_empty()
+                && let Some((from, to)) = state.mark()
+            {
+                return Some(AsyncAction::DiffSnapshots { from, to });
+            }
+        }
+
         // === Branch input ===
         CloseBranchInput => {
             app.close_branch_input();
@@ -2137,6 +3358,39 @@ async fn process_action(
         KillSession => {
             return Some(AsyncAction::KillSession);
         }
+        OpenKillIdleConfirm => {
+            app.open_kill_idle_confirm();
+        }
+        CloseKillIdleConfirm => {
+            app.close_kill_idle_confirm();
+        }
+        KillIdleSessions => {
+            return Some(AsyncAction::KillIdleSessions);
+        }
+        RestartSession => {
+            return Some(AsyncAction::RestartSession);
+        }
+        RunVerify => {
+            return Some(AsyncAction::RunVerify);
+        }
+        OpenVerifyOutput => {
+            let never_ran = app
+                .selected_session()
+                .is_some_and(|s| s.verify_status.is_none());
+            app.open_verify_output();
+            if never_ran {
+                return Some(AsyncAction::RunVerify);
+            }
+        }
+        CloseVerifyOutput => {
+            app.close_verify_output();
+        }
+        ScrollVerifyOutput(delta) => {
+            app.scroll_verify_output(delta);
+        }
+        SummarizeSession => {
+            return Some(AsyncAction::Summarize);
+        }
 
         // === Bug report ===
         OpenBugReport => {
@@ -2216,9 +3470,75 @@ enum AsyncAction {
     DuplicateSession,
     ClearSession,
     KillSession,
+    KillIdleSessions,
+    RestartSession,
+    RunVerify,
+    Summarize,
+    DiffSnapshots {
+        from: String,
+        to: String,
+    },
     SubmitBugReport,
 }
 
+/// Pipe a transcript through the summary command, returning its trimmed stdout
+async fn run_summary_command(
+    command: &str,
+    cwd: &std::path::Path,
+    transcript: String,
+) -> Result<String, String> {
+    use tokio::io::AsyncWriteExt;
+
+    let mut child = tokio::process::Command::new("sh")
+        .arg("-c")
+        .arg(command)
+        .current_dir(cwd)
+        .stdin(std::process::Stdio::piped())
+        .stdout(std::process::Stdio::piped())
+        .stderr(std::process::Stdio::piped())
+        .kill_on_drop(true)
+        .spawn()
+        .map_err(|e| e.to_string())?;
+
+    if let Some(mut stdin) = child.stdin.take() {
+        // Dropping stdin after writing signals end of input
+        stdin
+            .write_all(transcript.as_bytes())
+            .await
+            .map_err(|e| e.to_string())?;
+    }
+
+    let output = child.wait_with_output().await.map_err(|e| e.to_string())?;
+    let stdout = String::from_utf8_lossy(&output.stdout).trim().to_string();
+    if !output.status.success() {
+        let stderr = String::from_utf8_lossy(&output.stderr);
+        return Err(format!("{} ({})", stderr.trim(), output.status));
+    }
+    if stdout.is_empty() {
+        return Err("command printed nothing".to_string());
+    }
+    Ok(stdout)
+}
+
+/// Forward each line of a verify command's output stream to the event loop
+async fn forward_verify_output<R>(reader: R, session_id: String, tx: mpsc::Sender<AppEvent>)
+where
+    R: tokio::io::AsyncRead + Unpin,
+{
+    use tokio::io::AsyncBufReadExt;
+
+    let mut lines = tokio::io::BufReader::new(reader).lines();
+    while let Ok(Some(line)) = lines.next_line().await {
+        let event = AppEvent::VerifyOutput {
+            session_id: session_id.clone(),
+            line,
+        };
+        if tx.send(event).await.is_err() {
+            break;
+        }
+    }
+}
+
 /// Handle async actions in the main event loop.
 /// This function contains all the async logic that was previously duplicated in Insert mode handling.
 #[allow(clippy::too_many_lines)]
@@ -2256,6 +3576,7 @@ async fn handle_async_in_loop(
                             .arg("-c")
                             .arg(&command)
                             .current_dir(&cwd)
+                            .kill_on_drop(true)
                             .output()
                             .await;
 
@@ -2285,8 +3606,8 @@ async fn handle_async_in_loop(
                             .await;
                     });
                 }
-            } else if !text.is_empty() || app.has_attachments() {
-                send_prompt(app, agent_commands, &text).await;
+            } else {
+                submit_prompt(app, agent_commands, text).await;
             }
             app.exit_insert_mode();
         }
@@ -2475,6 +3796,12 @@ async fn handle_async_in_loop(
 
                 // Spawn async deletion tasks for each selected worktree
                 for (worktree_path, branch) in selected {
+                    let mut entry =
+                        audit::AuditEntry::for_dir("delete-worktree", &worktree_path, true);
+                    if delete_branches && let Some(branch) = &branch {
+                        entry = entry.with_detail(format!("branch {} deleted too", branch));
+                    }
+                    audit::record(&entry);
                     let tx = app_event_tx.clone();
                     tokio::spawn(async move {
                         // Get the actual git repo for this worktree
@@ -2545,6 +3872,7 @@ async fn handle_async_in_loop(
                 let cwd = session.cwd.clone();
                 let is_worktree = session.is_worktree;
                 let old_session_id = session.id.clone();
+                audit::record(&audit::AuditEntry::for_session("clear", session, true));
 
                 // Remove agent command channel
                 agent_commands.remove(&old_session_id);
@@ -2563,10 +3891,225 @@ async fn handle_async_in_loop(
         AsyncAction::KillSession => {
             if let Some(session) = app.sessions.selected_session() {
                 let session_id = session.id.clone();
+                audit::record(&audit::AuditEntry::for_session("kill", session, false));
                 agent_commands.remove(&session_id);
             }
             app.kill_selected_session();
         }
+        AsyncAction::KillIdleSessions => {
+            app.close_kill_idle_confirm();
+            let ids: Vec<String> = app
+                .idle_sessions()
+                .into_iter()
+                .map(|session| {
+                    let idle = session.last_activity.map(|at| at.elapsed().as_secs() / 60);
+                    audit::record(
+                        &audit::AuditEntry::for_session("kill", session, true)
+                            .with_detail(format!("idle {}m", idle.unwrap_or_default())),
+                    );
+                    session.id.clone()
+                })
+                .collect();
+            for id in &ids {
+                agent_commands.remove(id);
+            }
+            app.kill_sessions(&ids);
+            log::log_event(&format!("Killed {} idle sessions", ids.len()));
+            app.show_toast(
+                format!(
+                    "Killed {} idle agent{}",
+                    ids.len(),
+                    if ids.len() == 1 { "" } else { "s" }
+                ),
+                false,
+            );
+        }
+        AsyncAction::RunVerify => {
+            let verify_command = app.verify_command.clone();
+            let Some(session) = app.sessions.selected_session_mut() else {
+                return Ok(());
+            };
+            let Some(command) = verify_command else {
+                app.show_toast(
+                    "No verify command configured (set verify_command in config.toml)",
+                    true,
+                );
+                return Ok(());
+            };
+            if session.verify_status == Some(VerifyStatus::Running) {
+                app.show_toast("Verify is already running", false);
+                return Ok(());
+            }
+
+            let session_id = session.id.clone();
+            let cwd = session.cwd.clone();
+            session.verify_status = Some(VerifyStatus::Running);
+            audit::record(
+                &audit::AuditEntry::for_session("verify", session, false).with_detail(&command),
+            );
+            session.verify_output.clear();
+            session.add_verify_output(format!("$ {}", command));
+            log::log_event(&format!("Running verify for session {}", session.name));
+            app.open_verify_output();
+
+            // Run asynchronously, streaming output lines back to the event loop
+            let tx = app_event_tx.clone();
+            tokio::spawn(async move {
+                let child = tokio::process::Command::new("sh")
+                    .arg("-c")
+                    .arg(&command)
+                    .current_dir(&cwd)
+                    .stdin(std::process::Stdio::null())
+                    .stdout(std::process::Stdio::piped())
+                    .stderr(std::process::Stdio::piped())
+                    .kill_on_drop(true)
+                    .spawn();
+
+                let success = match child {
+                    Ok(mut child) => {
+                        let stdout = child.stdout.take().map(|out| {
+                            tokio::spawn(forward_verify_output(out, session_id.clone(), tx.clone()))
+                        });
+                        let stderr = child.stderr.take().map(|err| {
+                            tokio::spawn(forward_verify_output(err, session_id.clone(), tx.clone()))
+                        });
+                        let status = child.wait().await;
+
+                        // Drain remaining output before reporting completion
+                        for task in [stdout, stderr].into_iter().flatten() {
+                            let _ = task.await;
+                        }
+                        status.map(|s| s.success()).unwrap_or(false)
+                    }
+                    Err(e) => {
+                        let _ = tx
+                            .send(AppEvent::VerifyOutput {
+                                session_id: session_id.clone(),
+                                line: format!("Error: {}", e),
+                            })
+                            .await;
+                        false
+                    }
+                };
+
+                let _ = tx
+                    .send(AppEvent::VerifyCompleted {
+                        session_id,
+                        success,
+                    })
+                    .await;
+            });
+        }
+        AsyncAction::Summarize => {
+            let summary_command = app.summary_command.clone();
+            let Some(session) = app.sessions.selected_session() else {
+                return Ok(());
+            };
+            let Some(command) = summary_command else {
+                if let Some(session) = app.sessions.selected_session_mut() {
+                    session.add_output(
+                        "No summary command configured (set summary_command in config.toml)"
+                            .to_string(),
+                        OutputType::Error,
+                    );
+                    session.scroll_to_bottom();
+                }
+                return Ok(());
+            };
+            if session.summarizing
+                || session
+                    .summary
+                    .as_ref()
+                    .is_some_and(|s| s.output_len == session.output.len())
+            {
+                // Already running, or the cached summary covers the whole transcript
+                return Ok(());
+            }
+
+            // The command may send the transcript to a hosted model: redact it first
+            let transcript = app.redactor.redact(&transcript::to_markdown(session));
+            let session_id = session.id.clone();
+            let output_len = session.output.len();
+            let cwd = session.cwd.clone();
+            log::log_event(&format!("Summarizing session {}", session.name));
+            audit::record(
+                &audit::AuditEntry::for_session("summary", session, false).with_detail(&command),
+            );
+            if let Some(session) = app.sessions.selected_session_mut() {
+                session.summarizing = true;
+            }
+
+            let tx = app_event_tx.clone();
+            tokio::spawn(async move {
+                let result = run_summary_command(&command, &cwd, transcript).await;
+                let _ = tx
+                    .send(AppEvent::SummaryCompleted {
+                        session_id,
+                        output_len,
+                        result,
+                    })
+                    .await;
+            });
+        }
+        AsyncAction::RestartSession => {
+            if let Some(session) = app.sessions.selected_session_mut() {
+                let session_id = session.id.clone();
+                let agent_type = session.agent_type;
+                let cwd = session.cwd.clone();
+                // Agents that can't load a session start a new one
+                let resume_session_id = session.acp_session_id.clone().filter(|_| session.can_load);
+                audit::record(&audit::AuditEntry::for_session("restart", session, false));
+
+                // Kill the old agent process and wait for its command loop to exit
+                if let Some(cmd_tx) = agent_commands.remove(&session_id) {
+                    let _ = cmd_tx.send(AgentCommand::Kill).await;
+                    let _ = tokio::time::timeout(Duration::from_secs(5), cmd_tx.closed()).await;
+                }
+
+                // Loading the session replays the whole conversation, so
+                // start from empty output; a new session keeps the old output
+                if resume_session_id.is_some() {
+                    session.clear_output();
+                    app.tag_cursor = None;
+                }
+
+                // Reset transient state
+                session.state = SessionState::Spawning;
+                session.pending_permission = None;
+                session.pending_question = None;
+                session.complete_active_tool();
+                session.clear_thought();
+                session.running_terminals = 0;
+                session.interrupted = false;
+                session.add_output("Restarting agent...".to_string(), OutputType::SystemMessage);
+                log::log_event(&format!("Restarting agent for session {}", session.name));
+
+                let mcp_servers: Vec<acp::McpServer> =
+                    app.mcp_servers.iter().map(acp::McpServer::from).collect();
+                start_agent(
+                    agent_tx,
+                    agent_commands,
+                    session_id,
+                    agent_type,
+                    cwd,
+                    mcp_servers,
+                    resume_session_id,
+                );
+            }
+        }
+        AsyncAction::DiffSnapshots { from, to } => {
+            let Some(session) = app.sessions.selected_session() else {
+                return Ok(());
+            };
+            let cwd = session.cwd.clone();
+            let tx = app_event_tx.clone();
+            tokio::spawn(async move {
+                let result = git::diff_snapshots(&cwd, &from, &to)
+                    .await
+                    .map_err(|e| e.to_string());
+                let _ = tx.send(AppEvent::WorkspaceDiffLoaded(result)).await;
+            });
+        }
         AsyncAction::SubmitBugReport => {
             if let Some(bug_report) = &app.bug_report {
                 let description = bug_report.description.clone();
@@ -2580,6 +4123,31 @@ async fn handle_async_in_loop(
     Ok(())
 }
 
+/// Send a prompt to the selected session, or queue it while the agent is busy
+async fn submit_prompt(
+    app: &mut App,
+    agent_commands: &HashMap<String, mpsc::Sender<AgentCommand>>,
+    text: String,
+) {
+    if !text.is_empty()
+        && !app.has_attachments()
+        && let Some(session) = app.sessions.selected_session_mut()
+        && session.state != SessionState::Idle
+    {
+        // Agent is busy - queue the prompt and dispatch it when the agent goes idle
+        session.queued_prompts.push_back(text.clone());
+        session.add_output(format!("Queued: {}", text), OutputType::SystemMessage);
+        session.scroll_to_bottom();
+        log::log_event(&format!(
+            "Queued prompt for {} ({} pending)",
+            session.name,
+            session.queued_prompts.len()
+        ));
+    } else if !text.is_empty() || app.has_attachments() {
+        send_prompt(app, agent_commands, &text).await;
+    }
+}
+
 async fn send_prompt(
     app: &mut App,
     agent_commands: &HashMap<String, mpsc::Sender<AgentCommand>>,
@@ -2607,6 +4175,11 @@ async fn send_prompt(
         session.scroll_to_bottom(); // Scroll to show the user's input
         session.state = SessionState::Prompting;
         session.idle_notified = false; // Reset so we notify when this prompt completes
+        session.needs_input = false;
+        session.done = false;
+        if let Some(otlp) = &mut app.otlp {
+            otlp.turn_started(&session.id);
+        }
 
         // Use local ID for HashMap lookup, ACP session ID for protocol
         let local_id = session.id.clone();
@@ -2654,6 +4227,96 @@ async fn send_prompt(
     }
 }
 
+/// Snapshot a session's work tree in the background, labeled with the turn
+/// that just ended (or the session start)
+fn snapshot_workspace(app: &App, session_id: &str, app_event_tx: &mpsc::Sender<AppEvent>) {
+    let Some(session) = app.sessions.get_by_id(session_id) else {
+        return;
+    };
+    if session.git_branch.is_empty() {
+        return;
+    }
+    let session_id = session_id.to_string();
+    let name = session.name.clone();
+    let cwd = session.cwd.clone();
+    let output_len = session.output.len();
+    let label = session.last_prompt().unwrap_or("session start").to_string();
+    let tx = app_event_tx.clone();
+    tokio::spawn(async move {
+        match git::snapshot_work_tree(&cwd).await {
+            Ok(tree) => {
+                let snapshot = session::WorkspaceSnapshot {
+                    tree,
+                    taken_at: std::time::SystemTime::now(),
+                    output_len,
+                    label,
+                };
+                let _ = tx
+                    .send(AppEvent::WorkspaceSnapshotTaken {
+                        session_id,
+                        snapshot,
+                    })
+                    .await;
+            }
+            Err(e) => log::log(&format!("Work tree snapshot of {} failed: {}", name, e)),
+        }
+    });
+}
+
+/// Send the next queued prompt, or the next work order for its project, to
+/// a session if it is idle.
+async fn dispatch_queued_prompt(
+    app: &mut App,
+    agent_commands: &HashMap<String, mpsc::Sender<AgentCommand>>,
+    session_id: &str,
+) {
+    let Some(session) = app.sessions.get_by_id(session_id) else {
+        return;
+    };
+    if session.state != SessionState::Idle
+        || (session.queued_prompts.is_empty() && app.work_order_for(session).is_none())
+    {
+        return;
+    }
+    // Near the usage quota, prompts wait for the window to reset (retried on tick)
+    if let Some(until) = app.dispatch_held_until() {
+        log::log_event(&format!(
+            "Holding queued prompts until {} (usage quota)",
+            until.format("%H:%M")
+        ));
+        return;
+    }
+    let Some(text) = app.next_queued_prompt(session_id) else {
+        return;
+    };
+    let Some(session) = app.sessions.get_by_id_mut(session_id) else {
+        return;
+    };
+
+    session.add_output(String::new(), OutputType::Text);
+    session.add_output(format!("> {}", text), OutputType::UserInput);
+    if session.scroll_offset == usize::MAX {
+        session.scroll_to_bottom();
+    }
+    session.state = SessionState::Prompting;
+    session.idle_notified = false;
+    session.needs_input = false;
+    session.done = false;
+    if let Some(otlp) = &mut app.otlp {
+        otlp.turn_started(session_id);
+    }
+
+    let acp_session_id = session.acp_session_id.clone().unwrap_or_default();
+    if let Some(cmd_tx) = agent_commands.get(session_id) {
+        let _ = cmd_tx
+            .send(AgentCommand::Prompt {
+                session_id: acp_session_id,
+                text,
+            })
+            .await;
+    }
+}
+
 /// Notification to send after handling an event
 enum NotificationEvent {
     PermissionRequired {
@@ -2666,6 +4329,21 @@ enum NotificationEvent {
     SessionIdle {
         session_name: String,
     },
+    InputNeeded {
+        session_name: String,
+    },
+}
+
+impl NotificationEvent {
+    /// Terminal cue configured for this kind of event
+    fn alert_event(&self) -> AlertEvent {
+        match self {
+            NotificationEvent::PermissionRequired { .. } => AlertEvent::Permission,
+            NotificationEvent::QuestionAsked { .. } => AlertEvent::Question,
+            NotificationEvent::SessionIdle { .. } => AlertEvent::Complete,
+            NotificationEvent::InputNeeded { .. } => AlertEvent::Question,
+        }
+    }
 }
 
 /// Process a notification event by sending it through the notification manager
@@ -2686,6 +4364,9 @@ fn process_notification(
         NotificationEvent::SessionIdle { session_name } => {
             notifications.notify_idle(&session_name);
         }
+        NotificationEvent::InputNeeded { session_name } => {
+            notifications.notify_input_needed(&session_name);
+        }
     }
 }
 
@@ -2710,6 +4391,7 @@ fn handle_agent_event(app: &mut App, session_id: &str, event: AgentEvent) -> Eve
     let is_insert_mode = app.input_mode == InputMode::Insert;
     let input_buffer = app.input_buffer.clone();
     let cursor_position = app.cursor_position;
+    let allowed_write_dirs = app.allowed_write_dirs.clone();
 
     // Check if this session is the currently selected one
     let is_selected_session = app
@@ -2720,6 +4402,9 @@ fn handle_agent_event(app: &mut App, session_id: &str, event: AgentEvent) -> Eve
 
     if let Some(session) = app.sessions.get_by_id_mut(session_id) {
         match event {
+            AgentEvent::Spawned { pid } => {
+                session.agent_pid = pid;
+            }
             AgentEvent::Initialized {
                 agent_info,
                 agent_capabilities,
@@ -2728,28 +4413,51 @@ fn handle_agent_event(app: &mut App, session_id: &str, event: AgentEvent) -> Eve
                 if let Some(info) = agent_info
                     && let Some(name) = info.name
                 {
-                    session.add_output(format!("Connected to {}", name), OutputType::Text);
+                    session.add_output(format!("Connected to {}", name), OutputType::SystemMessage);
                 }
                 if let Some(caps) = agent_capabilities {
+                    session.can_load = caps
+                        .get("loadSession")
+                        .and_then(|v| v.as_bool())
+                        .unwrap_or(false);
                     // Format capabilities nicely
                     let formatted = format_agent_capabilities(&caps);
-                    session.add_output(formatted, OutputType::Text);
+                    session.add_output(formatted, OutputType::SystemMessage);
                 }
             }
             AgentEvent::SessionCreated { session_id, models } => {
                 // Store the ACP session ID (used in protocol messages)
                 // Keep session.id as the local stable ID (used for HashMap keys)
-                session.acp_session_id = Some(session_id);
+                // session/load reports an empty ID: keep the one we resumed
+                let resumed = session_id.is_empty();
+                if !resumed {
+                    session.acp_session_id = Some(session_id);
+                }
+                // Bring back whether a resumed session was hidden, its notes and plan
+                // history, or save notes written while it started
+                if let Some(id) = &session.acp_session_id {
+                    session.hidden |= hidden::is_hidden(id);
+                    if session.plan_history.is_empty() {
+                        session.plan_history = plan_history::load(id);
+                    }
+                    if session.notes.is_empty() {
+                        session.notes = notes::load(id).unwrap_or_default();
+                    } else if let Err(e) = notes::save(id, &session.notes) {
+                        log::log(&format!("Failed to save notes: {}", e));
+                    }
+                }
                 session.state = SessionState::Idle;
                 // Store model info if available
                 if let Some(models_state) = models {
                     session.available_models = models_state.available_models;
                     session.current_model_id = Some(models_state.current_model_id);
                 }
-                session.add_output(
-                    "Session ready. Press [i] to type.".to_string(),
-                    OutputType::Text,
-                );
+                let message = if resumed {
+                    "Session resumed. Press [i] to type."
+                } else {
+                    "Session ready. Press [i] to type."
+                };
+                session.add_output(message.to_string(), OutputType::SystemMessage);
             }
             AgentEvent::Update { update, .. } => {
                 match update {
@@ -2768,6 +4476,9 @@ fn handle_agent_event(app: &mut App, session_id: &str, event: AgentEvent) -> Eve
                     SessionUpdate::ToolCall {
                         tool_call_id,
                         title,
+                        kind,
+                        locations,
+                        raw_description,
                         raw_json,
                         ..
                     } => {
@@ -2787,12 +4498,47 @@ fn handle_agent_event(app: &mut App, session_id: &str, event: AgentEvent) -> Eve
                         if is_new {
                             session.add_output(String::new(), OutputType::Text);
                         }
+                        let outside = scope::outside_writes(
+                            kind.as_ref(),
+                            &locations,
+                            raw_json.as_deref(),
+                            &session.cwd,
+                            &allowed_write_dirs,
+                        );
+                        // Web tools: show what was searched or fetched, list results later
+                        let mut name = name;
+                        if web::is_web_tool(kind.as_ref(), &name) {
+                            session.web_tool_calls.insert(tool_call_id.clone());
+                            if let Some(target) = raw_description
+                                && !name.contains(target.as_str())
+                            {
+                                name = format!("{}: {}", name, target);
+                            }
+                        }
+                        if let Some(otlp) = &mut app.otlp {
+                            otlp.tool_started(&session.id, &tool_call_id, &name);
+                        }
                         session.add_tool_call(tool_call_id, name, None, raw_json);
+                        for path in outside {
+                            session.record_outside_write(path);
+                        }
                     }
                     SessionUpdate::ToolCallUpdate {
                         tool_call_id,
                         status,
+                        content,
                     } => {
+                        // Keep web results whole: links are listed, the text is expandable
+                        if let Some(text) = content
+                            && session.web_tool_calls.contains(&tool_call_id)
+                        {
+                            session.set_web_result(tool_call_id.clone(), text);
+                        }
+                        if let Some(otlp) = &mut app.otlp
+                            && matches!(status.as_str(), "completed" | "error" | "failed")
+                        {
+                            otlp.tool_finished(&session.id, &tool_call_id, status != "completed");
+                        }
                         // Check if this tool is completing
                         if status == "completed" {
                             // Mark the tool as complete if it's the active one
@@ -2811,7 +4557,13 @@ fn handle_agent_event(app: &mut App, session_id: &str, event: AgentEvent) -> Eve
                         }
                     }
                     SessionUpdate::Plan { entries } => {
-                        session.plan_entries = entries;
+                        let id = session.acp_session_id.clone();
+                        let changes = session.set_plan(entries);
+                        if let Some(id) = id
+                            && let Err(e) = plan_history::append(&id, changes)
+                        {
+                            log::log(&format!("Failed to save plan history: {}", e));
+                        }
                     }
                     SessionUpdate::CurrentModeUpdate { current_mode_id } => {
                         session.current_mode = Some(current_mode_id);
@@ -2822,7 +4574,7 @@ fn handle_agent_event(app: &mut App, session_id: &str, event: AgentEvent) -> Eve
                     SessionUpdate::Other { raw_type } => {
                         session.add_output(
                             format!("[Unknown update: {}]", raw_type.as_deref().unwrap_or("?")),
-                            OutputType::Text,
+                            OutputType::SystemMessage,
                         );
                     }
                 }
@@ -2842,7 +4594,7 @@ fn handle_agent_event(app: &mut App, session_id: &str, event: AgentEvent) -> Eve
                     // Find the first allow_once option
                     if let Some(option) = options
                         .iter()
-                        .find(|o| o.kind == crate::acp::PermissionKind::AllowOnce)
+                        .find(|o| o.kind == acp::PermissionKind::AllowOnce)
                     {
                         session.state = SessionState::Prompting;
                         // Auto-scroll to bottom only if already at bottom
@@ -2905,36 +4657,102 @@ fn handle_agent_event(app: &mut App, session_id: &str, event: AgentEvent) -> Eve
                     session_name,
                 });
             }
-            AgentEvent::PromptComplete { .. } => {
+            AgentEvent::PromptComplete {
+                stop_reason,
+                raw_json,
+            } => {
                 let session_name = session.name.clone();
+                if let Some(json) = raw_json {
+                    session.add_output(json, OutputType::RawJson);
+                }
                 let should_notify = !session.idle_notified;
 
                 session.state = SessionState::Idle;
                 session.pending_permission = None;
                 session.complete_active_tool();
                 session.clear_thought(); // Clear any remaining thought
+                if let Some(otlp) = &mut app.otlp {
+                    otlp.turn_finished(session, &stop_reason);
+                }
+                let ended_normally = stop_reason == StopReason::EndTurn;
+                session.record_stop_reason(stop_reason);
+                // Warn about commands the agent left running after its turn ended
+                if session.running_terminals > 0 {
+                    session.add_output(
+                        format!(
+                            "{} command(s) still running in the background",
+                            session.running_terminals
+                        ),
+                        OutputType::SystemMessage,
+                    );
+                }
+                // A turn that ends with a question waits on the user, it isn't done
+                session.needs_input = session.last_agent_text().is_some_and(attention::asks_user);
+                // A turn that completes the plan or wraps up with a summary is done
+                session.done = ended_normally
+                    && !session.needs_input
+                    && (session.plan_completed()
+                        || session
+                            .last_agent_text()
+                            .is_some_and(attention::is_final_summary));
                 // Add blank line after response for spacing
                 session.add_output(String::new(), OutputType::Text);
 
                 // Send idle notification if not already sent for this prompt
                 if should_notify {
                     session.idle_notified = true;
+                    if session.needs_input {
+                        return EventResult::Notification(NotificationEvent::InputNeeded {
+                            session_name,
+                        });
+                    }
                     return EventResult::Notification(NotificationEvent::SessionIdle {
                         session_name,
                     });
                 }
             }
-            AgentEvent::FileWritten { diff, .. } => {
+            AgentEvent::TerminalsChanged { running } => {
+                session.running_terminals = running;
+            }
+            AgentEvent::FileWritten {
+                path,
+                diff,
+                changed_lines,
+                ..
+            } => {
                 // Show the diff (file path is already shown in the tool call)
                 session.add_tool_output(diff);
+                let path = PathBuf::from(path);
+                if scope::is_outside(&path, &session.cwd, &allowed_write_dirs) {
+                    session.record_outside_write(scope::normalize(&path, &session.cwd));
+                }
+                session.record_file_write(path, changed_lines);
+                session.git_dirty = true;
             }
             AgentEvent::Error { message } => {
                 session.state = SessionState::Idle;
                 session.add_output(format!("Error: {}", message), OutputType::Error);
             }
             AgentEvent::Disconnected => {
+                // A process that was still starting is being restarted, not interrupted
+                if !matches!(
+                    session.state,
+                    SessionState::Spawning | SessionState::Initializing
+                ) && session.is_mid_task()
+                {
+                    session.interrupted = true;
+                    log::log_event(&format!("Agent for {} died mid-task", session.name));
+                }
                 session.state = SessionState::Idle;
-                session.add_output("Disconnected".to_string(), OutputType::Text);
+                session.add_output("Disconnected".to_string(), OutputType::SystemMessage);
+                if session.interrupted {
+                    let message = if session.can_load {
+                        "Agent exited mid-task - press R to resume"
+                    } else {
+                        "Agent exited mid-task - press R to restart it in a new session"
+                    };
+                    session.add_output(message.to_string(), OutputType::SystemMessage);
+                }
             }
         }
         // Auto-scroll to bottom only if already at bottom (not scrolled up)
@@ -0,0 +1,46 @@
+//! Scratchpad notes on sessions.
+//!
+//! Free-form notes ("waiting on the schema decision") keep track of the human
+//! context around an agent's work. They're stored in `~/.amux/notes.json`,
+//! keyed by the agent-side session ID, so they come back when a session is
+//! resumed.
+
+use std::collections::BTreeMap;
+use std::path::{Path, PathBuf};
+
+/// Path of the notes file
+pub fn notes_path() -> PathBuf {
+    dirs::home_dir()
+        .unwrap_or_else(|| PathBuf::from("."))
+        .join(".amux")
+        .join("notes.json")
+}
+
+fn read_all(path: &Path) -> BTreeMap<String, String> {
+    std::fs::read_to_string(path)
+        .ok()
+        .and_then(|text| serde_json::from_str(&text).ok())
+        .unwrap_or_default()
+}
+
+/// Notes stored for an agent session
+pub fn load(session_id: &str) -> Option<String> {
+    read_all(&notes_path()).remove(session_id)
+}
+
+/// Store the notes for an agent session; empty notes remove the entry
+pub fn save(session_id: &str, notes: &str) -> std::io::Result<()> {
+    let path = notes_path();
+    let mut all = read_all(&path);
+    if notes.trim().is_empty() {
+        if all.remove(session_id).is_none() {
+            return Ok(());
+        }
+    } else {
+        all.insert(session_id.to_string(), notes.to_string());
+    }
+    if let Some(dir) = path.parent() {
+        std::fs::create_dir_all(dir)?;
+    }
+    std::fs::write(&path, serde_json::to_string_pretty(&all)?)
+}
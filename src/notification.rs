@@ -4,11 +4,29 @@
 //! - Permission requests from agents
 //! - Clarifying questions from agents
 //! - Session becoming idle after completing work
+//! - Bash commands running for minutes without a result
+//!
+//! Notifications are suppressed during configured quiet hours and for muted
+//! sessions; suppressed ones are written to the debug log and summarized in
+//! the next notification that goes out. Where the desktop supports actions,
+//! clicking a session's notification jumps to the session (see [`focus`]).
 
-use std::time::Instant;
+use std::collections::HashSet;
+use std::time::{Duration, Instant};
 
+use chrono::{DateTime, Datelike, Local, NaiveTime, Weekday};
 use notify_rust::{Notification, Timeout};
 
+#[cfg(all(unix, not(target_os = "macos")))]
+use std::sync::atomic::{AtomicUsize, Ordering};
+
+#[cfg(all(unix, not(target_os = "macos")))]
+use notify_rust::NotificationHandle;
+
+#[cfg(all(unix, not(target_os = "macos")))]
+use crate::focus;
+use crate::log;
+
 /// Types of notifications that can be sent.
 #[derive(Debug, Clone, Copy, PartialEq, Eq)]
 pub enum NotificationType {
@@ -18,6 +36,8 @@ pub enum NotificationType {
     QuestionAsked,
     /// Session finished work and is now idle
     SessionIdle,
+    /// A Bash command has been running for a long time without a result
+    LongCommand,
 }
 
 /// Configuration for notifications.
@@ -30,6 +50,8 @@ pub struct NotificationConfig {
     pub idle_delay_secs: u64,
     /// Minimum seconds between same notification type (prevents spam)
     pub dedupe_interval_secs: u64,
+    /// Schedule during which notifications are suppressed
+    pub quiet_hours: QuietHours,
 }
 
 impl Default for NotificationConfig {
@@ -38,14 +60,86 @@ impl Default for NotificationConfig {
             enabled: true,
             idle_delay_secs: 5,
             dedupe_interval_secs: 30,
+            quiet_hours: QuietHours::default(),
+        }
+    }
+}
+
+/// Times when notifications are suppressed.
+#[derive(Debug, Clone, Default, PartialEq)]
+pub struct QuietHours {
+    /// Daily window as (start, end); wraps past midnight when start > end
+    pub window: Option<(NaiveTime, NaiveTime)>,
+    /// Whether Saturdays and Sundays are quiet all day
+    pub weekends: bool,
+}
+
+impl QuietHours {
+    /// Parse a window like "22:00-08:00"
+    pub fn parse_window(spec: &str) -> Option<(NaiveTime, NaiveTime)> {
+        let (start, end) = spec.split_once('-')?;
+        let start = NaiveTime::parse_from_str(start.trim(), "%H:%M").ok()?;
+        let end = NaiveTime::parse_from_str(end.trim(), "%H:%M").ok()?;
+        Some((start, end))
+    }
+
+    /// Check whether the given moment falls into quiet hours
+    pub fn is_quiet(&self, now: DateTime<Local>) -> bool {
+        if self.weekends && matches!(now.weekday(), Weekday::Sat | Weekday::Sun) {
+            return true;
         }
+        match self.window {
+            Some((start, end)) if start <= end => {
+                let time = now.time();
+                time >= start && time < end
+            }
+            Some((start, end)) => {
+                let time = now.time();
+                time >= start || time < end
+            }
+            None => false,
+        }
+    }
+}
+
+/// Most notifications waiting for a click at once; later ones still show but
+/// can't be clicked until earlier ones close
+#[cfg(all(unix, not(target_os = "macos")))]
+const MAX_WAITING: usize = 4;
+
+/// Notifications currently waiting for a click
+#[cfg(all(unix, not(target_os = "macos")))]
+static WAITING: AtomicUsize = AtomicUsize::new(0);
+
+/// Focus `session_name` when the shown notification is clicked. Waiting
+/// blocks until the notification closes, so it happens on a thread of its
+/// own, and only for a few notifications at a time.
+#[cfg(all(unix, not(target_os = "macos")))]
+fn wait_for_click(handle: NotificationHandle, session_name: String) {
+    if WAITING.fetch_add(1, Ordering::SeqCst) >= MAX_WAITING {
+        WAITING.fetch_sub(1, Ordering::SeqCst);
+        return;
     }
+    std::thread::spawn(move || {
+        handle.wait_for_action(|action| {
+            if action == "default"
+                && let Err(e) = focus::request(&session_name, Some(std::process::id()))
+            {
+                log::log(&format!("Failed to focus {}: {}", session_name, e));
+            }
+        });
+        WAITING.fetch_sub(1, Ordering::SeqCst);
+    });
 }
 
 /// Manages sending desktop notifications with deduplication.
 pub struct NotificationManager {
     config: NotificationConfig,
     last_notification: Option<(NotificationType, Instant)>,
+    /// Session names whose notifications are muted (toggle with 'M')
+    muted_sessions: HashSet<String>,
+    /// Notifications suppressed since the last one was shown
+    missed: usize,
 }
 
 impl NotificationManager {
@@ -54,54 +148,165 @@ impl NotificationManager {
         Self {
             config,
             last_notification: None,
+            muted_sessions: HashSet::new(),
+            missed: 0,
         }
     }
 
-    /// Send a notification if enabled and not a duplicate.
+    /// Replace the configuration, keeping mutes and deduplication state.
+    pub fn set_config(&mut self, config: NotificationConfig) {
+        self.config = config;
+    }
+
+    /// Send a notification if enabled, not a duplicate, and not in quiet hours.
     ///
     /// Returns `true` if the notification was sent.
     pub fn send(&mut self, ntype: NotificationType, title: &str, body: &str) -> bool {
+        self.deliver(ntype, title, body, None)
+    }
+
+    /// Send a notification; clicking it focuses `session_name`, when given.
+    #[cfg_attr(target_os = "macos", allow(unused_variables))]
+    fn deliver(
+        &mut self,
+        ntype: NotificationType,
+        title: &str,
+        body: &str,
+        session_name: Option<&str>,
+    ) -> bool {
         if !self.config.enabled {
             return false;
         }
 
+        if self.config.quiet_hours.is_quiet(Local::now()) {
+            self.record_missed("quiet hours", title, body);
+            return false;
+        }
+
         if self.is_duplicate(ntype) {
             return false;
         }
 
-        let result = Notification::new()
+        // Point at the log when notifications were held back
+        let body = match self.missed {
+            0 => body.to_string(),
+            n => format!("{}\n(+{} missed, see log)", body, n),
+        };
+
+        let mut notification = Notification::new();
+        notification
             .summary(title)
-            .body(body)
-            .timeout(Timeout::Milliseconds(5000))
-            .show();
+            .body(&body)
+            .timeout(Timeout::Milliseconds(5000));
 
-        if result.is_ok() {
-            self.last_notification = Some((ntype, Instant::now()));
-            true
-        } else {
-            false
+        // Session notifications jump to the session when clicked (needs a
+        // notification server with actions)
+        #[cfg(all(unix, not(target_os = "macos")))]
+        if session_name.is_some() {
+            notification.action("default", "Open");
+        }
+
+        let handle = match notification.show() {
+            Ok(handle) => handle,
+            Err(e) => {
+                log::log(&format!("Failed to show notification: {}", e));
+                return false;
+            }
+        };
+        self.last_notification = Some((ntype, Instant::now()));
+        self.missed = 0;
+
+        #[cfg(all(unix, not(target_os = "macos")))]
+        if let Some(name) = session_name {
+            wait_for_click(handle, name.to_string());
+        }
+        #[cfg(not(all(unix, not(target_os = "macos"))))]
+        let _ = handle;
+        true
+    }
+
+    /// Send a notification for a session unless it is muted.
+    fn send_for_session(
+        &mut self,
+        session_name: &str,
+        ntype: NotificationType,
+        title: &str,
+        body: &str,
+    ) {
+        if self.is_muted(session_name) {
+            self.record_missed("muted", title, body);
+            return;
         }
+        self.deliver(ntype, title, body, Some(session_name));
+    }
+
+    /// Log a suppressed notification for later review
+    fn record_missed(&mut self, reason: &str, title: &str, body: &str) {
+        self.missed += 1;
+        log::log_event(&format!(
+            "Missed notification ({}): {}: {}",
+            reason, title, body
+        ));
     }
 
     /// Send a permission required notification.
     pub fn notify_permission_required(&mut self, session_name: &str, tool_name: &str) {
         let title = "Permission Required";
         let body = format!("{}: {} needs approval", session_name, tool_name);
-        self.send(NotificationType::PermissionRequired, title, &body);
+        self.send_for_session(
+            session_name,
+            NotificationType::PermissionRequired,
+            title,
+            &body,
+        );
     }
 
     /// Send a question notification.
     pub fn notify_question(&mut self, session_name: &str) {
         let title = "Question";
         let body = format!("{}: Agent has a question", session_name);
-        self.send(NotificationType::QuestionAsked, title, &body);
+        self.send_for_session(session_name, NotificationType::QuestionAsked, title, &body);
+    }
+
+    /// Send a notification that the agent ended its turn asking the user something.
+    pub fn notify_input_needed(&mut self, session_name: &str) {
+        let title = "Needs Input";
+        let body = format!("{}: Agent is waiting for your answer", session_name);
+        self.send_for_session(session_name, NotificationType::QuestionAsked, title, &body);
     }
 
     /// Send a session idle notification.
     pub fn notify_idle(&mut self, session_name: &str) {
         let title = "Task Complete";
         let body = format!("{} is now idle", session_name);
-        self.send(NotificationType::SessionIdle, title, &body);
+        self.send_for_session(session_name, NotificationType::SessionIdle, title, &body);
+    }
+
+    /// Send a notification that a Bash command has been running for a long time.
+    pub fn notify_long_command(&mut self, session_name: &str, command: &str, elapsed: Duration) {
+        let title = "Command Still Running";
+        let body = format!(
+            "{}: {} running {}m",
+            session_name,
+            command,
+            elapsed.as_secs() / 60
+        );
+        self.send_for_session(session_name, NotificationType::LongCommand, title, &body);
+    }
+
+    /// Mute or unmute notifications for a session. Returns whether it is now muted.
+    pub fn toggle_mute(&mut self, session_name: &str) -> bool {
+        if self.muted_sessions.remove(session_name) {
+            false
+        } else {
+            self.muted_sessions.insert(session_name.to_string());
+            true
+        }
+    }
+
+    /// Check whether a session's notifications are muted.
+    pub fn is_muted(&self, session_name: &str) -> bool {
+        self.muted_sessions.contains(session_name)
     }
 
     /// Check if this notification type was recently sent.
@@ -119,8 +324,12 @@ impl NotificationManager {
         self.config.idle_delay_secs
     }
 
+    /// Number of sessions with muted notifications.
+    pub fn muted_count(&self) -> usize {
+        self.muted_sessions.len()
+    }
+
     /// Check if notifications are enabled.
-    #[allow(dead_code)] // May be useful for UI display
     pub fn is_enabled(&self) -> bool {
         self.config.enabled
     }
@@ -147,5 +356,41 @@ mod tests {
         assert!(config.enabled);
         assert_eq!(config.idle_delay_secs, 5);
         assert_eq!(config.dedupe_interval_secs, 30);
+        assert_eq!(config.quiet_hours, QuietHours::default());
+    }
+
+    #[test]
+    fn test_quiet_hours_window() {
+        use chrono::TimeZone;
+
+        let quiet = QuietHours {
+            window: QuietHours::parse_window("22:00-08:00"),
+            weekends: false,
+        };
+        // 2024-01-03 is a Wednesday
+        let at = |h, m| Local.with_ymd_and_hms(2024, 1, 3, h, m, 0).unwrap();
+        assert!(quiet.is_quiet(at(23, 30)));
+        assert!(quiet.is_quiet(at(7, 59)));
+        assert!(!quiet.is_quiet(at(8, 0)));
+        assert!(!quiet.is_quiet(at(12, 0)));
+
+        let weekends = QuietHours {
+            window: None,
+            weekends: true,
+        };
+        // 2024-01-06 is a Saturday
+        assert!(weekends.is_quiet(Local.with_ymd_and_hms(2024, 1, 6, 12, 0, 0).unwrap()));
+        assert!(!weekends.is_quiet(at(12, 0)));
+
+        assert!(QuietHours::parse_window("late").is_none());
+    }
+
+    #[test]
+    fn test_toggle_mute() {
+        let mut manager = NotificationManager::new(NotificationConfig::default());
+        assert!(manager.toggle_mute("api"));
+        assert!(manager.is_muted("api"));
+        assert!(!manager.toggle_mute("api"));
+        assert!(!manager.is_muted("api"));
     }
 }
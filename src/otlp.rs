@@ -0,0 +1,345 @@
+//! OpenTelemetry trace export.
+//!
+//! When an `[otlp]` endpoint is configured, every agent turn (from sending a
+//! prompt to the agent's stop reason) is exported as a span, with a child
+//! span per tool call. Spans are posted as OTLP/HTTP JSON in the background
+//! when the turn ends; export failures are logged and never affect sessions.
+//!
+//! ACP does not report token usage, so the turn spans of Claude Code
+//! sessions read it from the session's transcript (as the statistics popup
+//! does): the session's token totals so far, and the tokens the turn used
+//! once an earlier turn's totals are known. Other agents' spans carry none.
+
+use std::collections::HashMap;
+use std::collections::hash_map::RandomState;
+use std::hash::{BuildHasher, Hasher};
+use std::sync::{Arc, Mutex};
+use std::time::{SystemTime, UNIX_EPOCH};
+
+use serde_json::{Value, json};
+
+use crate::acp::StopReason;
+use crate::config::OtlpConfig;
+use crate::http;
+use crate::log;
+use crate::serve;
+use crate::session::{AgentType, Session};
+use crate::usage::{self, SessionTokens};
+
+/// Path OTLP/HTTP collectors accept traces on
+const TRACES_PATH: &str = "/v1/traces";
+
+/// A tool call within a turn
+#[derive(Debug, Clone)]
+struct ToolSpan {
+    span_id: String,
+    tool_call_id: String,
+    name: String,
+    start: SystemTime,
+    end: Option<SystemTime>,
+    failed: bool,
+}
+
+/// An agent turn in progress
+#[derive(Debug, Clone)]
+struct Turn {
+    trace_id: String,
+    span_id: String,
+    start: SystemTime,
+    tools: Vec<ToolSpan>,
+}
+
+/// Check an `http(s)://host[:port][/path]` endpoint, adding `default_path`
+/// when it names none
+pub fn parse_endpoint(endpoint: &str, default_path: &str) -> Option<String> {
+    let endpoint = endpoint.trim().trim_end_matches('/');
+    if !http::is_http_url(endpoint) {
+        return None;
+    }
+    let has_path = endpoint
+        .split_once("://")
+        .is_some_and(|(_, rest)| rest.contains('/'));
+    Some(if has_path {
+        endpoint.to_string()
+    } else {
+        format!("{}{}", endpoint, default_path)
+    })
+}
+
+/// Exports agent turns as OTLP spans.
+#[derive(Debug)]
+pub struct Exporter {
+    url: String,
+    service_name: String,
+    /// Open turns by local session ID
+    turns: HashMap<String, Turn>,
+    /// Token totals of each session after its last exported turn
+    tokens: Arc<Mutex<HashMap<String, u64>>>,
+}
+
+impl Exporter {
+    /// Create an exporter, or None (with a logged reason) for an unusable endpoint
+    pub fn new(config: &OtlpConfig) -> Option<Self> {
+        let Some(url) = parse_endpoint(&config.endpoint, TRACES_PATH) else {
+            log::log(&format!(
+                "OTLP export disabled: endpoint must be http(s)://host[:port][/path], got {}",
+                config.endpoint
+            ));
+            return None;
+        };
+        Some(Self {
+            url,
+            service_name: config.service_name.clone(),
+            turns: HashMap::new(),
+            tokens: Arc::default(),
+        })
+    }
+
+    /// Start a turn span when a prompt is sent
+    pub fn turn_started(&mut self, session_id: &str) {
+        self.turns.insert(
+            session_id.to_string(),
+            Turn {
+                trace_id: random_hex(16),
+                span_id: random_hex(8),
+                start: SystemTime::now(),
+                tools: vec![],
+            },
+        );
+    }
+
+    /// Start a tool span (repeated tool call announcements are ignored)
+    pub fn tool_started(&mut self, session_id: &str, tool_call_id: &str, name: &str) {
+        if !self.turns.contains_key(session_id) {
+            // Agent activity without a prompt from amux (e.g. a resumed turn)
+            self.turn_started(session_id);
+        }
+        let Some(turn) = self.turns.get_mut(session_id) else {
+            return;
+        };
+        if turn.tools.iter().any(|t| t.tool_call_id == tool_call_id) {
+            return;
+        }
+        turn.tools.push(ToolSpan {
+            span_id: random_hex(8),
+            tool_call_id: tool_call_id.to_string(),
+            name: name.to_string(),
+            start: SystemTime::now(),
+            end: None,
+            failed: false,
+        });
+    }
+
+    /// End a tool span
+    pub fn tool_finished(&mut self, session_id: &str, tool_call_id: &str, failed: bool) {
+        if let Some(tool) = self.turns.get_mut(session_id).and_then(|turn| {
+            turn.tools
+                .iter_mut()
+                .find(|t| t.tool_call_id == tool_call_id)
+        }) && tool.end.is_none()
+        {
+            tool.end = Some(SystemTime::now());
+            tool.failed = failed;
+        }
+    }
+
+    /// End the session's turn and export its spans in the background
+    pub fn turn_finished(&mut self, session: &Session, stop_reason: &StopReason) {
+        let Some(turn) = self.turns.remove(&session.id) else {
+            return;
+        };
+        let mut payload = self.payload(session, &turn, stop_reason, SystemTime::now());
+        let url = self.url.clone();
+        let id = session.id.clone();
+        let acp_session_id = session
+            .acp_session_id
+            .clone()
+            .filter(|_| session.agent_type == AgentType::ClaudeCode);
+        let totals = self.tokens.clone();
+        tokio::spawn(async move {
+            if let Some(acp_session_id) = acp_session_id
+                && let Ok(Some(tokens)) =
+                    tokio::task::spawn_blocking(move || session_tokens(&acp_session_id)).await
+            {
+                let previous = totals
+                    .lock()
+                    .ok()
+                    .and_then(|mut totals| totals.insert(id, tokens.total()));
+                add_token_attributes(&mut payload, &tokens, previous);
+            }
+            if let Err(e) = http::post_json(&url, &payload.to_string(), http::TIMEOUT).await {
+                log::log(&format!("OTLP export failed: {}", e));
+            }
+        });
+    }
+
+    /// Build the OTLP/JSON request body for a finished turn
+    fn payload(
+        &self,
+        session: &Session,
+        turn: &Turn,
+        stop_reason: &StopReason,
+        end: SystemTime,
+    ) -> Value {
+        let failed_tools = turn.tools.iter().filter(|t| t.failed).count();
+        let mut spans = vec![json!({
+            "traceId": turn.trace_id,
+            "spanId": turn.span_id,
+            "name": "agent.turn",
+            "kind": 1,
+            "startTimeUnixNano": unix_nanos(turn.start),
+            "endTimeUnixNano": unix_nanos(end),
+            "attributes": [
+                attribute("session.name", json!({ "stringValue": session.name })),
+                attribute(
+                    "agent.name",
+                    json!({ "stringValue": session.agent_type.display_name() }),
+                ),
+                attribute(
+                    "session.cwd",
+                    json!({ "stringValue": session.cwd.display().to_string() }),
+                ),
+                attribute(
+                    "turn.stop_reason",
+                    json!({ "stringValue": stop_reason.description() }),
+                ),
+                attribute("turn.tool_calls", json!({ "intValue": turn.tools.len().to_string() })),
+                attribute("turn.failed_tool_calls", json!({ "intValue": failed_tools.to_string() })),
+            ],
+            "status": { "code": if *stop_reason == StopReason::EndTurn { 1 } else { 2 } },
+        })];
+
+        for tool in &turn.tools {
+            spans.push(json!({
+                "traceId": turn.trace_id,
+                "spanId": tool.span_id,
+                "parentSpanId": turn.span_id,
+                "name": "tool.call",
+                "kind": 1,
+                "startTimeUnixNano": unix_nanos(tool.start),
+                // Tools still running when the turn ends close with it
+                "endTimeUnixNano": unix_nanos(tool.end.unwrap_or(end)),
+                "attributes": [
+                    attribute("tool.name", json!({ "stringValue": tool.name })),
+                    attribute("tool.call_id", json!({ "stringValue": tool.tool_call_id })),
+                ],
+                "status": { "code": if tool.failed { 2 } else { 1 } },
+            }));
+        }
+
+        json!({
+            "resourceSpans": [{
+                "resource": {
+                    "attributes": [
+                        attribute("service.name", json!({ "stringValue": self.service_name })),
+                    ],
+                },
+                "scopeSpans": [{
+                    "scope": { "name": "amux", "version": env!("CARGO_PKG_VERSION") },
+                    "spans": spans,
+                }],
+            }],
+        })
+    }
+}
+
+/// Token totals of a Claude Code session from its transcript. Blocking.
+fn session_tokens(acp_session_id: &str) -> Option<SessionTokens> {
+    let path = serve::find_session_file(&usage::projects_dir()?, acp_session_id)?;
+    Some(usage::session_tokens(&path))
+}
+
+/// Add a session's token totals to the turn span of `payload`, and the
+/// tokens of the turn when the totals after the previous one are known
+fn add_token_attributes(payload: &mut Value, tokens: &SessionTokens, previous: Option<u64>) {
+    let Some(attributes) = payload
+        .pointer_mut("/resourceSpans/0/scopeSpans/0/spans/0/attributes")
+        .and_then(Value::as_array_mut)
+    else {
+        return;
+    };
+    let int = |n: u64| json!({ "intValue": n.to_string() });
+    attributes.push(attribute("session.tokens", int(tokens.total())));
+    attributes.push(attribute("session.tokens.subagents", int(tokens.subagents)));
+    if let Some(previous) = previous {
+        attributes.push(attribute(
+            "turn.tokens",
+            int(tokens.total().saturating_sub(previous)),
+        ));
+    }
+}
+
+/// OTLP key/value attribute
+fn attribute(key: &str, value: Value) -> Value {
+    json!({ "key": key, "value": value })
+}
+
+/// Nanoseconds since the epoch as a string (OTLP/JSON encodes 64-bit ints as strings)
+fn unix_nanos(time: SystemTime) -> String {
+    time.duration_since(UNIX_EPOCH)
+        .map(|d| d.as_nanos())
+        .unwrap_or(0)
+        .to_string()
+}
+
+/// Random lowercase hex ID of `bytes` bytes (trace IDs are 16, span IDs 8)
+pub fn random_hex(bytes: usize) -> String {
+    let mut id = String::new();
+    while id.len() < bytes * 2 {
+        // Each RandomState is freshly keyed, so hashing the time gives unrelated values
+        let mut hasher = RandomState::new().build_hasher();
+        hasher.write_u128(unix_nanos(SystemTime::now()).parse().unwrap_or(0));
+        id.push_str(&format!("{:016x}", hasher.finish()));
+    }
+    id.truncate(bytes * 2);
+    id
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_endpoint() {
+        assert_eq!(
+            parse_endpoint("http://localhost:4318", TRACES_PATH).as_deref(),
+            Some("http://localhost:4318/v1/traces")
+        );
+        assert_eq!(
+            parse_endpoint("https://collector/otel/v1/traces/", TRACES_PATH).as_deref(),
+            Some("https://collector/otel/v1/traces")
+        );
+        assert_eq!(parse_endpoint("collector:4318", TRACES_PATH), None);
+        assert_eq!(parse_endpoint("http://", TRACES_PATH), None);
+    }
+
+    #[test]
+    fn test_token_attributes() {
+        let mut payload = json!({
+            "resourceSpans": [{ "scopeSpans": [{ "spans": [{ "attributes": [] }] }] }],
+        });
+        let tokens = SessionTokens {
+            agent: 1200,
+            subagents: 300,
+            subagent_count: 1,
+        };
+        add_token_attributes(&mut payload, &tokens, Some(1000));
+        let attributes = &payload["resourceSpans"][0]["scopeSpans"][0]["spans"][0]["attributes"];
+        assert_eq!(
+            attributes[0],
+            attribute("session.tokens", json!({ "intValue": "1500" }))
+        );
+        assert_eq!(
+            attributes[2],
+            attribute("turn.tokens", json!({ "intValue": "500" }))
+        );
+    }
+
+    #[test]
+    fn test_random_hex_ids() {
+        let trace_id = random_hex(16);
+        assert_eq!(trace_id.len(), 32);
+        assert!(trace_id.chars().all(|c| c.is_ascii_hexdigit()));
+        assert_ne!(random_hex(8), random_hex(8));
+    }
+}
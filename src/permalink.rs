@@ -0,0 +1,176 @@
+//! Message permalinks.
+//!
+//! A permalink names one message of a session as `amux://<session-id>/<n>`,
+//! where the session ID is the agent's session ID and `n` counts the
+//! session's prompts and agent replies from 1. Output is only ever appended,
+//! and messages moved to disk for the memory budget still count, so the
+//! number of a message never changes. Transcripts mark each message
+//! with an invisible `<a id="m<n>"></a>` anchor so `amux open` can find it
+//! in an archived session.
+
+use std::fmt;
+
+use crate::session::Session;
+
+/// URI scheme of permalinks
+const SCHEME: &str = "amux://";
+
+/// A reference to one message of a session
+#[derive(Debug, Clone, PartialEq)]
+pub struct Permalink {
+    /// Agent-side session ID
+    pub session_id: String,
+    /// Message number, counting from 1
+    pub message: usize,
+}
+
+impl Permalink {
+    /// Permalink for the message at `index` in the session's output, if the
+    /// session has an agent session ID and the line is a message
+    pub fn for_message(session: &Session, index: usize) -> Option<Self> {
+        let session_id = session.acp_session_id.clone()?;
+        if !session.is_taggable(index) {
+            return None;
+        }
+        Some(Self {
+            session_id,
+            message: message_number(session, index),
+        })
+    }
+
+    /// Parse `amux://<session-id>/<n>`
+    pub fn parse(text: &str) -> Option<Self> {
+        let rest = text.trim().strip_prefix(SCHEME)?;
+        let (session_id, message) = rest.rsplit_once('/')?;
+        let message: usize = message.parse().ok()?;
+        if session_id.is_empty() || message == 0 {
+            return None;
+        }
+        Some(Self {
+            session_id: session_id.to_string(),
+            message,
+        })
+    }
+}
+
+impl fmt::Display for Permalink {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "{}{}/{}", SCHEME, self.session_id, self.message)
+    }
+}
+
+/// Number of the message at `index`: messages up to and including it,
+/// counting those trimmed from the output
+pub fn message_number(session: &Session, index: usize) -> usize {
+    session.trimmed_messages + (0..=index).filter(|&i| session.is_taggable(i)).count()
+}
+
+/// Anchor line placed before message `n` in transcripts
+pub fn anchor(message: usize) -> String {
+    format!("<a id=\"m{}\"></a>", message)
+}
+
+/// Message number of an anchor line
+pub fn parse_anchor(line: &str) -> Option<usize> {
+    line.strip_prefix("<a id=\"m")?
+        .strip_suffix("\"></a>")?
+        .parse()
+        .ok()
+}
+
+/// Index in the session's output of message `n`, if it is still in memory
+pub fn message_index(session: &Session, message: usize) -> Option<usize> {
+    let nth = message.checked_sub(session.trimmed_messages + 1)?;
+    (0..session.output.len())
+        .filter(|&i| session.is_taggable(i))
+        .nth(nth)
+}
+
+/// Extract message `n` from a transcript, up to the next message or section
+pub fn find_in_transcript(transcript: &str, message: usize) -> Option<String> {
+    let start_anchor = anchor(message);
+    let mut lines = transcript.lines().skip_while(|line| *line != start_anchor);
+    lines.next()?;
+    let text: Vec<&str> = lines
+        .take_while(|line| !line.starts_with("<a id=\"m") && !line.starts_with("## "))
+        .collect();
+    Some(text.join("\n").trim().to_string())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_round_trip() {
+        let link = Permalink {
+            session_id: "0b5c-42".to_string(),
+            message: 7,
+        };
+        assert_eq!(link.to_string(), "amux://0b5c-42/7");
+        assert_eq!(Permalink::parse("amux://0b5c-42/7"), Some(link));
+        assert_eq!(Permalink::parse("amux://0b5c-42/0"), None);
+        assert_eq!(Permalink::parse("amux:///3"), None);
+        assert_eq!(Permalink::parse("https://0b5c-42/3"), None);
+    }
+
+    #[test]
+    fn test_find_in_transcript() {
+        let transcript = format!(
+            "# s (Claude Code)\n\n{}\n> fix it\n{}\nDone.\n- **Tool:** Edit\n\n## Plan history\n",
+            anchor(1),
+            anchor(2)
+        );
+        assert_eq!(
+            find_in_transcript(&transcript, 1),
+            Some("> fix it".to_string())
+        );
+        assert_eq!(
+            find_in_transcript(&transcript, 2),
+            Some("Done.\n- **Tool:** Edit".to_string())
+        );
+        assert_eq!(find_in_transcript(&transcript, 3), None);
+        assert_eq!(parse_anchor(&anchor(12)), Some(12));
+        assert_eq!(parse_anchor("> fix it"), None);
+    }
+
+    #[test]
+    fn test_numbers_survive_trim() {
+        use crate::session::{AgentType, MessageTag, OutputType};
+
+        let mut session = Session::mock("permalink-trim", "s", AgentType::ClaudeCode, "main");
+        for (content, line_type) in [
+            ("fix it", OutputType::UserInput),
+            ("On it.", OutputType::Text),
+            ("ok", OutputType::ToolOutput),
+            ("and the tests", OutputType::UserInput),
+            ("Done.", OutputType::Text),
+        ] {
+            session.add_output(content.to_string(), line_type);
+        }
+        session.toggle_tag(4, MessageTag::Decision);
+        assert_eq!(message_number(&session, 4), 4);
+
+        assert_eq!(session.trim_output(2).unwrap(), 3);
+        assert_eq!(session.output.len(), 2);
+        assert_eq!(message_number(&session, 1), 4);
+        assert_eq!(message_index(&session, 4), Some(1));
+        assert_eq!(message_index(&session, 2), None);
+        assert_eq!(session.message_tags.keys().collect::<Vec<_>>(), [&1]);
+
+        // Exports get the trimmed lines back, numbered the same
+        let full = session.with_spilled_output().unwrap();
+        session.remove_spill_file();
+        assert_eq!(full.output.len(), 5);
+        assert_eq!(full.output[0].content, "fix it");
+        assert_eq!(message_number(&full, 4), 4);
+        assert_eq!(full.message_tags.keys().collect::<Vec<_>>(), [&4]);
+        assert!(crate::transcript::to_markdown(&full).contains(&anchor(4)));
+
+        // Tagged messages stay in memory
+        let mut tagged = full.clone();
+        tagged.toggle_tag(1, MessageTag::Decision);
+        assert_eq!(tagged.trim_output(2).unwrap(), 1);
+        tagged.remove_spill_file();
+    }
+}
@@ -0,0 +1,149 @@
+//! Plan history kept across restarts.
+//!
+//! The timeline of plan changes (`p`) is stored in
+//! `~/.amux/plan_history.json`, keyed by the agent-side session ID, so a
+//! resumed session shows how its plan evolved before the restart.
+
+use std::collections::BTreeMap;
+use std::path::{Path, PathBuf};
+
+use chrono::{DateTime, Local};
+use serde::{Deserialize, Serialize};
+
+use crate::session::{PlanChange, PlanChangeKind};
+
+/// Changes kept per session; older ones are dropped
+const MAX_CHANGES: usize = 500;
+
+/// A plan change as stored in the file
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+struct StoredChange {
+    /// RFC 3339 time of the change
+    at: String,
+    kind: PlanChangeKind,
+    content: String,
+}
+
+impl From<&PlanChange> for StoredChange {
+    fn from(change: &PlanChange) -> Self {
+        Self {
+            at: change.at.to_rfc3339(),
+            kind: change.kind,
+            content: change.content.clone(),
+        }
+    }
+}
+
+impl StoredChange {
+    fn to_change(&self) -> Option<PlanChange> {
+        Some(PlanChange {
+            at: DateTime::parse_from_rfc3339(&self.at)
+                .ok()?
+                .with_timezone(&Local),
+            kind: self.kind,
+            content: self.content.clone(),
+        })
+    }
+}
+
+/// Path of the plan history file
+pub fn plan_history_path() -> PathBuf {
+    dirs::home_dir()
+        .unwrap_or_else(|| PathBuf::from("."))
+        .join(".amux")
+        .join("plan_history.json")
+}
+
+fn read_all(path: &Path) -> BTreeMap<String, Vec<StoredChange>> {
+    std::fs::read_to_string(path)
+        .ok()
+        .and_then(|text| serde_json::from_str(&text).ok())
+        .unwrap_or_default()
+}
+
+/// Plan history stored for an agent session, oldest change first
+pub fn load(session_id: &str) -> Vec<PlanChange> {
+    load_from(&plan_history_path(), session_id)
+}
+
+fn load_from(path: &Path, session_id: &str) -> Vec<PlanChange> {
+    read_all(path)
+        .remove(session_id)
+        .unwrap_or_default()
+        .iter()
+        .filter_map(StoredChange::to_change)
+        .collect()
+}
+
+/// Add changes to an agent session's stored plan history
+pub fn append(session_id: &str, changes: &[PlanChange]) -> std::io::Result<()> {
+    append_in(&plan_history_path(), session_id, changes)
+}
+
+fn append_in(path: &Path, session_id: &str, changes: &[PlanChange]) -> std::io::Result<()> {
+    if changes.is_empty() {
+        return Ok(());
+    }
+    let mut all = read_all(path);
+    let stored = all.entry(session_id.to_string()).or_default();
+    stored.extend(changes.iter().map(StoredChange::from));
+    let excess = stored.len().saturating_sub(MAX_CHANGES);
+    stored.drain(..excess);
+    if let Some(dir) = path.parent() {
+        std::fs::create_dir_all(dir)?;
+    }
+    std::fs::write(path, serde_json::to_string_pretty(&all)?)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn change(kind: PlanChangeKind, content: &str) -> PlanChange {
+        PlanChange {
+            at: Local::now(),
+            kind,
+            content: content.to_string(),
+        }
+    }
+
+    #[test]
+    fn test_append_and_load() {
+        let dir = std::env::temp_dir().join(format!("amux-plan-history-{}", std::process::id()));
+        let path = dir.join("plan_history.json");
+
+        // Nothing is stored without a file
+        assert!(load_from(&path, "abc").is_empty());
+
+        append_in(
+            &path,
+            "abc",
+            &[change(PlanChangeKind::Added, "Write tests")],
+        )
+        .unwrap();
+        append_in(
+            &path,
+            "abc",
+            &[change(PlanChangeKind::Started, "Write tests")],
+        )
+        .unwrap();
+        append_in(&path, "def", &[change(PlanChangeKind::Added, "Other")]).unwrap();
+        let loaded = load_from(&path, "abc");
+        assert_eq!(loaded.len(), 2);
+        assert_eq!(loaded[0].kind, PlanChangeKind::Added);
+        assert_eq!(loaded[1].kind, PlanChangeKind::Started);
+        assert_eq!(loaded[1].content, "Write tests");
+        assert_eq!(load_from(&path, "def").len(), 1);
+
+        // Only the newest changes are kept
+        let many: Vec<_> = (0..MAX_CHANGES)
+            .map(|i| change(PlanChangeKind::Completed, &i.to_string()))
+            .collect();
+        append_in(&path, "abc", &many).unwrap();
+        let loaded = load_from(&path, "abc");
+        assert_eq!(loaded.len(), MAX_CHANGES);
+        assert_eq!(loaded[0].content, "0");
+
+        std::fs::remove_dir_all(&dir).unwrap();
+    }
+}
@@ -0,0 +1,181 @@
+//! What token usage would cost at API prices.
+//!
+//! Subscription (Pro/Max) users don't pay per token, but many track what
+//! their usage would have cost at API rates to judge their plan tier. Prices
+//! are looked up by model name: built-in list prices for Claude's model
+//! families, which `[pricing]` in the config can override or extend.
+
+use std::collections::BTreeMap;
+
+use serde_json::Value;
+
+/// List prices in USD per million input and output tokens, for models whose
+/// name contains the key (the first match wins)
+const BUILTIN_PRICES: &[(&str, f64, f64)] = &[
+    ("opus-4-5", 5.0, 25.0),
+    ("opus-4-6", 5.0, 25.0),
+    ("opus", 15.0, 75.0),
+    ("sonnet", 3.0, 15.0),
+    ("haiku-4", 1.0, 5.0),
+    ("haiku", 0.8, 4.0),
+];
+
+/// Writing to the prompt cache costs this much more than input
+const CACHE_WRITE_FACTOR: f64 = 1.25;
+
+/// Reading from the prompt cache costs this fraction of input
+const CACHE_READ_FACTOR: f64 = 0.1;
+
+/// Tokens of a response (or a total), by how the API bills them
+#[derive(Debug, Clone, Copy, Default, PartialEq)]
+pub struct TokenCounts {
+    pub input: u64,
+    pub output: u64,
+    pub cache_write: u64,
+    pub cache_read: u64,
+}
+
+impl TokenCounts {
+    /// Counts of a response's `usage` object in a session file
+    pub fn from_usage(usage: &Value) -> Self {
+        let field = |name: &str| usage.get(name).and_then(Value::as_u64).unwrap_or(0);
+        Self {
+            input: field("input_tokens"),
+            output: field("output_tokens"),
+            cache_write: field("cache_creation_input_tokens"),
+            cache_read: field("cache_read_input_tokens"),
+        }
+    }
+
+    pub fn add(&mut self, other: &TokenCounts) {
+        self.input += other.input;
+        self.output += other.output;
+        self.cache_write += other.cache_write;
+        self.cache_read += other.cache_read;
+    }
+
+    pub fn total(&self) -> u64 {
+        self.input + self.output + self.cache_write + self.cache_read
+    }
+}
+
+/// USD per million tokens of each kind
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub struct Price {
+    pub input: f64,
+    pub output: f64,
+    pub cache_write: f64,
+    pub cache_read: f64,
+}
+
+impl Price {
+    /// Price with the cache priced relative to input, as the API does
+    pub fn new(input: f64, output: f64) -> Self {
+        Self {
+            input,
+            output,
+            cache_write: input * CACHE_WRITE_FACTOR,
+            cache_read: input * CACHE_READ_FACTOR,
+        }
+    }
+
+    /// What `tokens` cost at this price, in USD
+    pub fn cost(&self, tokens: &TokenCounts) -> f64 {
+        (tokens.input as f64 * self.input
+            + tokens.output as f64 * self.output
+            + tokens.cache_write as f64 * self.cache_write
+            + tokens.cache_read as f64 * self.cache_read)
+            / 1_000_000.0
+    }
+}
+
+/// Prices to value usage with, and what the subscription costs
+#[derive(Debug, Clone, Default)]
+pub struct Pricing {
+    /// Prices for models whose name contains the key, checked before the
+    /// built-in ones
+    pub models: Vec<(String, Price)>,
+    /// What the subscription costs per month, to compare with
+    pub plan_usd_per_month: Option<f64>,
+}
+
+impl Pricing {
+    /// Price of a model, None for models without a known price
+    pub fn price(&self, model: &str) -> Option<Price> {
+        let configured = self
+            .models
+            .iter()
+            .find(|(key, _)| model.contains(key.as_str()))
+            .map(|(_, price)| *price);
+        configured.or_else(|| {
+            BUILTIN_PRICES
+                .iter()
+                .find(|(key, _, _)| model.contains(key))
+                .map(|(_, input, output)| Price::new(*input, *output))
+        })
+    }
+
+    /// What the tokens of each model would cost at API prices, and the
+    /// tokens of models without a known price (left out of the cost)
+    pub fn cost(&self, tokens_by_model: &BTreeMap<String, TokenCounts>) -> (f64, u64) {
+        let mut cost = 0.0;
+        let mut unpriced = 0;
+        for (model, tokens) in tokens_by_model {
+            match self.price(model) {
+                Some(price) => cost += price.cost(tokens),
+                None => unpriced += tokens.total(),
+            }
+        }
+        (cost, unpriced)
+    }
+
+    /// What the subscription costs over `days` days
+    pub fn plan_cost(&self, days: i64) -> Option<f64> {
+        self.plan_usd_per_month
+            .map(|monthly| monthly * 12.0 / 365.0 * days as f64)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_cost() {
+        let usage = serde_json::json!({
+            "input_tokens": 1_000_000,
+            "output_tokens": 100_000,
+            "cache_creation_input_tokens": 200_000,
+            "cache_read_input_tokens": 2_000_000
+        });
+        let tokens = TokenCounts::from_usage(&usage);
+        assert_eq!(tokens.total(), 3_300_000);
+
+        // 3 + 1.5 + 0.75 + 0.6
+        let pricing = Pricing::default();
+        let sonnet = pricing.price("claude-sonnet-4-20250514").unwrap();
+        assert!((sonnet.cost(&tokens) - 5.85).abs() < 1e-9);
+        assert_eq!(
+            pricing.price("claude-opus-4-5-20251101"),
+            Some(Price::new(5.0, 25.0))
+        );
+        assert_eq!(
+            pricing.price("claude-opus-4-1-20250805"),
+            Some(Price::new(15.0, 75.0))
+        );
+
+        // Configured prices come first; unknown models aren't priced
+        let pricing = Pricing {
+            models: vec![("sonnet".to_string(), Price::new(1.0, 1.0))],
+            plan_usd_per_month: Some(200.0),
+        };
+        let by_model = BTreeMap::from([
+            ("claude-sonnet-4".to_string(), tokens),
+            ("gpt-5".to_string(), tokens),
+        ]);
+        let (cost, unpriced) = pricing.cost(&by_model);
+        assert!((cost - (1.0 + 0.1 + 0.25 + 0.2)).abs() < 1e-9);
+        assert_eq!(unpriced, 3_300_000);
+        assert!((pricing.plan_cost(365).unwrap() - 2400.0).abs() < 1e-9);
+    }
+}
@@ -0,0 +1,314 @@
+//! Processes running under an agent (the process tree, `X`).
+//!
+//! The agent runs its Bash tool's commands as child processes, so the tree
+//! below the agent's process shows what it's executing right now (node,
+//! shells, test runners) and how busy each one is. Processes are listed with
+//! `ps`, which takes the same arguments on Linux and macOS.
+//!
+//! The same tree tells whether an agent is talking to its API: a working
+//! agent with an established TCP connection to another host is streaming or
+//! waiting on the model, one without is stuck locally. Connections are read
+//! from `/proc` on Linux and from `lsof` on macOS; elsewhere none are found.
+
+use std::collections::HashSet;
+use std::path::Path;
+use std::process::Command;
+
+/// A running process
+#[derive(Debug, Clone, PartialEq)]
+pub struct Process {
+    pub pid: u32,
+    pub ppid: u32,
+    /// CPU usage in percent of one core, as `ps` reports it
+    pub cpu: f32,
+    /// Command line
+    pub command: String,
+}
+
+/// A process in the tree below an agent
+#[derive(Debug, Clone, PartialEq)]
+pub struct TreeRow {
+    pub process: Process,
+    /// 0 for the agent itself
+    pub depth: usize,
+    /// Number of direct children (shown even when collapsed)
+    pub children: usize,
+}
+
+/// List all processes. Blocking.
+pub fn list() -> Result<Vec<Process>, String> {
+    let output = Command::new("ps")
+        .args(["-axo", "pid=,ppid=,pcpu=,args="])
+        .output()
+        .map_err(|e| format!("failed to run ps: {}", e))?;
+    if !output.status.success() {
+        return Err(format!("ps exited with {}", output.status));
+    }
+    Ok(parse(&String::from_utf8_lossy(&output.stdout)))
+}
+
+/// Parse `ps -o pid=,ppid=,pcpu=,args=` output
+fn parse(output: &str) -> Vec<Process> {
+    output
+        .lines()
+        .filter_map(|line| {
+            let mut fields = line.split_whitespace();
+            let pid = fields.next()?.parse().ok()?;
+            let ppid = fields.next()?.parse().ok()?;
+            // Some locales print a decimal comma
+            let cpu = fields.next()?.replace(',', ".").parse().ok()?;
+            let command = fields.collect::<Vec<_>>().join(" ");
+            Some(Process {
+                pid,
+                ppid,
+                cpu,
+                command,
+            })
+        })
+        .collect()
+}
+
+/// The process `root` and its descendants, depth-first in PID order.
+/// Descendants of PIDs in `collapsed` are left out.
+pub fn tree(processes: &[Process], root: u32, collapsed: &HashSet<u32>) -> Vec<TreeRow> {
+    let mut rows = vec![];
+    if let Some(process) = processes.iter().find(|p| p.pid == root) {
+        add_subtree(processes, process, 0, collapsed, &mut rows);
+    }
+    rows
+}
+
+fn add_subtree(
+    processes: &[Process],
+    process: &Process,
+    depth: usize,
+    collapsed: &HashSet<u32>,
+    rows: &mut Vec<TreeRow>,
+) {
+    let mut children: Vec<&Process> = processes
+        .iter()
+        .filter(|p| p.ppid == process.pid && p.pid != process.pid)
+        .collect();
+    children.sort_by_key(|p| p.pid);
+    rows.push(TreeRow {
+        process: process.clone(),
+        depth,
+        children: children.len(),
+    });
+    if collapsed.contains(&process.pid) {
+        return;
+    }
+    for child in children {
+        add_subtree(processes, child, depth + 1, collapsed, rows);
+    }
+}
+
+/// Total CPU of a tree's processes, in percent of one core
+pub fn total_cpu(rows: &[TreeRow]) -> f32 {
+    rows.iter().map(|row| row.process.cpu).sum()
+}
+
+/// The agents (by PID) among `roots` that have an established TCP
+/// connection to another host, themselves or in a child process. Blocking.
+pub fn networking(roots: &[u32]) -> HashSet<u32> {
+    if roots.is_empty() {
+        return HashSet::new();
+    }
+    let Ok(processes) = list() else {
+        return HashSet::new();
+    };
+    let trees: Vec<(u32, Vec<u32>)> = roots
+        .iter()
+        .map(|&root| {
+            let pids = tree(&processes, root, &HashSet::new())
+                .into_iter()
+                .map(|row| row.process.pid)
+                .collect();
+            (root, pids)
+        })
+        .collect();
+    let all: Vec<u32> = trees.iter().flat_map(|(_, pids)| pids.clone()).collect();
+    let connected = if cfg!(target_os = "linux") {
+        proc_connected(&all)
+    } else if cfg!(target_os = "macos") {
+        lsof_connected(&all)
+    } else {
+        HashSet::new()
+    };
+    trees
+        .into_iter()
+        .filter(|(_, pids)| pids.iter().any(|pid| connected.contains(pid)))
+        .map(|(root, _)| root)
+        .collect()
+}
+
+/// PIDs with an established remote connection, from `/proc` (Linux)
+fn proc_connected(pids: &[u32]) -> HashSet<u32> {
+    let mut remote = HashSet::new();
+    for table in ["/proc/net/tcp", "/proc/net/tcp6"] {
+        if let Ok(text) = std::fs::read_to_string(table) {
+            remote.extend(established_inodes(&text));
+        }
+    }
+    pids.iter()
+        .copied()
+        .filter(|pid| {
+            socket_inodes(&Path::new("/proc").join(pid.to_string()).join("fd"))
+                .iter()
+                .any(|inode| remote.contains(inode))
+        })
+        .collect()
+}
+
+/// Inodes of the sockets a process has open (its `/proc/<pid>/fd` links
+/// read `socket:[<inode>]`)
+fn socket_inodes(fd_dir: &Path) -> Vec<u64> {
+    let Ok(entries) = std::fs::read_dir(fd_dir) else {
+        return vec![];
+    };
+    entries
+        .flatten()
+        .filter_map(|entry| std::fs::read_link(entry.path()).ok())
+        .filter_map(|target| {
+            target
+                .to_str()?
+                .strip_prefix("socket:[")?
+                .strip_suffix(']')?
+                .parse()
+                .ok()
+        })
+        .collect()
+}
+
+/// Inodes of established connections to other hosts in a `/proc/net/tcp`
+/// or `/proc/net/tcp6` table
+fn established_inodes(table: &str) -> HashSet<u64> {
+    table
+        .lines()
+        .skip(1)
+        .filter_map(|line| {
+            let fields: Vec<&str> = line.split_whitespace().collect();
+            let remote = fields.get(2)?;
+            // State 01 is ESTABLISHED
+            if *fields.get(3)? != "01" || is_loopback_hex(remote.split(':').next()?) {
+                return None;
+            }
+            fields.get(9)?.parse().ok()
+        })
+        .collect()
+}
+
+/// Whether a hex address from `/proc/net/tcp{,6}` is loopback. IPv4
+/// addresses are little-endian, so 127.0.0.1 reads `0100007F`.
+fn is_loopback_hex(address: &str) -> bool {
+    match address.len() {
+        8 => address.ends_with("7F"),
+        // ::1, or 127.x mapped into IPv6 (::ffff:127.0.0.1)
+        32 => {
+            address == "00000000000000000000000001000000"
+                || (address.starts_with("0000000000000000FFFF0000") && address.ends_with("7F"))
+        }
+        _ => false,
+    }
+}
+
+/// PIDs with an established remote connection, from `lsof` (macOS)
+fn lsof_connected(pids: &[u32]) -> HashSet<u32> {
+    let pid_list = pids
+        .iter()
+        .map(u32::to_string)
+        .collect::<Vec<_>>()
+        .join(",");
+    match Command::new("lsof")
+        .args(["-nP", "-a", "-iTCP", "-sTCP:ESTABLISHED", "-Fpn", "-p"])
+        .arg(pid_list)
+        .output()
+    {
+        Ok(output) => parse_lsof(&String::from_utf8_lossy(&output.stdout)),
+        Err(_) => HashSet::new(),
+    }
+}
+
+/// Parse `lsof -Fpn` output: a `p<pid>` line per process followed by an
+/// `n<local>-><remote>` line per connection
+fn parse_lsof(output: &str) -> HashSet<u32> {
+    let mut connected = HashSet::new();
+    let mut pid = None;
+    for line in output.lines() {
+        if let Some(value) = line.strip_prefix('p') {
+            pid = value.parse::<u32>().ok();
+        } else if let Some(name) = line.strip_prefix('n')
+            && let Some((_, remote)) = name.split_once("->")
+            && !(remote.starts_with("127.") || remote.starts_with("[::1]"))
+            && let Some(pid) = pid
+        {
+            connected.insert(pid);
+        }
+    }
+    connected
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const PS: &str = "    1     0   0.0 /sbin/init
+  100     1   2.5 node /usr/bin/claude-code-acp
+  130   100   0.0 /bin/bash -c npm test
+  131   130  97,3 node jest --runInBand
+  120   100   0.1 /bin/zsh
+  200     1   1.0 vim
+";
+
+    #[test]
+    fn test_parse() {
+        let processes = parse(PS);
+        assert_eq!(processes.len(), 6);
+        assert_eq!(
+            processes[3],
+            Process {
+                pid: 131,
+                ppid: 130,
+                cpu: 97.3,
+                command: "node jest --runInBand".to_string(),
+            }
+        );
+    }
+
+    #[test]
+    fn test_tree() {
+        let processes = parse(PS);
+        let rows = tree(&processes, 100, &HashSet::new());
+        let shape: Vec<(u32, usize, usize)> = rows
+            .iter()
+            .map(|row| (row.process.pid, row.depth, row.children))
+            .collect();
+        assert_eq!(shape, [(100, 0, 2), (120, 1, 0), (130, 1, 1), (131, 2, 0)]);
+        assert!((total_cpu(&rows) - 99.9).abs() < 0.01);
+
+        let rows = tree(&processes, 100, &HashSet::from([130]));
+        assert_eq!(rows.len(), 3);
+        assert_eq!(rows[2].children, 1);
+
+        assert!(tree(&processes, 999, &HashSet::new()).is_empty());
+    }
+
+    #[test]
+    fn test_established_inodes() {
+        let table = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1001 1 0 100 0 0 10 0
+   1: 0A00020F:D2A4 2254CE68:01BB 01 00000000:00000000 02:000A1F4B 00000000  1000        0 1002 2 0 20 4 30 10 -1
+   2: 0100007F:D2A6 0100007F:1F90 01 00000000:00000000 00:00000000 00000000  1000        0 1003 1 0 20 4 30 10 -1
+";
+        assert_eq!(established_inodes(table), HashSet::from([1002]));
+        assert!(is_loopback_hex("00000000000000000000000001000000"));
+        assert!(is_loopback_hex("0000000000000000FFFF00000100007F"));
+        assert!(!is_loopback_hex("20010DB8000000000000000000000001"));
+    }
+
+    #[test]
+    fn test_parse_lsof() {
+        let output = "p100\nf23\nn10.0.2.15:53924->104.18.32.47:443\np130\nf5\nn127.0.0.1:53930->127.0.0.1:9229\n";
+        assert_eq!(parse_lsof(output), HashSet::from([100]));
+    }
+}
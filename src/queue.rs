@@ -0,0 +1,219 @@
+//! Work orders queued for a project.
+//!
+//! `amux enqueue "fix the flaky test" --project ~/src/api` (or `a` in the
+//! queue view, `Q`) leaves an order in amux's state directory. When an agent
+//! working in that project goes idle with no prompts of its own queued, the
+//! running amux sends it the project's oldest order, so whichever agent frees
+//! up first picks up the work. Orders stay in the file until dispatched or
+//! cancelled, so they survive a restart.
+
+use std::path::{Path, PathBuf};
+use std::time::SystemTime;
+
+use chrono::{DateTime, Local};
+use serde::{Deserialize, Serialize};
+
+use crate::snapshot;
+
+/// A task waiting for an idle agent in its project
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct WorkOrder {
+    /// Project directory; agents working in it or below it take the order
+    pub project: PathBuf,
+    pub text: String,
+    pub added: DateTime<Local>,
+}
+
+impl WorkOrder {
+    /// Whether an agent working in `cwd` takes this order
+    pub fn is_for(&self, cwd: &Path) -> bool {
+        cwd.starts_with(&self.project)
+            || cwd
+                .canonicalize()
+                .is_ok_and(|cwd| cwd.starts_with(&self.project))
+    }
+}
+
+/// Path of the work queue file
+pub fn queue_path() -> PathBuf {
+    snapshot::state_dir().join("queue.json")
+}
+
+fn read_all(path: &Path) -> Vec<WorkOrder> {
+    std::fs::read_to_string(path)
+        .ok()
+        .and_then(|text| serde_json::from_str(&text).ok())
+        .unwrap_or_default()
+}
+
+fn write_all(path: &Path, orders: &[WorkOrder]) -> std::io::Result<()> {
+    if let Some(dir) = path.parent() {
+        std::fs::create_dir_all(dir)?;
+    }
+    std::fs::write(path, serde_json::to_string_pretty(orders)?)
+}
+
+/// Add an order for `project` (`amux enqueue`)
+pub fn enqueue(project: &Path, text: &str) -> std::io::Result<()> {
+    WorkQueue::new().push(project, text)
+}
+
+/// The work queue as last read from its file. Changes read the file again
+/// first, so orders enqueued from the command line meanwhile aren't lost.
+#[derive(Debug)]
+pub struct WorkQueue {
+    path: PathBuf,
+    orders: Vec<WorkOrder>,
+    /// Modification time of the file when last read
+    modified: Option<SystemTime>,
+}
+
+impl Default for WorkQueue {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl WorkQueue {
+    /// The queue in amux's state directory (not read until `reload`)
+    pub fn new() -> Self {
+        Self::at(queue_path())
+    }
+
+    /// The queue kept in `path` (not read until `reload`)
+    pub fn at(path: PathBuf) -> Self {
+        Self {
+            path,
+            orders: vec![],
+            modified: None,
+        }
+    }
+
+    /// Orders, oldest first
+    pub fn orders(&self) -> &[WorkOrder] {
+        &self.orders
+    }
+
+    /// Read the file again if it changed since last read; returns whether it did
+    pub fn reload(&mut self) -> bool {
+        let modified = std::fs::metadata(&self.path)
+            .and_then(|m| m.modified())
+            .ok();
+        if modified == self.modified {
+            return false;
+        }
+        self.modified = modified;
+        self.orders = read_all(&self.path);
+        true
+    }
+
+    /// Apply a change to the orders in the file
+    fn update<T>(&mut self, change: impl FnOnce(&mut Vec<WorkOrder>) -> T) -> std::io::Result<T> {
+        let mut orders = read_all(&self.path);
+        let result = change(&mut orders);
+        write_all(&self.path, &orders)?;
+        self.orders = orders;
+        self.modified = std::fs::metadata(&self.path)
+            .and_then(|m| m.modified())
+            .ok();
+        Ok(result)
+    }
+
+    /// Add an order at the end of the queue
+    pub fn push(&mut self, project: &Path, text: &str) -> std::io::Result<()> {
+        let project = project
+            .canonicalize()
+            .unwrap_or_else(|_| project.to_path_buf());
+        self.update(|orders| {
+            orders.push(WorkOrder {
+                project,
+                text: text.to_string(),
+                added: Local::now(),
+            })
+        })
+    }
+
+    /// Oldest order an agent working in `cwd` takes
+    pub fn next_for(&self, cwd: &Path) -> Option<&WorkOrder> {
+        self.orders.iter().find(|order| order.is_for(cwd))
+    }
+
+    /// Remove and return the oldest order an agent working in `cwd` takes
+    pub fn take_for(&mut self, cwd: &Path) -> std::io::Result<Option<WorkOrder>> {
+        // Skip writing when there's nothing to take (the cached orders may be stale)
+        if self.next_for(cwd).is_none() && !self.reload() {
+            return Ok(None);
+        }
+        self.update(|orders| {
+            let index = orders.iter().position(|order| order.is_for(cwd))?;
+            Some(orders.remove(index))
+        })
+    }
+
+    /// Cancel an order; returns false if it was already dispatched or cancelled
+    pub fn remove(&mut self, order: &WorkOrder) -> std::io::Result<bool> {
+        self.update(|orders| {
+            let index = orders.iter().position(|o| o == order);
+            index.map(|index| orders.remove(index)).is_some()
+        })
+    }
+
+    /// Change an order's text; returns false if it was already dispatched or cancelled
+    pub fn replace(&mut self, order: &WorkOrder, text: &str) -> std::io::Result<bool> {
+        self.update(|orders| match orders.iter_mut().find(|o| *o == order) {
+            Some(found) => {
+                found.text = text.to_string();
+                true
+            }
+            None => false,
+        })
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_dispatch_order() {
+        let dir = std::env::temp_dir().join(format!("amux-queue-{}", std::process::id()));
+        let path = dir.join("queue.json");
+        let api = dir.join("api");
+        let web = dir.join("web");
+        std::fs::create_dir_all(&api).unwrap();
+        std::fs::create_dir_all(&web).unwrap();
+
+        // Orders enqueued from the command line show up after a reload
+        let mut queue = WorkQueue::at(path.clone());
+        WorkQueue::at(path.clone())
+            .push(&api, "fix the flaky test")
+            .unwrap();
+        WorkQueue::at(path.clone()).push(&web, "bump deps").unwrap();
+        WorkQueue::at(path.clone())
+            .push(&api, "update docs")
+            .unwrap();
+        assert!(queue.orders().is_empty());
+        assert!(queue.reload());
+        assert!(!queue.reload());
+        assert_eq!(queue.orders().len(), 3);
+
+        // An agent in the project (or below it) takes the oldest order for it
+        let order = queue.take_for(&api.join("src")).unwrap().unwrap();
+        assert_eq!(order.text, "fix the flaky test");
+        assert_eq!(queue.next_for(&api).unwrap().text, "update docs");
+        assert!(queue.take_for(&dir.join("other")).unwrap().is_none());
+
+        // Editing and cancelling act on the file other instances read
+        let docs = queue.next_for(&api).unwrap().clone();
+        assert!(queue.replace(&docs, "update the README").unwrap());
+        assert!(!queue.remove(&docs).unwrap());
+        let mut other = WorkQueue::at(path.clone());
+        other.reload();
+        assert_eq!(other.next_for(&api).unwrap().text, "update the README");
+        let web_order = other.next_for(&web).unwrap().clone();
+        assert!(other.remove(&web_order).unwrap());
+        assert!(queue.take_for(&web).unwrap().is_none());
+
+        std::fs::remove_dir_all(&dir).unwrap();
+    }
+}
@@ -0,0 +1,212 @@
+//! Detection of agent writes outside a session's project.
+//!
+//! Write targets come from the locations of edit/delete/move tool calls and,
+//! for shell commands, from redirections and the arguments of common
+//! file-modifying commands. A path is outside the project when it is under
+//! neither the session directory nor one of the configured `allowed_write_dirs`.
+
+use std::path::{Component, Path, PathBuf};
+
+use crate::acp::protocol::{ToolCallKind, ToolCallLocation};
+
+/// Commands whose every non-flag argument is modified
+const MODIFY_ALL_ARGS: &[&str] = &["rm", "rmdir", "touch", "mkdir", "tee", "truncate", "mv"];
+
+/// Commands whose last non-flag argument is the write destination
+const MODIFY_LAST_ARG: &[&str] = &["cp", "install", "ln"];
+
+/// Expand a leading `~` to the home directory
+pub fn expand_home(path: &Path) -> PathBuf {
+    match path.strip_prefix("~") {
+        Ok(rest) => dirs::home_dir()
+            .map(|home| home.join(rest))
+            .unwrap_or_else(|| path.to_path_buf()),
+        Err(_) => path.to_path_buf(),
+    }
+}
+
+/// Resolve a path against `cwd` and remove `.`/`..` components without touching
+/// the filesystem (the file may not exist yet)
+pub fn normalize(path: &Path, cwd: &Path) -> PathBuf {
+    let path = expand_home(path);
+    let joined = if path.is_absolute() {
+        path
+    } else {
+        cwd.join(path)
+    };
+
+    let mut normalized = PathBuf::new();
+    for component in joined.components() {
+        match component {
+            Component::CurDir => {}
+            Component::ParentDir => {
+                normalized.pop();
+            }
+            other => normalized.push(other),
+        }
+    }
+    normalized
+}
+
+/// Check whether a path is outside the session directory and all allowed directories
+pub fn is_outside(path: &Path, cwd: &Path, allowed_dirs: &[PathBuf]) -> bool {
+    let path = normalize(path, cwd);
+    // Device files like /dev/null are not project files
+    if path.starts_with("/dev") {
+        return false;
+    }
+    !std::iter::once(cwd)
+        .chain(allowed_dirs.iter().map(PathBuf::as_path))
+        .any(|dir| path.starts_with(normalize(dir, cwd)))
+}
+
+/// Extract paths a shell command writes to (redirections and file-modifying commands)
+pub fn command_write_targets(command: &str) -> Vec<String> {
+    let mut targets = vec![];
+
+    // Split into simple commands on shell separators
+    let separated = command
+        .replace("&&", ";")
+        .replace("||", ";")
+        .replace('|', ";");
+
+    for simple in separated.split(';') {
+        let tokens: Vec<String> = simple
+            .split_whitespace()
+            .map(|t| t.trim_matches(|c| c == '"' || c == '\''))
+            .map(str::to_string)
+            .collect();
+
+        let mut args = vec![];
+        let mut iter = tokens.iter();
+        while let Some(token) = iter.next() {
+            // Redirections: "> file", ">> file", ">file", "2>file"
+            let redirect = token.trim_start_matches(|c: char| c.is_ascii_digit());
+            if let Some(rest) = redirect.strip_prefix('>') {
+                let rest = rest.trim_start_matches('>');
+                if rest.starts_with('&') {
+                    continue; // fd duplication like 2>&1
+                }
+                if !rest.is_empty() {
+                    targets.push(rest.to_string());
+                } else if let Some(next) = iter.next() {
+                    targets.push(next.clone());
+                }
+                continue;
+            }
+            args.push(token.as_str());
+        }
+
+        // Skip env assignments and sudo to find the program
+        let mut words = args
+            .into_iter()
+            .skip_while(|w| w.contains('=') || *w == "sudo");
+        let Some(program) = words.next() else {
+            continue;
+        };
+        let program = program.rsplit('/').next().unwrap_or(program);
+        let operands: Vec<&str> = words.filter(|w| !w.starts_with('-')).collect();
+
+        if MODIFY_ALL_ARGS.contains(&program) {
+            targets.extend(operands.iter().map(|s| s.to_string()));
+        } else if MODIFY_LAST_ARG.contains(&program)
+            && operands.len() >= 2
+            && let Some(last) = operands.last()
+        {
+            targets.push(last.to_string());
+        }
+    }
+
+    targets
+}
+
+/// Extract the shell command from a tool call's raw JSON (`rawInput.command`)
+pub fn raw_command(raw_json: &str) -> Option<String> {
+    let value: serde_json::Value = serde_json::from_str(raw_json).ok()?;
+    value
+        .get("rawInput")?
+        .get("command")?
+        .as_str()
+        .map(str::to_string)
+}
+
+/// Find the paths a tool call writes outside the project
+pub fn outside_writes(
+    kind: Option<&ToolCallKind>,
+    locations: &[ToolCallLocation],
+    raw_json: Option<&str>,
+    cwd: &Path,
+    allowed_dirs: &[PathBuf],
+) -> Vec<PathBuf> {
+    let candidates: Vec<String> = match kind {
+        Some(ToolCallKind::Edit | ToolCallKind::Delete | ToolCallKind::Move) => {
+            locations.iter().map(|l| l.path.clone()).collect()
+        }
+        Some(ToolCallKind::Execute) => raw_json
+            .and_then(raw_command)
+            .map(|command| command_write_targets(&command))
+            .unwrap_or_default(),
+        _ => vec![],
+    };
+
+    candidates
+        .into_iter()
+        .map(PathBuf::from)
+        .filter(|path| is_outside(path, cwd, allowed_dirs))
+        .map(|path| normalize(&path, cwd))
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_is_outside() {
+        let cwd = Path::new("/work/project");
+        let allowed = vec![PathBuf::from("/tmp")];
+        assert!(!is_outside(Path::new("src/main.rs"), cwd, &allowed));
+        assert!(!is_outside(Path::new("/work/project/a.rs"), cwd, &allowed));
+        assert!(!is_outside(Path::new("/tmp/scratch.txt"), cwd, &allowed));
+        assert!(!is_outside(Path::new("/dev/null"), cwd, &allowed));
+        assert!(is_outside(Path::new("../other/a.rs"), cwd, &allowed));
+        assert!(is_outside(Path::new("/etc/hosts"), cwd, &allowed));
+        assert!(is_outside(
+            Path::new("/work/project-old/a.rs"),
+            cwd,
+            &allowed
+        ));
+    }
+
+    #[test]
+    fn test_command_write_targets() {
+        assert_eq!(
+            command_write_targets("echo hi > /etc/motd 2>&1"),
+            vec!["/etc/motd"]
+        );
+        assert_eq!(command_write_targets("cat a >>log.txt"), vec!["log.txt"]);
+        assert_eq!(
+            command_write_targets("rm -rf build ../cache && cp a.txt /opt/b.txt"),
+            vec!["build", "../cache", "/opt/b.txt"]
+        );
+        assert_eq!(
+            command_write_targets("sudo mkdir -p '/var/lib/x'"),
+            vec!["/var/lib/x"]
+        );
+        assert!(command_write_targets("cargo test | grep ok").is_empty());
+    }
+
+    #[test]
+    fn test_outside_writes_for_bash() {
+        let raw = r#"{"rawInput": {"command": "touch notes.md ~/.bashrc"}}"#;
+        let paths = outside_writes(
+            Some(&ToolCallKind::Execute),
+            &[],
+            Some(raw),
+            Path::new("/work/project"),
+            &[],
+        );
+        assert_eq!(paths.len(), 1);
+        assert!(paths[0].ends_with(".bashrc"));
+    }
+}
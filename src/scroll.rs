@@ -2,6 +2,8 @@
 //!
 //! Accumulates scroll deltas over a time window to prevent jittery scrolling
 //! from high-resolution scroll events (e.g., trackpads, precision mice).
+//! [`ScrollAccelerator`] grows the step size while a scroll key is held.
+//! [`keep_in_view`] scrolls lists whose rows span several lines.
 //!
 //! # Example
 //!
@@ -114,6 +116,78 @@ impl ScrollHelper {
     }
 }
 
+/// Events closer together than this count as a held key (key repeat)
+const REPEAT_WINDOW: Duration = Duration::from_millis(150);
+
+/// Repeated events needed to step up one acceleration level
+const EVENTS_PER_LEVEL: u32 = 5;
+
+/// Largest step multiplier reached while holding a scroll key
+const MAX_MULTIPLIER: i32 = 10;
+
+/// Accelerates line scrolling while a key is held.
+///
+/// Each run of events in the same direction arriving within the key repeat
+/// window progressively multiplies the step, so holding `Ctrl+e` through a
+/// 10k-line transcript doesn't take minutes. A pause or a direction change
+/// resets to single steps.
+#[derive(Debug, Clone, Default)]
+pub struct ScrollAccelerator {
+    /// Time of the last scroll event
+    last_event: Option<Instant>,
+    /// Direction of the last scroll event (-1 up, 1 down)
+    direction: i32,
+    /// Number of consecutive repeated events
+    streak: u32,
+}
+
+impl ScrollAccelerator {
+    /// Scale a scroll delta by the current acceleration level.
+    pub fn step(&mut self, delta: i32) -> i32 {
+        let now = Instant::now();
+        let direction = delta.signum();
+
+        let repeated = self.direction == direction
+            && self
+                .last_event
+                .is_some_and(|last| now.duration_since(last) <= REPEAT_WINDOW);
+        self.streak = if repeated { self.streak + 1 } else { 0 };
+        self.direction = direction;
+        self.last_event = Some(now);
+
+        let multiplier = (1 + (self.streak / EVENTS_PER_LEVEL) as i32).min(MAX_MULTIPLIER);
+        delta * multiplier
+    }
+}
+
+/// Adjust the first visible line of a list so a row spanning
+/// `row_start..row_start + row_len` stays fully visible.
+///
+/// Works in rendered lines rather than rows, so rows of different heights
+/// and viewport size changes (terminal resizes) are handled alike. The
+/// result is clamped so the viewport never scrolls past the end of the list.
+pub fn keep_in_view(
+    top: usize,
+    row_start: usize,
+    row_len: usize,
+    total_lines: usize,
+    viewport: usize,
+) -> usize {
+    if total_lines <= viewport {
+        return 0;
+    }
+    let row_end = row_start + row_len;
+    let top = if row_start < top {
+        row_start
+    } else if row_end > top + viewport {
+        // Rows taller than the viewport show their first lines
+        row_end.saturating_sub(viewport).min(row_start)
+    } else {
+        top
+    };
+    top.min(total_lines - viewport)
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -152,4 +226,46 @@ mod tests {
         assert!(helper.accumulate(-1).is_none());
         assert_eq!(helper.accumulate(-1), Some(-2));
     }
+
+    #[test]
+    fn test_accelerator_grows_while_held() {
+        let mut accel = ScrollAccelerator::default();
+
+        // Single steps until enough repeats arrive
+        for _ in 0..EVENTS_PER_LEVEL {
+            assert_eq!(accel.step(1), 1);
+        }
+        assert_eq!(accel.step(1), 2);
+
+        // Direction change resets
+        assert_eq!(accel.step(-1), -1);
+    }
+
+    #[test]
+    fn test_keep_in_view_multi_line_rows() {
+        // Everything fits: no scrolling
+        assert_eq!(keep_in_view(5, 8, 3, 10, 12), 0);
+        // Row below the viewport: scroll until its last line is visible
+        assert_eq!(keep_in_view(0, 12, 3, 30, 10), 5);
+        // Row above the viewport: scroll up to its first line
+        assert_eq!(keep_in_view(10, 4, 3, 30, 10), 4);
+        // Row already visible: keep position
+        assert_eq!(keep_in_view(3, 5, 3, 30, 10), 3);
+        // Viewport grew (resize): don't leave empty space past the end
+        assert_eq!(keep_in_view(20, 25, 3, 30, 15), 15);
+        // Row taller than the viewport: show its start
+        assert_eq!(keep_in_view(0, 6, 8, 30, 5), 6);
+    }
+
+    #[test]
+    fn test_accelerator_resets_after_pause() {
+        let mut accel = ScrollAccelerator::default();
+        for _ in 0..20 {
+            accel.step(1);
+        }
+        assert!(accel.step(1) > 1);
+
+        thread::sleep(REPEAT_WINDOW + Duration::from_millis(20));
+        assert_eq!(accel.step(1), 1);
+    }
 }
@@ -0,0 +1,148 @@
+//! `amux serve --tui`: a read-only view of the fleet for teammates.
+//!
+//! Meant as the forced command of a teammate's SSH key in the
+//! `authorized_keys` of the user running amux, so whoever holds the key gets
+//! the view and nothing else:
+//!
+//! ```text
+//! command="amux serve --tui",restrict,pty ssh-ed25519 AAAA… teammate
+//! ```
+//!
+//! The view reads the snapshot the running amux writes (`snapshot = true`)
+//! and opens the session file Claude Code keeps for each agent as a
+//! read-only transcript. Session files can be large and sit on a network
+//! home, so one is only read once its session is selected, in the
+//! background, and after that only what the agent appends to it. Only keys
+//! that change what's shown work: nothing can be sent to the agents,
+//! spawned, killed or exported.
+
+use std::io::{Read, Seek, SeekFrom};
+use std::path::{Path, PathBuf};
+
+use crate::snapshot::Snapshot;
+
+/// An agent's session file shared in the view
+#[derive(Debug, Clone, PartialEq)]
+pub struct SharedTranscript {
+    /// Agent-side session ID
+    pub session_id: String,
+    /// Session name shown in the list
+    pub name: String,
+    pub path: PathBuf,
+}
+
+/// Session files of the agents in a snapshot that have one under `projects`
+/// (Claude Code's `~/.claude/projects`)
+pub fn shared_transcripts(snapshot: &Snapshot, projects: &Path) -> Vec<SharedTranscript> {
+    snapshot
+        .agents
+        .iter()
+        .filter_map(|agent| {
+            let session_id = agent.session_id.clone()?;
+            let path = find_session_file(projects, &session_id)?;
+            Some(SharedTranscript {
+                session_id,
+                name: agent.name.clone(),
+                path,
+            })
+        })
+        .collect()
+}
+
+/// `<session_id>.jsonl` in any project directory under `projects`
+pub fn find_session_file(projects: &Path, session_id: &str) -> Option<PathBuf> {
+    let file_name = format!("{}.jsonl", session_id);
+    std::fs::read_dir(projects)
+        .ok()?
+        .flatten()
+        .map(|entry| entry.path().join(&file_name))
+        .find(|path| path.is_file())
+}
+
+/// Complete lines of a transcript read from `from` on
+#[derive(Debug, PartialEq)]
+pub struct Appended {
+    /// Where reading started: 0 if the file shrank since it was last read
+    pub from: u64,
+    pub text: String,
+    /// Bytes read in total, up to the end of the last complete line
+    pub len: u64,
+}
+
+/// Read the lines appended to a transcript since `from` bytes were read, so
+/// a reload doesn't read the whole file again. A line still being written is
+/// left for the next read.
+pub fn read_appended(path: &Path, from: u64) -> std::io::Result<Appended> {
+    let mut file = std::fs::File::open(path)?;
+    let from = if file.metadata()?.len() < from {
+        0
+    } else {
+        from
+    };
+    file.seek(SeekFrom::Start(from))?;
+    let mut bytes = vec![];
+    file.read_to_end(&mut bytes)?;
+    let complete = bytes.iter().rposition(|&b| b == b'\n').map_or(0, |i| i + 1);
+    bytes.truncate(complete);
+    Ok(Appended {
+        from,
+        text: String::from_utf8_lossy(&bytes).into_owned(),
+        len: from + complete as u64,
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::session::{AgentType, Session};
+
+    #[test]
+    fn test_shared_transcripts() {
+        let projects = std::env::temp_dir().join(format!("amux-serve-{}", std::process::id()));
+        let project = projects.join("-srv-api");
+        std::fs::create_dir_all(&project).unwrap();
+        std::fs::write(project.join("abc-123.jsonl"), "").unwrap();
+
+        let mut api = Session::mock("1", "api", AgentType::ClaudeCode, "main");
+        api.acp_session_id = Some("abc-123".to_string());
+        let mut web = Session::mock("2", "web", AgentType::ClaudeCode, "main");
+        web.acp_session_id = Some("no-file".to_string());
+        let cli = Session::mock("3", "cli", AgentType::ClaudeCode, "main");
+        let snapshot = Snapshot::from_sessions(&[api, web, cli]);
+
+        let shared = shared_transcripts(&snapshot, &projects);
+        let _ = std::fs::remove_dir_all(&projects);
+        assert_eq!(
+            shared,
+            vec![SharedTranscript {
+                session_id: "abc-123".to_string(),
+                name: "api".to_string(),
+                path: project.join("abc-123.jsonl"),
+            }]
+        );
+    }
+
+    #[test]
+    fn test_read_appended() {
+        let path =
+            std::env::temp_dir().join(format!("amux-serve-appended-{}.jsonl", std::process::id()));
+        std::fs::write(&path, "{\"a\":1}\n{\"b\":").unwrap();
+        let first = read_appended(&path, 0).unwrap();
+        assert_eq!(first.text, "{\"a\":1}\n");
+        assert_eq!(first.len, 8);
+
+        // Only the rest of the file is read, from the start of the unfinished line
+        std::fs::write(&path, "{\"a\":1}\n{\"b\":2}\n").unwrap();
+        let second = read_appended(&path, first.len).unwrap();
+        assert_eq!(second.from, 8);
+        assert_eq!(second.text, "{\"b\":2}\n");
+        assert_eq!(second.len, 16);
+
+        // A file that shrank is read again from the start
+        std::fs::write(&path, "{}\n").unwrap();
+        let third = read_appended(&path, second.len).unwrap();
+        let _ = std::fs::remove_file(&path);
+        assert_eq!(third.from, 0);
+        assert_eq!(third.text, "{}\n");
+    }
+}
@@ -0,0 +1,96 @@
+//! Claude Code project settings
+//!
+//! Reads the project's `.claude/settings.json` (and `settings.local.json`) to
+//! discover the default permission mode the agent starts in. It's only shown
+//! as a badge: amux's own permission mode, which decides what amux accepts
+//! on its own, is never taken from files checked into a project.
+
+use std::path::Path;
+
+use serde::Deserialize;
+
+#[derive(Debug, Default, Deserialize)]
+struct ClaudeSettings {
+    #[serde(default)]
+    permissions: Option<PermissionSettings>,
+}
+
+#[derive(Debug, Default, Deserialize)]
+#[serde(rename_all = "camelCase")]
+struct PermissionSettings {
+    #[serde(default)]
+    default_mode: Option<String>,
+}
+
+/// A permission mode from Claude's project settings (other than `default`)
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum ClaudeMode {
+    Plan,
+    AcceptEdits,
+    BypassPermissions,
+}
+
+impl ClaudeMode {
+    /// Badge shown on the session's row
+    pub fn badge(&self) -> &'static str {
+        match self {
+            ClaudeMode::Plan => "[PLAN]",
+            ClaudeMode::AcceptEdits => "[EDITS]",
+            ClaudeMode::BypassPermissions => "[YOLO]",
+        }
+    }
+}
+
+/// Map a Claude `defaultMode` value to a mode worth a badge
+fn parse_mode(mode: &str) -> Option<ClaudeMode> {
+    match mode {
+        "plan" => Some(ClaudeMode::Plan),
+        "acceptEdits" => Some(ClaudeMode::AcceptEdits),
+        "bypassPermissions" => Some(ClaudeMode::BypassPermissions),
+        _ => None,
+    }
+}
+
+/// Read `permissions.defaultMode` from a single settings file
+fn read_default_mode(path: &Path) -> Option<ClaudeMode> {
+    let content = std::fs::read_to_string(path).ok()?;
+    let settings: ClaudeSettings = serde_json::from_str(&content).ok()?;
+    settings
+        .permissions
+        .and_then(|p| p.default_mode)
+        .and_then(|m| parse_mode(&m))
+}
+
+/// Get the default permission mode configured for a project directory.
+///
+/// `settings.local.json` takes precedence over `settings.json`.
+pub fn default_permission_mode(cwd: &Path) -> Option<ClaudeMode> {
+    let dir = cwd.join(".claude");
+    read_default_mode(&dir.join("settings.local.json"))
+        .or_else(|| read_default_mode(&dir.join("settings.json")))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_mode() {
+        assert_eq!(parse_mode("plan"), Some(ClaudeMode::Plan));
+        assert_eq!(parse_mode("acceptEdits"), Some(ClaudeMode::AcceptEdits));
+        assert_eq!(
+            parse_mode("bypassPermissions"),
+            Some(ClaudeMode::BypassPermissions)
+        );
+        assert_eq!(parse_mode("default"), None);
+        assert_eq!(parse_mode("unknown"), None);
+    }
+
+    #[test]
+    fn test_parse_settings() {
+        let json = r#"{"permissions": {"defaultMode": "plan", "allow": ["Bash(ls)"]}}"#;
+        let settings: ClaudeSettings = serde_json::from_str(json).unwrap();
+        let mode = settings.permissions.and_then(|p| p.default_mode);
+        assert_eq!(mode.as_deref(), Some("plan"));
+    }
+}
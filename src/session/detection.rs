@@ -43,7 +43,7 @@ impl AgentAvailability {
 }
 
 /// Check if a command exists in PATH
-fn command_exists(cmd: &str) -> bool {
+pub fn command_exists(cmd: &str) -> bool {
     Command::new("which")
         .arg(cmd)
         .output()
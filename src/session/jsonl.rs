@@ -0,0 +1,265 @@
+//! Loading Claude Code JSONL transcripts into a session for `amux view`.
+//!
+//! Each line of a transcript is one event: user prompts and tool results
+//! (`"type": "user"`), assistant text, thinking and tool calls
+//! (`"type": "assistant"`), plus bookkeeping lines that are skipped. Lines
+//! that aren't valid JSON are skipped too, so truncated copies still load.
+//!
+//! Every line records the `entrypoint` that started the session: `cli` for
+//! interactive sessions, `sdk-*` for the Agent SDK (and CI pipelines using it).
+//!
+//! Slash commands (`/compact`, `/model opus`) are recorded as user lines
+//! wrapped in `<command-name>` tags, their output in `<local-command-stdout>`.
+//! They're shown as system actions ("⌘ /model opus"), not prompts.
+
+use std::path::PathBuf;
+
+use serde_json::Value;
+
+use super::{OutputType, Session};
+
+/// Prefix of slash command actions in the output
+pub const COMMAND_PREFIX: &str = "⌘ ";
+
+/// Input fields that best describe a tool call, in order of preference
+const DESCRIPTION_FIELDS: &[&str] = &[
+    "file_path",
+    "command",
+    "pattern",
+    "url",
+    "query",
+    "path",
+    "description",
+];
+
+/// Fill a session's output from a JSONL transcript; returns the number of
+/// messages loaded. The session takes its directory and branch from the
+/// transcript when it records them, and is marked headless for SDK transcripts.
+pub fn load_jsonl(session: &mut Session, text: &str) -> usize {
+    let mut messages = 0;
+
+    for line in text.lines() {
+        let Ok(entry) = serde_json::from_str::<Value>(line) else {
+            continue;
+        };
+        if let Some(cwd) = entry.get("cwd").and_then(Value::as_str) {
+            session.cwd = PathBuf::from(cwd);
+        }
+        if let Some(branch) = entry.get("gitBranch").and_then(Value::as_str) {
+            session.git_branch = branch.to_string();
+        }
+        if let Some(entrypoint) = entry.get("entrypoint").and_then(Value::as_str) {
+            session.headless = is_headless_entrypoint(entrypoint);
+        }
+        // Meta lines are injected by the CLI (command output, caveats), not typed
+        if entry.get("isMeta").and_then(Value::as_bool) == Some(true) {
+            continue;
+        }
+        let Some(content) = entry.pointer("/message/content") else {
+            continue;
+        };
+
+        match entry.get("type").and_then(Value::as_str) {
+            Some("user") => {
+                if let Some(prompt) = content.as_str() {
+                    if let Some(action) = command_action(prompt) {
+                        if !action.is_empty() {
+                            session.add_output(action, OutputType::SystemMessage);
+                        }
+                        continue;
+                    }
+                    add_prompt(session, prompt);
+                    messages += 1;
+                    continue;
+                }
+                for block in content.as_array().into_iter().flatten() {
+                    match block.get("type").and_then(Value::as_str) {
+                        Some("text") => {
+                            add_prompt(session, block_text(block, "text"));
+                            messages += 1;
+                        }
+                        Some("tool_result") => add_tool_result(session, block),
+                        _ => {}
+                    }
+                }
+            }
+            Some("assistant") => {
+                for block in content.as_array().into_iter().flatten() {
+                    match block.get("type").and_then(Value::as_str) {
+                        Some("text") => {
+                            session.add_output(String::new(), OutputType::Text);
+                            session.add_output(
+                                block_text(block, "text").to_string(),
+                                OutputType::Text,
+                            );
+                            messages += 1;
+                        }
+                        Some("thinking") => {
+                            session.add_output(
+                                block_text(block, "thinking").to_string(),
+                                OutputType::Thought,
+                            );
+                        }
+                        Some("tool_use") => add_tool_use(session, block),
+                        _ => {}
+                    }
+                }
+            }
+            _ => {}
+        }
+    }
+
+    session.complete_active_tool();
+    messages
+}
+
+/// Whether a transcript's entrypoint is non-interactive (Agent SDK, print mode)
+fn is_headless_entrypoint(entrypoint: &str) -> bool {
+    entrypoint.starts_with("sdk")
+}
+
+/// Text of a tag in a command entry, e.g. `<command-args>opus</command-args>`
+fn tag_text<'a>(text: &'a str, tag: &str) -> Option<&'a str> {
+    let open = format!("<{}>", tag);
+    let close = format!("</{}>", tag);
+    let start = text.find(&open)? + open.len();
+    let end = start + text[start..].find(&close)?;
+    Some(text[start..end].trim())
+}
+
+/// The system action a slash command entry stands for ("⌘ /model opus"), or
+/// its output; None for ordinary prompts. Empty output gives an empty string.
+fn command_action(text: &str) -> Option<String> {
+    if let Some(name) = tag_text(text, "command-name") {
+        let name = if name.starts_with('/') {
+            name.to_string()
+        } else {
+            format!("/{}", name)
+        };
+        return Some(match tag_text(text, "command-args") {
+            Some(args) if !args.is_empty() => format!("{}{} {}", COMMAND_PREFIX, name, args),
+            _ => format!("{}{}", COMMAND_PREFIX, name),
+        });
+    }
+    ["local-command-stdout", "local-command-stderr"]
+        .iter()
+        .find_map(|tag| tag_text(text, tag))
+        .map(str::to_string)
+}
+
+/// Whether a prompt typed into amux is a slash command ("/compact")
+pub fn is_slash_command(prompt: &str) -> bool {
+    prompt.strip_prefix('/').is_some_and(|rest| {
+        let name = rest.split_whitespace().next().unwrap_or_default();
+        !name.is_empty()
+            && name
+                .chars()
+                .all(|c| c.is_ascii_alphanumeric() || matches!(c, '-' | '_' | ':'))
+    })
+}
+
+fn block_text<'a>(block: &'a Value, field: &str) -> &'a str {
+    block.get(field).and_then(Value::as_str).unwrap_or_default()
+}
+
+fn add_prompt(session: &mut Session, prompt: &str) {
+    session.add_output(String::new(), OutputType::Text);
+    session.add_output(format!("> {}", prompt), OutputType::UserInput);
+}
+
+fn add_tool_use(session: &mut Session, block: &Value) {
+    let id = block_text(block, "id").to_string();
+    let name = block_text(block, "name").to_string();
+    let input = block.get("input");
+    let description = DESCRIPTION_FIELDS.iter().find_map(|field| {
+        input
+            .and_then(|input| input.get(*field))
+            .and_then(Value::as_str)
+            .map(str::to_string)
+    });
+    let raw_json = input.map(|input| input.to_string());
+    session.add_tool_call(id, name, description, raw_json);
+}
+
+fn add_tool_result(session: &mut Session, block: &Value) {
+    // Result content is either a string or a list of text blocks
+    let text = match block.get("content") {
+        Some(Value::String(text)) => text.clone(),
+        Some(Value::Array(parts)) => parts
+            .iter()
+            .filter_map(|part| part.get("text").and_then(Value::as_str))
+            .collect::<Vec<_>>()
+            .join("\n"),
+        _ => String::new(),
+    };
+    if block.get("is_error").and_then(Value::as_bool) == Some(true) {
+        session.mark_tool_failed(block_text(block, "tool_use_id"));
+    }
+    if !text.trim().is_empty() {
+        session.add_tool_output(text);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::session::AgentType;
+
+    #[test]
+    fn test_load_jsonl() {
+        let transcript = r#"{"type":"summary","summary":"Fix tests"}
+{"type":"user","cwd":"/work/api","gitBranch":"fix-tests","entrypoint":"sdk-ts","message":{"role":"user","content":"run the tests"}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"thinking","thinking":"Use cargo"},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"cargo test"}}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"test result: ok"}]}}
+not json
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"All tests pass."}]}}
+"#;
+        let mut session = Session::mock("1", "view", AgentType::ClaudeCode, "main");
+        session.output.clear();
+
+        assert_eq!(load_jsonl(&mut session, transcript), 2);
+        assert_eq!(session.cwd, PathBuf::from("/work/api"));
+        assert_eq!(session.git_branch, "fix-tests");
+        assert!(session.headless);
+        assert!(session.output.iter().any(|line| matches!(
+            &line.line_type,
+            OutputType::ToolCall { name, description, .. }
+                if name == "Bash" && description.as_deref() == Some("cargo test")
+        )));
+        let last = session.output.last().unwrap();
+        assert_eq!(last.content, "All tests pass.");
+    }
+
+    #[test]
+    fn test_slash_commands_are_system_actions() {
+        let transcript = r#"{"type":"user","message":{"role":"user","content":"<command-name>/model</command-name>\n<command-message>model</command-message>\n<command-args>opus</command-args>"}}
+{"type":"user","message":{"role":"user","content":"<local-command-stdout>Set model to opus</local-command-stdout>"}}
+{"type":"user","message":{"role":"user","content":"<command-name>/compact</command-name>\n<command-args></command-args>"}}
+{"type":"user","message":{"role":"user","content":"fix the tests"}}
+"#;
+        let mut session = Session::mock("1", "view", AgentType::ClaudeCode, "main");
+        session.output.clear();
+
+        assert_eq!(load_jsonl(&mut session, transcript), 1);
+        let actions: Vec<&str> = session
+            .output
+            .iter()
+            .filter(|line| line.line_type == OutputType::SystemMessage)
+            .map(|line| line.content.as_str())
+            .collect();
+        assert_eq!(
+            actions,
+            ["⌘ /model opus", "Set model to opus", "⌘ /compact"]
+        );
+        assert_eq!(session.conversation_stats().user_messages, 1);
+    }
+
+    #[test]
+    fn test_is_slash_command() {
+        assert!(is_slash_command("/compact"));
+        assert!(is_slash_command("/model opus"));
+        assert!(!is_slash_command("/usr/bin/env is missing"));
+        assert!(!is_slash_command("fix /compact"));
+        assert!(!is_slash_command("/"));
+    }
+}
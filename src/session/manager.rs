@@ -83,6 +83,16 @@ impl SessionManager {
         Some(removed)
     }
 
+    /// Remove the sessions with the given IDs, keeping the selected session
+    /// selected if it's not among them
+    pub fn remove_by_ids(&mut self, ids: &[String]) {
+        let selected_id = self.selected_session().map(|s| s.id.clone());
+        self.sessions.retain(|s| !ids.contains(&s.id));
+        self.selected = selected_id
+            .and_then(|id| self.sessions.iter().position(|s| s.id == id))
+            .unwrap_or(self.selected.min(self.sessions.len().saturating_sub(1)));
+    }
+
     /// Find a session by its unique ID and return a mutable reference
     pub fn get_by_id_mut(&mut self, id: &str) -> Option<&mut Session> {
         self.sessions.iter_mut().find(|s| s.id == id)
@@ -92,4 +102,33 @@ impl SessionManager {
     pub fn get_by_id(&self, id: &str) -> Option<&Session> {
         self.sessions.iter().find(|s| s.id == id)
     }
+
+    /// Recompute which sessions change overlapping files in different
+    /// checkouts of the same repository (e.g. two worktrees)
+    pub fn update_file_conflicts(&mut self) {
+        let conflicts: Vec<Vec<String>> = self
+            .sessions
+            .iter()
+            .map(|session| {
+                self.sessions
+                    .iter()
+                    .filter(|other| {
+                        other.id != session.id
+                            && other.cwd != session.cwd
+                            && session.git_origin.is_some()
+                            && other.git_origin == session.git_origin
+                            && other
+                                .changed_files
+                                .iter()
+                                .any(|file| session.changed_files.contains(file))
+                    })
+                    .map(|other| other.name.clone())
+                    .collect()
+            })
+            .collect();
+
+        for (session, file_conflicts) in self.sessions.iter_mut().zip(conflicts) {
+            session.file_conflicts = file_conflicts;
+        }
+    }
 }
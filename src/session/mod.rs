@@ -1,12 +1,17 @@
+mod claude_settings;
 mod detection;
+mod jsonl;
 mod manager;
 mod state;
 // mod scanner; // TODO: Enable when session/load ACP is supported
 
-pub use detection::{AgentAvailability, check_all_agents};
+pub use claude_settings::{ClaudeMode, default_permission_mode};
+pub use detection::{AgentAvailability, check_all_agents, command_exists};
+pub use jsonl::{COMMAND_PREFIX, is_slash_command, load_jsonl};
 pub use manager::SessionManager;
 pub use state::{
-    AgentType, OutputType, PendingPermission, PendingQuestion, PermissionMode, Session,
-    SessionState,
+    AgentType, ConversationStats, MessageTag, OutputType, PendingPermission, PendingQuestion,
+    PermissionMode, PlanChange, PlanChangeKind, RecentFile, Session, SessionState, SessionSummary,
+    VerifyStatus, WorkspaceSnapshot, remove_spill_dir,
 };
 // pub use scanner::scan_resumable_sessions;
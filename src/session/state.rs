@@ -1,8 +1,16 @@
-use crate::acp::{AgentCommand, AskUserOption, PermissionKind, PermissionOptionInfo, PlanEntry};
+use crate::acp::{
+    AgentCommand, AskUserOption, PermissionKind, PermissionOptionInfo, PlanEntry, PlanStatus,
+    StopReason,
+};
+use crate::env::EnvVar;
+use crate::session::ClaudeMode;
+use std::collections::{BTreeMap, BTreeSet, HashSet, VecDeque};
+use std::io::{BufRead, Write};
 use std::path::PathBuf;
-use std::time::{Instant, SystemTime};
+use std::time::{Duration, Instant, SystemTime};
 
-use serde::Deserialize;
+use chrono::{DateTime, Local};
+use serde::{Deserialize, Serialize};
 
 #[derive(Debug, Clone, Copy, PartialEq, Deserialize)]
 pub enum AgentType {
@@ -156,6 +164,120 @@ impl SessionState {
     }
 }
 
+/// Status of the configured verify command for a session
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum VerifyStatus {
+    Running,
+    Passed,
+    Failed,
+}
+
+/// A summary of the conversation produced by the configured summary command
+#[derive(Debug, Clone)]
+pub struct SessionSummary {
+    pub text: String,
+    /// Length of `output` when the summary was made, to tell when it's stale
+    pub output_len: usize,
+    pub at: DateTime<Local>,
+}
+
+/// Message statistics per role for a session's conversation
+#[derive(Debug, Clone, Copy, Default, PartialEq)]
+pub struct ConversationStats {
+    pub user_messages: usize,
+    pub user_chars: usize,
+    pub agent_messages: usize,
+    pub agent_chars: usize,
+    pub tool_calls: usize,
+}
+
+impl ConversationStats {
+    /// Average user message length in characters
+    pub fn avg_user_len(&self) -> usize {
+        self.user_chars.checked_div(self.user_messages).unwrap_or(0)
+    }
+
+    /// Average agent message length in characters
+    pub fn avg_agent_len(&self) -> usize {
+        self.agent_chars
+            .checked_div(self.agent_messages)
+            .unwrap_or(0)
+    }
+
+    /// Agent messages per user message (high = "fire and forget", low = pair programming)
+    pub fn agent_turns_per_user(&self) -> f64 {
+        if self.user_messages == 0 {
+            0.0
+        } else {
+            self.agent_messages as f64 / self.user_messages as f64
+        }
+    }
+}
+
+/// Maximum number of recently written files remembered per session
+const MAX_RECENT_FILES: usize = 20;
+
+/// Maximum number of verify output lines kept per session (oldest dropped)
+const MAX_VERIFY_LINES: usize = 2000;
+
+/// A file the agent wrote, with the lines changed by its latest write
+#[derive(Debug, Clone)]
+pub struct RecentFile {
+    pub path: PathBuf,
+    /// 1-based line numbers added or modified by the latest write
+    pub changed_lines: Vec<usize>,
+}
+
+/// The session's work tree at one point in the conversation, recorded as a
+/// tree object (see `git::snapshot_work_tree`) to diff against other points
+#[derive(Debug, Clone)]
+pub struct WorkspaceSnapshot {
+    pub tree: String,
+    pub taken_at: SystemTime,
+    /// Length of the output when it was taken
+    pub output_len: usize,
+    /// What had just happened, e.g. the prompt of the turn that ended
+    pub label: String,
+}
+
+/// Label attached to a message for later extraction (see `transcript::export_tagged`)
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord)]
+pub enum MessageTag {
+    Decision,
+    Bug,
+    Todo,
+}
+
+impl MessageTag {
+    pub const ALL: [MessageTag; 3] = [MessageTag::Decision, MessageTag::Bug, MessageTag::Todo];
+
+    pub fn label(&self) -> &'static str {
+        match self {
+            MessageTag::Decision => "decision",
+            MessageTag::Bug => "bug",
+            MessageTag::Todo => "todo",
+        }
+    }
+}
+
+/// How a plan entry changed between two plan updates
+#[derive(Debug, Clone, Copy, PartialEq, Serialize, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum PlanChangeKind {
+    Added,
+    Started,
+    Completed,
+    Removed,
+}
+
+/// A timestamped change to the agent's plan (browse with 'p')
+#[derive(Debug, Clone)]
+pub struct PlanChange {
+    pub at: DateTime<Local>,
+    pub kind: PlanChangeKind,
+    pub content: String,
+}
+
 /// Pending permission request
 #[derive(Debug, Clone)]
 pub struct PendingPermission {
@@ -328,9 +450,22 @@ pub struct Session {
     pub pending_permission: Option<PendingPermission>,
     pub pending_question: Option<PendingQuestion>,
     pub plan_entries: Vec<PlanEntry>,
+    /// When the current plan started (used to estimate time to completion)
+    pub plan_started_at: Option<Instant>,
+    /// What the agent says it's doing for the current task, kept from when
+    /// the plan or todo list last changed so frames don't parse tool calls
+    current_task_active_form: Option<String>,
+    /// Timeline of plan entries being added, started, completed and removed
+    pub plan_history: Vec<PlanChange>,
     pub current_mode: Option<String>,
     pub active_tool_call_id: Option<String>,
+    /// When the active tool call started
+    pub active_tool_started_at: Option<Instant>,
+    /// Bash command flagged as running too long (cleared when the tool finishes)
+    pub long_command: Option<String>,
     pub permission_mode: PermissionMode,
+    /// Mode the project's Claude settings start the agent in (display only)
+    pub project_mode: Option<ClaudeMode>,
     pub available_models: Vec<ModelInfo>,
     pub current_model_id: Option<String>,
     /// Available slash commands from the agent
@@ -341,28 +476,102 @@ pub struct Session {
     pub input_buffer: String,
     /// Per-session cursor position in input buffer
     pub input_cursor: usize,
-    /// Current thought text from agent (ephemeral, cleared when response arrives)
+    /// Thought in progress from agent (cleared when response arrives)
     pub current_thought: Option<String>,
     /// Whether we've sent an idle notification for this session (reset on new prompt)
     pub idle_notified: bool,
+    /// The agent's last turn ended by asking the user something (reset on new prompt)
+    pub needs_input: bool,
+    /// The agent's last turn finished the work: its plan is complete or it
+    /// ended with a summary (reset on new prompt)
+    pub done: bool,
     /// Git diff statistics (insertions/deletions compared to base branch)
     pub diff_stats: Option<crate::git::DiffStats>,
+    /// Size of the diff relative to the configured review thresholds
+    pub diff_severity: crate::git::DiffSeverity,
+    /// Generation of the git refresh last applied; results of older refreshes are dropped
+    pub git_generation: u64,
+    /// The agent wrote files since the last git refresh; refresh this session soon
+    pub git_dirty: bool,
+    /// Prompts queued while the agent was busy, dispatched in order when it goes idle
+    pub queued_prompts: VecDeque<String>,
+    /// Number of agent-run terminal commands still executing
+    pub running_terminals: usize,
+    /// The agent process died mid-task; resume it with 'R'
+    pub interrupted: bool,
+    /// The agent can load its session again after a restart (its
+    /// `loadSession` capability)
+    pub can_load: bool,
+    /// Hidden from the session list for this run (toggle with 'D')
+    pub hidden: bool,
+    /// Files changed compared to the base branch (refreshed with diff stats)
+    pub changed_files: Vec<String>,
+    /// Names of sessions in other worktrees of the same repo touching the same files
+    pub file_conflicts: Vec<String>,
+    /// Result of the last verify command run (started with 'V')
+    pub verify_status: Option<VerifyStatus>,
+    /// Output of the last verify command run, kept apart from the conversation
+    pub verify_output: VecDeque<String>,
+    /// Files written by the agent, most recent first (browse with 'o')
+    pub recent_files: Vec<RecentFile>,
+    /// Work tree snapshots taken at session start and after each turn, oldest first (diff with 'C')
+    pub workspace_snapshots: Vec<WorkspaceSnapshot>,
+    /// Paths the agent wrote outside its project directory (and allowed_write_dirs)
+    pub outside_writes: Vec<PathBuf>,
+    /// Why each completed turn ended, oldest first
+    pub stop_reasons: Vec<StopReason>,
+    /// Subjects of commits made in the session's repo since it started, newest first
+    pub session_commits: Vec<String>,
+    /// Tags on messages, keyed by index into `output`
+    pub message_tags: BTreeMap<usize, MessageTag>,
+    /// Messages whose tool results are shown with their ANSI colors rather
+    /// than stripped ('c' while tagging), keyed by index into `output`
+    pub ansi_messages: BTreeSet<usize>,
+    /// IDs of tool calls that read from the web (WebSearch, WebFetch)
+    pub web_tool_calls: HashSet<String>,
+    /// Cached summary from the summary command (made with 'S')
+    pub summary: Option<SessionSummary>,
+    /// Lines moved from the start of `output` to `spill_file` to stay within
+    /// the memory budget
+    pub trimmed_lines: usize,
+    /// Messages (prompts and agent text) among the trimmed lines, so message
+    /// numbers stay the same after a trim
+    pub trimmed_messages: usize,
+    /// File holding the trimmed lines, one JSON line each
+    pub spill_file: Option<PathBuf>,
+    /// Whether the summary command is running for this session
+    pub summarizing: bool,
+    /// Opened from a transcript file with `amux view`; there is no agent to prompt
+    pub read_only: bool,
+    /// Transcript was produced by the Agent SDK or a CI pipeline, not an interactive session
+    pub headless: bool,
+    /// Relevant environment variables when the session started
+    pub env_snapshot: Vec<EnvVar>,
+    /// Scratchpad notes on the session's human context (e.g. "waiting on a schema decision")
+    pub notes: String,
+    /// Larger piece of work this session is one part of, shared with the sessions working on the rest
+    pub epic: Option<String>,
+    /// Process ID of the running agent, for the audit log
+    pub agent_pid: Option<u32>,
+    /// Whether the working agent had a connection to its API open when last
+    /// sampled (streaming or waiting on the model, not stuck locally)
+    pub streaming: bool,
 }
 
 /// Re-export ModelInfo for use in session
 pub use crate::acp::ModelInfo;
 
-#[derive(Debug, Clone)]
+#[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct OutputLine {
     pub content: String,
     pub line_type: OutputType,
 }
 
-#[derive(Debug, Clone, PartialEq)]
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
 pub enum OutputType {
     Text,      // Agent response text
     UserInput, // User's prompt
-    Thought,   // Agent thinking (ephemeral, removed when new content arrives)
+    Thought,   // Agent thinking (hidden once finalized unless thinking is shown)
     ToolCall {
         tool_call_id: String,
         name: String,
@@ -379,6 +588,37 @@ pub enum OutputType {
     BashCommand,   // User's bash command (prefixed with !)
     BashOutput,    // Output from a bash command
     SystemMessage, // System messages (e.g., "Cancelled")
+    WebResult {
+        tool_call_id: String, // Full result of a web tool (links listed, text expandable)
+    },
+    RawJson, // Raw ACP JSON of a turn result (shown only in debug mode)
+}
+
+/// Directory of this run's spill files: output trimmed for the memory budget
+/// (removed when amux quits or panics)
+pub fn spill_dir() -> PathBuf {
+    dirs::home_dir()
+        .unwrap_or_else(|| PathBuf::from("."))
+        .join(".amux")
+        .join("spill")
+        .join(std::process::id().to_string())
+}
+
+/// Create the spill directory, readable only by the user since spill files
+/// hold whole transcripts
+fn create_spill_dir() -> std::io::Result<PathBuf> {
+    let dir = spill_dir();
+    let mut builder = std::fs::DirBuilder::new();
+    builder.recursive(true);
+    #[cfg(unix)]
+    std::os::unix::fs::DirBuilderExt::mode(&mut builder, 0o700);
+    builder.create(&dir)?;
+    Ok(dir)
+}
+
+/// Delete this run's spill files
+pub fn remove_spill_dir() {
+    let _ = std::fs::remove_dir_all(spill_dir());
 }
 
 impl Session {
@@ -409,9 +649,15 @@ impl Session {
             pending_permission: None,
             pending_question: None,
             plan_entries: vec![],
+            plan_started_at: None,
+            current_task_active_form: None,
+            plan_history: vec![],
             current_mode: None,
             active_tool_call_id: None,
+            active_tool_started_at: None,
+            long_command: None,
             permission_mode: PermissionMode::default(),
+            project_mode: None,
             available_models: vec![],
             current_model_id: None,
             available_commands: vec![],
@@ -420,7 +666,41 @@ impl Session {
             input_cursor: 0,
             current_thought: None,
             idle_notified: false,
+            needs_input: false,
+            done: false,
             diff_stats: None,
+            diff_severity: crate::git::DiffSeverity::default(),
+            git_generation: 0,
+            git_dirty: false,
+            queued_prompts: VecDeque::new(),
+            running_terminals: 0,
+            interrupted: false,
+            can_load: false,
+            hidden: false,
+            changed_files: vec![],
+            file_conflicts: vec![],
+            verify_status: None,
+            verify_output: VecDeque::new(),
+            recent_files: vec![],
+            workspace_snapshots: vec![],
+            outside_writes: vec![],
+            stop_reasons: vec![],
+            session_commits: vec![],
+            message_tags: BTreeMap::new(),
+            ansi_messages: BTreeSet::new(),
+            web_tool_calls: HashSet::new(),
+            summary: None,
+            trimmed_lines: 0,
+            trimmed_messages: 0,
+            spill_file: None,
+            summarizing: false,
+            read_only: false,
+            headless: false,
+            env_snapshot: vec![],
+            notes: String::new(),
+            epic: None,
+            agent_pid: None,
+            streaming: false,
         }
     }
 
@@ -502,6 +782,301 @@ impl Session {
         })
     }
 
+    /// Get the plan entry the agent is currently working on (first in-progress entry)
+    pub fn current_task(&self) -> Option<&PlanEntry> {
+        self.plan_entries
+            .iter()
+            .find(|e| e.status == PlanStatus::InProgress)
+    }
+
+    /// What the agent says it's doing for the current task ("Fixing the
+    /// parser"). Plans don't carry it, so it's read from the todo list of the
+    /// latest TodoWrite call (`rawInput.todos[].activeForm`).
+    pub fn current_task_active_form(&self) -> Option<&str> {
+        self.current_task_active_form.as_deref()
+    }
+
+    /// Look up the current task's active form again after the plan or a todo
+    /// list changed
+    fn update_current_task_active_form(&mut self) {
+        self.current_task_active_form = self.find_current_task_active_form();
+    }
+
+    fn find_current_task_active_form(&self) -> Option<String> {
+        let task = self.current_task()?;
+        self.output.iter().rev().find_map(|line| {
+            let OutputType::ToolCall { raw_json, .. } = &line.line_type else {
+                return None;
+            };
+            raw_json.iter().rev().find_map(|json| {
+                let value: serde_json::Value = serde_json::from_str(json).ok()?;
+                let todo = value
+                    .pointer("/rawInput/todos")?
+                    .as_array()?
+                    .iter()
+                    .find(|todo| {
+                        todo.get("content").and_then(serde_json::Value::as_str)
+                            == Some(task.content.as_str())
+                    })?;
+                Some(todo.get("activeForm")?.as_str()?.to_string())
+            })
+        })
+    }
+
+    /// Output lines shown by the errors-only filter: errors, failed tool calls
+    /// with their output, and warnings, each with `context` lines around them
+    pub fn error_lines(&self, context: usize) -> BTreeSet<usize> {
+        let mut lines = BTreeSet::new();
+        let mut in_failed_tool = false;
+        for (index, line) in self.output.iter().enumerate() {
+            let is_hit = match &line.line_type {
+                OutputType::Error => true,
+                OutputType::ToolCall { failed, .. } => {
+                    in_failed_tool = *failed;
+                    *failed
+                }
+                OutputType::ToolOutput
+                | OutputType::DiffAdd
+                | OutputType::DiffRemove
+                | OutputType::DiffContext
+                | OutputType::DiffHeader
+                    if in_failed_tool =>
+                {
+                    true
+                }
+                OutputType::UserInput | OutputType::Thought | OutputType::RawJson => false,
+                _ => {
+                    let content = line.content.trim_start().to_lowercase();
+                    content.starts_with('⚠')
+                        || content.starts_with("warning")
+                        || content.contains("warning:")
+                }
+            };
+            if !matches!(
+                line.line_type,
+                OutputType::ToolCall { .. }
+                    | OutputType::ToolOutput
+                    | OutputType::DiffAdd
+                    | OutputType::DiffRemove
+                    | OutputType::DiffContext
+                    | OutputType::DiffHeader
+            ) {
+                in_failed_tool = false;
+            }
+            if is_hit {
+                let last = (index + context).min(self.output.len() - 1);
+                lines.extend(index.saturating_sub(context)..=last);
+            }
+        }
+        lines
+    }
+
+    /// Whether the agent has a plan and every entry of it is completed
+    pub fn plan_completed(&self) -> bool {
+        !self.plan_entries.is_empty()
+            && self
+                .plan_entries
+                .iter()
+                .all(|e| e.status == PlanStatus::Completed)
+    }
+
+    /// Whether the agent is in the middle of a task: running a turn or with a
+    /// todo in progress
+    pub fn is_mid_task(&self) -> bool {
+        matches!(
+            self.state,
+            SessionState::Prompting
+                | SessionState::AwaitingPermission
+                | SessionState::AwaitingUserInput
+        ) || self.current_task().is_some()
+    }
+
+    /// Text of the agent's latest message
+    pub fn last_agent_text(&self) -> Option<&str> {
+        self.output
+            .iter()
+            .rev()
+            .filter(|line| matches!(line.line_type, OutputType::Text))
+            .map(|line| line.content.as_str())
+            .find(|content| !content.trim().is_empty())
+    }
+
+    /// First line of the agent's latest message, for a preview in the session list
+    pub fn last_agent_message(&self) -> Option<&str> {
+        self.last_agent_text()?
+            .lines()
+            .map(str::trim)
+            .find(|l| !l.is_empty())
+    }
+
+    /// Record how a turn ended, noting unusual endings in the output
+    pub fn record_stop_reason(&mut self, reason: StopReason) {
+        // Cancellation is already shown when the user cancels
+        if !matches!(reason, StopReason::EndTurn | StopReason::Cancelled) {
+            self.add_output(
+                format!("Turn ended: {}", reason.description()),
+                OutputType::SystemMessage,
+            );
+        }
+        self.stop_reasons.push(reason);
+    }
+
+    /// Number of turns cut off by the max token limit
+    pub fn truncated_turns(&self) -> usize {
+        self.stop_reasons
+            .iter()
+            .filter(|r| **r == StopReason::MaxTokens)
+            .count()
+    }
+
+    /// Whether an output line is a message that can be tagged (prompts and agent text)
+    pub fn is_taggable(&self, index: usize) -> bool {
+        self.output
+            .get(index)
+            .is_some_and(|line| match line.line_type {
+                OutputType::UserInput => true,
+                OutputType::Text => !line.content.is_empty(),
+                _ => false,
+            })
+    }
+
+    /// Find the nearest taggable message before or after `index`
+    pub fn adjacent_taggable(&self, index: usize, forward: bool) -> Option<usize> {
+        if forward {
+            (index + 1..self.output.len()).find(|&i| self.is_taggable(i))
+        } else {
+            (0..index).rev().find(|&i| self.is_taggable(i))
+        }
+    }
+
+    /// Set a tag on a message, or remove it if the message already has that tag
+    pub fn toggle_tag(&mut self, index: usize, tag: MessageTag) {
+        if self.message_tags.get(&index) == Some(&tag) {
+            self.message_tags.remove(&index);
+        } else if self.is_taggable(index) {
+            self.message_tags.insert(index, tag);
+        }
+    }
+
+    /// Show the tool results after a message with their ANSI colors, or
+    /// stripped again
+    pub fn toggle_ansi(&mut self, index: usize) {
+        if !self.ansi_messages.remove(&index) && self.is_taggable(index) {
+            self.ansi_messages.insert(index);
+        }
+    }
+
+    /// Count messages and their lengths per role. An agent message streams
+    /// into one text line until a tool call, thought or prompt starts the
+    /// next; amux's own notices are system messages and don't count.
+    pub fn conversation_stats(&self) -> ConversationStats {
+        let mut stats = ConversationStats::default();
+        for line in &self.output {
+            match &line.line_type {
+                OutputType::UserInput => {
+                    let prompt = line.content.strip_prefix("> ").unwrap_or(&line.content);
+                    // Slash commands typed into amux are actions, not messages
+                    if !super::is_slash_command(prompt) {
+                        stats.user_messages += 1;
+                        stats.user_chars += prompt.chars().count();
+                    }
+                }
+                // Empty text lines are spacing, not messages
+                OutputType::Text if !line.content.trim().is_empty() => {
+                    stats.agent_messages += 1;
+                    stats.agent_chars += line.content.chars().count();
+                }
+                OutputType::ToolCall { .. } => stats.tool_calls += 1,
+                _ => {}
+            }
+        }
+        stats
+    }
+
+    /// Replace the plan, restarting the pace clock when a new plan begins.
+    /// Returns the changes appended to the plan history.
+    pub fn set_plan(&mut self, entries: Vec<PlanEntry>) -> &[PlanChange] {
+        let completed = |entries: &[PlanEntry]| {
+            entries
+                .iter()
+                .filter(|e| e.status == PlanStatus::Completed)
+                .count()
+        };
+        // A plan with fewer completed entries than before is a fresh plan
+        if self.plan_started_at.is_none() || completed(&entries) < completed(&self.plan_entries) {
+            self.plan_started_at = Some(Instant::now());
+        }
+        let recorded = self.plan_history.len();
+        self.record_plan_changes(&entries);
+        self.plan_entries = entries;
+        self.update_current_task_active_form();
+        &self.plan_history[recorded..]
+    }
+
+    /// Append the differences between the current plan and `entries` to the plan history
+    fn record_plan_changes(&mut self, entries: &[PlanEntry]) {
+        let at = Local::now();
+        let mut changes = vec![];
+
+        for entry in entries {
+            let previous = self
+                .plan_entries
+                .iter()
+                .find(|e| e.content == entry.content);
+            let kind = match (previous.map(|e| &e.status), &entry.status) {
+                (None, PlanStatus::Completed) => Some(PlanChangeKind::Completed),
+                (None, PlanStatus::InProgress) => Some(PlanChangeKind::Started),
+                (None, _) => Some(PlanChangeKind::Added),
+                (Some(old), PlanStatus::Completed) if *old != PlanStatus::Completed => {
+                    Some(PlanChangeKind::Completed)
+                }
+                (Some(old), PlanStatus::InProgress) if *old != PlanStatus::InProgress => {
+                    Some(PlanChangeKind::Started)
+                }
+                _ => None,
+            };
+            if let Some(kind) = kind {
+                changes.push((kind, entry.content.clone()));
+            }
+        }
+
+        for old in &self.plan_entries {
+            if !entries.iter().any(|e| e.content == old.content) {
+                changes.push((PlanChangeKind::Removed, old.content.clone()));
+            }
+        }
+
+        self.plan_history
+            .extend(
+                changes
+                    .into_iter()
+                    .map(|(kind, content)| PlanChange { at, kind, content }),
+            );
+    }
+
+    /// Plan progress as (completed, total, estimated time remaining).
+    /// The estimate extrapolates the average time per completed entry so far.
+    pub fn plan_progress(&self) -> Option<(usize, usize, Option<Duration>)> {
+        if self.plan_entries.is_empty() {
+            return None;
+        }
+        let total = self.plan_entries.len();
+        let completed = self
+            .plan_entries
+            .iter()
+            .filter(|e| e.status == PlanStatus::Completed)
+            .count();
+
+        let eta = match self.plan_started_at {
+            Some(started) if completed > 0 && completed < total => {
+                let per_entry = started.elapsed() / completed as u32;
+                Some(per_entry * (total - completed) as u32)
+            }
+            _ => None,
+        };
+        Some((completed, total, eta))
+    }
+
     /// Scroll up by n lines. If at bottom (usize::MAX), first normalize to actual position.
     pub fn scroll_up(&mut self, n: usize, total_lines: usize, viewport_height: usize) {
         // Normalize usize::MAX to actual bottom position
@@ -522,6 +1097,119 @@ impl Session {
         self.scroll_offset = self.scroll_offset.saturating_add(n).min(max_scroll);
     }
 
+    /// Whether the view follows new output (scrolled to the bottom sentinel)
+    pub fn is_following(&self) -> bool {
+        self.scroll_offset == usize::MAX
+    }
+
+    /// Approximate memory held by the output, in bytes
+    pub fn output_bytes(&self) -> usize {
+        self.output
+            .iter()
+            .map(|line| {
+                let raw_json = match &line.line_type {
+                    OutputType::ToolCall { raw_json, .. } => raw_json.iter().map(String::len).sum(),
+                    _ => 0,
+                };
+                std::mem::size_of::<OutputLine>() + line.content.len() + raw_json
+            })
+            .sum()
+    }
+
+    /// Move all but the last `keep` output lines to the session's spill
+    /// file, moving everything indexed into the output along. Tagged messages
+    /// stay in memory for tag exports, so trimming stops short of the first.
+    /// Returns the number of lines moved; nothing is moved if the file can't
+    /// be written.
+    pub fn trim_output(&mut self, keep: usize) -> std::io::Result<usize> {
+        let first_tagged = self.message_tags.keys().next().copied();
+        let drop = self
+            .output
+            .len()
+            .saturating_sub(keep)
+            .min(first_tagged.unwrap_or(usize::MAX));
+        if drop == 0 {
+            return Ok(0);
+        }
+        let path = create_spill_dir()?.join(format!("{}.jsonl", self.id));
+        let mut options = std::fs::OpenOptions::new();
+        options.create(true).append(true);
+        #[cfg(unix)]
+        std::os::unix::fs::OpenOptionsExt::mode(&mut options, 0o600);
+        let mut file = std::io::BufWriter::new(options.open(&path)?);
+        for line in &self.output[..drop] {
+            serde_json::to_writer(&mut file, line)?;
+            file.write_all(b"\n")?;
+        }
+        file.flush()?;
+        self.spill_file = Some(path);
+
+        self.trimmed_messages += (0..drop).filter(|&i| self.is_taggable(i)).count();
+        self.output.drain(..drop);
+        self.trimmed_lines += drop;
+        self.shift_indices(|index| index.checked_sub(drop));
+        Ok(drop)
+    }
+
+    /// Move indices into `output` (tags, colored messages, summary and
+    /// snapshot positions); indices mapped to None are forgotten
+    fn shift_indices(&mut self, shift: impl Fn(usize) -> Option<usize>) {
+        self.message_tags = std::mem::take(&mut self.message_tags)
+            .into_iter()
+            .filter_map(|(index, tag)| Some((shift(index)?, tag)))
+            .collect();
+        self.ansi_messages = std::mem::take(&mut self.ansi_messages)
+            .into_iter()
+            .filter_map(&shift)
+            .collect();
+        if let Some(summary) = &mut self.summary {
+            summary.output_len = shift(summary.output_len).unwrap_or(0);
+        }
+        for snapshot in &mut self.workspace_snapshots {
+            snapshot.output_len = shift(snapshot.output_len).unwrap_or(0);
+        }
+    }
+
+    /// Drop all output and everything indexed into it, before the agent
+    /// replays the conversation when its session is loaded again
+    pub fn clear_output(&mut self) {
+        self.output.clear();
+        self.shift_indices(|_| None);
+        self.trimmed_lines = 0;
+        self.trimmed_messages = 0;
+        self.remove_spill_file();
+        self.scroll_offset = 0;
+        self.total_rendered_lines = 0;
+    }
+
+    /// A copy of the session with the lines moved to its spill file back in
+    /// `output`, for exporting and archiving all of it
+    pub fn with_spilled_output(&self) -> std::io::Result<Session> {
+        let mut session = self.clone();
+        let Some(path) = &self.spill_file else {
+            return Ok(session);
+        };
+        let mut output = Vec::with_capacity(self.trimmed_lines + self.output.len());
+        for line in std::io::BufReader::new(std::fs::File::open(path)?).lines() {
+            output.push(serde_json::from_str(&line?)?);
+        }
+        let restored = output.len();
+        output.append(&mut session.output);
+        session.output = output;
+        session.shift_indices(|index| Some(index + restored));
+        session.trimmed_lines = 0;
+        session.trimmed_messages = 0;
+        session.spill_file = None;
+        Ok(session)
+    }
+
+    /// Delete the spill file (when the session is killed)
+    pub fn remove_spill_file(&mut self) {
+        if let Some(path) = self.spill_file.take() {
+            let _ = std::fs::remove_file(path);
+        }
+    }
+
     /// Scroll to bottom of output (uses sentinel value, renderer handles actual positioning)
     pub fn scroll_to_bottom(&mut self) {
         self.scroll_offset = usize::MAX;
@@ -532,6 +1220,60 @@ impl Session {
         self.tokens_input + self.tokens_output
     }
 
+    /// Flag a write outside the project, warning in the output the first time a path is seen
+    pub fn record_outside_write(&mut self, path: PathBuf) {
+        if self.outside_writes.contains(&path) {
+            return;
+        }
+        self.add_output(
+            format!("⚠ Write outside project: {}", path.display()),
+            OutputType::Error,
+        );
+        self.outside_writes.push(path);
+    }
+
+    /// Remember a file written by the agent (moves it to the front if already known)
+    pub fn record_file_write(&mut self, path: PathBuf, changed_lines: Vec<usize>) {
+        self.recent_files.retain(|f| f.path != path);
+        self.recent_files.insert(
+            0,
+            RecentFile {
+                path,
+                changed_lines,
+            },
+        );
+        self.recent_files.truncate(MAX_RECENT_FILES);
+    }
+
+    /// Append a line of verify output, dropping the oldest beyond the limit
+    pub fn add_verify_output(&mut self, line: String) {
+        if self.verify_output.len() == MAX_VERIFY_LINES {
+            self.verify_output.pop_front();
+        }
+        self.verify_output.push_back(line);
+    }
+
+    /// Keep a work tree snapshot, unless nothing changed since the last one
+    pub fn add_workspace_snapshot(&mut self, snapshot: WorkspaceSnapshot) {
+        if self
+            .workspace_snapshots
+            .last()
+            .is_some_and(|last| last.tree == snapshot.tree)
+        {
+            return;
+        }
+        self.workspace_snapshots.push(snapshot);
+    }
+
+    /// Prompt of the latest turn, for labeling what happened in it
+    pub fn last_prompt(&self) -> Option<&str> {
+        self.output
+            .iter()
+            .rev()
+            .find(|line| line.line_type == OutputType::UserInput)
+            .map(|line| line.content.strip_prefix("> ").unwrap_or(&line.content))
+    }
+
     pub fn add_output(&mut self, content: String, line_type: OutputType) {
         self.output.push(OutputLine { content, line_type });
         self.last_activity = Some(Instant::now());
@@ -551,22 +1293,24 @@ impl Session {
         self.add_output(text, OutputType::Text);
     }
 
-    /// Set or replace the current thought
-    /// If the last output line is an unfinalized Thought, replaces its content entirely
+    /// Append a thought chunk to the current thought
+    /// If the last output line is an unfinalized Thought, appends to its content
     /// Otherwise creates a new thought line
     pub fn set_thought(&mut self, text: String) {
-        // Update the ephemeral current_thought for title bar
-        self.current_thought = Some(text.clone());
-
-        // Check if the last output line is a Thought - if so, replace it
-        if let Some(last) = self.output.last_mut()
+        // Check if the last output line is the thought in progress - if so, extend it
+        if self.current_thought.is_some()
+            && let Some(last) = self.output.last_mut()
             && matches!(last.line_type, OutputType::Thought)
         {
-            last.content = text;
+            last.content.push_str(&text);
+            self.current_thought = Some(last.content.clone());
             self.last_activity = Some(Instant::now());
             return;
         }
 
+        // Update the ephemeral current_thought for title bar
+        self.current_thought = Some(text.clone());
+
         // Create a new thought line
         self.output.push(OutputLine {
             content: text,
@@ -575,30 +1319,15 @@ impl Session {
         self.last_activity = Some(Instant::now());
     }
 
-    /// Remove the current thought line (called when non-thought content arrives)
-    /// Thinking is ephemeral and should only be shown while actively thinking
+    /// Finalize the current thought (called when non-thought content arrives)
+    /// The thought line stays in output so it can be revealed with 'T'
     pub fn finalize_thought(&mut self) {
-        // Clear the ephemeral thought
         self.current_thought = None;
-
-        // Remove the last thought line from output if it exists
-        if let Some(last) = self.output.last()
-            && matches!(last.line_type, OutputType::Thought)
-        {
-            self.output.pop();
-        }
     }
 
-    /// Clear the current thought (called when response/tool output arrives)
+    /// Clear the current thought (called when the prompt completes)
     pub fn clear_thought(&mut self) {
         self.current_thought = None;
-
-        // Remove the last thought line from output if it exists
-        if let Some(last) = self.output.last()
-            && matches!(last.line_type, OutputType::Thought)
-        {
-            self.output.pop();
-        }
     }
 
     /// Check if a tool call with this ID already exists
@@ -616,6 +1345,23 @@ impl Session {
         name: String,
         description: Option<String>,
         raw_json: Option<String>,
+    ) {
+        // A TodoWrite call may arrive after the plan it belongs to
+        let has_todos = raw_json
+            .as_ref()
+            .is_some_and(|json| json.contains("\"todos\""));
+        self.add_or_update_tool_call(tool_call_id, name, description, raw_json);
+        if has_todos {
+            self.update_current_task_active_form();
+        }
+    }
+
+    fn add_or_update_tool_call(
+        &mut self,
+        tool_call_id: String,
+        name: String,
+        description: Option<String>,
+        raw_json: Option<String>,
     ) {
         // Check if we already have this tool call - if so, update it
         for line in self.output.iter_mut().rev() {
@@ -647,6 +1393,8 @@ impl Session {
 
         // New tool call - add it
         self.active_tool_call_id = Some(tool_call_id.clone());
+        self.active_tool_started_at = Some(Instant::now());
+        self.long_command = None;
         self.output.push(OutputLine {
             content: String::new(),
             line_type: OutputType::ToolCall {
@@ -663,6 +1411,33 @@ impl Session {
     /// Mark the current tool as complete
     pub fn complete_active_tool(&mut self) {
         self.active_tool_call_id = None;
+        self.active_tool_started_at = None;
+        self.long_command = None;
+    }
+
+    /// Shell command of the active tool call and how long it has been running,
+    /// if the active tool is a Bash command
+    pub fn running_command(&self) -> Option<(String, Duration)> {
+        let active_id = self.active_tool_call_id.as_ref()?;
+        let started_at = self.active_tool_started_at?;
+        let raw_json = self
+            .output
+            .iter()
+            .rev()
+            .find_map(|line| match &line.line_type {
+                OutputType::ToolCall {
+                    tool_call_id,
+                    raw_json,
+                    ..
+                } if tool_call_id == active_id => Some(raw_json),
+                _ => None,
+            })?;
+        let command = raw_json.iter().find_map(|json| {
+            let value: serde_json::Value = serde_json::from_str(json).ok()?;
+            let command = value.get("rawInput")?.get("command")?.as_str()?;
+            Some(command.trim().lines().next()?.to_string())
+        })?;
+        Some((command, started_at.elapsed()))
     }
 
     /// Mark a tool call as failed
@@ -681,11 +1456,22 @@ impl Session {
         }
         // Also complete the tool so it stops spinning
         if self.active_tool_call_id.as_ref() == Some(&tool_call_id.to_string()) {
-            self.active_tool_call_id = None;
+            self.complete_active_tool();
         }
     }
 
     /// Add tool output, parsing for diff content
+    /// Set the result text of a web tool call, replacing an earlier update's text
+    pub fn set_web_result(&mut self, tool_call_id: String, text: String) {
+        let existing = self.output.iter_mut().rev().find(|line| {
+            matches!(&line.line_type, OutputType::WebResult { tool_call_id: id } if *id == tool_call_id)
+        });
+        match existing {
+            Some(line) => line.content = text,
+            None => self.add_output(text, OutputType::WebResult { tool_call_id }),
+        }
+    }
+
     pub fn add_tool_output(&mut self, content: String) {
         // Skip status-only lines like "completed", "running", etc.
         let dominated = content.trim().to_lowercase();
@@ -759,9 +1545,15 @@ impl Session {
             pending_permission: None,
             pending_question: None,
             plan_entries: vec![],
+            plan_started_at: None,
+            current_task_active_form: None,
+            plan_history: vec![],
             current_mode: None,
             active_tool_call_id: None,
+            active_tool_started_at: None,
+            long_command: None,
             permission_mode: PermissionMode::default(),
+            project_mode: None,
             available_models: vec![],
             current_model_id: None,
             available_commands: vec![],
@@ -770,7 +1562,189 @@ impl Session {
             input_cursor: 0,
             current_thought: None,
             idle_notified: false,
+            needs_input: false,
+            done: false,
             diff_stats: None,
+            diff_severity: crate::git::DiffSeverity::default(),
+            git_generation: 0,
+            git_dirty: false,
+            queued_prompts: VecDeque::new(),
+            running_terminals: 0,
+            interrupted: false,
+            can_load: false,
+            hidden: false,
+            changed_files: vec![],
+            file_conflicts: vec![],
+            verify_status: None,
+            verify_output: VecDeque::new(),
+            recent_files: vec![],
+            workspace_snapshots: vec![],
+            outside_writes: vec![],
+            stop_reasons: vec![],
+            session_commits: vec![],
+            message_tags: BTreeMap::new(),
+            ansi_messages: BTreeSet::new(),
+            web_tool_calls: HashSet::new(),
+            summary: None,
+            trimmed_lines: 0,
+            trimmed_messages: 0,
+            spill_file: None,
+            summarizing: false,
+            read_only: false,
+            headless: false,
+            env_snapshot: vec![],
+            notes: String::new(),
+            epic: None,
+            agent_pid: None,
+            streaming: false,
         }
     }
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_conversation_stats() {
+        let mut session = Session::mock("1", "api", AgentType::ClaudeCode, "main");
+        session.output.clear();
+        let lines = [
+            (
+                "Session ready. Press [i] to type.",
+                OutputType::SystemMessage,
+            ),
+            ("> fix it", OutputType::UserInput),
+            ("Looking.", OutputType::Text),
+            ("", OutputType::Text),
+            (
+                "Read auth.rs",
+                OutputType::ToolCall {
+                    tool_call_id: "t1".to_string(),
+                    name: "Read".to_string(),
+                    description: None,
+                    failed: false,
+                    raw_json: vec![],
+                },
+            ),
+            ("Fixed.\nAll tests pass.", OutputType::Text),
+            ("", OutputType::Text),
+            ("> /compact", OutputType::UserInput),
+            ("Disconnected", OutputType::SystemMessage),
+        ];
+        for (content, line_type) in lines {
+            session.add_output(content.to_string(), line_type);
+        }
+
+        let stats = session.conversation_stats();
+        assert_eq!(
+            (
+                stats.user_messages,
+                stats.user_chars,
+                stats.agent_messages,
+                stats.agent_chars,
+                stats.tool_calls
+            ),
+            (1, 6, 2, 8 + 22, 1)
+        );
+        assert_eq!(stats.avg_user_len(), 6);
+        assert_eq!(stats.agent_turns_per_user(), 2.0);
+    }
+
+    #[test]
+    fn test_clear_output() {
+        let mut session = Session::mock("1", "api", AgentType::ClaudeCode, "main");
+        session.add_output("> fix it".to_string(), OutputType::UserInput);
+        session.message_tags.insert(1, MessageTag::Bug);
+        session.ansi_messages.insert(1);
+        session.trimmed_lines = 40;
+        session.trimmed_messages = 3;
+
+        session.clear_output();
+        assert!(session.output.is_empty());
+        assert!(session.message_tags.is_empty());
+        assert!(session.ansi_messages.is_empty());
+        assert_eq!((session.trimmed_lines, session.trimmed_messages), (0, 0));
+    }
+
+    #[test]
+    fn test_current_task_active_form() {
+        let mut session = Session::mock("1", "api", AgentType::ClaudeCode, "main");
+        session.output.clear();
+        session.set_plan(vec![PlanEntry {
+            content: "Fix the parser".to_string(),
+            priority: crate::acp::protocol::PlanPriority::Medium,
+            status: PlanStatus::InProgress,
+            meta: None,
+        }]);
+        assert_eq!(session.current_task_active_form(), None);
+
+        // The todo list arriving after the plan is picked up too
+        let todos = r#"{"rawInput":{"todos":[
+            {"content":"Read the parser","status":"completed","activeForm":"Reading the parser"},
+            {"content":"Fix the parser","status":"in_progress","activeForm":"Fixing the parser"}]}}"#;
+        session.add_tool_call(
+            "t1".to_string(),
+            "Update Todos".to_string(),
+            None,
+            Some(todos.to_string()),
+        );
+        assert_eq!(
+            session.current_task_active_form(),
+            Some("Fixing the parser")
+        );
+    }
+
+    #[test]
+    fn test_record_plan_changes() {
+        let entry = |content: &str, status: PlanStatus| PlanEntry {
+            content: content.to_string(),
+            priority: crate::acp::protocol::PlanPriority::Medium,
+            status,
+            meta: None,
+        };
+        let kinds = |changes: &[PlanChange]| {
+            changes
+                .iter()
+                .map(|c| (c.kind, c.content.clone()))
+                .collect::<Vec<_>>()
+        };
+        let mut session = Session::mock("1", "api", AgentType::ClaudeCode, "main");
+
+        let changes = session.set_plan(vec![
+            entry("Read the parser", PlanStatus::InProgress),
+            entry("Fix the parser", PlanStatus::Pending),
+        ]);
+        assert_eq!(
+            kinds(changes),
+            vec![
+                (PlanChangeKind::Started, "Read the parser".to_string()),
+                (PlanChangeKind::Added, "Fix the parser".to_string()),
+            ]
+        );
+
+        // Unchanged entries record nothing; dropped ones are removals
+        let changes = session.set_plan(vec![
+            entry("Read the parser", PlanStatus::Completed),
+            entry("Fix the parser", PlanStatus::Pending),
+            entry("Run the tests", PlanStatus::Pending),
+        ]);
+        assert_eq!(
+            kinds(changes),
+            vec![
+                (PlanChangeKind::Completed, "Read the parser".to_string()),
+                (PlanChangeKind::Added, "Run the tests".to_string()),
+            ]
+        );
+        let changes = session.set_plan(vec![entry("Fix the parser", PlanStatus::InProgress)]);
+        assert_eq!(
+            kinds(changes),
+            vec![
+                (PlanChangeKind::Started, "Fix the parser".to_string()),
+                (PlanChangeKind::Removed, "Read the parser".to_string()),
+                (PlanChangeKind::Removed, "Run the tests".to_string()),
+            ]
+        );
+        assert_eq!(session.plan_history.len(), 7);
+    }
+}
@@ -0,0 +1,180 @@
+//! Machine-readable snapshot of the session list.
+//!
+//! With `snapshot = true` in the config, amux writes its sessions to
+//! `~/.local/state/amux/agents.json` (or under `$XDG_STATE_HOME`) every few
+//! seconds, so prompts and statuslines like starship can show fleet state
+//! without invoking amux. The file is replaced atomically, so readers never
+//! see a partial write.
+
+use std::path::{Path, PathBuf};
+
+use chrono::Local;
+use serde::{Deserialize, Serialize};
+
+use crate::acp::PlanStatus;
+use crate::session::Session;
+
+/// One session in the snapshot
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct AgentSnapshot {
+    pub name: String,
+    pub agent: String,
+    /// Agent-side session ID (names Claude Code's session file)
+    #[serde(default)]
+    pub session_id: Option<String>,
+    /// "working", "permission", "question", "done", "idle" or "interrupted"
+    pub status: String,
+    pub cwd: PathBuf,
+    pub branch: String,
+    pub pid: Option<u32>,
+    pub epic: Option<String>,
+    /// Todo the agent is working on
+    pub current_task: Option<String>,
+    pub todos_done: usize,
+    pub todos_total: usize,
+    /// Seconds since the agent last produced output
+    pub idle_secs: Option<u64>,
+}
+
+/// All sessions at one point in time
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct Snapshot {
+    /// RFC 3339 time the snapshot was taken
+    pub updated_at: String,
+    /// Process ID of the amux that wrote the snapshot
+    #[serde(default)]
+    pub amux_pid: Option<u32>,
+    /// Sessions working, waiting on the user and idle, for one-glance prompts
+    pub working: usize,
+    pub waiting: usize,
+    pub idle: usize,
+    pub agents: Vec<AgentSnapshot>,
+}
+
+/// Directory amux keeps state files in (`$XDG_STATE_HOME/amux`)
+pub fn state_dir() -> PathBuf {
+    std::env::var_os("XDG_STATE_HOME")
+        .map(PathBuf::from)
+        .filter(|dir| dir.is_absolute())
+        .or_else(|| dirs::home_dir().map(|home| home.join(".local").join("state")))
+        .unwrap_or_else(|| PathBuf::from("."))
+        .join("amux")
+}
+
+/// Path of the snapshot file
+pub fn snapshot_path() -> PathBuf {
+    state_dir().join("agents.json")
+}
+
+/// Read the snapshot file, if there is one
+pub fn read() -> Option<Snapshot> {
+    let text = std::fs::read_to_string(snapshot_path()).ok()?;
+    serde_json::from_str(&text).ok()
+}
+
+/// Status of a session as reported in the snapshot
+fn status(session: &Session) -> &'static str {
+    if session.pending_permission.is_some() {
+        "permission"
+    } else if session.pending_question.is_some() || session.needs_input {
+        "question"
+    } else if session.interrupted {
+        "interrupted"
+    } else if session.state.is_active() {
+        "working"
+    } else if session.done {
+        "done"
+    } else {
+        "idle"
+    }
+}
+
+impl Snapshot {
+    /// Snapshot of the given sessions, taken now
+    pub fn from_sessions(sessions: &[Session]) -> Self {
+        let agents: Vec<AgentSnapshot> = sessions
+            .iter()
+            .filter(|s| !s.read_only)
+            .map(|session| AgentSnapshot {
+                name: session.name.clone(),
+                agent: session.agent_type.display_name().to_string(),
+                session_id: session.acp_session_id.clone(),
+                status: status(session).to_string(),
+                cwd: session.cwd.clone(),
+                branch: session.git_branch.clone(),
+                pid: session.agent_pid,
+                epic: session.epic.clone(),
+                current_task: session.current_task().map(|e| e.content.clone()),
+                todos_done: session
+                    .plan_entries
+                    .iter()
+                    .filter(|e| e.status == PlanStatus::Completed)
+                    .count(),
+                todos_total: session.plan_entries.len(),
+                idle_secs: session.last_activity.map(|t| t.elapsed().as_secs()),
+            })
+            .collect();
+        let count = |wanted: &[&str]| {
+            agents
+                .iter()
+                .filter(|a| wanted.contains(&a.status.as_str()))
+                .count()
+        };
+
+        Self {
+            updated_at: Local::now().to_rfc3339(),
+            amux_pid: Some(std::process::id()),
+            working: count(&["working"]),
+            waiting: count(&["permission", "question"]),
+            idle: count(&["done", "idle", "interrupted"]),
+            agents,
+        }
+    }
+}
+
+/// Write the snapshot to `path`, replacing the file atomically
+pub fn write(path: &Path, snapshot: &Snapshot) -> std::io::Result<()> {
+    if let Some(dir) = path.parent() {
+        std::fs::create_dir_all(dir)?;
+    }
+    // Rename within the same directory so the replacement is atomic
+    let tmp = path.with_extension("json.tmp");
+    std::fs::write(&tmp, serde_json::to_string_pretty(snapshot)?)?;
+    std::fs::rename(&tmp, path)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::session::AgentType;
+
+    #[test]
+    fn test_from_sessions_counts_statuses() {
+        let mut working = Session::mock("working", "working", AgentType::ClaudeCode, "main");
+        working.state = crate::session::SessionState::Prompting;
+        let mut waiting = Session::mock("waiting", "waiting", AgentType::ClaudeCode, "main");
+        waiting.needs_input = true;
+        let idle = Session::mock("idle", "idle", AgentType::ClaudeCode, "main");
+
+        let snapshot = Snapshot::from_sessions(&[working, waiting, idle]);
+        assert_eq!(snapshot.working, 1);
+        assert_eq!(snapshot.waiting, 1);
+        assert_eq!(snapshot.idle, 1);
+        assert_eq!(snapshot.agents[1].status, "question");
+    }
+
+    #[test]
+    fn test_write_replaces_file() {
+        let dir = std::env::temp_dir().join(format!("amux-snapshot-{}", std::process::id()));
+        let path = dir.join("agents.json");
+        let snapshot = Snapshot::from_sessions(&[]);
+        write(&path, &snapshot).unwrap();
+        write(&path, &snapshot).unwrap();
+
+        let text = std::fs::read_to_string(&path).unwrap();
+        let value: serde_json::Value = serde_json::from_str(&text).unwrap();
+        assert_eq!(value["agents"], serde_json::json!([]));
+        assert!(!path.with_extension("json.tmp").exists());
+        std::fs::remove_dir_all(&dir).unwrap();
+    }
+}
@@ -0,0 +1,253 @@
+//! Opt-in, anonymized telemetry of amux's own performance.
+//!
+//! Nothing is sent unless `[telemetry]` is configured with `enabled = true`
+//! and an endpoint, which can be a self-hosted collector. Events are small
+//! JSON objects posted one at a time in the background: how long usage scans
+//! of `~/.claude/projects` take, how many sessions ran, and crash reports.
+//! They carry the amux version, OS and a random ID per run, never session
+//! names, paths, prompts or output; crash messages have the home directory
+//! and secrets redacted. Endpoints may be http or https; every send has a
+//! timeout, so an unresponsive endpoint never piles up requests.
+//!
+//! A crash can't be reported while amux goes down, so the panic hook leaves
+//! the report in `~/.amux/crash-report.json` and the next start with
+//! telemetry enabled sends it.
+
+use std::path::PathBuf;
+use std::sync::atomic::{AtomicBool, Ordering};
+use std::time::{Duration, Instant};
+
+use chrono::Utc;
+use serde_json::{Value, json};
+
+use crate::config::TelemetryConfig;
+use crate::http;
+use crate::log;
+use crate::otlp;
+use crate::redact::Redactor;
+use crate::usage::ScanProgress;
+
+/// Whether the panic hook leaves crash reports (telemetry is enabled)
+static CRASH_REPORTS: AtomicBool = AtomicBool::new(false);
+
+/// Longest crash message sent, in chars
+const MAX_MESSAGE_CHARS: usize = 500;
+
+/// How long an event sent in the background may take
+const SEND_TIMEOUT: Duration = Duration::from_secs(5);
+
+/// How long quitting waits for the last event to be sent
+const EXIT_TIMEOUT: Duration = Duration::from_secs(2);
+
+/// Path a crash report waits in until it's sent
+pub fn crash_report_path() -> PathBuf {
+    dirs::home_dir()
+        .unwrap_or_else(|| PathBuf::from("."))
+        .join(".amux")
+        .join("crash-report.json")
+}
+
+/// Sends telemetry events to the configured endpoint.
+#[derive(Debug)]
+pub struct Reporter {
+    /// Endpoint as configured, to notice when the config changes it
+    pub url: String,
+    endpoint: String,
+    /// Random ID grouping the events of one run
+    run_id: String,
+    started: Instant,
+    /// Most sessions open at the same time
+    max_sessions: usize,
+}
+
+impl Reporter {
+    /// Create a reporter, or None when telemetry is off or the endpoint is
+    /// unusable (with a logged reason). Sends the crash report a previous
+    /// run left behind.
+    pub fn new(config: &TelemetryConfig) -> Option<Self> {
+        if !config.enabled {
+            return None;
+        }
+        let Some(endpoint) = otlp::parse_endpoint(&config.endpoint, "/") else {
+            log::log(&format!(
+                "Telemetry disabled: endpoint must be http(s)://host[:port][/path], got {}",
+                config.endpoint
+            ));
+            return None;
+        };
+        log::log(&format!(
+            "Telemetry enabled: sending anonymized performance metrics and crash reports to {}",
+            config.endpoint
+        ));
+        let reporter = Self {
+            url: config.endpoint.clone(),
+            endpoint,
+            run_id: otlp::random_hex(8),
+            started: Instant::now(),
+            max_sessions: 0,
+        };
+        reporter.send_crash_report();
+        Some(reporter)
+    }
+
+    /// Build an event of `kind` with its data
+    fn event(&self, kind: &str, data: Value) -> Value {
+        json!({
+            "event": kind,
+            "run": self.run_id,
+            "version": env!("CARGO_PKG_VERSION"),
+            "os": std::env::consts::OS,
+            "arch": std::env::consts::ARCH,
+            "at": Utc::now().to_rfc3339(),
+            "data": data,
+        })
+    }
+
+    /// Post an event in the background; failures (including timeouts) are logged
+    fn send(&self, event: Value) {
+        let endpoint = self.endpoint.clone();
+        tokio::spawn(async move {
+            if let Err(e) = http::post_json(&endpoint, &event.to_string(), SEND_TIMEOUT).await {
+                log::log(&format!("Telemetry event not sent: {}", e));
+            }
+        });
+    }
+
+    /// A usage scan finished after `duration`
+    pub fn usage_scanned(&self, duration: Duration, progress: &ScanProgress) {
+        self.send(self.event(
+            "usage_scan",
+            json!({
+                "duration_ms": duration.as_millis() as u64,
+                "dirs": progress.dirs_total,
+                "truncated": progress.truncated,
+            }),
+        ));
+    }
+
+    /// Note how many sessions are open
+    pub fn observe_sessions(&mut self, open: usize) {
+        self.max_sessions = self.max_sessions.max(open);
+    }
+
+    /// Report the run when amux quits, waiting briefly for it to be sent
+    pub async fn run_finished(&self, open: usize) {
+        let event = self.event(
+            "run",
+            json!({
+                "duration_secs": self.started.elapsed().as_secs(),
+                "max_sessions": self.max_sessions.max(open),
+                "sessions_at_exit": open,
+            }),
+        );
+        if let Err(e) = http::post_json(&self.endpoint, &event.to_string(), EXIT_TIMEOUT).await {
+            log::log(&format!("Telemetry event not sent: {}", e));
+        }
+    }
+
+    /// Send the crash report a previous run left, removing it once it's
+    /// accepted
+    fn send_crash_report(&self) {
+        let path = crash_report_path();
+        let Ok(text) = std::fs::read_to_string(&path) else {
+            return;
+        };
+        let Ok(report) = serde_json::from_str::<Value>(&text) else {
+            let _ = std::fs::remove_file(&path);
+            return;
+        };
+        let endpoint = self.endpoint.clone();
+        tokio::spawn(async move {
+            match http::post_json(&endpoint, &report.to_string(), SEND_TIMEOUT).await {
+                Ok(()) => {
+                    let _ = std::fs::remove_file(&path);
+                }
+                Err(e) => log::log(&format!("Crash report not sent: {}", e)),
+            }
+        });
+    }
+}
+
+/// Turn crash reports on or off, following whether telemetry is enabled
+pub fn set_crash_reports(enabled: bool) {
+    CRASH_REPORTS.store(enabled, Ordering::Relaxed);
+}
+
+/// Leave a crash report for the next run to send (called from the panic
+/// hook; does nothing unless telemetry is enabled)
+pub fn record_crash(message: &str, location: &str) {
+    if !CRASH_REPORTS.load(Ordering::Relaxed) {
+        return;
+    }
+    let report = json!({
+        "event": "crash",
+        "version": env!("CARGO_PKG_VERSION"),
+        "os": std::env::consts::OS,
+        "arch": std::env::consts::ARCH,
+        "at": Utc::now().to_rfc3339(),
+        "data": {
+            "message": anonymize(message, dirs::home_dir().as_deref()),
+            "location": location,
+        },
+    });
+    let path = crash_report_path();
+    if let Some(dir) = path.parent() {
+        let _ = std::fs::create_dir_all(dir);
+    }
+    let _ = std::fs::write(&path, report.to_string());
+}
+
+/// A crash message with the home directory and secrets taken out, cut to
+/// MAX_MESSAGE_CHARS
+fn anonymize(message: &str, home: Option<&std::path::Path>) -> String {
+    let mut message = message.to_string();
+    if let Some(home) = home.and_then(|home| home.to_str()).filter(|h| h.len() > 1) {
+        message = message.replace(home, "~");
+    }
+    Redactor::default()
+        .redact(&message)
+        .chars()
+        .take(MAX_MESSAGE_CHARS)
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::path::Path;
+
+    #[test]
+    fn test_anonymize() {
+        assert_eq!(
+            anonymize(
+                "No such file: /home/ada/work/api/.env",
+                Some(Path::new("/home/ada"))
+            ),
+            "No such file: ~/work/api/.env"
+        );
+        let long = "x".repeat(2 * MAX_MESSAGE_CHARS);
+        assert_eq!(anonymize(&long, None).len(), MAX_MESSAGE_CHARS);
+    }
+
+    #[test]
+    fn test_disabled_by_default() {
+        let config: crate::config::Config = toml::from_str("").unwrap();
+        assert!(config.telemetry.is_none());
+        let config: crate::config::Config =
+            toml::from_str("[telemetry]\nendpoint = \"http://localhost:8080\"").unwrap();
+        assert!(Reporter::new(config.telemetry.as_ref().unwrap()).is_none());
+    }
+
+    #[test]
+    fn test_endpoints() {
+        assert_eq!(
+            otlp::parse_endpoint("https://telemetry.example.com", "/").as_deref(),
+            Some("https://telemetry.example.com/")
+        );
+        assert_eq!(
+            otlp::parse_endpoint("http://localhost:8080/amux", "/").as_deref(),
+            Some("http://localhost:8080/amux")
+        );
+        assert_eq!(otlp::parse_endpoint("telemetry.example.com", "/"), None);
+    }
+}
@@ -0,0 +1,184 @@
+//! `amux tmux-status`: fleet state for the tmux status bar.
+//!
+//! Reads the snapshot amux writes with `snapshot = true` (see [`crate::snapshot`])
+//! and prints a short summary with tmux `#[fg=…]` color escapes: sessions
+//! waiting on you, working, done and idle. `amux tmux-status --install` adds
+//! it to `status-right` of the running tmux server and to `~/.tmux.conf`.
+
+use std::path::{Path, PathBuf};
+use std::process::Command;
+
+use chrono::{DateTime, Local};
+
+use crate::snapshot::{self, Snapshot};
+
+/// Snapshots older than this mean amux isn't running
+const STALE_AFTER_SECS: i64 = 30;
+
+/// Seconds between status bar refreshes set by `--install`
+const STATUS_INTERVAL: u32 = 5;
+
+/// Status bar text for a snapshot (None: no snapshot file)
+pub fn status_line(snapshot: Option<&Snapshot>, now: DateTime<Local>) -> String {
+    let Some(snapshot) = snapshot else {
+        return "#[fg=colour244]amux off#[default]".to_string();
+    };
+    let fresh = DateTime::parse_from_rfc3339(&snapshot.updated_at)
+        .is_ok_and(|at| (now - at.with_timezone(&Local)).num_seconds() <= STALE_AFTER_SECS);
+    if !fresh {
+        return "#[fg=colour244]amux off#[default]".to_string();
+    }
+    if snapshot.agents.is_empty() {
+        return "#[fg=colour244]amux idle#[default]".to_string();
+    }
+
+    let done = snapshot
+        .agents
+        .iter()
+        .filter(|a| a.status == "done")
+        .count();
+    let counts = [
+        (snapshot.waiting, "colour214", "?"),
+        (snapshot.working, "green", "▶"),
+        (done, "colour39", "✓"),
+        (snapshot.idle - done, "colour244", "·"),
+    ];
+    let parts: Vec<String> = counts
+        .iter()
+        .filter(|(count, _, _)| *count > 0)
+        .map(|(count, color, symbol)| format!("#[fg={}]{}{}", color, symbol, count))
+        .collect();
+    format!("amux {}#[default]", parts.join(" "))
+}
+
+/// Run `amux tmux-status`: print the status bar text
+pub fn print_status() {
+    println!("{}", status_line(snapshot::read().as_ref(), Local::now()));
+}
+
+/// tmux commands that add the status to `status-right`
+fn install_commands(amux: &Path) -> Vec<Vec<String>> {
+    vec![
+        vec![
+            "set-option".to_string(),
+            "-ga".to_string(),
+            "status-right".to_string(),
+            format!(" #({} tmux-status)", amux.display()),
+        ],
+        vec![
+            "set-option".to_string(),
+            "-g".to_string(),
+            "status-interval".to_string(),
+            STATUS_INTERVAL.to_string(),
+        ],
+    ]
+}
+
+/// Quote a tmux.conf argument
+fn quote(arg: &str) -> String {
+    if arg.contains([' ', '#', '"', '\'']) {
+        format!("'{}'", arg.replace('\'', "'\\''"))
+    } else {
+        arg.to_string()
+    }
+}
+
+/// Run `amux tmux-status --install`: add the status to the running tmux
+/// server and to `~/.tmux.conf`, once. Returns an error message on failure.
+pub fn install(snapshot_enabled: bool) -> Result<(), String> {
+    let amux = std::env::current_exe().unwrap_or_else(|_| PathBuf::from("amux"));
+    let commands = install_commands(&amux);
+
+    let conf = dirs::home_dir()
+        .ok_or("no home directory")?
+        .join(".tmux.conf");
+    let existing = std::fs::read_to_string(&conf).unwrap_or_default();
+    if existing.contains("tmux-status") {
+        println!("{} already shows amux", conf.display());
+    } else {
+        let mut text = existing;
+        if !text.is_empty() && !text.ends_with('\n') {
+            text.push('\n');
+        }
+        text.push_str("\n# amux fleet state (amux tmux-status --install)\n");
+        for command in &commands {
+            let args: Vec<String> = command.iter().map(|arg| quote(arg)).collect();
+            text.push_str(&args.join(" "));
+            text.push('\n');
+        }
+        std::fs::write(&conf, text).map_err(|e| format!("{}: {}", conf.display(), e))?;
+        println!("Added amux to status-right in {}", conf.display());
+
+        // Apply to the running server too, if there is one
+        if std::env::var_os("TMUX").is_some() {
+            for command in &commands {
+                let status = Command::new("tmux")
+                    .args(command)
+                    .status()
+                    .map_err(|e| format!("failed to run tmux: {}", e))?;
+                if !status.success() {
+                    return Err(format!("tmux {} failed", command.join(" ")));
+                }
+            }
+            println!("Applied to the running tmux server");
+        }
+    }
+
+    if !snapshot_enabled {
+        println!(
+            "Note: set `snapshot = true` in the amux config, the status reads its snapshot file"
+        );
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::session::{AgentType, Session, SessionState};
+
+    #[test]
+    fn test_status_line() {
+        let mut working = Session::mock("1", "api", AgentType::ClaudeCode, "main");
+        working.state = SessionState::Prompting;
+        let mut waiting = Session::mock("2", "web", AgentType::ClaudeCode, "main");
+        waiting.needs_input = true;
+        let mut done = Session::mock("3", "cli", AgentType::ClaudeCode, "main");
+        done.done = true;
+        let snapshot = Snapshot::from_sessions(&[working, waiting, done]);
+
+        let line = status_line(Some(&snapshot), Local::now());
+        assert_eq!(
+            line,
+            "amux #[fg=colour214]?1 #[fg=green]▶1 #[fg=colour39]✓1#[default]"
+        );
+    }
+
+    #[test]
+    fn test_stale_or_missing_snapshot() {
+        let snapshot = Snapshot::from_sessions(&[]);
+        let later = Local::now() + chrono::Duration::minutes(5);
+        assert_eq!(
+            status_line(Some(&snapshot), later),
+            "#[fg=colour244]amux off#[default]"
+        );
+        assert_eq!(
+            status_line(None, later),
+            "#[fg=colour244]amux off#[default]"
+        );
+        assert_eq!(
+            status_line(Some(&snapshot), Local::now()),
+            "#[fg=colour244]amux idle#[default]"
+        );
+    }
+
+    #[test]
+    fn test_install_quotes_format() {
+        let commands = install_commands(Path::new("/usr/bin/amux"));
+        let args: Vec<String> = commands[0].iter().map(|arg| quote(arg)).collect();
+        assert_eq!(
+            args.join(" "),
+            "set-option -ga status-right ' #(/usr/bin/amux tmux-status)'"
+        );
+    }
+}
@@ -0,0 +1,156 @@
+//! Transcript export.
+//!
+//! Renders a session's conversation as markdown and writes it to
+//! `~/.amux/exports/`, passing every line through the configured redactor.
+
+use std::path::PathBuf;
+
+use chrono::Local;
+
+use crate::permalink;
+use crate::redact::Redactor;
+use crate::session::{MessageTag, OutputType, PlanChangeKind, Session};
+
+/// Render a session's output as markdown
+pub fn to_markdown(session: &Session) -> String {
+    let mut out = format!(
+        "# {} ({})\n\n- Directory: {}\n- Branch: {}\n\n",
+        session.name,
+        session.agent_type.display_name(),
+        session.cwd.display(),
+        session.git_branch
+    );
+
+    // Numbered like permalinks, counting messages trimmed from the output
+    let mut message = session.trimmed_messages;
+    for (index, line) in session.output.iter().enumerate() {
+        // Anchor each message so permalinks can find it
+        if session.is_taggable(index) {
+            message += 1;
+            out.push_str(&permalink::anchor(message));
+            out.push('\n');
+        }
+        let content = &line.content;
+        let rendered = match &line.line_type {
+            OutputType::Text => content.clone(),
+            OutputType::UserInput => format!("> {}", content),
+            OutputType::Thought => format!("*Thinking: {}*", content),
+            OutputType::ToolCall { name, failed, .. } => {
+                if *failed {
+                    format!("- **Tool:** {} (failed)", name)
+                } else {
+                    format!("- **Tool:** {}", name)
+                }
+            }
+            OutputType::ToolOutput | OutputType::BashOutput => format!("    {}", content),
+            OutputType::WebResult { .. } => content
+                .lines()
+                .map(|line| format!("    {}", line))
+                .collect::<Vec<_>>()
+                .join("\n"),
+            OutputType::DiffAdd => format!("    +{}", content),
+            OutputType::DiffRemove => format!("    -{}", content),
+            OutputType::DiffContext => format!("     {}", content),
+            OutputType::DiffHeader => format!("    {}", content),
+            OutputType::Error => format!("**Error:** {}", content),
+            OutputType::BashCommand => format!("`{}`", content),
+            OutputType::SystemMessage => format!("*{}*", content),
+            // Debug-only protocol data
+            OutputType::RawJson => continue,
+        };
+        out.push_str(&rendered);
+        out.push('\n');
+    }
+
+    if !session.plan_history.is_empty() {
+        out.push_str("\n## Plan history\n\n");
+        for change in &session.plan_history {
+            let kind = match change.kind {
+                PlanChangeKind::Added => "added",
+                PlanChangeKind::Started => "started",
+                PlanChangeKind::Completed => "completed",
+                PlanChangeKind::Removed => "removed",
+            };
+            out.push_str(&format!(
+                "- {} {}: {}\n",
+                change.at.format("%H:%M:%S"),
+                kind,
+                change.content
+            ));
+        }
+    }
+
+    out
+}
+
+/// Directory exports are written to
+pub(crate) fn export_dir() -> std::io::Result<PathBuf> {
+    let export_dir = dirs::home_dir()
+        .unwrap_or_else(|| PathBuf::from("."))
+        .join(".amux")
+        .join("exports");
+    std::fs::create_dir_all(&export_dir)?;
+    Ok(export_dir)
+}
+
+/// Render tagged messages across sessions as a markdown digest, grouped by tag
+pub fn tagged_digest(sessions: &[Session]) -> String {
+    let mut out = String::from("# Tagged messages\n");
+
+    for tag in MessageTag::ALL {
+        let mut section = String::new();
+        for session in sessions {
+            for (&index, _) in session.message_tags.iter().filter(|(_, t)| **t == tag) {
+                let Some(line) = session.output.get(index) else {
+                    continue;
+                };
+                let who = if line.line_type == OutputType::UserInput {
+                    "you"
+                } else {
+                    session.agent_type.display_name()
+                };
+                section.push_str(&format!(
+                    "\n### {} ({})\n\n{}\n",
+                    session.name, who, line.content
+                ));
+            }
+        }
+        if !section.is_empty() {
+            out.push_str(&format!("\n## {}\n", tag.label()));
+            out.push_str(&section);
+        }
+    }
+
+    out
+}
+
+/// Export a redacted digest of tagged messages, returning the written file path
+pub fn export_tagged(sessions: &[Session], redactor: &Redactor) -> std::io::Result<PathBuf> {
+    let timestamp = Local::now().format("%Y%m%d_%H%M%S");
+    let path = export_dir()?.join(format!("tagged_{}.md", timestamp));
+    std::fs::write(&path, redactor.redact(&tagged_digest(sessions)))?;
+    Ok(path)
+}
+
+/// Export a redacted markdown transcript, returning the written file path
+pub fn export(session: &Session, redactor: &Redactor) -> std::io::Result<PathBuf> {
+    let export_dir = export_dir()?;
+
+    // Keep the file name shell-friendly (session names can be paths)
+    let name: String = session
+        .name
+        .chars()
+        .map(|c| {
+            if c.is_alphanumeric() || c == '-' {
+                c
+            } else {
+                '_'
+            }
+        })
+        .collect();
+    let timestamp = Local::now().format("%Y%m%d_%H%M%S");
+    let path = export_dir.join(format!("{}_{}.md", name, timestamp));
+
+    std::fs::write(&path, redactor.redact(&to_markdown(session)))?;
+    Ok(path)
+}
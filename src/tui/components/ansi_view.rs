@@ -0,0 +1,214 @@
+//! ANSI view - terminal output in tool results with its colors (toggle per
+//! message with 'c' while tagging).
+//!
+//! Test runners and build tools color their output with ANSI escape
+//! sequences. By default the conversation strips them; shown, the SGR
+//! sequences (colors, bold, underline) become span styles and everything
+//! else (cursor movement, terminal titles) is dropped, so the output can't
+//! move the cursor or bleed into the rest of the view. Each line starts from
+//! the base style and is hard-wrapped like a terminal would.
+
+use ratatui::{
+    style::{Color, Modifier, Style},
+    text::Span,
+};
+
+/// Colors of SGR codes 30-37 / 40-47
+const BASIC: [Color; 8] = [
+    Color::Black,
+    Color::Red,
+    Color::Green,
+    Color::Yellow,
+    Color::Blue,
+    Color::Magenta,
+    Color::Cyan,
+    Color::Gray,
+];
+
+/// Colors of SGR codes 90-97 / 100-107
+const BRIGHT: [Color; 8] = [
+    Color::DarkGray,
+    Color::LightRed,
+    Color::LightGreen,
+    Color::LightYellow,
+    Color::LightBlue,
+    Color::LightMagenta,
+    Color::LightCyan,
+    Color::White,
+];
+
+/// Apply the codes of one SGR sequence (`ESC [ <params> m`) to `style`
+fn apply_sgr(mut style: Style, params: &str, base: Style) -> Style {
+    // "ESC [ m" and empty parameters mean reset
+    let mut codes = params.split(';').map(|p| p.parse::<u16>().unwrap_or(0));
+    while let Some(code) = codes.next() {
+        style = match code {
+            0 => base,
+            1 => style.add_modifier(Modifier::BOLD),
+            2 => style.add_modifier(Modifier::DIM),
+            3 => style.add_modifier(Modifier::ITALIC),
+            4 => style.add_modifier(Modifier::UNDERLINED),
+            7 => style.add_modifier(Modifier::REVERSED),
+            22 => style.remove_modifier(Modifier::BOLD | Modifier::DIM),
+            23 => style.remove_modifier(Modifier::ITALIC),
+            24 => style.remove_modifier(Modifier::UNDERLINED),
+            27 => style.remove_modifier(Modifier::REVERSED),
+            30..=37 => style.fg(BASIC[(code - 30) as usize]),
+            90..=97 => style.fg(BRIGHT[(code - 90) as usize]),
+            40..=47 => style.bg(BASIC[(code - 40) as usize]),
+            100..=107 => style.bg(BRIGHT[(code - 100) as usize]),
+            39 => Style {
+                fg: base.fg,
+                ..style
+            },
+            49 => Style {
+                bg: base.bg,
+                ..style
+            },
+            // 256 colors (5;n) and true color (2;r;g;b)
+            38 | 48 => {
+                let color = match codes.next() {
+                    Some(5) => codes.next().map(|n| Color::Indexed(n as u8)),
+                    Some(2) => match (codes.next(), codes.next(), codes.next()) {
+                        (Some(r), Some(g), Some(b)) => Some(Color::Rgb(r as u8, g as u8, b as u8)),
+                        _ => None,
+                    },
+                    _ => None,
+                };
+                match color {
+                    Some(color) if code == 38 => style.fg(color),
+                    Some(color) => style.bg(color),
+                    None => style,
+                }
+            }
+            _ => style,
+        };
+    }
+    style
+}
+
+/// Split a line of terminal output into runs of text and their style
+fn segments(text: &str, base: Style) -> Vec<(String, Style)> {
+    let mut segments = vec![];
+    let mut style = base;
+    let mut current = String::new();
+    let mut chars = text.chars().peekable();
+    while let Some(c) = chars.next() {
+        if c != '\x1b' {
+            if !c.is_control() || c == '\t' {
+                current.push(c);
+            }
+            continue;
+        }
+        match chars.next() {
+            // CSI: parameters up to a final byte in @..~; only SGR ('m') is kept
+            Some('[') => {
+                let mut params = String::new();
+                let mut last = None;
+                for c in chars.by_ref() {
+                    if ('@'..='~').contains(&c) {
+                        last = Some(c);
+                        break;
+                    }
+                    params.push(c);
+                }
+                if last == Some('m') {
+                    if !current.is_empty() {
+                        segments.push((std::mem::take(&mut current), style));
+                    }
+                    style = apply_sgr(style, &params, base);
+                }
+            }
+            // OSC: up to BEL or ESC \
+            Some(']') => {
+                while let Some(c) = chars.next() {
+                    if c == '\x07' {
+                        break;
+                    }
+                    if c == '\x1b' && chars.peek() == Some(&'\\') {
+                        chars.next();
+                        break;
+                    }
+                }
+            }
+            // Two-character sequences
+            _ => {}
+        }
+    }
+    if !current.is_empty() {
+        segments.push((current, style));
+    }
+    segments
+}
+
+/// Rows of a line of terminal output with its colors applied on top of
+/// `base`, hard-wrapped at `width` chars
+pub fn ansi_rows(text: &str, width: usize, base: Style) -> Vec<Vec<Span<'static>>> {
+    let mut rows = vec![];
+    let mut row: Vec<Span<'static>> = vec![];
+    let mut row_chars = 0;
+    for (segment, style) in segments(text, base) {
+        let mut rest = segment.as_str();
+        while !rest.is_empty() {
+            if width > 0 && row_chars == width {
+                rows.push(std::mem::take(&mut row));
+                row_chars = 0;
+            }
+            let room = if width == 0 {
+                usize::MAX
+            } else {
+                width - row_chars
+            };
+            let split = rest.char_indices().nth(room).map_or(rest.len(), |(i, _)| i);
+            let (head, tail) = rest.split_at(split);
+            row_chars += head.chars().count();
+            row.push(Span::styled(head.to_string(), style));
+            rest = tail;
+        }
+    }
+    rows.push(row);
+    rows
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn text(rows: &[Vec<Span>]) -> Vec<String> {
+        rows.iter()
+            .map(|row| row.iter().map(|s| s.content.as_ref()).collect())
+            .collect()
+    }
+
+    #[test]
+    fn test_colors_become_styles() {
+        let base = Style::new().fg(Color::Gray);
+        let rows = ansi_rows("\x1b[32m✓\x1b[0m parses \x1b[1;31mFAIL\x1b[m", 80, base);
+        assert_eq!(text(&rows), ["✓ parses FAIL"]);
+        let spans = &rows[0];
+        assert_eq!(spans[0].style.fg, Some(Color::Green));
+        assert_eq!(spans[1].style, base);
+        assert_eq!(spans[2].style.fg, Some(Color::Red));
+        assert!(spans[2].style.add_modifier.contains(Modifier::BOLD));
+    }
+
+    #[test]
+    fn test_extended_colors() {
+        let rows = ansi_rows("\x1b[38;5;208ma\x1b[48;2;1;2;3mb", 80, Style::new());
+        assert_eq!(rows[0][0].style.fg, Some(Color::Indexed(208)));
+        assert_eq!(rows[0][1].style.bg, Some(Color::Rgb(1, 2, 3)));
+    }
+
+    #[test]
+    fn test_drops_other_sequences() {
+        let rows = ansi_rows("\x1b]0;title\x07\x1b[2K\x1b[1Gdone\r", 80, Style::new());
+        assert_eq!(text(&rows), ["done"]);
+    }
+
+    #[test]
+    fn test_hard_wraps() {
+        let rows = ansi_rows("abc\x1b[31mdefg", 4, Style::new());
+        assert_eq!(text(&rows), ["abcd", "efg"]);
+        assert_eq!(text(&ansi_rows("", 4, Style::new())), [""]);
+    }
+}
@@ -0,0 +1,75 @@
+//! Audit log popup component - recent kills, restarts and commands run.
+
+use chrono::DateTime;
+use ratatui::{
+    Frame,
+    layout::Rect,
+    style::{Color, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+};
+
+use crate::app::App;
+use crate::tui::theme::*;
+
+use super::truncate_text;
+
+/// Render the audit log, newest entry first.
+pub fn render_audit_log_popup(frame: &mut Frame, area: Rect, app: &App) {
+    // Calculate centered popup area, growing with the log up to the screen size
+    let popup_width = 80u16.min(area.width);
+    let max_rows = area.height.saturating_sub(6) as usize;
+    let rows = app.audit_entries.len().clamp(1, max_rows.max(1));
+    let popup_height = (rows as u16 + 6).min(area.height);
+    let x = area.x + (area.width.saturating_sub(popup_width)) / 2;
+    let y = area.y + (area.height.saturating_sub(popup_height)) / 2;
+    let popup_area = Rect::new(x, y, popup_width, popup_height);
+
+    // Clear the area behind the popup
+    frame.render_widget(Clear, popup_area);
+
+    let mut lines: Vec<Line> = vec![];
+
+    // Title
+    lines.push(Line::from(vec![Span::styled(
+        "Audit Log",
+        Style::new().fg(LOGO_LIGHT_BLUE).bold(),
+    )]));
+    lines.push(Line::raw(""));
+
+    if app.audit_entries.is_empty() {
+        lines.push(Line::styled(
+            "  (nothing recorded yet)",
+            Style::new().fg(TEXT_DIM),
+        ));
+    }
+
+    let content_width = (popup_width as usize).saturating_sub(2 + 16);
+    for entry in app.audit_entries.iter().take(rows) {
+        let at = DateTime::parse_from_rfc3339(&entry.at)
+            .map(|at| at.format("%m-%d %H:%M").to_string())
+            .unwrap_or_else(|_| entry.at.clone());
+        lines.push(Line::from(vec![
+            Span::styled(format!("  {}  ", at), Style::new().fg(TEXT_DIM)),
+            Span::styled(
+                truncate_text(&entry.summary(), content_width),
+                Style::new().fg(TEXT_WHITE),
+            ),
+        ]));
+    }
+    lines.push(Line::raw(""));
+
+    // Footer
+    lines.push(Line::from(vec![
+        Span::styled("[Esc]", Style::new().fg(TEXT_WHITE)),
+        Span::styled(" close", Style::new().fg(TEXT_DIM)),
+    ]));
+
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .border_style(Style::new().fg(LOGO_LIGHT_BLUE))
+        .style(Style::new().bg(Color::Black));
+
+    let paragraph = Paragraph::new(lines).block(block);
+    frame.render_widget(paragraph, popup_area);
+}
@@ -5,23 +5,130 @@ use ratatui::{
     layout::Rect,
     style::Style,
     text::{Line, Span},
-    widgets::Paragraph,
+    widgets::{Paragraph, Scrollbar, ScrollbarOrientation, ScrollbarState},
 };
 
-use crate::app::{App, ClickRegion};
+use crate::app::{App, ClickRegion, InputMode};
+use crate::attachments;
 use crate::events::Action;
-use crate::session::{OutputType, SessionState};
+use crate::session::{COMMAND_PREFIX, OutputType, SessionState};
 use crate::tui::theme::*;
+use crate::web;
 
-use super::wrap_text;
+use super::ansi_view::ansi_rows;
+use super::json_view::json_lines;
+use super::{strip_ansi, truncate_text, wrap_text};
+
+/// Links listed per web tool result
+const MAX_WEB_LINKS: usize = 8;
+
+/// Most lines of a session summary pinned above the conversation
+const MAX_SUMMARY_LINES: usize = 8;
+
+/// Lines of context shown around each error by the errors-only filter
+const ERROR_CONTEXT_LINES: usize = 2;
+
+/// Rows of a line of tool or shell output: with its ANSI colors when shown,
+/// stripped and word-wrapped otherwise
+fn output_rows(content: &str, width: usize, ansi: bool) -> Vec<Vec<Span<'static>>> {
+    let style = Style::new().fg(TEXT_DIM);
+    if ansi {
+        return ansi_rows(content, width, style);
+    }
+    wrap_text(&strip_ansi(content), width)
+        .into_iter()
+        .map(|text| vec![Span::styled(text, style)])
+        .collect()
+}
+
+/// Summary lines pinned at the top of the conversation, ending in a separator
+fn summary_lines(app: &App, width: usize) -> Vec<Line<'static>> {
+    let Some(session) = app.selected_session() else {
+        return vec![];
+    };
+    let mut lines = vec![];
+    if let Some(summary) = &session.summary {
+        let new_messages = session.output.len().saturating_sub(summary.output_len);
+        let mut header = vec![
+            Span::styled("Summary", Style::new().fg(LOGO_LIGHT_BLUE).bold()),
+            Span::styled(
+                format!(" {}", summary.at.format("%H:%M")),
+                Style::new().fg(TEXT_DIM),
+            ),
+        ];
+        if session.summarizing {
+            header.push(Span::styled(
+                format!("  {} updating", app.spinner()),
+                Style::new().fg(TEXT_DIM),
+            ));
+        } else if new_messages > 0 {
+            header.push(Span::styled(
+                "  outdated, [S] to update",
+                Style::new().fg(LOGO_GOLD),
+            ));
+        }
+        lines.push(Line::from(header));
+
+        let wrapped: Vec<String> = summary
+            .text
+            .lines()
+            .flat_map(|line| wrap_text(line, width))
+            .collect();
+        let shown = wrapped.len().min(MAX_SUMMARY_LINES);
+        for text in &wrapped[..shown] {
+            lines.push(Line::styled(text.clone(), Style::new().fg(TEXT_WHITE)));
+        }
+        if wrapped.len() > shown {
+            lines.push(Line::styled(
+                format!("… {} more lines", wrapped.len() - shown),
+                Style::new().fg(TEXT_DIM),
+            ));
+        }
+    } else if session.summarizing {
+        lines.push(Line::from(vec![
+            Span::styled("Summary", Style::new().fg(LOGO_LIGHT_BLUE).bold()),
+            Span::styled(
+                format!("  {} summarizing…", app.spinner()),
+                Style::new().fg(TEXT_DIM),
+            ),
+        ]));
+    } else {
+        return vec![];
+    }
+    lines.push(Line::styled(
+        "─".repeat(width),
+        Style::new().fg(TOOL_CONNECTOR),
+    ));
+    lines
+}
 
 /// Render the conversation view showing agent messages.
 pub fn render_conversation_view(frame: &mut Frame, area: Rect, app: &mut App) {
+    // Pin the session summary (if any) above the scrolling output
+    let summary = summary_lines(app, area.width.saturating_sub(2) as usize);
+    let area = if summary.is_empty() {
+        area
+    } else {
+        let summary_height = (summary.len() as u16).min(area.height / 2);
+        let summary_area = Rect::new(area.x, area.y, area.width, summary_height);
+        frame.render_widget(Paragraph::new(summary), summary_area);
+        let area = Rect::new(
+            area.x,
+            area.y + summary_height,
+            area.width,
+            area.height - summary_height,
+        );
+        app.viewport_height = area.height as usize;
+        area
+    };
+
     let inner_height = area.height as usize;
     let inner_width = area.width.saturating_sub(2) as usize; // Account for border
 
     // Track total rendered lines to update session afterwards
     let mut computed_total_lines: Option<usize> = None;
+    // First visible line, for the scrollbar
+    let mut scroll_position = 0;
 
     let lines: Vec<Line> = if let Some(session) = app.selected_session() {
         if session.output.is_empty() {
@@ -38,17 +145,87 @@ pub fn render_conversation_view(frame: &mut Frame, area: Rect, app: &mut App) {
                 }
             };
             vec![Line::styled(status, Style::new().fg(TEXT_DIM))]
+        } else if app.detail_settling() {
+            // Skip laying out every transcript passed while a navigation key is
+            // held; nothing loads in the background, the layout just waits
+            vec![Line::styled(
+                format!("{} {}", app.spinner(), session.name),
+                Style::new().fg(TEXT_DIM),
+            )]
         } else {
             // Get active tool call ID and spinner for rendering
             let active_tool_id = session.active_tool_call_id.as_deref();
             let spinner = app.spinner();
             let debug_tool_json = app.debug_tool_json;
+            let show_thinking = app.show_thinking;
+            let show_web_bodies = app.show_web_bodies;
+            let last_index = session.output.len().saturating_sub(1);
+            let thinking_now = session.current_thought.is_some();
+            let tag_cursor = if app.input_mode == InputMode::Tagging {
+                app.tag_cursor
+            } else {
+                None
+            };
+            // Visual line of the tagging cursor, kept in view while tagging
+            let mut cursor_line: Option<usize> = None;
+            // Errors-only filter: the output lines shown, and the last one shown
+            let error_lines = app
+                .errors_only
+                .then(|| session.error_lines(ERROR_CONTEXT_LINES));
+            let mut last_shown: Option<usize> = None;
 
             // First expand all output to visual lines
             let mut all_lines: Vec<Line> = vec![];
             let mut last_line_type: Option<&OutputType> = None;
 
-            for output_line in session.output.iter() {
+            if session.trimmed_lines > 0 {
+                all_lines.push(Line::styled(
+                    format!(
+                        "… {} earlier lines moved to disk to stay within the memory budget (exports and archives include them)",
+                        session.trimmed_lines
+                    ),
+                    Style::new().fg(TEXT_DIM),
+                ));
+            }
+            if error_lines.as_ref().is_some_and(|lines| lines.is_empty()) {
+                all_lines.push(Line::styled(
+                    "No errors, failed tools or warnings. Press [F] to show everything.",
+                    Style::new().fg(TEXT_DIM),
+                ));
+            }
+
+            // Whether the tool results after the latest message keep their ANSI colors
+            let mut ansi = false;
+
+            for (index, output_line) in session.output.iter().enumerate() {
+                if session.is_taggable(index) {
+                    ansi = session.ansi_messages.contains(&index);
+                }
+                if let Some(lines) = &error_lines {
+                    if !lines.contains(&index) {
+                        continue;
+                    }
+                    // Separate groups of errors that aren't next to each other
+                    if last_shown.is_some_and(|last| index > last + 1) {
+                        all_lines.push(Line::styled("  ⋯", Style::new().fg(TEXT_DIM)));
+                        last_line_type = None;
+                    }
+                    last_shown = Some(index);
+                }
+
+                // Finalized thoughts are only shown when thinking is toggled on
+                let is_active_thought = thinking_now && index == last_index;
+                if matches!(output_line.line_type, OutputType::Thought)
+                    && !show_thinking
+                    && !is_active_thought
+                {
+                    continue;
+                }
+                // Raw turn results are only shown in debug mode
+                if matches!(output_line.line_type, OutputType::RawJson) && !debug_tool_json {
+                    continue;
+                }
+
                 let mut lines_for_output: Vec<Line> = match &output_line.line_type {
                     OutputType::Text => {
                         // Empty lines for spacing
@@ -67,7 +244,7 @@ pub fn render_conversation_view(frame: &mut Frame, area: Rect, app: &mut App) {
                     OutputType::UserInput => {
                         // User prompt - cyan/blue
                         let wrapped = wrap_text(&output_line.content, inner_width);
-                        wrapped
+                        let mut lines: Vec<Line> = wrapped
                             .into_iter()
                             .map(|text| {
                                 Line::from(vec![Span::styled(
@@ -75,15 +252,45 @@ pub fn render_conversation_view(frame: &mut Frame, area: Rect, app: &mut App) {
                                     Style::new().fg(LOGO_LIGHT_BLUE).bold(),
                                 )])
                             })
-                            .collect()
+                            .collect();
+                        // Referenced files as chips (Enter in tagging mode opens them)
+                        let references = attachments::references(&output_line.content);
+                        if !references.is_empty() {
+                            let mut chips = vec![Span::raw("  ")];
+                            for reference in references {
+                                chips.push(Span::styled(
+                                    format!(" 📎 {} ", reference),
+                                    Style::new().fg(TEXT_WHITE).bg(TOOL_CONNECTOR),
+                                ));
+                                chips.push(Span::raw(" "));
+                            }
+                            lines.push(Line::from(chips));
+                        }
+                        lines
                     }
 
                     OutputType::Thought => {
-                        // Agent thinking - just show lightbulb and "Thinking..."
-                        vec![Line::from(vec![
+                        // Agent thinking - lightbulb header, full reasoning when toggled on
+                        let header = if is_active_thought {
+                            "Thinking..."
+                        } else {
+                            "Thought"
+                        };
+                        let mut lines = vec![Line::from(vec![
                             Span::styled("💡 ", Style::new().fg(LOGO_GOLD)),
-                            Span::styled("Thinking...", Style::new().fg(LOGO_GOLD).italic()),
-                        ])]
+                            Span::styled(header, Style::new().fg(LOGO_GOLD).italic()),
+                        ])];
+                        if show_thinking {
+                            let wrapped =
+                                wrap_text(&output_line.content, inner_width.saturating_sub(3));
+                            lines.extend(wrapped.into_iter().map(|text| {
+                                Line::from(vec![
+                                    Span::raw("   "),
+                                    Span::styled(text, Style::new().fg(TEXT_DIM).italic()),
+                                ])
+                            }));
+                        }
+                        lines
                     }
                     OutputType::ToolCall {
                         tool_call_id,
@@ -128,41 +335,26 @@ pub fn render_conversation_view(frame: &mut Frame, area: Rect, app: &mut App) {
                         // If debug mode is on, render all raw JSON requests below the tool call
                         if debug_tool_json {
                             for json in raw_json {
-                                for json_line in json.lines() {
-                                    // Truncate long lines rather than wrap to preserve indentation
-                                    let max_len = inner_width.saturating_sub(4);
-                                    let display_line = if json_line.len() > max_len {
-                                        format!("{}…", &json_line[..max_len.saturating_sub(1)])
-                                    } else {
-                                        json_line.to_string()
-                                    };
-                                    lines.push(Line::from(vec![
-                                        Span::styled("  │ ", Style::new().fg(TEXT_DIM)),
-                                        Span::styled(display_line, Style::new().fg(TEXT_DIM)),
-                                    ]));
-                                }
+                                lines.extend(json_lines(json, inner_width));
                             }
                         }
 
                         lines
                     }
                     OutputType::ToolOutput => {
-                        // Tool output - └ connector, plain text (no markdown)
-                        let wrapped =
-                            wrap_text(&output_line.content, inner_width.saturating_sub(2));
-                        wrapped
+                        // Tool output - └ connector, plain text (no markdown), ANSI
+                        // colors stripped unless shown for this message
+                        output_rows(&output_line.content, inner_width.saturating_sub(2), ansi)
                             .into_iter()
                             .enumerate()
-                            .map(|(i, text)| {
+                            .map(|(i, mut spans)| {
                                 let prefix = if i == 0 {
                                     Span::styled("└ ", Style::new().fg(TOOL_CONNECTOR))
                                 } else {
                                     Span::styled("  ", Style::new().fg(TOOL_CONNECTOR))
                                 };
-                                Line::from(vec![
-                                    prefix,
-                                    Span::styled(text, Style::new().fg(TEXT_DIM)),
-                                ])
+                                spans.insert(0, prefix);
+                                Line::from(spans)
                             })
                             .collect()
                     }
@@ -253,19 +445,90 @@ pub fn render_conversation_view(frame: &mut Frame, area: Rect, app: &mut App) {
                             .collect()
                     }
                     OutputType::BashOutput => {
-                        // Bash output - dim text with connector
-                        let wrapped =
-                            wrap_text(&output_line.content, inner_width.saturating_sub(2));
-                        wrapped
+                        // Bash output - dim text with connector, ANSI colors as for tools
+                        output_rows(&output_line.content, inner_width.saturating_sub(2), ansi)
                             .into_iter()
-                            .map(|text| {
-                                let prefix = Span::styled("│ ", Style::new().fg(LOGO_GOLD));
+                            .map(|mut spans| {
+                                spans.insert(0, Span::styled("│ ", Style::new().fg(LOGO_GOLD)));
+                                Line::from(spans)
+                            })
+                            .collect()
+                    }
+                    OutputType::WebResult { .. } => {
+                        // Web tool result - the pages read, with the text collapsed
+                        let content = &output_line.content;
+                        let links = web::extract_links(content);
+                        let mut lines: Vec<Line> = links
+                            .iter()
+                            .take(MAX_WEB_LINKS)
+                            .enumerate()
+                            .map(|(i, (title, url))| {
+                                let connector = if i == 0 { "└ " } else { "  " };
+                                let title_width = inner_width.saturating_sub(2) / 2;
+                                let title = truncate_text(title, title_width);
+                                let url_width =
+                                    inner_width.saturating_sub(2 + title.chars().count() + 2);
+                                let mut spans = vec![
+                                    Span::styled(connector, Style::new().fg(TOOL_CONNECTOR)),
+                                    Span::styled(title.clone(), Style::new().fg(TEXT_WHITE)),
+                                ];
+                                if title != *url {
+                                    spans.push(Span::styled(
+                                        format!("  {}", truncate_text(url, url_width)),
+                                        Style::new().fg(TEXT_DIM),
+                                    ));
+                                }
+                                Line::from(spans)
+                            })
+                            .collect();
+                        if links.len() > MAX_WEB_LINKS {
+                            lines.push(Line::styled(
+                                format!("  … {} more links", links.len() - MAX_WEB_LINKS),
+                                Style::new().fg(TEXT_DIM),
+                            ));
+                        }
+
+                        let connector = if lines.is_empty() { "└ " } else { "  " };
+                        if show_web_bodies {
+                            let wrapped = content
+                                .lines()
+                                .flat_map(|line| wrap_text(line, inner_width.saturating_sub(4)));
+                            lines.extend(wrapped.map(|text| {
                                 Line::from(vec![
-                                    prefix,
+                                    Span::styled("  │ ", Style::new().fg(TOOL_CONNECTOR)),
                                     Span::styled(text, Style::new().fg(TEXT_DIM)),
                                 ])
-                            })
-                            .collect()
+                            }));
+                        } else {
+                            lines.push(Line::from(vec![
+                                Span::styled(connector, Style::new().fg(TOOL_CONNECTOR)),
+                                Span::styled(
+                                    format!(
+                                        "▸ {} lines read ([W] expand)",
+                                        content.lines().count()
+                                    ),
+                                    Style::new().fg(TEXT_DIM),
+                                ),
+                            ]));
+                        }
+                        lines
+                    }
+                    OutputType::RawJson => {
+                        // Raw turn result (stop reason, usage, ids)
+                        json_lines(&output_line.content, inner_width)
+                    }
+                    OutputType::SystemMessage
+                        if output_line.content.starts_with(COMMAND_PREFIX) =>
+                    {
+                        // Slash command action - dim, with the command highlighted
+                        let command = &output_line.content[COMMAND_PREFIX.len()..];
+                        vec![Line::from(vec![
+                            Span::styled(COMMAND_PREFIX, Style::new().fg(TEXT_DIM)),
+                            Span::styled(
+                                truncate_text(command, inner_width.saturating_sub(4)),
+                                Style::new().fg(LOGO_LIGHT_BLUE),
+                            ),
+                        ])]
                     }
                     OutputType::SystemMessage => {
                         // System message - light red/coral, italic
@@ -310,8 +573,11 @@ pub fn render_conversation_view(frame: &mut Frame, area: Rect, app: &mut App) {
                 let should_add_spacing = match (&last_line_type, &output_line.line_type) {
                     // Add spacing after user input
                     (Some(OutputType::UserInput), _) => true,
-                    // Note: Thinking is now ephemeral and removed when new content arrives,
-                    // so we don't need spacing rules for it anymore
+                    // Add spacing after a revealed thought (hidden thoughts are skipped above)
+                    (
+                        Some(OutputType::Thought),
+                        OutputType::Text | OutputType::UserInput | OutputType::ToolCall { .. },
+                    ) => true,
                     // Add spacing after tool calls (before next content)
                     (
                         Some(OutputType::ToolCall { .. }),
@@ -324,7 +590,7 @@ pub fn render_conversation_view(frame: &mut Frame, area: Rect, app: &mut App) {
                     ) => true,
                     // Add spacing after tool output before new messages
                     (
-                        Some(OutputType::ToolOutput),
+                        Some(OutputType::ToolOutput | OutputType::WebResult { .. }),
                         OutputType::Text | OutputType::UserInput | OutputType::ToolCall { .. },
                     ) => true,
                     // Add spacing after bash output
@@ -340,6 +606,25 @@ pub fn render_conversation_view(frame: &mut Frame, area: Rect, app: &mut App) {
                     all_lines.push(Line::raw(""));
                 }
 
+                // Tag badge on the first line of tagged messages
+                if let Some(tag) = session.message_tags.get(&index)
+                    && let Some(first) = lines_for_output.first_mut()
+                {
+                    first.spans.insert(
+                        0,
+                        Span::styled(format!("[{}] ", tag.label()), Style::new().fg(LOGO_GOLD)),
+                    );
+                }
+
+                // Bar alongside the message under the tagging cursor
+                if tag_cursor == Some(index) {
+                    cursor_line = Some(all_lines.len());
+                    for line in lines_for_output.iter_mut() {
+                        line.spans
+                            .insert(0, Span::styled("▌", Style::new().fg(LOGO_LIGHT_BLUE)));
+                    }
+                }
+
                 all_lines.extend(lines_for_output);
                 last_line_type = Some(&output_line.line_type);
             }
@@ -349,13 +634,17 @@ pub fn render_conversation_view(frame: &mut Frame, area: Rect, app: &mut App) {
             let total_lines = all_lines.len();
             computed_total_lines = Some(total_lines);
             let scroll_offset = session.scroll_offset;
-            let start = if scroll_offset == usize::MAX {
+            let start = if let Some(line) = cursor_line {
+                // Tagging: start at the cursor's message, or as far down as fits
+                line.min(total_lines.saturating_sub(inner_height))
+            } else if scroll_offset == usize::MAX {
                 // Scroll to bottom: show last viewport worth of lines
                 total_lines.saturating_sub(inner_height)
             } else {
                 scroll_offset.min(total_lines.saturating_sub(1))
             };
             let end = (start + inner_height).min(total_lines);
+            scroll_position = start;
             all_lines[start..end].to_vec()
         }
     } else {
@@ -368,6 +657,23 @@ pub fn render_conversation_view(frame: &mut Frame, area: Rect, app: &mut App) {
     let paragraph = Paragraph::new(lines);
     frame.render_widget(paragraph, area);
 
+    // Scrollbar in the right margin (text wraps short of it) once output overflows
+    if let Some(total_lines) = computed_total_lines
+        && total_lines > inner_height
+    {
+        let mut scrollbar_state = ScrollbarState::new(total_lines - inner_height)
+            .position(scroll_position)
+            .viewport_content_length(inner_height);
+        let scrollbar = Scrollbar::new(ScrollbarOrientation::VerticalRight)
+            .begin_symbol(None)
+            .end_symbol(None)
+            .track_symbol(Some("│"))
+            .thumb_symbol("┃")
+            .track_style(Style::new().fg(TOOL_CONNECTOR))
+            .thumb_style(Style::new().fg(TEXT_DIM));
+        frame.render_stateful_widget(scrollbar, area, &mut scrollbar_state);
+    }
+
     // Register output area as scrollable region
     let output_bounds = ClickRegion::new(area.x, area.y, area.width, area.height);
     app.interactions.register_scroll(
@@ -0,0 +1,81 @@
+//! Epic input component - links the selected session to an epic.
+
+use ratatui::{
+    Frame,
+    layout::{Position, Rect},
+    style::{Color, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+};
+
+use crate::app::App;
+use crate::tui::theme::*;
+
+use super::truncate_text;
+
+/// Render the epic name input for the selected session.
+pub fn render_epic_input(frame: &mut Frame, area: Rect, app: &App) {
+    let Some(input) = &app.epic_input else {
+        return;
+    };
+
+    // Calculate centered popup area
+    let popup_width = 50u16.min(area.width);
+    let popup_height = 8u16.min(area.height);
+    let x = area.x + (area.width.saturating_sub(popup_width)) / 2;
+    let y = area.y + (area.height.saturating_sub(popup_height)) / 2;
+    let popup_area = Rect::new(x, y, popup_width, popup_height);
+
+    // Clear the area behind the popup
+    frame.render_widget(Clear, popup_area);
+
+    let content_width = (popup_width as usize).saturating_sub(4);
+    let epics = app.epics();
+    let existing = if epics.is_empty() {
+        "No epics yet".to_string()
+    } else {
+        format!("Epics: {}", epics.join(", "))
+    };
+
+    let lines = vec![
+        // Title
+        Line::from(vec![Span::styled(
+            "Link to Epic",
+            Style::new().fg(LOGO_LIGHT_BLUE).bold(),
+        )]),
+        Line::raw(""),
+        Line::styled(
+            truncate_text(&existing, content_width + 2),
+            Style::new().fg(TEXT_DIM),
+        ),
+        Line::from(vec![
+            Span::styled("> ", Style::new().fg(LOGO_MINT)),
+            Span::styled(
+                truncate_text(&input.text, content_width),
+                Style::new().fg(TEXT_WHITE),
+            ),
+        ]),
+        Line::raw(""),
+        // Footer
+        Line::from(vec![
+            Span::styled("[Enter]", Style::new().fg(TEXT_WHITE)),
+            Span::styled(" link (empty unlinks)  ", Style::new().fg(TEXT_DIM)),
+            Span::styled("[Tab]", Style::new().fg(TEXT_WHITE)),
+            Span::styled(" complete", Style::new().fg(TEXT_DIM)),
+        ]),
+    ];
+
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .border_style(Style::new().fg(LOGO_LIGHT_BLUE))
+        .style(Style::new().bg(Color::Black));
+
+    let paragraph = Paragraph::new(lines).block(block);
+    frame.render_widget(paragraph, popup_area);
+
+    // Account for border (1), title (1), empty (1), epics (1); prompt "> " (2)
+    let cursor_col = input.text[..input.cursor_position].chars().count();
+    let cursor_x = popup_area.x + 1 + 2 + cursor_col.min(content_width) as u16;
+    let cursor_y = popup_area.y + 4;
+    frame.set_cursor_position(Position::new(cursor_x, cursor_y));
+}
@@ -14,21 +14,6 @@ use crate::tui::theme::*;
 /// Render the help popup with keyboard shortcuts.
 #[allow(clippy::vec_init_then_push)]
 pub fn render_help_popup(frame: &mut Frame, area: Rect, app: &App) {
-    // Calculate centered popup area
-    let popup_width = 50u16;
-    let popup_height = 28u16; // Increased to fit bug report line
-    let x = area.x + (area.width.saturating_sub(popup_width)) / 2;
-    let y = area.y + (area.height.saturating_sub(popup_height)) / 2;
-    let popup_area = Rect::new(
-        x,
-        y,
-        popup_width.min(area.width),
-        popup_height.min(area.height),
-    );
-
-    // Clear the area behind the popup
-    frame.render_widget(Clear, popup_area);
-
     let mut lines: Vec<Line> = vec![];
 
     // Title
@@ -59,6 +44,29 @@ pub fn render_help_popup(frame: &mut Frame, area: Rect, app: &App) {
         Span::styled("  x       ", Style::new().fg(TEXT_WHITE)),
         Span::styled("Kill session", Style::new().fg(TEXT_DIM)),
     ]));
+    lines.push(Line::from(vec![
+        Span::styled("  Z       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Kill all idle agents", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  X       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Processes under the agent", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  R       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Restart agent (resume session)", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  V       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled(
+            "Verify command output (r runs again)",
+            Style::new().fg(TEXT_DIM),
+        ),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  S       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Summarize session", Style::new().fg(TEXT_DIM)),
+    ]));
     lines.push(Line::from(vec![
         Span::styled("  d       ", Style::new().fg(TEXT_WHITE)),
         Span::styled("Duplicate session", Style::new().fg(TEXT_DIM)),
@@ -79,14 +87,112 @@ pub fn render_help_popup(frame: &mut Frame, area: Rect, app: &App) {
         Span::styled("  1-9     ", Style::new().fg(TEXT_WHITE)),
         Span::styled("Select session by number", Style::new().fg(TEXT_DIM)),
     ]));
+    lines.push(Line::from(vec![
+        Span::styled("  D/H     ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Hide session / show hidden", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  M       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Mute session notifications", Style::new().fg(TEXT_DIM)),
+    ]));
     lines.push(Line::from(vec![
         Span::styled("  C-u/C-d ", Style::new().fg(TEXT_WHITE)),
         Span::styled("Scroll half page", Style::new().fg(TEXT_DIM)),
     ]));
+    lines.push(Line::from(vec![
+        Span::styled("  C-y/C-e ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Scroll lines (hold to speed up)", Style::new().fg(TEXT_DIM)),
+    ]));
     lines.push(Line::from(vec![
         Span::styled("  g/G     ", Style::new().fg(TEXT_WHITE)),
         Span::styled("Scroll to top/bottom", Style::new().fg(TEXT_DIM)),
     ]));
+    lines.push(Line::from(vec![
+        Span::styled("  e       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Export transcript (redacted)", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  A       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Archive session", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  o       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Recently written files", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  C       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Diff work tree between turns", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  s       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Conversation statistics", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  p       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Plan history timeline", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  r       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Quick reply templates", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  N       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Session notes", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  E       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Link session to an epic", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  Q       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Queued prompts and work orders", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  /       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Search archived sessions", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  L       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Audit log", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  a       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled(
+            "Tag messages (decision/bug/todo)",
+            Style::new().fg(TEXT_DIM),
+        ),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  T       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Show/hide thinking", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  F       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Errors only (with context)", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  W       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Expand/collapse web results", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  P       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Preview latest agent messages", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  I       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled(
+            "Interactive sessions only (hide [SDK])",
+            Style::new().fg(TEXT_DIM),
+        ),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  O       ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Open/close session as tab", Style::new().fg(TEXT_DIM)),
+    ]));
+    lines.push(Line::from(vec![
+        Span::styled("  [ / ]   ", Style::new().fg(TEXT_WHITE)),
+        Span::styled("Previous/next tab", Style::new().fg(TEXT_DIM)),
+    ]));
     lines.push(Line::from(vec![
         Span::styled("  Tab     ", Style::new().fg(TEXT_WHITE)),
         Span::styled("Cycle permission mode", Style::new().fg(TEXT_DIM)),
@@ -146,6 +252,21 @@ pub fn render_help_popup(frame: &mut Frame, area: Rect, app: &App) {
         Span::styled(" to close", Style::new().fg(TEXT_DIM)),
     ]));
 
+    // Calculate centered popup area (sized to fit all lines plus borders)
+    let popup_width = 50u16;
+    let popup_height = lines.len() as u16 + 2;
+    let x = area.x + (area.width.saturating_sub(popup_width)) / 2;
+    let y = area.y + (area.height.saturating_sub(popup_height)) / 2;
+    let popup_area = Rect::new(
+        x,
+        y,
+        popup_width.min(area.width),
+        popup_height.min(area.height),
+    );
+
+    // Clear the area behind the popup
+    frame.render_widget(Clear, popup_area);
+
     let block = Block::default()
         .borders(Borders::ALL)
         .border_style(Style::new().fg(LOGO_LIGHT_BLUE))
@@ -0,0 +1,180 @@
+//! JSON view - pretty-printed, colorized JSON for raw tool calls and turn
+//! results (toggle with 't').
+//!
+//! Keys, strings, numbers and literals get their own colors, and long arrays
+//! are folded to their first few items so one big tool input doesn't push
+//! the conversation off screen. Text that isn't JSON is shown as is.
+
+use ratatui::{
+    style::{Color, Style},
+    text::{Line, Span},
+};
+use serde_json::Value;
+
+use crate::tui::theme::*;
+
+use super::truncate_text;
+
+/// Arrays longer than this are folded
+const MAX_ARRAY_ITEMS: usize = 5;
+
+/// Items shown of a folded array
+const FOLDED_ITEMS: usize = 3;
+
+/// Indentation per nesting level
+const INDENT: &str = "  ";
+
+/// Builds the lines of one JSON document
+struct Printer {
+    lines: Vec<Line<'static>>,
+    current: Vec<Span<'static>>,
+    /// Longest string value shown, in chars
+    max_string: usize,
+}
+
+impl Printer {
+    fn push(&mut self, text: impl Into<String>, color: Color) {
+        self.current
+            .push(Span::styled(text.into(), Style::new().fg(color)));
+    }
+
+    fn newline(&mut self, depth: usize) {
+        let spans = std::mem::take(&mut self.current);
+        self.lines.push(Line::from(spans));
+        self.current.push(Span::raw(INDENT.repeat(depth)));
+    }
+
+    fn value(&mut self, value: &Value, depth: usize) {
+        match value {
+            Value::Null => self.push("null", LOGO_CORAL),
+            Value::Bool(b) => self.push(b.to_string(), LOGO_CORAL),
+            Value::Number(n) => self.push(n.to_string(), LOGO_GOLD),
+            Value::String(s) => {
+                let quoted = serde_json::to_string(s).unwrap_or_default();
+                self.push(truncate_text(&quoted, self.max_string), LOGO_MINT);
+            }
+            Value::Array(items) if items.is_empty() => self.push("[]", TEXT_DIM),
+            Value::Array(items) => {
+                self.push("[", TEXT_DIM);
+                let folded = items.len() > MAX_ARRAY_ITEMS;
+                let shown = if folded { FOLDED_ITEMS } else { items.len() };
+                for (i, item) in items.iter().take(shown).enumerate() {
+                    self.newline(depth + 1);
+                    self.value(item, depth + 1);
+                    if i + 1 < items.len() {
+                        self.push(",", TEXT_DIM);
+                    }
+                }
+                if folded {
+                    self.newline(depth + 1);
+                    self.push(format!("… {} more items", items.len() - shown), TEXT_DIM);
+                }
+                self.newline(depth);
+                self.push("]", TEXT_DIM);
+            }
+            Value::Object(map) if map.is_empty() => self.push("{}", TEXT_DIM),
+            Value::Object(map) => {
+                self.push("{", TEXT_DIM);
+                for (i, (key, item)) in map.iter().enumerate() {
+                    self.newline(depth + 1);
+                    self.push(format!("\"{}\"", key), LOGO_LIGHT_BLUE);
+                    self.push(": ", TEXT_DIM);
+                    self.value(item, depth + 1);
+                    if i + 1 < map.len() {
+                        self.push(",", TEXT_DIM);
+                    }
+                }
+                self.newline(depth);
+                self.push("}", TEXT_DIM);
+            }
+        }
+    }
+}
+
+/// Lines of a JSON document, colorized and with long arrays folded, each
+/// behind a "│" gutter. Text that isn't JSON is shown dim, line by line.
+pub fn json_lines(json: &str, width: usize) -> Vec<Line<'static>> {
+    let gutter = || Span::styled("  │ ", Style::new().fg(TEXT_DIM));
+    let Ok(value) = serde_json::from_str::<Value>(json) else {
+        return json
+            .lines()
+            .map(|line| {
+                Line::from(vec![
+                    gutter(),
+                    Span::styled(
+                        truncate_text(line, width.saturating_sub(4)),
+                        Style::new().fg(TEXT_DIM),
+                    ),
+                ])
+            })
+            .collect();
+    };
+
+    let mut printer = Printer {
+        lines: vec![],
+        current: vec![],
+        max_string: width.saturating_sub(16).max(20),
+    };
+    printer.value(&value, 0);
+    printer.newline(0);
+    printer
+        .lines
+        .into_iter()
+        .map(|mut line| {
+            line.spans.insert(0, gutter());
+            line
+        })
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn text(lines: &[Line]) -> Vec<String> {
+        lines
+            .iter()
+            .map(|line| line.spans.iter().map(|s| s.content.as_ref()).collect())
+            .collect()
+    }
+
+    #[test]
+    fn test_pretty_prints_single_line_json() {
+        let lines = json_lines(r#"{"command":"cargo test","timeout":60,"flags":[]}"#, 80);
+        assert_eq!(
+            text(&lines),
+            [
+                "  │ {",
+                "  │   \"command\": \"cargo test\",",
+                "  │   \"flags\": [],",
+                "  │   \"timeout\": 60",
+                "  │ }",
+            ]
+        );
+        // Keys and values are colored differently
+        let key = &lines[1].spans[2];
+        let value = &lines[1].spans[4];
+        assert_ne!(key.style.fg, value.style.fg);
+    }
+
+    #[test]
+    fn test_folds_long_arrays() {
+        let lines = json_lines("[1,2,3,4,5,6,7,8]", 80);
+        assert_eq!(
+            text(&lines),
+            [
+                "  │ [",
+                "  │   1,",
+                "  │   2,",
+                "  │   3,",
+                "  │   … 5 more items",
+                "  │ ]",
+            ]
+        );
+    }
+
+    #[test]
+    fn test_not_json_shown_as_is() {
+        assert_eq!(text(&json_lines("not json", 80)), ["  │ not json"]);
+    }
+}
@@ -0,0 +1,119 @@
+//! Kill idle sessions confirmation popup component.
+
+use ratatui::{
+    Frame,
+    layout::Rect,
+    style::{Color, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+};
+
+use crate::app::App;
+use crate::tui::theme::*;
+
+use super::truncate_text;
+
+/// Sessions listed by name; the rest are counted
+const MAX_LISTED: usize = 8;
+
+/// Render the confirmation to kill all idle sessions.
+pub fn render_kill_idle_popup(frame: &mut Frame, area: Rect, app: &App) {
+    let idle = app.idle_sessions();
+    let listed = idle.len().min(MAX_LISTED);
+
+    // Calculate centered popup area
+    let popup_width = 50u16;
+    let popup_height = 8 + listed as u16 + u16::from(idle.len() > listed);
+    let x = area.x + (area.width.saturating_sub(popup_width)) / 2;
+    let y = area.y + (area.height.saturating_sub(popup_height)) / 2;
+    let popup_area = Rect::new(
+        x,
+        y,
+        popup_width.min(area.width),
+        popup_height.min(area.height),
+    );
+
+    // Clear the area behind the popup
+    frame.render_widget(Clear, popup_area);
+
+    let mut lines: Vec<Line> = vec![];
+
+    // Title
+    lines.push(Line::from(vec![Span::styled(
+        "Kill Idle Agents",
+        Style::new().fg(LOGO_CORAL).bold(),
+    )]));
+    lines.push(Line::raw(""));
+
+    // Projects can set their own threshold; name it when all share the global one
+    let agents = format!(
+        "{} agent{}",
+        idle.len(),
+        if idle.len() == 1 { "" } else { "s" }
+    );
+    let question = if idle
+        .iter()
+        .all(|s| app.idle_kill_after_for(s) == app.idle_kill_after)
+    {
+        format!(
+            "Kill {} idle for {}m or longer?",
+            agents,
+            app.idle_kill_after.as_secs() / 60
+        )
+    } else {
+        format!("Kill {} idle past their project's limit?", agents)
+    };
+    lines.push(Line::from(vec![Span::styled(
+        question,
+        Style::new().fg(TEXT_WHITE),
+    )]));
+    lines.push(Line::raw(""));
+
+    // The sessions, with how long they've been idle
+    let name_width = usize::from(popup_width).saturating_sub(14);
+    for session in idle.iter().take(listed) {
+        let minutes = session
+            .last_activity
+            .map(|at| at.elapsed().as_secs() / 60)
+            .unwrap_or_default();
+        let idle_for = if minutes < 60 {
+            format!("{}m", minutes)
+        } else {
+            format!("{}h {}m", minutes / 60, minutes % 60)
+        };
+        lines.push(Line::from(vec![
+            Span::styled(
+                format!(
+                    "  {:width$}",
+                    truncate_text(&session.name, name_width),
+                    width = name_width
+                ),
+                Style::new().fg(TEXT_WHITE),
+            ),
+            Span::styled(format!(" {:>8}", idle_for), Style::new().fg(TEXT_DIM)),
+        ]));
+    }
+    if idle.len() > listed {
+        lines.push(Line::from(vec![Span::styled(
+            format!("  … and {} more", idle.len() - listed),
+            Style::new().fg(TEXT_DIM),
+        )]));
+    }
+    lines.push(Line::raw(""));
+
+    // Footer with options
+    lines.push(Line::from(vec![
+        Span::styled("[y]", Style::new().fg(LOGO_CORAL)),
+        Span::styled(" yes  ", Style::new().fg(TEXT_DIM)),
+        Span::styled("[n]", Style::new().fg(TEXT_WHITE)),
+        Span::styled(" no", Style::new().fg(TEXT_DIM)),
+    ]));
+
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .border_style(Style::new().fg(LOGO_CORAL))
+        .style(Style::new().bg(Color::Black));
+
+    let paragraph = Paragraph::new(lines).block(block);
+    frame.render_widget(paragraph, popup_area);
+}
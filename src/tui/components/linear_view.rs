@@ -0,0 +1,201 @@
+//! Linear view component - screen reader friendly layout (--screen-reader).
+//!
+//! Instead of the sidebar and boxed panes, everything is one column of
+//! labeled regions read top to bottom: an announcement line, the session
+//! list, then whichever of permission request, question or conversation is
+//! current, and the prompt. Nothing animates, so screen readers only speak
+//! real changes, and the announcement line names the latest one.
+
+use ratatui::{
+    Frame,
+    layout::{Position, Rect},
+    text::Line,
+    widgets::Paragraph,
+};
+
+use crate::app::{App, InputMode};
+use crate::session::{OutputType, Session, SessionState};
+
+use super::{strip_ansi, wrap_text};
+
+/// Status of a session in words, as announced on change
+fn spoken_status(session: &Session) -> &'static str {
+    if session.pending_permission.is_some() {
+        "needs permission"
+    } else if session.pending_question.is_some() || session.needs_input {
+        "has a question"
+    } else if session.interrupted {
+        "interrupted"
+    } else {
+        match session.state {
+            SessionState::Spawning | SessionState::Initializing => "starting",
+            SessionState::Prompting => "working",
+            SessionState::AwaitingPermission => "needs permission",
+            SessionState::AwaitingUserInput => "has a question",
+            SessionState::Idle if session.done => "done",
+            SessionState::Idle => "idle",
+        }
+    }
+}
+
+/// Label read before a run of output lines of one type (None: not shown)
+fn output_label(line_type: &OutputType) -> Option<&'static str> {
+    match line_type {
+        OutputType::Text => Some("Agent"),
+        OutputType::UserInput => Some("You"),
+        OutputType::ToolCall { failed: true, .. } => Some("Failed tool"),
+        OutputType::ToolCall { .. } => Some("Tool"),
+        OutputType::ToolOutput => Some("Tool output"),
+        OutputType::DiffAdd
+        | OutputType::DiffRemove
+        | OutputType::DiffContext
+        | OutputType::DiffHeader => Some("Diff"),
+        OutputType::Error => Some("Error"),
+        OutputType::BashCommand => Some("Shell"),
+        OutputType::BashOutput => Some("Shell output"),
+        OutputType::SystemMessage => Some("amux"),
+        OutputType::WebResult { .. } => Some("Web result"),
+        OutputType::Thought | OutputType::RawJson => None,
+    }
+}
+
+/// Remember each session's status and announce the latest change
+fn update_announcement(app: &mut App) {
+    for session in app.sessions.sessions() {
+        let status = spoken_status(session);
+        let previous = app
+            .announced_statuses
+            .insert(session.id.clone(), status.to_string());
+        if previous.is_some_and(|previous| previous != status) {
+            app.announcement = Some(format!("{} {}", session.name, status));
+        }
+    }
+}
+
+/// Render the whole screen as one column of labeled regions.
+pub fn render_linear_view(frame: &mut Frame, area: Rect, app: &mut App) {
+    update_announcement(app);
+    let width = area.width.max(1) as usize;
+    let sessions = app.sessions.sessions();
+    let selected_index = app.sessions.selected_index();
+
+    let mut lines: Vec<Line> = vec![];
+    let announcement = app.announcement.as_deref().unwrap_or("ready");
+    lines.push(Line::raw(format!(
+        "amux, {} sessions. {}.",
+        sessions.len(),
+        announcement
+    )));
+    lines.push(Line::raw(""));
+
+    // Session list
+    lines.push(Line::raw("Sessions:"));
+    for (i, session) in sessions.iter().enumerate() {
+        let marker = if i == selected_index { ">" } else { " " };
+        let mut entry = format!(
+            "{} {}. {}, {}, {}",
+            marker,
+            i + 1,
+            session.name,
+            session.agent_type.display_name(),
+            spoken_status(session)
+        );
+        if !session.git_branch.is_empty() {
+            entry.push_str(&format!(", branch {}", session.git_branch));
+        }
+        if let Some(task) = session.current_task() {
+            entry.push_str(&format!(", doing {}", task.content));
+        }
+        lines.extend(wrap_text(&entry, width).into_iter().map(Line::raw));
+    }
+    if sessions.is_empty() {
+        lines.push(Line::raw("  No sessions. Press n to create one."));
+    }
+    lines.push(Line::raw(""));
+
+    // Current region of the selected session
+    let mut region: Vec<String> = vec![];
+    let mut footer: Vec<String> = vec![];
+    // Column of the cursor in the prompt line, the footer's only line while typing
+    let mut cursor: Option<usize> = None;
+    if let Some(session) = app.selected_session() {
+        if let Some(permission) = &session.pending_permission {
+            region.push(format!(
+                "Permission request from {}: {}",
+                session.name,
+                permission.title.as_deref().unwrap_or("tool call")
+            ));
+            for (i, option) in permission.options.iter().enumerate() {
+                let marker = if i == permission.selected { ">" } else { " " };
+                region.push(format!("{} {}. {}", marker, i + 1, option.name));
+            }
+            footer.push("Press y or Enter to allow, n or Escape to deny.".to_string());
+        } else if let Some(question) = &session.pending_question {
+            region.push(format!(
+                "Question from {}: {}",
+                session.name, question.question
+            ));
+            for (i, option) in question.options.iter().enumerate() {
+                let marker = if i == question.selected { ">" } else { " " };
+                region.push(format!("{} {}. {}", marker, i + 1, option.label));
+            }
+            footer.push(format!("Answer: {}", question.input));
+            footer.push("Press Enter to answer, Escape to dismiss.".to_string());
+        } else {
+            region.push(format!("Conversation with {}:", session.name));
+            let mut last_label = None;
+            for output in &session.output {
+                let Some(label) = output_label(&output.line_type) else {
+                    continue;
+                };
+                // Name the speaker once per run of lines
+                if last_label != Some(label) {
+                    region.push(format!("{}:", label));
+                    last_label = Some(label);
+                }
+                region.push(format!("  {}", strip_ansi(&output.content)));
+            }
+            if app.input_mode == InputMode::Insert {
+                footer.push(format!("Prompt: {}", app.input_buffer));
+                let before = app.input_buffer[..app.cursor_position].chars().count();
+                cursor = Some("Prompt: ".len() + before);
+            } else {
+                footer.push("Press i to type a prompt, ? for help.".to_string());
+            }
+        }
+    }
+
+    // Keep the end of the region in view above the footer
+    let region_lines: Vec<String> = region
+        .iter()
+        .flat_map(|line| wrap_text(line, width))
+        .collect();
+    let footer_lines: Vec<String> = footer
+        .iter()
+        .flat_map(|line| wrap_text(line, width))
+        .collect();
+    let room = (area.height as usize)
+        .saturating_sub(lines.len())
+        .saturating_sub(footer_lines.len() + 1);
+    let skip = region_lines.len().saturating_sub(room);
+    // The region's label stays when its start scrolls away
+    if skip > 0 && room > 0 {
+        lines.push(Line::raw(region_lines[0].clone()));
+        lines.extend(region_lines[skip + 1..].iter().cloned().map(Line::raw));
+    } else {
+        lines.extend(region_lines.into_iter().map(Line::raw));
+    }
+    lines.push(Line::raw(""));
+    let footer_top = lines.len();
+    lines.extend(footer_lines.into_iter().map(Line::raw));
+
+    // Lines past the bottom are cut; the session list comes first
+    frame.render_widget(Paragraph::new(lines), area);
+
+    if let Some(col) = cursor {
+        let row = footer_top + col / width;
+        let y = area.y + row.min(area.height.saturating_sub(1) as usize) as u16;
+        let x = area.x + (col % width) as u16;
+        frame.set_cursor_position(Position::new(x, y));
+    }
+}
@@ -6,6 +6,9 @@
 //!
 //! - `sidebar` - Logo, session list, hotkeys, and plan entries
 //! - `conversation_view` - Main conversation/chat area with markdown rendering
+//! - `linear_view` - Screen reader layout: one column of labeled regions
+//! - `json_view` - Colorized JSON of raw tool calls and turn results
+//! - `ansi_view` - Terminal colors in tool results, shown per message
 //! - `prompt` - Prompt input with attachments and mode indicators
 //! - `permission_dialog` - Permission request dialog
 //! - `question_dialog` - Agent question dialog
@@ -15,44 +18,95 @@
 //! - `worktree_cleanup` - Worktree cleanup dialog
 //! - `agent_picker` - Agent type selection picker
 //! - `session_picker` - Session resume picker
+//! - `recent_files` - Recently written files with content preview
+//! - `process_tree` - Processes running under the agent, with CPU usage
+//! - `workspace_diff` - Work tree snapshots and the diff between two of them
+//! - `reply_menu` - Quick reply templates to send to the agent
+//! - `notes_popup` - Scratchpad notes on a session
+//! - `epic_input` - Epic name input for linking sessions
 //! - `help_popup` - Help overlay with keybindings
 //! - `bug_report_popup` - Bug report dialog
 //! - `clear_confirm_popup` - Clear session confirmation
+//! - `kill_idle_popup` - Confirmation to kill all idle sessions
+//! - `stats_popup` - Conversation statistics per role
+//! - `plan_history_popup` - Timeline of plan changes
+//! - `queue_popup` - Queued prompts and work orders
+//! - `search_popup` - Ranked matches from archived sessions
+//! - `verify_popup` - Output of the verify command
+//! - `audit_log_popup` - Recorded kills, restarts and commands run
+//! - `tab_bar` - Sessions opened as tabs above the conversation
+//! - `toast` - Short-lived messages such as config reload results
 //! - `separators` - Vertical and horizontal line separators
 
 mod agent_picker;
+mod ansi_view;
+mod audit_log_popup;
 mod branch_input;
 mod bug_report_popup;
 mod clear_confirm_popup;
+mod conversation_view;
+mod epic_input;
 mod folder_picker;
 mod help_popup;
-mod prompt;
-mod conversation_view;
+mod json_view;
+mod kill_idle_popup;
+mod linear_view;
+mod notes_popup;
 mod permission_dialog;
+mod plan_history_popup;
+mod process_tree;
+mod prompt;
 mod question_dialog;
+mod queue_popup;
+mod recent_files;
+mod reply_menu;
+mod search_popup;
 mod separators;
 mod session_picker;
 mod sidebar;
+mod stats_popup;
+mod tab_bar;
+mod toast;
+mod verify_popup;
+mod workspace_diff;
 mod worktree_cleanup;
 mod worktree_picker;
 
 // Re-export all render functions for use in ui.rs
 pub use agent_picker::render_agent_picker;
+pub use audit_log_popup::render_audit_log_popup;
 pub use branch_input::render_branch_input;
 pub use bug_report_popup::render_bug_report_popup;
 pub use clear_confirm_popup::render_clear_confirm_popup;
+pub use conversation_view::render_conversation_view;
+pub use epic_input::render_epic_input;
 pub use folder_picker::render_folder_picker;
 pub use help_popup::render_help_popup;
-pub use prompt::render_prompt;
-pub use conversation_view::render_conversation_view;
+pub use kill_idle_popup::render_kill_idle_popup;
+pub use linear_view::render_linear_view;
+pub use notes_popup::render_notes_popup;
 pub use permission_dialog::render_permission_dialog;
+pub use plan_history_popup::render_plan_history_popup;
+pub use process_tree::render_process_tree;
+pub use prompt::render_prompt;
 pub use question_dialog::render_question_dialog;
+pub use queue_popup::render_queue_popup;
+pub use recent_files::render_recent_files;
+pub use reply_menu::render_reply_menu;
+pub use search_popup::render_search_popup;
 pub use separators::{render_horizontal_separator, render_separator};
 pub use session_picker::render_session_picker;
 pub use sidebar::{render_logo, render_session_list};
+pub use stats_popup::render_stats_popup;
+pub use tab_bar::render_tab_bar;
+pub use toast::render_toast;
+pub use verify_popup::render_verify_popup;
+pub use workspace_diff::render_workspace_diff;
 pub use worktree_cleanup::render_worktree_cleanup;
 pub use worktree_picker::render_worktree_picker;
 
+use unicode_width::{UnicodeWidthChar, UnicodeWidthStr};
+
 /// Wrap text to fit within width, preserving words where possible.
 pub fn wrap_text(text: &str, width: usize) -> Vec<String> {
     if width == 0 {
@@ -137,3 +191,101 @@ pub fn wrap_text(text: &str, width: usize) -> Vec<String> {
 
     result
 }
+
+/// Take chars from `chars` while they fit within `width` terminal columns
+fn take_width(chars: impl Iterator<Item = char>, width: usize) -> Vec<char> {
+    let mut used = 0;
+    chars
+        .take_while(|c| {
+            used += c.width().unwrap_or(0);
+            used <= width
+        })
+        .collect()
+}
+
+/// Truncate text to fit within width (in terminal columns, so wide CJK
+/// characters and emoji count twice), appending "…" when cut.
+pub fn truncate_text(text: &str, width: usize) -> String {
+    if text.width() <= width {
+        return text.to_string();
+    }
+    if width == 0 {
+        return String::new();
+    }
+    let mut truncated: String = take_width(text.chars(), width - 1).into_iter().collect();
+    truncated.push('…');
+    truncated
+}
+
+/// Truncate text to fit within width (in terminal columns) by cutting out
+/// the middle, so both ends of branch names and paths stay readable
+/// ("feature/…-login"). ANSI escape sequences are dropped first, so they
+/// neither count towards the width nor get cut in half.
+pub fn truncate_middle(text: &str, width: usize) -> String {
+    let text = strip_ansi(text);
+    if text.width() <= width {
+        return text;
+    }
+    if width == 0 {
+        return String::new();
+    }
+    let head = (width - 1).div_ceil(2);
+    let tail = width - 1 - head;
+    let mut truncated: String = take_width(text.chars(), head).into_iter().collect();
+    truncated.push('…');
+    truncated.extend(take_width(text.chars().rev(), tail).into_iter().rev());
+    truncated
+}
+
+/// Remove ANSI escape sequences (colors, cursor movement, terminal titles)
+pub fn strip_ansi(text: &str) -> String {
+    let mut result = String::with_capacity(text.len());
+    let mut chars = text.chars().peekable();
+    while let Some(c) = chars.next() {
+        if c != '\x1b' {
+            result.push(c);
+            continue;
+        }
+        match chars.next() {
+            // CSI: parameters up to a final byte in @..~
+            Some('[') => {
+                for c in chars.by_ref() {
+                    if ('@'..='~').contains(&c) {
+                        break;
+                    }
+                }
+            }
+            // OSC: up to BEL or ESC \
+            Some(']') => {
+                while let Some(c) = chars.next() {
+                    if c == '\x07' {
+                        break;
+                    }
+                    if c == '\x1b' && chars.peek() == Some(&'\\') {
+                        chars.next();
+                        break;
+                    }
+                }
+            }
+            // Two-character sequences
+            _ => {}
+        }
+    }
+    result
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_truncate_by_width() {
+        assert_eq!(truncate_text("fix the parser", 8), "fix the…");
+        // Wide characters take two columns each
+        assert_eq!(truncate_text("修复解析器的错误", 7), "修复解…");
+        assert_eq!(truncate_text("修复解析器的错误", 16), "修复解析器的错误");
+        assert_eq!(truncate_middle("feature/修复-login", 11), "featu…login");
+        assert_eq!(truncate_middle("修复/解析器/错误", 9), "修复…错误");
+        assert!(truncate_middle("🚀🚀🚀🚀🚀🚀", 5).width() <= 5);
+    }
+}
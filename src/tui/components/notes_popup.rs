@@ -0,0 +1,104 @@
+//! Notes popup component - scratchpad notes on the selected session.
+
+use ratatui::{
+    Frame,
+    layout::{Position, Rect},
+    style::{Color, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+};
+
+use crate::app::App;
+use crate::tui::theme::*;
+
+/// Rows of text shown in the editor
+const TEXT_ROWS: usize = 8;
+
+/// Split text into rows of at most `width` chars, breaking at newlines and
+/// then hard at the width so the cursor position is easy to follow
+fn text_rows(text: &str, width: usize) -> Vec<String> {
+    let mut rows = vec![];
+    for line in text.split('\n') {
+        let chars: Vec<char> = line.chars().collect();
+        if chars.is_empty() {
+            rows.push(String::new());
+        }
+        for chunk in chars.chunks(width.max(1)) {
+            rows.push(chunk.iter().collect());
+        }
+    }
+    rows
+}
+
+/// Render the notes editor for the selected session.
+pub fn render_notes_popup(frame: &mut Frame, area: Rect, app: &App) {
+    let Some(editor) = &app.notes_editor else {
+        return;
+    };
+    let name = app
+        .selected_session()
+        .map(|s| s.name.clone())
+        .unwrap_or_default();
+
+    // Calculate centered popup area
+    let popup_width = 60u16.min(area.width);
+    let popup_height = (TEXT_ROWS as u16 + 6).min(area.height);
+    let x = area.x + (area.width.saturating_sub(popup_width)) / 2;
+    let y = area.y + (area.height.saturating_sub(popup_height)) / 2;
+    let popup_area = Rect::new(x, y, popup_width, popup_height);
+
+    // Clear the area behind the popup
+    frame.render_widget(Clear, popup_area);
+
+    let mut lines: Vec<Line> = vec![
+        // Title
+        Line::from(vec![
+            Span::styled("Notes", Style::new().fg(LOGO_LIGHT_BLUE).bold()),
+            Span::styled(format!("  {}", name), Style::new().fg(TEXT_DIM)),
+        ]),
+        Line::raw(""),
+    ];
+
+    // Row and column of the cursor
+    let text_width = (popup_width as usize).saturating_sub(2).max(1);
+    let before = &editor.text[..editor.cursor_position];
+    let rows_before = text_rows(before, text_width);
+    let cursor_row = rows_before.len() - 1;
+    let cursor_col = rows_before.last().map_or(0, |row| row.chars().count());
+    // A cursor right after a full row sits at the start of the next one
+    let (cursor_row, cursor_col) = if cursor_col == text_width {
+        (cursor_row + 1, 0)
+    } else {
+        (cursor_row, cursor_col)
+    };
+
+    // Keep the cursor in view
+    let rows = text_rows(&editor.text, text_width);
+    let top = cursor_row.saturating_sub(TEXT_ROWS - 1);
+    for i in top..top + TEXT_ROWS {
+        let row = rows.get(i).cloned().unwrap_or_default();
+        lines.push(Line::styled(row, Style::new().fg(TEXT_WHITE)));
+    }
+    lines.push(Line::raw(""));
+
+    // Footer
+    lines.push(Line::from(vec![
+        Span::styled("[Enter]", Style::new().fg(TEXT_WHITE)),
+        Span::styled(" new line  ", Style::new().fg(TEXT_DIM)),
+        Span::styled("[Esc]", Style::new().fg(TEXT_WHITE)),
+        Span::styled(" done", Style::new().fg(TEXT_DIM)),
+    ]));
+
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .border_style(Style::new().fg(LOGO_LIGHT_BLUE))
+        .style(Style::new().bg(Color::Black));
+
+    let paragraph = Paragraph::new(lines).block(block);
+    frame.render_widget(paragraph, popup_area);
+
+    // Account for border (1), title (1), empty (1), then text rows
+    let cursor_x = popup_area.x + 1 + cursor_col as u16;
+    let cursor_y = popup_area.y + 3 + (cursor_row - top) as u16;
+    frame.set_cursor_position(Position::new(cursor_x, cursor_y));
+}
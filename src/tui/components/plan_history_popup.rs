@@ -0,0 +1,88 @@
+//! Plan history popup component - timeline of how the agent's plan evolved.
+
+use ratatui::{
+    Frame,
+    layout::Rect,
+    style::{Color, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+};
+
+use crate::app::App;
+use crate::session::PlanChangeKind;
+use crate::tui::theme::*;
+
+use super::truncate_text;
+
+/// Render the plan history timeline for the selected session.
+pub fn render_plan_history_popup(frame: &mut Frame, area: Rect, app: &App) {
+    let Some(session) = app.selected_session() else {
+        return;
+    };
+
+    // Calculate centered popup area, growing with the history up to the screen size
+    let popup_width = 70u16.min(area.width);
+    let max_rows = area.height.saturating_sub(6) as usize;
+    let rows = session.plan_history.len().clamp(1, max_rows.max(1));
+    let popup_height = (rows as u16 + 6).min(area.height);
+    let x = area.x + (area.width.saturating_sub(popup_width)) / 2;
+    let y = area.y + (area.height.saturating_sub(popup_height)) / 2;
+    let popup_area = Rect::new(x, y, popup_width, popup_height);
+
+    // Clear the area behind the popup
+    frame.render_widget(Clear, popup_area);
+
+    let mut lines: Vec<Line> = vec![];
+
+    // Title
+    lines.push(Line::from(vec![Span::styled(
+        "Plan History",
+        Style::new().fg(LOGO_LIGHT_BLUE).bold(),
+    )]));
+    lines.push(Line::raw(""));
+
+    if session.plan_history.is_empty() {
+        lines.push(Line::styled(
+            "  (the agent has not made a plan yet)",
+            Style::new().fg(TEXT_DIM),
+        ));
+    }
+
+    // Newest changes are at the bottom; show the most recent ones that fit
+    let skip = session.plan_history.len().saturating_sub(rows);
+    let content_width = (popup_width as usize).saturating_sub(2 + 22);
+    for change in session.plan_history.iter().skip(skip) {
+        let (label, color) = match change.kind {
+            PlanChangeKind::Added => ("+ added    ", TEXT_WHITE),
+            PlanChangeKind::Started => ("◐ started  ", LOGO_GOLD),
+            PlanChangeKind::Completed => ("✓ done     ", LOGO_MINT),
+            PlanChangeKind::Removed => ("- removed  ", LOGO_CORAL),
+        };
+        lines.push(Line::from(vec![
+            Span::styled(
+                format!("  {}  ", change.at.format("%H:%M:%S")),
+                Style::new().fg(TEXT_DIM),
+            ),
+            Span::styled(label, Style::new().fg(color)),
+            Span::styled(
+                truncate_text(&change.content, content_width),
+                Style::new().fg(TEXT_WHITE),
+            ),
+        ]));
+    }
+    lines.push(Line::raw(""));
+
+    // Footer
+    lines.push(Line::from(vec![
+        Span::styled("[Esc]", Style::new().fg(TEXT_WHITE)),
+        Span::styled(" close", Style::new().fg(TEXT_DIM)),
+    ]));
+
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .border_style(Style::new().fg(LOGO_LIGHT_BLUE))
+        .style(Style::new().bg(Color::Black));
+
+    let paragraph = Paragraph::new(lines).block(block);
+    frame.render_widget(paragraph, popup_area);
+}
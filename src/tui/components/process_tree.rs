@@ -0,0 +1,131 @@
+//! Process tree component - processes running under the agent, with CPU usage.
+
+use ratatui::{
+    Frame,
+    layout::Rect,
+    style::Style,
+    text::{Line, Span},
+    widgets::Paragraph,
+};
+
+use crate::app::App;
+use crate::procs::{self, TreeRow};
+use crate::tui::theme::*;
+
+use super::truncate_text;
+
+/// CPU usage (percent of one core) shown as busy
+const BUSY_CPU: f32 = 50.0;
+
+/// Tree glyphs in front of a row: a column per ancestor level, then the
+/// row's own branch
+fn tree_prefix(rows: &[TreeRow], index: usize) -> String {
+    let depth = rows[index].depth;
+    // Whether a later sibling at `level` follows before the subtree ends
+    let continues = |level: usize| {
+        rows[index + 1..]
+            .iter()
+            .take_while(|row| row.depth >= level)
+            .any(|row| row.depth == level)
+    };
+    let mut prefix = String::new();
+    for level in 1..depth {
+        prefix.push_str(if continues(level) { "│ " } else { "  " });
+    }
+    if depth > 0 {
+        prefix.push_str(if continues(depth) { "├ " } else { "└ " });
+    }
+    prefix
+}
+
+/// Render the processes below the selected session's agent.
+pub fn render_process_tree(frame: &mut Frame, area: Rect, app: &App) {
+    let mut lines: Vec<Line> = vec![];
+    let width = area.width as usize;
+
+    if let Some(tree) = &app.process_tree {
+        // Header with the tree's total CPU
+        let mut header = vec![Span::styled(
+            "Processes",
+            Style::new().fg(LOGO_LIGHT_BLUE).bold(),
+        )];
+        if !tree.rows.is_empty() {
+            header.push(Span::styled(
+                format!("  {:.0}% CPU", procs::total_cpu(&tree.rows)),
+                Style::new().fg(TEXT_WHITE),
+            ));
+        }
+        header.push(Span::styled(
+            "  [j/k] select  [Enter] collapse  [Esc] close",
+            Style::new().fg(TEXT_DIM),
+        ));
+        lines.push(Line::from(header));
+        lines.push(Line::raw("")); // spacing
+
+        match &tree.processes {
+            None => lines.push(Line::styled(
+                "  Listing processes…",
+                Style::new().fg(TEXT_DIM),
+            )),
+            Some(Err(e)) => lines.push(Line::styled(
+                format!("  {}", e),
+                Style::new().fg(LOGO_CORAL),
+            )),
+            Some(Ok(_)) if tree.rows.is_empty() => lines.push(Line::styled(
+                format!("  (agent process {} has exited)", tree.root),
+                Style::new().fg(TEXT_DIM),
+            )),
+            Some(Ok(_)) => {}
+        }
+
+        // Keep the selected process visible when the tree is taller than the area
+        let visible = (area.height as usize).saturating_sub(lines.len()).max(1);
+        let start = tree.selected.saturating_sub(visible - 1);
+        for (i, row) in tree.rows.iter().enumerate().skip(start).take(visible) {
+            let is_selected = i == tree.selected;
+            let cursor = if is_selected { "> " } else { "  " };
+            let marker = match row.children {
+                0 => "  ",
+                _ if tree.collapsed.contains(&row.process.pid) => "▸ ",
+                _ => "▾ ",
+            };
+            let hidden = if tree.collapsed.contains(&row.process.pid) {
+                format!(" (+{})", row.children)
+            } else {
+                String::new()
+            };
+            let cpu_style = if row.process.cpu >= BUSY_CPU {
+                Style::new().fg(LOGO_GOLD)
+            } else {
+                Style::new().fg(TEXT_DIM)
+            };
+            let prefix = tree_prefix(&tree.rows, i);
+            let used = 2 + 7 + prefix.chars().count() + 2 + 8 + hidden.chars().count();
+            let command_style = if is_selected {
+                Style::new().fg(TEXT_WHITE).bold()
+            } else {
+                Style::new().fg(TEXT_WHITE)
+            };
+
+            lines.push(Line::from(vec![
+                Span::raw(cursor),
+                Span::styled(format!("{:>5.1}% ", row.process.cpu), cpu_style),
+                Span::styled(prefix, Style::new().fg(TOOL_CONNECTOR)),
+                Span::styled(marker, Style::new().fg(TEXT_DIM)),
+                Span::styled(
+                    format!("{:<7} ", row.process.pid),
+                    Style::new().fg(TEXT_DIM),
+                ),
+                Span::styled(
+                    truncate_text(&row.process.command, width.saturating_sub(used)),
+                    command_style,
+                ),
+                Span::styled(hidden, Style::new().fg(TEXT_DIM)),
+            ]));
+        }
+    }
+
+    let paragraph = Paragraph::new(lines).style(Style::new().fg(TEXT_WHITE));
+
+    frame.render_widget(paragraph, area);
+}
@@ -91,9 +91,16 @@ pub fn render_prompt(frame: &mut Frame, area: Rect, app: &mut App) {
     // Show '!' for bash mode, '>' for normal prompt
     let prompt = if is_bash_mode { "! " } else { "> " };
 
-    // Wrap the input text
+    // Wrap the input text (tagging mode shows its keys in place of the input)
     let content_width = width.saturating_sub(2); // Account for prompt "> "
-    let wrapped = wrap_text(&app.input_buffer, content_width);
+    let wrapped = if app.input_mode == InputMode::Tagging {
+        wrap_text(
+            "tagging · j/k move · d decision · b bug · t todo · y copy permalink · enter open files · c colors · x export tagged · e/D/B/T append to dataset · esc done",
+            content_width,
+        )
+    } else {
+        wrap_text(&app.input_buffer, content_width)
+    };
 
     // Calculate how many lines the input takes (for click region calculation)
     let input_line_count = wrapped.len();
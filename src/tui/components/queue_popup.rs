@@ -0,0 +1,170 @@
+//! Queue popup component - prompts queued for busy agents and work orders
+//! waiting for an idle agent in their project.
+
+use ratatui::{
+    Frame,
+    layout::{Position, Rect},
+    style::{Color, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+};
+
+use crate::app::{App, QueueEditTarget, QueueItem};
+use crate::tui::theme::*;
+
+use super::{truncate_middle, truncate_text};
+
+/// Width of the session or project column
+const TARGET_WIDTH: usize = 16;
+
+/// Render the queue of prompts and work orders.
+pub fn render_queue_popup(frame: &mut Frame, area: Rect, app: &App) {
+    let Some(view) = &app.queue_view else {
+        return;
+    };
+    let items = app.queue_items();
+    let held_until = app.dispatch_held_until();
+
+    // Calculate centered popup area, growing with the queue up to the screen size
+    let popup_width = 80u16.min(area.width);
+    let fixed = 6 + u16::from(held_until.is_some()) + 2 * u16::from(view.edit.is_some());
+    let max_rows = area.height.saturating_sub(fixed).max(1) as usize;
+    let rows = items.len().clamp(1, max_rows);
+    let popup_height = (rows as u16 + fixed).min(area.height);
+    let x = area.x + (area.width.saturating_sub(popup_width)) / 2;
+    let y = area.y + (area.height.saturating_sub(popup_height)) / 2;
+    let popup_area = Rect::new(x, y, popup_width, popup_height);
+
+    // Clear the area behind the popup
+    frame.render_widget(Clear, popup_area);
+
+    let content_width = (popup_width as usize).saturating_sub(4);
+    let mut lines: Vec<Line> = vec![];
+
+    // Title
+    lines.push(Line::from(vec![Span::styled(
+        "Queue",
+        Style::new().fg(LOGO_LIGHT_BLUE).bold(),
+    )]));
+    lines.push(Line::raw(""));
+
+    // When the usage quota lets queued work go out again
+    if let Some(until) = held_until {
+        lines.push(Line::styled(
+            format!(
+                "  Held until {} - the 5-hour usage quota is nearly used",
+                until.format("%H:%M")
+            ),
+            Style::new().fg(LOGO_GOLD),
+        ));
+    }
+
+    if items.is_empty() {
+        lines.push(Line::styled(
+            "  (nothing queued: Enter while an agent works queues a prompt, a adds a work order)",
+            Style::new().fg(TEXT_DIM),
+        ));
+    }
+
+    // Keep the selected item visible when the queue is taller than the popup
+    let start = view.selected.saturating_sub(rows - 1);
+    for (i, item) in items.iter().enumerate().skip(start).take(rows) {
+        let is_selected = i == view.selected;
+        let cursor = if is_selected { "> " } else { "  " };
+        let (target, kind, when) = match item {
+            QueueItem::Prompt { session_id, .. } => {
+                let name = app
+                    .sessions
+                    .get_by_id(session_id)
+                    .map_or("?", |s| s.name.as_str());
+                (name.to_string(), "prompt", String::new())
+            }
+            QueueItem::Order(order) => (
+                order.project.display().to_string(),
+                "order ",
+                format!(" {}", order.added.format("%H:%M")),
+            ),
+        };
+        let text_width = content_width.saturating_sub(TARGET_WIDTH + 1 + 7 + when.len());
+        let text_style = if is_selected {
+            Style::new().fg(TEXT_WHITE).bold()
+        } else {
+            Style::new().fg(TEXT_WHITE)
+        };
+        lines.push(Line::from(vec![
+            Span::raw(cursor),
+            Span::styled(
+                format!(
+                    "{:<width$} ",
+                    truncate_middle(&target, TARGET_WIDTH),
+                    width = TARGET_WIDTH
+                ),
+                Style::new().fg(LOGO_LIGHT_BLUE),
+            ),
+            Span::styled(format!("{} ", kind), Style::new().fg(TEXT_DIM)),
+            Span::styled(truncate_text(item.text(), text_width), text_style),
+            Span::styled(when, Style::new().fg(TEXT_DIM)),
+        ]));
+    }
+    lines.push(Line::raw(""));
+
+    // Input line for editing or adding
+    let mut cursor = None;
+    if let Some((target, input)) = &view.edit {
+        let label = match target {
+            QueueEditTarget::Item(_) => "Edit:".to_string(),
+            QueueEditTarget::NewOrder(project) => format!(
+                "New work order for {}:",
+                truncate_middle(&project.display().to_string(), content_width)
+            ),
+        };
+        lines.push(Line::styled(label, Style::new().fg(TEXT_DIM)));
+        cursor = Some(lines.len());
+        lines.push(Line::from(vec![
+            Span::styled("> ", Style::new().fg(LOGO_MINT)),
+            Span::styled(
+                truncate_text(&input.text, content_width),
+                Style::new().fg(TEXT_WHITE),
+            ),
+        ]));
+    }
+
+    // Footer
+    let keys: &[(&str, &str)] = if view.edit.is_some() {
+        &[("[Enter]", " save  "), ("[Esc]", " cancel")]
+    } else {
+        &[
+            ("[j/k]", " select  "),
+            ("[e]", " edit  "),
+            ("[x]", " cancel  "),
+            ("[a]", " add work order  "),
+            ("[Esc]", " close"),
+        ]
+    };
+    lines.push(Line::from(
+        keys.iter()
+            .flat_map(|(key, label)| {
+                [
+                    Span::styled(*key, Style::new().fg(TEXT_WHITE)),
+                    Span::styled(*label, Style::new().fg(TEXT_DIM)),
+                ]
+            })
+            .collect::<Vec<_>>(),
+    ));
+
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .border_style(Style::new().fg(LOGO_LIGHT_BLUE))
+        .style(Style::new().bg(Color::Black));
+
+    let paragraph = Paragraph::new(lines).block(block);
+    frame.render_widget(paragraph, popup_area);
+
+    // Account for the border (1) and the prompt "> " (2)
+    if let (Some(row), Some((_, input))) = (cursor, &view.edit) {
+        let cursor_col = input.text[..input.cursor_position].chars().count();
+        let cursor_x = popup_area.x + 1 + 2 + cursor_col.min(content_width) as u16;
+        let cursor_y = popup_area.y + 1 + row as u16;
+        frame.set_cursor_position(Position::new(cursor_x, cursor_y));
+    }
+}
@@ -0,0 +1,125 @@
+//! Recent files component - files the agent wrote, with a preview of the selected one.
+
+use ratatui::{
+    Frame,
+    layout::Rect,
+    style::Style,
+    text::{Line, Span},
+    widgets::Paragraph,
+};
+
+use crate::app::App;
+use crate::picker::Picker;
+use crate::tui::theme::*;
+
+use super::truncate_text;
+
+/// Maximum number of file rows shown above the preview
+const MAX_LIST_ROWS: usize = 8;
+
+/// Lines of context shown above the agent's latest change
+const PREVIEW_CONTEXT: usize = 3;
+
+/// Render the recent files list and a preview of the selected file.
+pub fn render_recent_files(frame: &mut Frame, area: Rect, app: &App) {
+    let mut lines: Vec<Line> = vec![];
+    let width = area.width as usize;
+    let cwd = app.selected_session().map(|s| s.cwd.clone());
+
+    if let Some(recent) = &app.recent_files {
+        // Header
+        lines.push(Line::from(vec![
+            Span::styled("Recent files", Style::new().fg(LOGO_LIGHT_BLUE).bold()),
+            Span::styled("  [j/k] select  [Esc] close", Style::new().fg(TEXT_DIM)),
+        ]));
+        lines.push(Line::raw("")); // spacing
+
+        if recent.files.is_empty() {
+            lines.push(Line::styled(
+                "  (the agent has not written any files yet)",
+                Style::new().fg(TEXT_DIM),
+            ));
+        }
+
+        // Keep the selected file visible when the list is longer than the rows shown
+        let list_start = recent.selected.saturating_sub(MAX_LIST_ROWS - 1);
+        for (i, file) in recent
+            .files
+            .iter()
+            .enumerate()
+            .skip(list_start)
+            .take(MAX_LIST_ROWS)
+        {
+            let is_selected = i == recent.selected;
+            let cursor = if is_selected { "> " } else { "  " };
+
+            // Show paths relative to the session directory when possible
+            let display_path = cwd
+                .as_ref()
+                .and_then(|cwd| file.path.strip_prefix(cwd).ok())
+                .unwrap_or(&file.path)
+                .display()
+                .to_string();
+
+            lines.push(Line::from(vec![
+                Span::raw(cursor),
+                Span::styled(
+                    truncate_text(&display_path, width.saturating_sub(14)),
+                    if is_selected {
+                        Style::new().fg(TEXT_WHITE).bold()
+                    } else {
+                        Style::new().fg(TEXT_WHITE)
+                    },
+                ),
+                Span::styled(
+                    format!("  {} changed", file.changed_lines.len()),
+                    Style::new().fg(TEXT_DIM),
+                ),
+            ]));
+        }
+
+        // Preview of the selected file with the agent's latest changes highlighted
+        if let Some(file) = recent.selected_item() {
+            lines.push(Line::raw(""));
+
+            match &recent.preview {
+                Some(content) => {
+                    let remaining = (area.height as usize).saturating_sub(lines.len());
+                    let first_changed = file.changed_lines.first().copied().unwrap_or(1);
+                    let start = first_changed.saturating_sub(PREVIEW_CONTEXT + 1);
+                    let gutter = content.len().to_string().len();
+
+                    for (idx, text) in content.iter().enumerate().skip(start).take(remaining) {
+                        let line_number = idx + 1;
+                        let changed = file.changed_lines.contains(&line_number);
+                        let text = truncate_text(text, width.saturating_sub(gutter + 3));
+                        let (marker, text_style) = if changed {
+                            ("+", Style::new().fg(DIFF_ADD_FG).bg(DIFF_ADD_BG))
+                        } else {
+                            (" ", Style::new().fg(TEXT_WHITE))
+                        };
+                        lines.push(Line::from(vec![
+                            Span::styled(
+                                format!("{:>gutter$} ", line_number),
+                                Style::new().fg(TEXT_DIM),
+                            ),
+                            Span::styled(marker, Style::new().fg(DIFF_ADD_FG)),
+                            Span::raw(" "),
+                            Span::styled(text, text_style),
+                        ]));
+                    }
+                }
+                None => {
+                    lines.push(Line::styled(
+                        "  (file no longer readable)",
+                        Style::new().fg(LOGO_CORAL),
+                    ));
+                }
+            }
+        }
+    }
+
+    let paragraph = Paragraph::new(lines).style(Style::new().fg(TEXT_WHITE));
+
+    frame.render_widget(paragraph, area);
+}
@@ -0,0 +1,89 @@
+//! Quick reply menu component - follow-up templates to send to the agent.
+
+use ratatui::{
+    Frame,
+    layout::Rect,
+    style::{Color, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+};
+
+use crate::app::App;
+use crate::tui::theme::*;
+
+use super::truncate_text;
+
+/// Render the quick reply menu for the selected session.
+pub fn render_reply_menu(frame: &mut Frame, area: Rect, app: &App) {
+    let Some(menu) = &app.reply_menu else {
+        return;
+    };
+
+    // Calculate centered popup area, one row per template
+    let popup_width = 60u16.min(area.width);
+    let popup_height = (menu.templates.len() as u16 + 6).min(area.height);
+    let x = area.x + (area.width.saturating_sub(popup_width)) / 2;
+    let y = area.y + (area.height.saturating_sub(popup_height)) / 2;
+    let popup_area = Rect::new(x, y, popup_width, popup_height);
+
+    // Clear the area behind the popup
+    frame.render_widget(Clear, popup_area);
+
+    let mut lines: Vec<Line> = vec![];
+
+    // Title
+    lines.push(Line::from(vec![Span::styled(
+        "Quick Reply",
+        Style::new().fg(LOGO_LIGHT_BLUE).bold(),
+    )]));
+    lines.push(Line::raw(""));
+
+    let content_width = (popup_width as usize).saturating_sub(2 + 6);
+    for (i, template) in menu.templates.iter().enumerate() {
+        let is_selected = i == menu.selected;
+        let number = if i < 9 {
+            format!("{}", i + 1)
+        } else {
+            " ".to_string()
+        };
+        let cursor = if is_selected { "> " } else { "  " };
+        lines.push(Line::from(vec![
+            Span::styled(
+                cursor,
+                if is_selected {
+                    Style::new().fg(LOGO_MINT)
+                } else {
+                    Style::new().fg(TEXT_DIM)
+                },
+            ),
+            Span::styled(format!("{}  ", number), Style::new().fg(TEXT_DIM)),
+            Span::styled(
+                truncate_text(template, content_width),
+                if is_selected {
+                    Style::new().fg(TEXT_WHITE).bold()
+                } else {
+                    Style::new().fg(TEXT_WHITE)
+                },
+            ),
+        ]));
+    }
+    lines.push(Line::raw(""));
+
+    // Footer
+    lines.push(Line::from(vec![
+        Span::styled("[Enter/1-9]", Style::new().fg(TEXT_WHITE)),
+        Span::styled(" send  ", Style::new().fg(TEXT_DIM)),
+        Span::styled("[Tab]", Style::new().fg(TEXT_WHITE)),
+        Span::styled(" edit first  ", Style::new().fg(TEXT_DIM)),
+        Span::styled("[Esc]", Style::new().fg(TEXT_WHITE)),
+        Span::styled(" close", Style::new().fg(TEXT_DIM)),
+    ]));
+
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .border_style(Style::new().fg(LOGO_LIGHT_BLUE))
+        .style(Style::new().bg(Color::Black));
+
+    let paragraph = Paragraph::new(lines).block(block);
+    frame.render_widget(paragraph, popup_area);
+}
@@ -0,0 +1,168 @@
+//! Search popup component - ranked matches from archived sessions, and the
+//! archived message opened from one.
+
+use ratatui::{
+    Frame,
+    layout::{Position, Rect},
+    style::{Color, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph, Wrap},
+};
+
+use crate::app::App;
+use crate::tui::theme::*;
+
+use super::{truncate_middle, truncate_text};
+
+/// Width of the session column
+const SESSION_WIDTH: usize = 20;
+
+/// Render the archive search screen.
+pub fn render_search_popup(frame: &mut Frame, area: Rect, app: &App) {
+    let Some(view) = &app.search_view else {
+        return;
+    };
+
+    // Calculate centered popup area
+    let popup_width = 100u16.min(area.width);
+    let popup_height = 30u16.min(area.height);
+    let x = area.x + (area.width.saturating_sub(popup_width)) / 2;
+    let y = area.y + (area.height.saturating_sub(popup_height)) / 2;
+    let popup_area = Rect::new(x, y, popup_width, popup_height);
+
+    // Clear the area behind the popup
+    frame.render_widget(Clear, popup_area);
+
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .border_style(Style::new().fg(LOGO_LIGHT_BLUE))
+        .style(Style::new().bg(Color::Black));
+
+    // An opened message replaces the results
+    if let Some((title, text)) = &view.preview {
+        let mut lines = vec![
+            Line::styled(title.clone(), Style::new().fg(LOGO_LIGHT_BLUE).bold()),
+            Line::raw(""),
+        ];
+        lines.extend(
+            text.lines()
+                .map(|line| Line::styled(line.to_string(), Style::new().fg(TEXT_WHITE))),
+        );
+        lines.push(Line::raw(""));
+        lines.push(Line::from(vec![
+            Span::styled("[Esc]", Style::new().fg(TEXT_WHITE)),
+            Span::styled(" back to results", Style::new().fg(TEXT_DIM)),
+        ]));
+        let paragraph = Paragraph::new(lines)
+            .block(block)
+            .wrap(Wrap { trim: false });
+        frame.render_widget(paragraph, popup_area);
+        return;
+    }
+
+    let content_width = (popup_width as usize).saturating_sub(4);
+    let mut lines: Vec<Line> = vec![];
+
+    // Title and query
+    lines.push(Line::from(vec![Span::styled(
+        "Search archived sessions",
+        Style::new().fg(LOGO_LIGHT_BLUE).bold(),
+    )]));
+    lines.push(Line::raw(""));
+    let input_row = lines.len();
+    lines.push(Line::from(vec![
+        Span::styled("/ ", Style::new().fg(LOGO_MINT)),
+        Span::styled(
+            truncate_text(&view.input.text, content_width.saturating_sub(2)),
+            Style::new().fg(TEXT_WHITE),
+        ),
+    ]));
+    lines.push(Line::raw(""));
+
+    // Results, one line per matching line
+    let results = view.results();
+    let rows = (popup_height as usize).saturating_sub(9).max(1);
+    if let Some(error) = &view.error {
+        lines.push(Line::styled(
+            format!("  {}", error),
+            Style::new().fg(LOGO_CORAL),
+        ));
+    } else if view.searching {
+        lines.push(Line::styled(
+            format!("  {} Searching…", app.spinner()),
+            Style::new().fg(TEXT_DIM),
+        ));
+    } else if view.searched.is_none() {
+        lines.push(Line::styled(
+            "  Filter with project:<TEXT> and since:<YYYY-MM-DD>",
+            Style::new().fg(TEXT_DIM),
+        ));
+    } else if results.is_empty() {
+        lines.push(Line::styled(
+            "  No archived sessions match",
+            Style::new().fg(TEXT_DIM),
+        ));
+    }
+
+    // Keep the selected line visible when there are more results than rows
+    let start = view.selected.saturating_sub(rows - 1);
+    for (i, (hit, snippet)) in results.iter().enumerate().skip(start).take(rows) {
+        let is_selected = i == view.selected;
+        let cursor = if is_selected { "> " } else { "  " };
+        // Name each session once, on its first line
+        let first_of_hit = i == start || !std::ptr::eq(results[i - 1].0, *hit);
+        let session = if first_of_hit {
+            let date = hit.entry.archived_at.get(..10).unwrap_or_default();
+            format!("{} {}", date, hit.entry.name)
+        } else {
+            String::new()
+        };
+        let text_width = content_width.saturating_sub(2 + SESSION_WIDTH + 1);
+        let text_style = if is_selected {
+            Style::new().fg(TEXT_WHITE).bold()
+        } else {
+            Style::new().fg(TEXT_WHITE)
+        };
+        lines.push(Line::from(vec![
+            Span::raw(cursor),
+            Span::styled(
+                format!(
+                    "{:<width$} ",
+                    truncate_middle(&session, SESSION_WIDTH),
+                    width = SESSION_WIDTH
+                ),
+                Style::new().fg(LOGO_LIGHT_BLUE),
+            ),
+            Span::styled(truncate_text(&snippet.text, text_width), text_style),
+        ]));
+    }
+    lines.push(Line::raw(""));
+
+    // Footer
+    lines.push(Line::from(
+        [
+            ("[Enter]", " search / open message  "),
+            ("[↑/↓]", " select  "),
+            ("[Esc]", " close"),
+        ]
+        .iter()
+        .flat_map(|(key, label)| {
+            [
+                Span::styled(*key, Style::new().fg(TEXT_WHITE)),
+                Span::styled(*label, Style::new().fg(TEXT_DIM)),
+            ]
+        })
+        .collect::<Vec<_>>(),
+    ));
+
+    let paragraph = Paragraph::new(lines).block(block);
+    frame.render_widget(paragraph, popup_area);
+
+    // Account for the border (1) and the prompt "/ " (2)
+    let cursor_col = view.input.text[..view.input.cursor_position]
+        .chars()
+        .count();
+    let cursor_x = popup_area.x + 1 + 2 + cursor_col.min(content_width) as u16;
+    let cursor_y = popup_area.y + 1 + input_row as u16;
+    frame.set_cursor_position(Position::new(cursor_x, cursor_y));
+}
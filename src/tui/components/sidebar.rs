@@ -2,23 +2,37 @@
 
 use std::collections::BTreeMap;
 
+use chrono::{DateTime, Local};
 use ratatui::{
     Frame,
     layout::Rect,
-    style::{Modifier, Style},
+    style::{Color, Modifier, Style},
     text::{Line, Span},
     widgets::Paragraph,
 };
 
 use crate::acp::PlanStatus;
 use crate::app::{App, ClickRegion, SortMode};
+use crate::config::RowField;
 use crate::events::Action;
+use crate::git::DiffSeverity;
 use crate::picker::Picker;
-use crate::session::{Session, SessionState};
+use crate::scroll;
+use crate::session::{ClaudeMode, PermissionMode, Session, SessionState, VerifyStatus};
 use crate::tui::interaction::InteractiveRegion;
 use crate::tui::theme::*;
+use crate::usage;
 
-use super::wrap_text;
+use super::{truncate_middle, truncate_text, wrap_text};
+
+/// Commit subjects listed under the selected session
+const MAX_COMMIT_SUBJECTS: usize = 3;
+
+/// Longest preview of an agent's latest message (with 'P')
+const MAX_PREVIEW_CHARS: usize = 80;
+
+/// Characters kept of a path or branch when a row is too long for the list
+const MIN_FIELD_CHARS: usize = 12;
 
 /// Render the colorful "amux" logo centered in the area.
 pub fn render_logo(frame: &mut Frame, area: Rect) {
@@ -35,6 +49,45 @@ pub fn render_logo(frame: &mut Frame, area: Rect) {
     frame.render_widget(paragraph, area);
 }
 
+/// How a session's row is displayed, besides its selection and number
+#[derive(Debug, Clone, Copy)]
+pub struct RowContext<'a> {
+    /// The session's notifications are muted
+    pub muted: bool,
+    /// Working without output for longer than the stall threshold
+    pub stalled: bool,
+    /// How far the row has faded towards TEXT_DIM (0.0 to 1.0)
+    pub fade: f32,
+    /// Preview the agent's latest message ('P')
+    pub show_preview: bool,
+    /// End of the quiet hours queued prompts are held until
+    pub held_until: Option<DateTime<Local>>,
+    /// Configured fields below the path
+    pub fields: &'a [RowField],
+    /// Width of the session list
+    pub width: usize,
+}
+
+impl<'a> RowContext<'a> {
+    /// The row context of a session in the session list
+    fn for_session(
+        app: &'a App,
+        session: &Session,
+        held_until: Option<DateTime<Local>>,
+        width: usize,
+    ) -> Self {
+        Self {
+            muted: app.notifications.is_muted(&session.name),
+            stalled: app.is_stalled(session),
+            fade: row_fade(app, session),
+            show_preview: app.show_previews,
+            held_until,
+            fields: &app.row_fields,
+            width,
+        }
+    }
+}
+
 /// Render a single session entry and return the lines.
 pub fn render_session_entry<'a>(
     session: &'a Session,
@@ -43,16 +96,56 @@ pub fn render_session_entry<'a>(
     spinner: &str,
     start_dir: &std::path::Path,
     show_number: bool,
+    row: RowContext<'_>,
 ) -> Vec<Line<'a>> {
+    let RowContext {
+        muted,
+        stalled,
+        fade,
+        show_preview,
+        held_until,
+        fields,
+        width,
+    } = row;
     let cursor = if is_selected { "> " } else { "  " };
+    // Idle rows dim towards TEXT_DIM the longer the agent has been inactive
+    let path_color = blend(TEXT_WHITE, TEXT_DIM, fade);
 
     // Activity indicator for working sessions
     let (activity, activity_color) = if session.pending_permission.is_some() {
         (" ⚠".to_string(), LOGO_GOLD) // Permission required - orange/gold
     } else if session.pending_question.is_some() {
         (" ?".to_string(), LOGO_GOLD) // Question pending - orange/gold
+    } else if session.needs_input && !session.state.is_active() {
+        (" ? needs input".to_string(), LOGO_GOLD) // Turn ended with a question
+    } else if let (Some(command), Some(started_at)) =
+        (&session.long_command, session.active_tool_started_at)
+    {
+        // Bash command without a result for minutes - likely stalled
+        (
+            format!(
+                " ⏳ {} running {}m",
+                truncate_text(command, 30),
+                started_at.elapsed().as_secs() / 60
+            ),
+            LOGO_GOLD,
+        )
+    } else if stalled {
+        // Working without output for longer than the stall threshold
+        (format!(" {} stalled", spinner), LOGO_GOLD)
+    } else if session.state.is_active() && session.streaming {
+        // Connection to the API open: streaming or waiting on the model
+        (format!(" {} ▲ streaming", spinner), LOGO_MINT)
     } else if session.state.is_active() {
         (format!(" {}", spinner), LOGO_MINT) // Animated spinner - green
+    } else if session.done {
+        (" ✅ DONE".to_string(), LOGO_MINT) // Finished work, ready for review
+    } else if let Some(last_activity) = session.last_activity {
+        // Idle: show how long ago the agent was last active (recomputed every frame)
+        (
+            format!(" · {}", format_time_ago(last_activity.elapsed())),
+            TEXT_DIM,
+        )
     } else {
         (String::new(), LOGO_MINT)
     };
@@ -69,82 +162,379 @@ pub fn render_session_entry<'a>(
         session.name.clone()
     };
 
-    // First line: cursor + optional number + relative path + activity
-    let first_line = if show_number {
-        Line::from(vec![
-            Span::raw(cursor),
-            Span::styled(format!("{}. ", index + 1), Style::new().fg(TEXT_DIM)),
-            Span::styled(
-                display_path,
-                if is_selected {
-                    Style::new().fg(TEXT_WHITE).bold()
-                } else {
-                    Style::new().fg(TEXT_WHITE)
-                },
-            ),
-            Span::styled(activity.clone(), Style::new().fg(activity_color)),
-        ])
+    // Number of prompts waiting to be dispatched, hidden marker (only visible with 'H'),
+    // and muted notifications marker
+    let mut queued = if session.queued_prompts.is_empty() {
+        String::new()
     } else {
-        Line::from(vec![
-            Span::raw(cursor),
-            Span::styled(
-                display_path,
-                if is_selected {
-                    Style::new().fg(TEXT_WHITE).bold()
-                } else {
-                    Style::new().fg(TEXT_WHITE)
-                },
-            ),
-            Span::styled(activity.clone(), Style::new().fg(activity_color)),
-        ])
+        format!(" +{} queued", session.queued_prompts.len())
     };
+    // Queued prompts held back by the usage quota, and when they go out
+    if let Some(until) = held_until.filter(|_| !session.queued_prompts.is_empty()) {
+        queued.push_str(&format!(" ⏸ until {}", until.format("%H:%M")));
+    }
+    if session.headless {
+        queued.push_str(" [SDK]");
+    }
+    if session.hidden {
+        queued.push_str(" (hidden)");
+    }
+    if muted {
+        queued.push_str(" (muted)");
+    }
 
-    // Second line: branch + worktree + diff stats + mode
-    let mut second_spans = vec![
-        Span::raw("   "),
-        Span::styled("🌿 ", Style::new().fg(BRANCH_GREEN)),
-        Span::styled(session.git_branch.clone(), Style::new().fg(TEXT_DIM)),
-    ];
+    // First line: cursor + optional number + relative path + activity + queue
+    let mut first_spans = vec![Span::raw(cursor)];
+    if show_number {
+        first_spans.push(Span::styled(
+            format!("{}. ", index + 1),
+            Style::new().fg(TEXT_DIM),
+        ));
+    }
+    let path_index = first_spans.len();
+    first_spans.push(Span::styled(
+        display_path,
+        if is_selected {
+            Style::new().fg(path_color).bold()
+        } else {
+            Style::new().fg(path_color)
+        },
+    ));
+    first_spans.push(Span::styled(activity, Style::new().fg(activity_color)));
+    first_spans.push(Span::styled(queued, Style::new().fg(TEXT_DIM)));
+    // Long paths give way to the activity indicator (full path in the stats popup)
+    fit_span(&mut first_spans, path_index, width);
+    let first_line = Line::from(first_spans);
 
-    // Show worktree indicator (compact)
-    if session.is_worktree {
-        second_spans.push(Span::styled(" (wt)", Style::new().fg(TEXT_DIM)));
+    // Second line: the configured fields (by default branch + worktree + diff
+    // stats + plan progress + procs + verify + mode)
+    let mut second_spans = vec![Span::raw("   ")];
+    let mut branch_index = None;
+    for field in fields {
+        match field {
+            RowField::Branch => {
+                separate(&mut second_spans);
+                second_spans.push(Span::styled("🌿 ", Style::new().fg(BRANCH_GREEN)));
+                branch_index = Some(second_spans.len());
+                second_spans.push(Span::styled(
+                    session.git_branch.clone(),
+                    Style::new().fg(TEXT_DIM),
+                ));
+
+                // Show worktree indicator (compact)
+                if session.is_worktree {
+                    second_spans.push(Span::styled(" (wt)", Style::new().fg(TEXT_DIM)));
+                }
+            }
+            // Show diff stats if available (e.g., "+45 -12"), escalating once the diff
+            // grows past the review thresholds
+            RowField::Diff => {
+                let Some(diff_stats) = session
+                    .diff_stats
+                    .as_ref()
+                    .filter(|d| d.insertions > 0 || d.deletions > 0)
+                else {
+                    continue;
+                };
+                let (add_style, remove_style) = match session.diff_severity {
+                    DiffSeverity::Normal => (
+                        Style::new().fg(DIFF_ADD_FG),
+                        Style::new().fg(DIFF_REMOVE_FG),
+                    ),
+                    DiffSeverity::Large => (
+                        Style::new().fg(LOGO_GOLD).bold(),
+                        Style::new().fg(LOGO_GOLD).bold(),
+                    ),
+                    DiffSeverity::Huge => (
+                        Style::new().fg(LOGO_CORAL).bold(),
+                        Style::new().fg(LOGO_CORAL).bold(),
+                    ),
+                };
+                separate(&mut second_spans);
+                if diff_stats.insertions > 0 {
+                    second_spans.push(Span::styled(
+                        format!("+{}", diff_stats.insertions),
+                        add_style,
+                    ));
+                }
+                if diff_stats.deletions > 0 {
+                    if diff_stats.insertions > 0 {
+                        second_spans.push(Span::raw(" "));
+                    }
+                    second_spans.push(Span::styled(
+                        format!("-{}", diff_stats.deletions),
+                        remove_style,
+                    ));
+                }
+                match session.diff_severity {
+                    DiffSeverity::Normal => {}
+                    DiffSeverity::Large => {
+                        second_spans.push(Span::styled(" ⚠", Style::new().fg(LOGO_GOLD)));
+                    }
+                    DiffSeverity::Huge => {
+                        second_spans.push(Span::styled(
+                            " ⚠ review",
+                            Style::new().fg(LOGO_CORAL).bold(),
+                        ));
+                    }
+                }
+            }
+            // Show task progress while a plan is in flight (e.g., "4/9 ~35m")
+            RowField::Plan => {
+                if let Some((completed, total, eta)) = session.plan_progress()
+                    && completed < total
+                {
+                    let progress = match eta {
+                        Some(eta) => format!("{}/{} ~{}", completed, total, format_eta(eta)),
+                        None => format!("{}/{}", completed, total),
+                    };
+                    separate(&mut second_spans);
+                    second_spans.push(Span::styled(progress, Style::new().fg(LOGO_MINT)));
+                }
+            }
+            // Show running terminal commands (e.g., "2 procs")
+            RowField::Procs => {
+                if session.running_terminals > 0 {
+                    separate(&mut second_spans);
+                    second_spans.push(Span::styled(
+                        format!(
+                            "{} proc{}",
+                            session.running_terminals,
+                            if session.running_terminals == 1 {
+                                ""
+                            } else {
+                                "s"
+                            }
+                        ),
+                        Style::new().fg(LOGO_LIGHT_BLUE),
+                    ));
+                }
+            }
+            // Show verify command result (e.g., "✓ verify")
+            RowField::Verify => {
+                let verify_badge = match session.verify_status {
+                    None => None,
+                    Some(VerifyStatus::Running) => Some(("… verify", LOGO_GOLD)),
+                    Some(VerifyStatus::Passed) => Some(("✓ verify", LOGO_MINT)),
+                    Some(VerifyStatus::Failed) => Some(("✗ verify", LOGO_CORAL)),
+                };
+                if let Some((badge, color)) = verify_badge {
+                    separate(&mut second_spans);
+                    second_spans.push(Span::styled(badge, Style::new().fg(color)));
+                }
+            }
+            // Show mode if set (e.g., "plan")
+            RowField::Mode => {
+                if let Some(mode) = &session.current_mode {
+                    separate(&mut second_spans);
+                    second_spans.push(Span::styled(
+                        format!("[{}]", mode),
+                        Style::new().fg(LOGO_GOLD),
+                    ));
+                }
+            }
+            // Shown on lines of their own below
+            RowField::Message | RowField::Task | RowField::Notes | RowField::Commits => {}
+        }
     }
 
-    // Show diff stats if available (e.g., "+45 -12")
-    if let Some(ref diff_stats) = session.diff_stats
-        && (diff_stats.insertions > 0 || diff_stats.deletions > 0)
-    {
-        second_spans.push(Span::raw("  "));
-        if diff_stats.insertions > 0 {
-            second_spans.push(Span::styled(
-                format!("+{}", diff_stats.insertions),
-                Style::new().fg(DIFF_ADD_FG),
-            ));
+    // Show permission mode badge when it reduces supervision (e.g., "[YOLO]"),
+    // whatever the configured fields. amux's own mode wins over the one the
+    // project's Claude settings start the agent in.
+    let permission_badge = match session.permission_mode {
+        PermissionMode::Normal => session.project_mode.map(|mode| {
+            let color = match mode {
+                ClaudeMode::Plan => LOGO_GOLD,
+                ClaudeMode::AcceptEdits => LOGO_MINT,
+                ClaudeMode::BypassPermissions => Color::Red,
+            };
+            (mode.badge(), color)
+        }),
+        PermissionMode::Plan => Some(("[PLAN]", LOGO_GOLD)),
+        PermissionMode::AcceptAll => Some(("[ACCEPT]", LOGO_MINT)),
+        PermissionMode::Yolo => Some(("[YOLO]", Color::Red)),
+    };
+    if let Some((badge, color)) = permission_badge {
+        separate(&mut second_spans);
+        second_spans.push(Span::styled(badge, Style::new().fg(color).bold()));
+    }
+
+    let mut lines = vec![first_line];
+    if second_spans.len() > 1 {
+        // Long branch names give way to the badges (full name in the stats popup)
+        if let Some(index) = branch_index {
+            fit_span(&mut second_spans, index, width);
         }
-        if diff_stats.deletions > 0 {
-            if diff_stats.insertions > 0 {
-                second_spans.push(Span::raw(" "));
+        lines.push(Line::from(second_spans));
+    }
+
+    // Preview of the agent's latest message leads the lines below when toggled
+    // with 'P' and not placed by the configured fields
+    let previewed = show_preview && !fields.contains(&RowField::Message);
+    let below = previewed
+        .then_some(&RowField::Message)
+        .into_iter()
+        .chain(fields);
+    for field in below {
+        match field {
+            // Preview of the agent's latest message
+            RowField::Message => {
+                if let Some(message) = session.last_agent_message() {
+                    let max_width = width.saturating_sub(5).min(MAX_PREVIEW_CHARS); // "   » " prefix
+                    lines.push(Line::from(vec![
+                        Span::styled("   » ", Style::new().fg(TEXT_DIM)),
+                        Span::styled(
+                            truncate_text(message, max_width),
+                            Style::new().fg(TEXT_DIM).italic(),
+                        ),
+                    ]));
+                }
             }
-            second_spans.push(Span::styled(
-                format!("-{}", diff_stats.deletions),
-                Style::new().fg(DIFF_REMOVE_FG),
-            ));
+            // Current task: full text for the selected session, truncated to width otherwise
+            RowField::Task => {
+                let Some(task) = session.current_task() else {
+                    continue;
+                };
+                let style = Style::new().fg(LOGO_MINT);
+                let max_width = width.saturating_sub(5); // "   ◐ " prefix
+                if is_selected {
+                    for (i, line_text) in
+                        wrap_text(&task.content, max_width).into_iter().enumerate()
+                    {
+                        let prefix = if i == 0 { "   ◐ " } else { "     " };
+                        lines.push(Line::from(vec![
+                            Span::styled(prefix, style),
+                            Span::styled(line_text, Style::new().fg(TEXT_DIM)),
+                        ]));
+                    }
+                } else {
+                    lines.push(Line::from(vec![
+                        Span::styled("   ◐ ", style),
+                        Span::styled(
+                            truncate_middle(&task.content, max_width),
+                            Style::new().fg(TEXT_DIM),
+                        ),
+                    ]));
+                }
+            }
+            // Scratchpad notes: all of them for the selected session, the first line otherwise
+            RowField::Notes => {
+                if session.notes.is_empty() {
+                    continue;
+                }
+                let style = Style::new().fg(LOGO_LIGHT_BLUE);
+                let max_width = width.saturating_sub(5); // "   ✎ " prefix
+                if is_selected {
+                    let rows = session
+                        .notes
+                        .lines()
+                        .flat_map(|line| wrap_text(line, max_width));
+                    for (i, row) in rows.enumerate() {
+                        let prefix = if i == 0 { "   ✎ " } else { "     " };
+                        lines.push(Line::from(vec![
+                            Span::styled(prefix, style),
+                            Span::styled(row, Style::new().fg(TEXT_DIM)),
+                        ]));
+                    }
+                } else {
+                    let first = session.notes.lines().next().unwrap_or_default();
+                    lines.push(Line::from(vec![
+                        Span::styled("   ✎ ", style),
+                        Span::styled(truncate_text(first, max_width), Style::new().fg(TEXT_DIM)),
+                    ]));
+                }
+            }
+            // Commits made this session; subjects listed for the selected session
+            RowField::Commits => {
+                if session.session_commits.is_empty() {
+                    continue;
+                }
+                let count = session.session_commits.len();
+                lines.push(Line::from(vec![
+                    Span::raw("   "),
+                    Span::styled("● ", Style::new().fg(BRANCH_GREEN)),
+                    Span::styled(
+                        format!(
+                            "{} commit{} this session",
+                            count,
+                            if count == 1 { "" } else { "s" }
+                        ),
+                        Style::new().fg(TEXT_DIM),
+                    ),
+                ]));
+                if is_selected {
+                    for subject in session.session_commits.iter().take(MAX_COMMIT_SUBJECTS) {
+                        lines.push(Line::from(vec![
+                            Span::raw("     "),
+                            Span::styled(
+                                truncate_text(subject, width.saturating_sub(5)),
+                                Style::new().fg(TEXT_DIM),
+                            ),
+                        ]));
+                    }
+                }
+            }
+            // Shown on the second line above
+            RowField::Branch
+            | RowField::Diff
+            | RowField::Plan
+            | RowField::Procs
+            | RowField::Verify
+            | RowField::Mode => {}
         }
     }
 
-    // Show mode if set (e.g., "plan")
-    if let Some(mode) = &session.current_mode {
-        second_spans.push(Span::raw("  "));
-        second_spans.push(Span::styled(
-            format!("[{}]", mode),
-            Style::new().fg(LOGO_GOLD),
-        ));
+    // Conflict warning: other agents changing the same files in another worktree
+    if !session.file_conflicts.is_empty() {
+        let style = Style::new().fg(LOGO_CORAL);
+        lines.push(Line::from(vec![
+            Span::styled("   ⚠ ", style),
+            Span::styled(
+                truncate_text(
+                    &format!("overlaps {}", session.file_conflicts.join(", ")),
+                    width.saturating_sub(5),
+                ),
+                style,
+            ),
+        ]));
     }
 
-    let second_line = Line::from(second_spans);
+    // Safety warning: the agent wrote files outside its project
+    if let Some(path) = session.outside_writes.last() {
+        let style = Style::new().fg(LOGO_CORAL).bold();
+        let label = match session.outside_writes.len() {
+            1 => format!("outside project: {}", path.display()),
+            n => format!("{} writes outside project", n),
+        };
+        lines.push(Line::from(vec![
+            Span::styled("   ⚠ ", style),
+            Span::styled(truncate_text(&label, width.saturating_sub(5)), style),
+        ]));
+    }
 
-    vec![first_line, second_line, Line::raw("")] // Include spacing
+    lines.push(Line::raw("")); // Include spacing
+    lines
+}
+
+/// Format a remaining duration compactly (e.g., "35m", "2h 10m").
+fn format_eta(remaining: std::time::Duration) -> String {
+    let mins = remaining.as_secs().div_ceil(60);
+    match mins {
+        0..=1 => "1m".to_string(),
+        2..=59 => format!("{}m", mins),
+        _ => format!("{}h {}m", mins / 60, mins % 60),
+    }
+}
+
+/// Format an elapsed duration as a compact relative time (e.g., "3m ago").
+fn format_time_ago(elapsed: std::time::Duration) -> String {
+    let secs = elapsed.as_secs();
+    match secs {
+        0..=59 => "now".to_string(),
+        60..=3599 => format!("{}m ago", secs / 60),
+        3600..=86399 => format!("{}h ago", secs / 3600),
+        _ => format!("{}d ago", secs / 86400),
+    }
 }
 
 /// Extract a display name from a git origin URL.
@@ -154,18 +544,220 @@ fn origin_display_name(origin: &str) -> String {
     origin.rsplit('/').next().unwrap_or(origin).to_string()
 }
 
+/// Modeline summarizing view state that changes what the list and output show.
+fn render_modeline(app: &App) -> Line<'static> {
+    let mut spans = vec![];
+    let mut push = |text: String, color: Color| {
+        if !spans.is_empty() {
+            spans.push(Span::styled(" · ", Style::new().fg(TEXT_DIM)));
+        }
+        spans.push(Span::styled(text, Style::new().fg(color)));
+    };
+
+    if !app.focused {
+        push("paused (unfocused)".to_string(), LOGO_GOLD);
+    }
+
+    match app.selected_session() {
+        Some(session) if session.is_following() => push("follow".to_string(), LOGO_MINT),
+        Some(_) => push("paused [G]".to_string(), LOGO_GOLD),
+        None => {}
+    }
+    if app.show_hidden {
+        push("hidden shown".to_string(), TEXT_WHITE);
+    }
+    if app.hide_headless {
+        push("interactive only".to_string(), TEXT_WHITE);
+    }
+    if app.show_thinking {
+        push("thinking".to_string(), TEXT_WHITE);
+    }
+    if app.errors_only {
+        push("errors only".to_string(), LOGO_CORAL);
+    }
+    if app.show_web_bodies {
+        push("web text".to_string(), TEXT_WHITE);
+    }
+    if app.show_previews {
+        push("previews".to_string(), TEXT_WHITE);
+    }
+    if app.debug_tool_json {
+        push("raw json".to_string(), TEXT_WHITE);
+    }
+    if !app.notifications.is_enabled() {
+        push("notify off".to_string(), TEXT_DIM);
+    } else if app.notifications.muted_count() > 0 {
+        push(
+            format!("{} muted", app.notifications.muted_count()),
+            TEXT_DIM,
+        );
+    }
+
+    // Claude API health; several agents stalling at once is likely the API
+    match &app.api_status {
+        Some(Ok(status)) if status.is_degraded() => {
+            let stalled = app.stalled_session_count();
+            if stalled >= 2 {
+                push(
+                    format!("{} stalled: API {}", stalled, status.description),
+                    LOGO_CORAL,
+                );
+            } else {
+                let color = if status.is_major() {
+                    LOGO_CORAL
+                } else {
+                    LOGO_GOLD
+                };
+                push(format!("API: {}", status.description), color);
+            }
+        }
+        Some(Ok(_)) => push("API ok".to_string(), TEXT_DIM),
+        Some(Err(_)) => push("API status unknown".to_string(), TEXT_DIM),
+        None => {}
+    }
+
+    // Subscription window usage, as a share of the configured limits
+    if let Some(used) = app.usage.filter(|u| u.weekly > 0) {
+        let windows = [
+            (
+                used.five_hour,
+                app.usage_limits.five_hour_tokens,
+                "5h",
+                "5h window",
+            ),
+            (used.weekly, app.usage_limits.weekly_tokens, "week", "week"),
+        ];
+        for (tokens, limit, short, label) in windows {
+            match limit {
+                Some(limit) => {
+                    let percent = usage::percent(tokens, limit);
+                    let color = if percent >= 100 {
+                        LOGO_CORAL
+                    } else if percent >= 80 {
+                        LOGO_GOLD
+                    } else {
+                        TEXT_DIM
+                    };
+                    push(format!("{}% of {}", percent, label), color);
+                }
+                None => push(
+                    format!("{} {} tok", short, usage::format_tokens(tokens)),
+                    TEXT_DIM,
+                ),
+            }
+        }
+    }
+    if let Some(progress) = app.usage_progress {
+        push(
+            format!(
+                "scanning usage {}/{}",
+                progress.dirs_scanned, progress.dirs_total
+            ),
+            TEXT_DIM,
+        );
+    } else if app.usage_truncated {
+        push("usage partial".to_string(), TEXT_DIM);
+    }
+
+    Line::from(spans)
+}
+
+/// Combined progress of the sessions on an epic: todos done across their
+/// plans and how many agents are working, waiting on the user or idle
+fn epic_progress(sessions: &[&Session]) -> Vec<Span<'static>> {
+    let (mut completed, mut total) = (0, 0);
+    let (mut working, mut waiting, mut idle) = (0, 0, 0);
+    for session in sessions {
+        if let Some((done, count, _)) = session.plan_progress() {
+            completed += done;
+            total += count;
+        }
+        if session.pending_permission.is_some()
+            || session.pending_question.is_some()
+            || session.needs_input
+        {
+            waiting += 1;
+        } else if session.state.is_active() {
+            working += 1;
+        } else {
+            idle += 1;
+        }
+    }
+
+    let mut spans = vec![];
+    if total > 0 {
+        spans.push(Span::styled(
+            format!("  {}/{} todos", completed, total),
+            Style::new().fg(LOGO_MINT),
+        ));
+    }
+    for (count, label, color) in [
+        (working, "working", LOGO_MINT),
+        (waiting, "waiting", LOGO_GOLD),
+        (idle, "idle", TEXT_DIM),
+    ] {
+        if count > 0 {
+            spans.push(Span::styled(
+                format!(" · {} {}", count, label),
+                Style::new().fg(color),
+            ));
+        }
+    }
+    spans
+}
+
+/// Shorten the span at `index` with a middle ellipsis until the line fits in
+/// `width` columns, keeping at least MIN_FIELD_CHARS of it
+fn fit_span(spans: &mut [Span<'_>], index: usize, width: usize) {
+    let total: usize = spans.iter().map(Span::width).sum();
+    if total <= width {
+        return;
+    }
+    let field = spans[index].content.chars().count();
+    let target = field.saturating_sub(total - width).max(MIN_FIELD_CHARS);
+    if target < field {
+        spans[index].content = truncate_middle(&spans[index].content, target).into();
+    }
+}
+
+/// Separate the next field of a row's second line from the previous one
+fn separate(spans: &mut Vec<Span<'_>>) {
+    if spans.len() > 1 {
+        spans.push(Span::raw("  "));
+    }
+}
+
+/// How far a session's row has faded; sessions that are working or waiting on the user stay bright
+fn row_fade(app: &App, session: &Session) -> f32 {
+    if session.state.is_active()
+        || session.pending_permission.is_some()
+        || session.pending_question.is_some()
+        || session.needs_input
+    {
+        return 0.0;
+    }
+    session
+        .last_activity
+        .map_or(0.0, |last| app.row_fade.fade(last.elapsed()))
+}
+
 /// Render the session list with hotkeys and plan at bottom.
 pub fn render_session_list(frame: &mut Frame, area: Rect, app: &mut App) {
     // Start with empty line for padding after logo
     let mut session_lines: Vec<Line> = vec![Line::raw("")];
+    // Session rows as (session index, first line, line count); rows span several lines
+    let mut rows: Vec<(usize, usize, usize)> = vec![];
 
     let spinner = app.spinner();
     let start_dir = app.start_dir.clone();
     let selected_index = app.sessions.selected_index();
+    let held_until = app.dispatch_held_until();
 
     // Build a sorted list of (original_index, session) pairs based on sort mode
     let sessions = app.sessions.sessions();
-    let mut sorted_indices: Vec<usize> = (0..sessions.len()).collect();
+    let mut sorted_indices: Vec<usize> = (0..sessions.len())
+        .filter(|&i| app.is_listed(&sessions[i]))
+        .collect();
 
     match app.sort_mode {
         SortMode::List => {
@@ -202,6 +794,10 @@ pub fn render_session_list(frame: &mut Frame, area: Rect, app: &mut App) {
                     .cmp(sessions[b].agent_type.display_name())
             });
         }
+        SortMode::ByEpic => {
+            // Sort by epic for grouping (sessions without one first)
+            sorted_indices.sort_by(|&a, &b| sessions[a].epic.cmp(&sessions[b].epic));
+        }
         SortMode::ByName => {
             // Sort alphabetically by session name
             sorted_indices.sort_by(|&a, &b| sessions[a].name.cmp(&sessions[b].name));
@@ -211,17 +807,20 @@ pub fn render_session_list(frame: &mut Frame, area: Rect, app: &mut App) {
             sorted_indices.sort_by(|&a, &b| sessions[a].created_at.cmp(&sessions[b].created_at));
         }
         SortMode::Priority => {
-            // Priority: permission prompts first, questions next, idle next, running last
+            // Priority: permission prompts first, questions next, finished work to
+            // review next, then idle, running last
             sorted_indices.sort_by(|&a, &b| {
                 let priority = |s: &Session| -> u8 {
                     if s.pending_permission.is_some() {
                         0 // Highest priority
-                    } else if s.pending_question.is_some() {
+                    } else if s.pending_question.is_some() || s.needs_input {
                         1
-                    } else if s.state == SessionState::Idle {
+                    } else if s.state == SessionState::Idle && s.done {
                         2
+                    } else if s.state == SessionState::Idle {
+                        3
                     } else {
-                        3 // Running sessions last
+                        4 // Running sessions last
                     }
                 };
                 priority(&sessions[a]).cmp(&priority(&sessions[b]))
@@ -229,15 +828,56 @@ pub fn render_session_list(frame: &mut Frame, area: Rect, app: &mut App) {
         }
     }
 
+    // Sessions whose agent died mid-task go first, in their own section
+    let interrupted: Vec<usize> = sorted_indices
+        .iter()
+        .copied()
+        .filter(|&i| sessions[i].interrupted)
+        .collect();
+    sorted_indices.retain(|&i| !sessions[i].interrupted);
+    if !interrupted.is_empty() {
+        session_lines.push(Line::from(vec![
+            Span::styled("⚠ ", Style::new().fg(LOGO_CORAL)),
+            Span::styled("Interrupted", Style::new().fg(TEXT_WHITE).bold()),
+            Span::styled(
+                format!(" ({})", interrupted.len()),
+                Style::new().fg(TEXT_DIM),
+            ),
+            Span::styled("  [R]", Style::new().fg(TEXT_WHITE)),
+            Span::styled(" resume", Style::new().fg(TEXT_DIM)),
+        ]));
+        for (display_idx, &original_idx) in interrupted.iter().enumerate() {
+            let session = &sessions[original_idx];
+            let entry_lines = render_session_entry(
+                session,
+                display_idx,
+                original_idx == selected_index,
+                spinner,
+                &start_dir,
+                true,
+                RowContext::for_session(app, session, held_until, area.width as usize),
+            );
+            rows.push((original_idx, session_lines.len(), entry_lines.len()));
+            session_lines.extend(entry_lines);
+        }
+    }
+    // Numbering of the other sessions continues after the interrupted ones
+    let offset = interrupted.len();
+
     // For grouped modes, render with group headers
-    if app.sort_mode == SortMode::Grouped || app.sort_mode == SortMode::ByAgent {
-        // Group sessions by git origin or agent type
+    if matches!(
+        app.sort_mode,
+        SortMode::Grouped | SortMode::ByAgent | SortMode::ByEpic
+    ) {
+        // Group sessions by git origin, agent type or epic
         let mut groups: BTreeMap<String, Vec<(usize, usize, &Session)>> = BTreeMap::new();
 
         for (display_idx, &original_idx) in sorted_indices.iter().enumerate() {
             let session = &sessions[original_idx];
             let key = if app.sort_mode == SortMode::ByAgent {
                 session.agent_type.display_name().to_string()
+            } else if app.sort_mode == SortMode::ByEpic {
+                session.epic.clone().unwrap_or_default()
             } else {
                 session.git_origin.clone().unwrap_or_else(|| {
                     session
@@ -251,30 +891,35 @@ pub fn render_session_list(frame: &mut Frame, area: Rect, app: &mut App) {
             groups
                 .entry(key)
                 .or_default()
-                .push((display_idx, original_idx, session));
+                .push((offset + display_idx, original_idx, session));
         }
 
         for (group_key, group_sessions) in &groups {
             // Group header - for ByAgent use key directly, otherwise extract display name
-            let display_name = if app.sort_mode == SortMode::ByAgent {
-                group_key.clone()
-            } else {
-                origin_display_name(group_key)
+            let display_name = match app.sort_mode {
+                SortMode::ByAgent => group_key.clone(),
+                SortMode::ByEpic if group_key.is_empty() => "(no epic)".to_string(),
+                SortMode::ByEpic => group_key.clone(),
+                _ => origin_display_name(group_key),
             };
 
-            session_lines.push(Line::from(vec![
+            let mut header = vec![
                 Span::styled("● ", Style::new().fg(LOGO_GOLD)),
                 Span::styled(display_name, Style::new().fg(TEXT_WHITE).bold()),
                 Span::styled(
                     format!(" ({})", group_sessions.len()),
                     Style::new().fg(TEXT_DIM),
                 ),
-            ]));
+            ];
+            if app.sort_mode == SortMode::ByEpic && !group_key.is_empty() {
+                let sessions: Vec<&Session> = group_sessions.iter().map(|&(_, _, s)| s).collect();
+                header.extend(epic_progress(&sessions));
+            }
+            session_lines.push(Line::from(header));
 
             // Sessions in this group
             for &(display_idx, original_idx, session) in group_sessions {
                 let is_selected = original_idx == selected_index;
-                let line_y = area.y + session_lines.len() as u16;
 
                 // Use display_idx for the number shown to user
                 let entry_lines = render_session_entry(
@@ -284,12 +929,11 @@ pub fn render_session_list(frame: &mut Frame, area: Rect, app: &mut App) {
                     spinner,
                     &start_dir,
                     true,
+                    RowContext::for_session(app, session, held_until, area.width as usize),
                 );
 
-                // Register interactive region for session item
-                let bounds = ClickRegion::new(area.x, line_y, area.width, 3);
-                app.interactions.register_session_item(original_idx, bounds);
-
+                // Remember the row's lines for scrolling and click regions
+                rows.push((original_idx, session_lines.len(), entry_lines.len()));
                 session_lines.extend(entry_lines);
             }
         }
@@ -298,22 +942,27 @@ pub fn render_session_list(frame: &mut Frame, area: Rect, app: &mut App) {
         for (display_idx, &original_idx) in sorted_indices.iter().enumerate() {
             let session = &sessions[original_idx];
             let is_selected = original_idx == selected_index;
-            let line_y = area.y + session_lines.len() as u16;
 
             // Use display_idx for the number shown to user
-            let entry_lines =
-                render_session_entry(session, display_idx, is_selected, spinner, &start_dir, true);
-
-            // Register interactive region for session item
-            let bounds = ClickRegion::new(area.x, line_y, area.width, 3);
-            app.interactions.register_session_item(original_idx, bounds);
+            let entry_lines = render_session_entry(
+                session,
+                offset + display_idx,
+                is_selected,
+                spinner,
+                &start_dir,
+                true,
+                RowContext::for_session(app, session, held_until, area.width as usize),
+            );
 
+            // Remember the row's lines for scrolling and click regions
+            rows.push((original_idx, session_lines.len(), entry_lines.len()));
             session_lines.extend(entry_lines);
         }
     }
 
     // Update display order mapping for hotkey selection (1-9)
-    app.session_display_order.display_to_internal = sorted_indices;
+    app.session_display_order.display_to_internal =
+        interrupted.into_iter().chain(sorted_indices).collect();
 
     if session_lines.is_empty() {
         session_lines.push(Line::styled("No sessions", Style::new().fg(TEXT_DIM)));
@@ -325,13 +974,22 @@ pub fn render_session_list(frame: &mut Frame, area: Rect, app: &mut App) {
 
     // Help hint line at bottom of sidebar with sort mode indicator
     let sort_mode_name = app.sort_mode.display_name();
-    let hotkey_lines: Vec<Line> = vec![Line::from(vec![
+    let mut hotkey_spans = vec![
         Span::styled("[?]", Style::new().fg(TEXT_WHITE)),
         Span::styled(" help  ", Style::new().fg(TEXT_DIM)),
         Span::styled("[v]", Style::new().fg(TEXT_WHITE)),
         Span::styled(" ", Style::new().fg(TEXT_DIM)),
         Span::styled(sort_mode_name, Style::new().fg(LOGO_LIGHT_BLUE)),
-    ])];
+    ];
+    let hidden_count = app.hidden_session_count();
+    if hidden_count > 0 {
+        hotkey_spans.push(Span::styled("  [H]", Style::new().fg(TEXT_WHITE)));
+        hotkey_spans.push(Span::styled(
+            format!(" {} hidden", hidden_count),
+            Style::new().fg(TEXT_DIM),
+        ));
+    }
+    let hotkey_lines: Vec<Line> = vec![render_modeline(app), Line::from(hotkey_spans)];
 
     // Build plan lines for selected session
     let mut plan_lines: Vec<Line> = vec![];
@@ -341,10 +999,34 @@ pub fn render_session_list(frame: &mut Frame, area: Rect, app: &mut App) {
         // Separator and header before plan
         let separator = "─".repeat(area.width.saturating_sub(1) as usize);
         plan_lines.push(Line::styled(separator, Style::new().fg(TEXT_DIM)));
-        plan_lines.push(Line::styled("Tasks", Style::new().fg(TEXT_WHITE).bold()));
+        let mut header_spans = vec![Span::styled("Tasks", Style::new().fg(TEXT_WHITE).bold())];
+        if let Some((completed, total, eta)) = session.plan_progress() {
+            header_spans.push(Span::styled(
+                format!("  {}/{}", completed, total),
+                Style::new().fg(TEXT_DIM),
+            ));
+            if let Some(eta) = eta {
+                header_spans.push(Span::styled(
+                    format!(", ~{} left", format_eta(eta)),
+                    Style::new().fg(TEXT_DIM),
+                ));
+            }
+
+            // Progress bar across the sidebar width
+            let bar_width = area.width.saturating_sub(1) as usize;
+            let filled = bar_width * completed / total;
+            plan_lines.push(Line::from(header_spans));
+            plan_lines.push(Line::from(vec![
+                Span::styled("━".repeat(filled), Style::new().fg(LOGO_MINT)),
+                Span::styled("━".repeat(bar_width - filled), Style::new().fg(TEXT_DIM)),
+            ]));
+        } else {
+            plan_lines.push(Line::from(header_spans));
+        }
         plan_lines.push(Line::raw("")); // Empty line after header
 
-        // Plan entries
+        // Plan entries, the one in progress with what the agent says it's doing
+        let active_form = session.current_task_active_form();
         for entry in &session.plan_entries {
             let (icon, style) = match entry.status {
                 PlanStatus::Pending => ("○", Style::new().fg(TEXT_DIM)),
@@ -377,16 +1059,59 @@ pub fn render_session_list(frame: &mut Frame, area: Rect, app: &mut App) {
                     ]));
                 }
             }
+            if entry.status == PlanStatus::InProgress
+                && let Some(active_form) = active_form
+            {
+                for line_text in wrap_text(&format!("{}…", active_form), max_width) {
+                    plan_lines.push(Line::from(vec![
+                        Span::raw("  "),
+                        Span::styled(line_text, Style::new().fg(TEXT_DIM).italic()),
+                    ]));
+                }
+            }
         }
     }
 
-    // Calculate padding to bottom-align hotkeys + plan
+    // The plan may take at most half the height when sessions need the rest
     let total_height = area.height as usize;
-    let session_height = session_lines.len();
     let hotkey_height = hotkey_lines.len();
-    let plan_height = plan_lines.len();
-    let bottom_height = hotkey_height + plan_height;
-    let padding = total_height.saturating_sub(session_height + bottom_height);
+    let max_bottom = total_height.saturating_sub(session_lines.len().min(total_height / 2));
+    plan_lines.truncate(max_bottom.saturating_sub(hotkey_height));
+    let bottom_height = hotkey_height + plan_lines.len();
+
+    // Scroll the session list so the selected row stays fully visible.
+    // Recomputed from the area every frame, so terminal resizes apply immediately.
+    let list_height = total_height.saturating_sub(bottom_height);
+    let top = match rows.iter().find(|(index, _, _)| *index == selected_index) {
+        Some(&(_, start, len)) => scroll::keep_in_view(
+            app.session_list_top,
+            start,
+            len,
+            session_lines.len(),
+            list_height,
+        ),
+        None => scroll::keep_in_view(app.session_list_top, 0, 0, session_lines.len(), list_height),
+    };
+    app.session_list_top = top;
+    let session_lines: Vec<Line> = session_lines
+        .into_iter()
+        .skip(top)
+        .take(list_height)
+        .collect();
+
+    // Register click regions for the visible part of each session row
+    for &(original_idx, start, len) in &rows {
+        let first = start.max(top);
+        let last = (start + len).min(top + list_height);
+        if first < last {
+            let line_y = area.y + (first - top) as u16;
+            let bounds = ClickRegion::new(area.x, line_y, area.width, (last - first) as u16);
+            app.interactions.register_session_item(original_idx, bounds);
+        }
+    }
+
+    // Calculate padding to bottom-align hotkeys + plan
+    let padding = total_height.saturating_sub(session_lines.len() + bottom_height);
 
     // Combine: sessions + padding + hotkeys + plan
     let mut lines = session_lines;
@@ -394,8 +1119,8 @@ pub fn render_session_list(frame: &mut Frame, area: Rect, app: &mut App) {
         lines.push(Line::raw(""));
     }
 
-    // Track hotkey line position for click regions
-    let hotkey_line_y = area.y + lines.len() as u16;
+    // Track hotkey line position for click regions (below the modeline)
+    let hotkey_line_y = area.y + lines.len() as u16 + 1;
 
     lines.extend(hotkey_lines);
     lines.extend(plan_lines);
@@ -419,3 +1144,69 @@ pub fn render_session_list(frame: &mut Frame, area: Rect, app: &mut App) {
             .with_priority(1),
     );
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::acp::PlanEntry;
+    use crate::acp::protocol::PlanPriority;
+    use crate::session::AgentType;
+
+    fn text(line: &Line) -> String {
+        line.spans
+            .iter()
+            .map(|span| span.content.as_ref())
+            .collect()
+    }
+
+    #[test]
+    fn test_task_row_layout() {
+        let mut session = Session::mock("1", "api", AgentType::ClaudeCode, "main");
+        session.plan_entries = vec![PlanEntry {
+            content: "Rewrite the transcript parser to stream large files".to_string(),
+            priority: PlanPriority::Medium,
+            status: PlanStatus::InProgress,
+            meta: None,
+        }];
+        let width = 30;
+        let render = |is_selected| -> Vec<String> {
+            render_session_entry(
+                &session,
+                0,
+                is_selected,
+                "*",
+                std::path::Path::new("/"),
+                true,
+                RowContext {
+                    muted: false,
+                    stalled: false,
+                    fade: 0.0,
+                    show_preview: false,
+                    held_until: None,
+                    fields: &[RowField::Task],
+                    width,
+                },
+            )
+            .iter()
+            .map(text)
+            .collect()
+        };
+
+        // Path line, task line, spacing; the task cut to the width
+        let rows = render(false);
+        assert_eq!(rows.len(), 3);
+        assert!(rows[1].starts_with("   ◐ Rewrite"));
+        assert!(rows[1].chars().count() <= width);
+
+        // Selected, the task wraps in full below the path
+        let rows = render(true);
+        let task: Vec<String> = rows[1..rows.len() - 1]
+            .iter()
+            .map(|row| row.chars().skip(5).collect())
+            .collect();
+        assert!(task.len() > 1);
+        assert_eq!(task.join(" "), session.plan_entries[0].content);
+        assert!(rows.iter().all(|row| row.chars().count() <= width));
+        assert_eq!(rows.last().map(String::as_str), Some(""));
+    }
+}
@@ -0,0 +1,234 @@
+//! Conversation statistics popup component.
+
+use ratatui::{
+    Frame,
+    layout::Rect,
+    style::{Color, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+};
+
+use crate::app::App;
+use crate::env::EnvSource;
+use crate::tui::theme::*;
+use crate::usage;
+
+use super::{strip_ansi, truncate_text, wrap_text};
+
+/// Agent messages per prompt at or below which a session counts as hands-on
+const PAIR_PROGRAMMING_RATIO: f64 = 3.0;
+
+/// Agent messages per prompt at or above which a session counts as fire and forget
+const FIRE_AND_FORGET_RATIO: f64 = 8.0;
+
+/// Environment variables shown at most, so the popup fits small terminals
+const MAX_ENV_ROWS: usize = 8;
+
+/// Bytes as megabytes with one decimal
+fn format_mb(bytes: usize) -> String {
+    format!("{:.1} MB", bytes as f64 / (1 << 20) as f64)
+}
+
+/// Render the conversation statistics popup for the selected session.
+pub fn render_stats_popup(frame: &mut Frame, area: Rect, app: &App) {
+    let Some(session) = app.selected_session() else {
+        return;
+    };
+    let stats = session.conversation_stats();
+
+    // Directory, branch, epic and notes in full, as the session list shortens them
+    let value_width = 50 - 2 - 12;
+    let mut location: Vec<Line> = vec![];
+    let fields = [
+        ("  Project   ", session.cwd.display().to_string()),
+        ("  Branch    ", session.git_branch.clone()),
+        ("  Epic      ", session.epic.clone().unwrap_or_default()),
+        ("  Notes     ", session.notes.clone()),
+    ];
+    for (label, value) in fields {
+        if value.is_empty() {
+            continue;
+        }
+        for (i, part) in wrap_text(&strip_ansi(&value), value_width)
+            .into_iter()
+            .enumerate()
+        {
+            location.push(Line::from(vec![
+                Span::styled(
+                    if i == 0 { label } else { "            " },
+                    Style::new().fg(TEXT_DIM),
+                ),
+                Span::styled(part, Style::new().fg(TEXT_WHITE)),
+            ]));
+        }
+    }
+    let location_rows = location.len() as u16 + 1;
+
+    // Calculate centered popup area
+    let popup_width = 50u16;
+    let env_rows = session.env_snapshot.len().min(MAX_ENV_ROWS) as u16;
+    let token_rows = if app.stats_tokens.is_some() { 2 } else { 0 };
+    let popup_height = location_rows + token_rows + if env_rows > 0 { 17 + env_rows } else { 15 };
+    let x = area.x + (area.width.saturating_sub(popup_width)) / 2;
+    let y = area.y + (area.height.saturating_sub(popup_height)) / 2;
+    let popup_area = Rect::new(
+        x,
+        y,
+        popup_width.min(area.width),
+        popup_height.min(area.height),
+    );
+
+    // Clear the area behind the popup
+    frame.render_widget(Clear, popup_area);
+
+    let mut lines: Vec<Line> = vec![];
+
+    // Title
+    lines.push(Line::from(vec![Span::styled(
+        "Conversation Stats",
+        Style::new().fg(LOGO_LIGHT_BLUE).bold(),
+    )]));
+    lines.push(Line::raw(""));
+    lines.extend(location);
+    lines.push(Line::raw(""));
+
+    let row = |label: &'static str, value: String| {
+        Line::from(vec![
+            Span::styled(label, Style::new().fg(TEXT_DIM)),
+            Span::styled(value, Style::new().fg(TEXT_WHITE)),
+        ])
+    };
+
+    lines.push(row(
+        "  You       ",
+        format!(
+            "{} messages, avg {} chars",
+            stats.user_messages,
+            stats.avg_user_len()
+        ),
+    ));
+    lines.push(row(
+        "  Agent     ",
+        format!(
+            "{} messages, avg {} chars",
+            stats.agent_messages,
+            stats.avg_agent_len()
+        ),
+    ));
+    lines.push(row("  Tools     ", format!("{} calls", stats.tool_calls)));
+
+    // What the agent itself used next to the session with its subagents
+    if let Some(tokens) = app.stats_tokens {
+        lines.push(row(
+            "  Tokens    ",
+            format!("{} this agent", usage::format_tokens(tokens.agent)),
+        ));
+        let subagents = match tokens.subagent_count {
+            0 => String::new(),
+            1 => " (1 subagent)".to_string(),
+            count => format!(" ({} subagents)", count),
+        };
+        lines.push(row(
+            "            ",
+            format!(
+                "{} whole session{}",
+                usage::format_tokens(tokens.total()),
+                subagents
+            ),
+        ));
+    }
+
+    // Truncated turns often explain odd agent behavior, so call them out
+    let truncated = session.truncated_turns();
+    lines.push(Line::from(vec![
+        Span::styled("  Turns     ", Style::new().fg(TEXT_DIM)),
+        Span::styled(
+            format!("{} completed", session.stop_reasons.len()),
+            Style::new().fg(TEXT_WHITE),
+        ),
+        Span::styled(
+            format!(", {} hit max tokens", truncated),
+            Style::new().fg(if truncated > 0 { LOGO_GOLD } else { TEXT_DIM }),
+        ),
+    ]));
+
+    // Output held in memory, against the budget shared by all sessions
+    let mut memory = format!(
+        "{}, {} of {} in all",
+        format_mb(session.output_bytes()),
+        format_mb(app.memory_usage()),
+        format_mb(app.memory_budget)
+    );
+    if session.trimmed_lines > 0 {
+        memory.push_str(&format!(", {} lines on disk", session.trimmed_lines));
+    }
+    lines.push(row("  Memory    ", memory));
+    lines.push(Line::raw(""));
+
+    // Turn ratio and a rough read on how the session was driven
+    if stats.user_messages == 0 {
+        lines.push(Line::styled(
+            "  (no prompts sent yet)",
+            Style::new().fg(TEXT_DIM),
+        ));
+    } else {
+        let ratio = stats.agent_turns_per_user();
+        lines.push(row("  Ratio     ", format!("1 : {:.1}", ratio)));
+
+        let (label, color) = if ratio <= PAIR_PROGRAMMING_RATIO {
+            ("hands-on pair programming", LOGO_MINT)
+        } else if ratio >= FIRE_AND_FORGET_RATIO {
+            ("fire and forget", LOGO_GOLD)
+        } else {
+            ("guided delegation", TEXT_WHITE)
+        };
+        lines.push(Line::from(vec![
+            Span::styled("  Style     ", Style::new().fg(TEXT_DIM)),
+            Span::styled(label, Style::new().fg(color)),
+        ]));
+    }
+    lines.push(Line::raw(""));
+
+    // Environment the agent started with; values may hold credentials
+    if !session.env_snapshot.is_empty() {
+        lines.push(Line::styled("  Environment", Style::new().fg(TEXT_DIM)));
+        for var in session.env_snapshot.iter().take(MAX_ENV_ROWS) {
+            let source = match var.source {
+                EnvSource::Process => "",
+                EnvSource::DotEnv => " (.env)",
+            };
+            let value = app.redactor.redact(&var.value);
+            lines.push(Line::from(vec![
+                Span::styled(
+                    format!("    {}=", var.name),
+                    Style::new().fg(LOGO_LIGHT_BLUE),
+                ),
+                Span::styled(truncate_text(&value, 30), Style::new().fg(TEXT_WHITE)),
+                Span::styled(source, Style::new().fg(TEXT_DIM)),
+            ]));
+        }
+        let hidden = session.env_snapshot.len().saturating_sub(MAX_ENV_ROWS);
+        lines.push(Line::styled(
+            if hidden > 0 {
+                format!("    +{} more", hidden)
+            } else {
+                String::new()
+            },
+            Style::new().fg(TEXT_DIM),
+        ));
+    }
+
+    // Footer
+    lines.push(Line::from(vec![
+        Span::styled("[Esc]", Style::new().fg(TEXT_WHITE)),
+        Span::styled(" close", Style::new().fg(TEXT_DIM)),
+    ]));
+
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .border_style(Style::new().fg(LOGO_LIGHT_BLUE))
+        .style(Style::new().bg(Color::Black));
+
+    let paragraph = Paragraph::new(lines).block(block);
+    frame.render_widget(paragraph, popup_area);
+}
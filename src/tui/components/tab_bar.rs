@@ -0,0 +1,49 @@
+//! Tab bar component - sessions opened as tabs above the conversation.
+
+use ratatui::{
+    Frame,
+    layout::Rect,
+    style::Style,
+    text::{Line, Span},
+    widgets::Paragraph,
+};
+
+use crate::app::App;
+use crate::tui::theme::*;
+
+use super::truncate_text;
+
+/// Longest session name shown on a tab
+const MAX_TAB_NAME: usize = 20;
+
+/// Render the tab bar: one label per tab, the selected session's highlighted.
+pub fn render_tab_bar(frame: &mut Frame, area: Rect, app: &App) {
+    let selected_id = app.selected_session().map(|s| s.id.as_str());
+    let mut spans = vec![];
+
+    for (i, session) in app
+        .tabs
+        .iter()
+        .filter_map(|id| app.sessions.sessions().iter().find(|s| s.id == *id))
+        .enumerate()
+    {
+        let label = format!(" {}:{} ", i + 1, truncate_text(&session.name, MAX_TAB_NAME));
+        let style = if Some(session.id.as_str()) == selected_id {
+            Style::new().fg(TEXT_WHITE).bg(TOOL_CONNECTOR).bold()
+        } else {
+            Style::new().fg(TEXT_DIM)
+        };
+        spans.push(Span::styled(label, style));
+
+        // Tabs waiting on the user get a marker so they aren't missed
+        if session.pending_permission.is_some()
+            || session.pending_question.is_some()
+            || session.needs_input
+        {
+            spans.push(Span::styled("⚠", Style::new().fg(LOGO_GOLD)));
+        }
+        spans.push(Span::raw(" "));
+    }
+
+    frame.render_widget(Paragraph::new(Line::from(spans)), area);
+}
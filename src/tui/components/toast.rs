@@ -0,0 +1,45 @@
+//! Toast component - short-lived message in the top right corner.
+
+use ratatui::{
+    Frame,
+    layout::Rect,
+    style::{Color, Style},
+    widgets::{Block, Borders, Clear, Paragraph},
+};
+
+use crate::app::App;
+use crate::tui::theme::*;
+
+use super::truncate_text;
+
+/// Render the current toast, if one is showing.
+pub fn render_toast(frame: &mut Frame, area: Rect, app: &App) {
+    let Some(toast) = app.visible_toast() else {
+        return;
+    };
+
+    // Size to the message, up to half the screen
+    let max_width = (area.width / 2).max(20).min(area.width);
+    let popup_width = (toast.message.chars().count() as u16 + 4).min(max_width);
+    let popup_height = 3u16.min(area.height);
+    let x = area.x + area.width.saturating_sub(popup_width + 1);
+    let popup_area = Rect::new(x, area.y, popup_width, popup_height);
+
+    frame.render_widget(Clear, popup_area);
+
+    let color = if toast.is_error {
+        LOGO_CORAL
+    } else {
+        LOGO_MINT
+    };
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .border_style(Style::new().fg(color))
+        .style(Style::new().bg(Color::Black));
+
+    let text = truncate_text(&toast.message, popup_width.saturating_sub(4) as usize);
+    let paragraph = Paragraph::new(format!(" {}", text))
+        .style(Style::new().fg(TEXT_WHITE))
+        .block(block);
+    frame.render_widget(paragraph, popup_area);
+}
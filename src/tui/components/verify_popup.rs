@@ -0,0 +1,93 @@
+//! Verify popup component - output of the configured verify command, kept
+//! apart from the conversation.
+
+use ratatui::{
+    Frame,
+    layout::Rect,
+    style::{Color, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+};
+
+use crate::app::App;
+use crate::session::VerifyStatus;
+use crate::tui::theme::*;
+
+use super::truncate_text;
+
+/// Render the verify output of the selected session.
+pub fn render_verify_popup(frame: &mut Frame, area: Rect, app: &App) {
+    let Some(session) = app.selected_session() else {
+        return;
+    };
+
+    // Calculate centered popup area
+    let popup_width = 100u16.min(area.width);
+    let popup_height = 30u16.min(area.height);
+    let x = area.x + (area.width.saturating_sub(popup_width)) / 2;
+    let y = area.y + (area.height.saturating_sub(popup_height)) / 2;
+    let popup_area = Rect::new(x, y, popup_width, popup_height);
+
+    // Clear the area behind the popup
+    frame.render_widget(Clear, popup_area);
+
+    let content_width = (popup_width as usize).saturating_sub(4);
+    let mut lines: Vec<Line> = vec![];
+
+    // Title with the run's status
+    let (status, color) = match session.verify_status {
+        Some(VerifyStatus::Running) => (format!("{} running", app.spinner()), LOGO_GOLD),
+        Some(VerifyStatus::Passed) => ("✓ passed".to_string(), LOGO_MINT),
+        Some(VerifyStatus::Failed) => ("✗ failed".to_string(), LOGO_CORAL),
+        None => ("not run".to_string(), TEXT_DIM),
+    };
+    lines.push(Line::from(vec![
+        Span::styled(
+            format!("Verify {} ", session.name),
+            Style::new().fg(LOGO_LIGHT_BLUE).bold(),
+        ),
+        Span::styled(status, Style::new().fg(color)),
+    ]));
+    lines.push(Line::raw(""));
+
+    // Newest output at the bottom, scrolled back by verify_scroll lines
+    let rows = (popup_height as usize).saturating_sub(6).max(1);
+    let output = &session.verify_output;
+    if output.is_empty() {
+        lines.push(Line::styled("  (no output)", Style::new().fg(TEXT_DIM)));
+    }
+    let end = output.len().saturating_sub(app.verify_scroll);
+    let start = end.saturating_sub(rows);
+    for line in output.range(start..end) {
+        lines.push(Line::styled(
+            truncate_text(line, content_width),
+            Style::new().fg(TEXT_WHITE),
+        ));
+    }
+    lines.push(Line::raw(""));
+
+    // Footer
+    lines.push(Line::from(
+        [
+            ("[j/k]", " scroll  "),
+            ("[r]", " run again  "),
+            ("[Esc]", " close"),
+        ]
+        .iter()
+        .flat_map(|(key, label)| {
+            [
+                Span::styled(*key, Style::new().fg(TEXT_WHITE)),
+                Span::styled(*label, Style::new().fg(TEXT_DIM)),
+            ]
+        })
+        .collect::<Vec<_>>(),
+    ));
+
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .border_style(Style::new().fg(LOGO_LIGHT_BLUE))
+        .style(Style::new().bg(Color::Black));
+
+    let paragraph = Paragraph::new(lines).block(block);
+    frame.render_widget(paragraph, popup_area);
+}
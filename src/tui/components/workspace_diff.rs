@@ -0,0 +1,127 @@
+//! Workspace diff component - the session's work tree snapshots, and the diff
+//! between two of them.
+
+use chrono::{DateTime, Local};
+use ratatui::{
+    Frame,
+    layout::Rect,
+    style::Style,
+    text::{Line, Span},
+    widgets::Paragraph,
+};
+
+use crate::app::App;
+use crate::tui::theme::*;
+
+use super::truncate_text;
+
+/// Maximum number of snapshot rows shown above the diff
+const MAX_LIST_ROWS: usize = 8;
+
+/// Render the snapshot list and the diff of the marked range.
+pub fn render_workspace_diff(frame: &mut Frame, area: Rect, app: &App) {
+    let mut lines: Vec<Line> = vec![];
+    let width = area.width as usize;
+
+    if let Some(state) = &app.workspace_diff {
+        // Header
+        let hint = match (&state.diff, state.from) {
+            (Some(_), _) => "  [j/k] scroll  [Esc] back",
+            (None, Some(_)) => "  [j/k] select  [Enter] diff to here  [Esc] back",
+            (None, None) => "  [j/k] select  [Enter] diff from here  [Esc] close",
+        };
+        lines.push(Line::from(vec![
+            Span::styled(
+                "Workspace snapshots",
+                Style::new().fg(LOGO_LIGHT_BLUE).bold(),
+            ),
+            Span::styled(hint, Style::new().fg(TEXT_DIM)),
+        ]));
+        lines.push(Line::raw("")); // spacing
+
+        if state.snapshots.is_empty() {
+            lines.push(Line::styled(
+                "  (snapshots are taken when the session starts and after each turn)",
+                Style::new().fg(TEXT_DIM),
+            ));
+        }
+
+        // Keep the selected snapshot visible when the list is longer than the rows shown
+        let list_start = state.selected.saturating_sub(MAX_LIST_ROWS - 1);
+        for (i, snapshot) in state
+            .snapshots
+            .iter()
+            .enumerate()
+            .skip(list_start)
+            .take(MAX_LIST_ROWS)
+        {
+            let is_selected = i == state.selected;
+            let cursor = if is_selected { "> " } else { "  " };
+            let in_range = match (state.from, state.diff.is_some()) {
+                (Some(from), true) => i == from || i == state.selected,
+                (Some(from), false) => i == from,
+                (None, _) => false,
+            };
+            let time = DateTime::<Local>::from(snapshot.taken_at)
+                .format("%H:%M:%S")
+                .to_string();
+
+            lines.push(Line::from(vec![
+                Span::raw(cursor),
+                Span::styled(
+                    if in_range { "● " } else { "  " },
+                    Style::new().fg(LOGO_GOLD),
+                ),
+                Span::styled(format!("{}  ", time), Style::new().fg(TEXT_DIM)),
+                Span::styled(
+                    truncate_text(&snapshot.label, width.saturating_sub(16)),
+                    if is_selected {
+                        Style::new().fg(TEXT_WHITE).bold()
+                    } else {
+                        Style::new().fg(TEXT_WHITE)
+                    },
+                ),
+            ]));
+        }
+
+        // Diff of the marked range
+        match &state.diff {
+            Some(Ok(diff)) => {
+                lines.push(Line::raw(""));
+                if diff.is_empty() {
+                    lines.push(Line::styled(
+                        "  (no changes between these snapshots)",
+                        Style::new().fg(TEXT_DIM),
+                    ));
+                }
+                let remaining = (area.height as usize).saturating_sub(lines.len());
+                for text in diff.iter().skip(state.diff_scroll).take(remaining) {
+                    let style = if text.starts_with("+++") || text.starts_with("---") {
+                        Style::new().fg(TEXT_WHITE).bold()
+                    } else if text.starts_with('+') {
+                        Style::new().fg(DIFF_ADD_FG).bg(DIFF_ADD_BG)
+                    } else if text.starts_with('-') {
+                        Style::new().fg(DIFF_REMOVE_FG).bg(DIFF_REMOVE_BG)
+                    } else if text.starts_with("@@") || text.starts_with("diff ") {
+                        Style::new().fg(LOGO_LIGHT_BLUE)
+                    } else {
+                        Style::new().fg(TEXT_DIM)
+                    };
+                    lines.push(Line::styled(truncate_text(text, width), style));
+                }
+            }
+            Some(Err(error)) => {
+                lines.push(Line::raw(""));
+                lines.push(Line::styled(
+                    format!("  {}", error),
+                    Style::new().fg(LOGO_CORAL),
+                ));
+            }
+            None => {}
+        }
+    }
+
+    let paragraph = Paragraph::new(lines).style(Style::new().fg(TEXT_WHITE));
+
+    frame.render_widget(paragraph, area);
+}
@@ -1,4 +1,7 @@
-use ratatui::style::Color;
+use ratatui::{
+    buffer::Buffer,
+    style::{Color, Modifier},
+};
 
 // Logo colors (circumflex-inspired)
 pub const LOGO_CORAL: Color = Color::Rgb(232, 131, 136); // #E88388
@@ -20,3 +23,70 @@ pub const DIFF_REMOVE_FG: Color = Color::Rgb(230, 130, 130); // Light red text
 // Tool output colors
 pub const TOOL_DOT: Color = Color::Rgb(161, 193, 129); // Green dot for tools (same as LOGO_MINT)
 pub const TOOL_CONNECTOR: Color = Color::Rgb(100, 100, 100); // Dim connector └
+
+/// ASCII fallbacks for UI glyphs in plain mode
+const ASCII_FALLBACKS: &[(&str, &str)] = &[
+    ("●", "*"),
+    ("○", "o"),
+    ("◐", "~"),
+    ("─", "-"),
+    ("━", "#"),
+    ("│", "|"),
+    ("┃", "#"),
+    ("▌", "|"),
+    ("▸", ">"),
+    ("»", ">"),
+    ("└", "`"),
+    ("✓", "+"),
+    ("✗", "x"),
+    ("⚠", "!"),
+    ("…", "."),
+];
+
+/// Mix two RGB colors, `t` from 0.0 (all `from`) to 1.0 (all `to`).
+/// Non-RGB colors are returned unchanged.
+pub fn blend(from: Color, to: Color, t: f32) -> Color {
+    match (from, to) {
+        (Color::Rgb(r1, g1, b1), Color::Rgb(r2, g2, b2)) => {
+            let t = t.clamp(0.0, 1.0);
+            let mix = |a: u8, b: u8| (a as f32 + (b as f32 - a as f32) * t).round() as u8;
+            Color::Rgb(mix(r1, r2), mix(g1, g2), mix(b1, b2))
+        }
+        _ => from,
+    }
+}
+
+/// Invert every cell of a rendered frame (visual alert).
+pub fn apply_flash(buf: &mut Buffer) {
+    for cell in buf.content.iter_mut() {
+        cell.modifier.toggle(Modifier::REVERSED);
+    }
+}
+
+/// Strip all colors from a rendered frame and swap glyphs for ASCII fallbacks.
+/// Used when NO_COLOR is set or --no-color is passed (dumb terminals, screen readers).
+pub fn apply_plain_mode(buf: &mut Buffer) {
+    for cell in buf.content.iter_mut() {
+        // Progress bars tell done from remaining by color alone
+        let remaining_bar = cell.symbol() == "━" && cell.fg == TEXT_DIM;
+        cell.set_fg(Color::Reset);
+        cell.set_bg(Color::Reset);
+
+        // Braille spinner frames become a plain asterisk
+        let is_braille = cell
+            .symbol()
+            .chars()
+            .next()
+            .is_some_and(|c| ('\u{2800}'..='\u{28FF}').contains(&c));
+        if is_braille {
+            cell.set_symbol("*");
+        } else if remaining_bar {
+            cell.set_symbol("-");
+        } else if let Some((_, ascii)) = ASCII_FALLBACKS
+            .iter()
+            .find(|(glyph, _)| *glyph == cell.symbol())
+        {
+            cell.set_symbol(ascii);
+        }
+    }
+}
@@ -6,13 +6,18 @@ use ratatui::{
 };
 
 use crate::app::{App, InputMode};
+use crate::tui::theme::{apply_flash, apply_plain_mode};
 
 // Re-export components for external use
 pub use super::components::{
-    render_agent_picker, render_branch_input, render_bug_report_popup, render_clear_confirm_popup,
-    render_conversation_view, render_folder_picker, render_help_popup, render_horizontal_separator,
-    render_logo, render_permission_dialog, render_prompt, render_question_dialog, render_separator,
-    render_session_list, render_session_picker, render_worktree_cleanup, render_worktree_picker,
+    render_agent_picker, render_audit_log_popup, render_branch_input, render_bug_report_popup,
+    render_clear_confirm_popup, render_conversation_view, render_epic_input, render_folder_picker,
+    render_help_popup, render_horizontal_separator, render_kill_idle_popup, render_linear_view,
+    render_logo, render_notes_popup, render_permission_dialog, render_plan_history_popup,
+    render_process_tree, render_prompt, render_question_dialog, render_queue_popup,
+    render_recent_files, render_reply_menu, render_search_popup, render_separator,
+    render_session_list, render_session_picker, render_stats_popup, render_tab_bar, render_toast,
+    render_verify_popup, render_workspace_diff, render_worktree_cleanup, render_worktree_picker,
 };
 
 // Layout constants
@@ -31,6 +36,124 @@ pub fn render(frame: &mut Frame, app: &mut App) {
 
     let area = frame.area();
 
+    if app.screen_reader {
+        // One column of labeled regions instead of the sidebar and panes;
+        // pickers that take over the content area get the whole screen
+        match app.input_mode {
+            InputMode::BranchInput => render_branch_input(frame, area, app),
+            InputMode::SessionPicker => render_session_picker(frame, area, app),
+            InputMode::WorktreeCleanup => render_worktree_cleanup(frame, area, app),
+            InputMode::RecentFiles => render_recent_files(frame, area, app),
+            InputMode::ProcessTree => render_process_tree(frame, area, app),
+            InputMode::WorkspaceDiff => render_workspace_diff(frame, area, app),
+            _ => render_linear_view(frame, area, app),
+        }
+    } else {
+        render_panes(frame, area, app);
+    }
+
+    // === Popup overlays (rendered on top of everything) ===
+
+    // Render folder picker popup on top
+    if app.input_mode == InputMode::FolderPicker
+        || app.input_mode == InputMode::WorktreeFolderPicker
+        || app.input_mode == InputMode::WorktreeCleanupRepoPicker
+    {
+        render_folder_picker(frame, area, app);
+    }
+
+    // Render agent picker popup on top
+    if app.input_mode == InputMode::AgentPicker {
+        render_agent_picker(frame, area, app);
+    }
+
+    // Render help popup on top if in Help mode
+    if app.input_mode == InputMode::Help {
+        render_help_popup(frame, area, app);
+    }
+
+    // Render conversation statistics popup on top if in Stats mode
+    if app.input_mode == InputMode::Stats {
+        render_stats_popup(frame, area, app);
+    }
+
+    // Render quick reply menu on top if in ReplyTemplates mode
+    if app.input_mode == InputMode::ReplyTemplates {
+        render_reply_menu(frame, area, app);
+    }
+
+    // Render epic input on top if in EpicInput mode
+    if app.input_mode == InputMode::EpicInput {
+        render_epic_input(frame, area, app);
+    }
+
+    // Render notes editor on top if in Notes mode
+    if app.input_mode == InputMode::Notes {
+        render_notes_popup(frame, area, app);
+    }
+
+    // Render audit log on top if in AuditLog mode
+    if app.input_mode == InputMode::AuditLog {
+        render_audit_log_popup(frame, area, app);
+    }
+
+    // Render plan history timeline on top if in PlanHistory mode
+    if app.input_mode == InputMode::PlanHistory {
+        render_plan_history_popup(frame, area, app);
+    }
+
+    // Render the queue on top if in Queue mode
+    if app.input_mode == InputMode::Queue {
+        render_queue_popup(frame, area, app);
+    }
+
+    // Render the archive search on top if in Search mode
+    if app.input_mode == InputMode::Search {
+        render_search_popup(frame, area, app);
+    }
+
+    // Render the verify output on top if in VerifyOutput mode
+    if app.input_mode == InputMode::VerifyOutput {
+        render_verify_popup(frame, area, app);
+    }
+
+    // Render bug report popup on top if in BugReport mode
+    if app.input_mode == InputMode::BugReport {
+        render_bug_report_popup(frame, area, app);
+    }
+
+    // Render clear session confirmation popup on top if in ClearConfirm mode
+    if app.input_mode == InputMode::ClearConfirm {
+        render_clear_confirm_popup(frame, area, app);
+    }
+
+    // Render kill idle sessions confirmation popup on top if in KillIdleConfirm mode
+    if app.input_mode == InputMode::KillIdleConfirm {
+        render_kill_idle_popup(frame, area, app);
+    }
+
+    // Render worktree picker popup on top
+    if app.input_mode == InputMode::WorktreePicker {
+        render_worktree_picker(frame, area, app);
+    }
+
+    // Toasts stay visible over popups
+    render_toast(frame, area, app);
+
+    // Visual alert: briefly invert the whole screen
+    if app.is_flashing() {
+        apply_flash(frame.buffer_mut());
+    }
+
+    // Strip colors and fancy glyphs last so every component is covered
+    if app.plain_mode {
+        apply_plain_mode(frame.buffer_mut());
+    }
+}
+
+/// Sidebar with the session list, and the selected session's conversation
+/// with the prompt, permission request or question below it.
+fn render_panes(frame: &mut Frame, area: Rect, app: &mut App) {
     // Horizontal split: sidebar | left padding | separator | content left padding | main content | content right padding
     let content_layout = Layout::horizontal([
         Constraint::Length(SIDEBAR_WIDTH),
@@ -147,10 +270,28 @@ pub fn render(frame: &mut Frame, app: &mut App) {
         render_session_picker(frame, right_layout[0], app);
     } else if app.input_mode == InputMode::WorktreeCleanup {
         render_worktree_cleanup(frame, right_layout[0], app);
+    } else if app.input_mode == InputMode::RecentFiles {
+        render_recent_files(frame, right_layout[0], app);
+    } else if app.input_mode == InputMode::ProcessTree {
+        render_process_tree(frame, right_layout[0], app);
+    } else if app.input_mode == InputMode::WorkspaceDiff {
+        render_workspace_diff(frame, right_layout[0], app);
     } else {
+        // Tab bar above the conversation once sessions are opened as tabs
+        let output_area = if app.tabs.is_empty() {
+            right_layout[0]
+        } else {
+            let tab_layout = Layout::vertical([
+                Constraint::Length(1), // Tab bar
+                Constraint::Min(0),    // Output
+            ])
+            .split(right_layout[0]);
+            render_tab_bar(frame, tab_layout[0], app);
+            tab_layout[1]
+        };
         // Update viewport_height for scroll calculations
-        app.viewport_height = right_layout[0].height as usize;
-        render_conversation_view(frame, right_layout[0], app);
+        app.viewport_height = output_area.height as usize;
+        render_conversation_view(frame, output_area, app);
     }
 
     // Render permission dialog, question dialog, or input bar
@@ -163,39 +304,4 @@ pub fn render(frame: &mut Frame, app: &mut App) {
         render_horizontal_separator(frame, right_layout[2]);
         render_prompt(frame, right_layout[4], app);
     }
-
-    // === Popup overlays (rendered on top of everything) ===
-
-    // Render folder picker popup on top
-    if app.input_mode == InputMode::FolderPicker
-        || app.input_mode == InputMode::WorktreeFolderPicker
-        || app.input_mode == InputMode::WorktreeCleanupRepoPicker
-    {
-        render_folder_picker(frame, area, app);
-    }
-
-    // Render agent picker popup on top
-    if app.input_mode == InputMode::AgentPicker {
-        render_agent_picker(frame, area, app);
-    }
-
-    // Render help popup on top if in Help mode
-    if app.input_mode == InputMode::Help {
-        render_help_popup(frame, area, app);
-    }
-
-    // Render bug report popup on top if in BugReport mode
-    if app.input_mode == InputMode::BugReport {
-        render_bug_report_popup(frame, area, app);
-    }
-
-    // Render clear session confirmation popup on top if in ClearConfirm mode
-    if app.input_mode == InputMode::ClearConfirm {
-        render_clear_confirm_popup(frame, area, app);
-    }
-
-    // Render worktree picker popup on top
-    if app.input_mode == InputMode::WorktreePicker {
-        render_worktree_picker(frame, area, app);
-    }
 }
@@ -0,0 +1,565 @@
+//! Token usage in Claude's subscription windows.
+//!
+//! Claude subscriptions limit usage per 5-hour window and per week. The
+//! limits aren't published, but Claude Code records the token usage of
+//! every response in its session files (`~/.claude/projects/*/*.jsonl`), so
+//! summing those over the last 5 hours and 7 days approximates how much of
+//! each window is used. Measured against limits set in the config, that
+//! paces long autonomous runs.
+//!
+//! Subagents (the Task tool) keep transcripts of their own in a directory
+//! next to the session file (`<session>/subagents/*.jsonl`); their usage
+//! counts like the session's. For a single session the two are also totaled
+//! apart, so the statistics popup can show what the agent itself used next
+//! to the whole session.
+//!
+//! Long-time users can have thousands of project directories. Scans report
+//! their running totals every few directories so results show up while the
+//! tree is walked, and stop at a directory and byte budget, newest
+//! directories first, so a huge tree can't keep the scan busy for minutes.
+//! Each scan keeps the responses it read per file in a [`ScanCache`], and the
+//! next one only stats the files and reads what was appended to them since.
+
+use std::collections::{HashMap, HashSet};
+use std::io::{BufRead, BufReader, Seek, SeekFrom};
+use std::path::{Path, PathBuf};
+use std::time::{Duration, SystemTime};
+
+use chrono::{DateTime, Utc};
+use serde_json::Value;
+
+/// Length of the short window
+const FIVE_HOURS: Duration = Duration::from_secs(5 * 3600);
+
+/// Length of the weekly window
+const WEEK: Duration = Duration::from_secs(7 * 24 * 3600);
+
+/// Shortest stretch the pace of a window is measured over, so a burst right
+/// after the window opened doesn't project to an absurd total
+const MIN_PACE_WINDOW: Duration = Duration::from_secs(30 * 60);
+
+/// Project directories scanned at most, most recently modified first
+const MAX_PROJECT_DIRS: usize = 2000;
+
+/// Bytes of session files read at most per scan
+const MAX_SCAN_BYTES: u64 = 512 * 1024 * 1024;
+
+/// Directories scanned between progress reports
+const BATCH_DIRS: usize = 50;
+
+/// How far a scan has got
+#[derive(Debug, Clone, Copy, Default, PartialEq)]
+pub struct ScanProgress {
+    pub dirs_scanned: usize,
+    pub dirs_total: usize,
+    /// Some directories or files were skipped to stay within the scan budget
+    pub truncated: bool,
+}
+
+impl ScanProgress {
+    pub fn is_done(&self) -> bool {
+        self.dirs_scanned >= self.dirs_total
+    }
+}
+
+/// Tokens used in each window
+#[derive(Debug, Clone, Copy, Default, PartialEq)]
+pub struct UsageWindows {
+    pub five_hour: u64,
+    pub weekly: u64,
+    /// Earliest response in the 5-hour window (approximates when it opened)
+    pub five_hour_start: Option<DateTime<Utc>>,
+}
+
+impl UsageWindows {
+    /// Count the usage recorded on one transcript line. A response spanning
+    /// several lines repeats its usage on each, so message IDs in `seen` are
+    /// counted once.
+    pub fn add_line(&mut self, line: &str, now: DateTime<Utc>, seen: &mut HashSet<String>) {
+        if let Some(response) = Response::parse(line) {
+            self.add(&response, now, seen);
+        }
+    }
+
+    /// Count a response's usage in the windows it falls in
+    fn add(&mut self, response: &Response, now: DateTime<Utc>, seen: &mut HashSet<String>) {
+        if let Some(id) = &response.id
+            && !seen.insert(id.clone())
+        {
+            return;
+        }
+
+        let age = (now - response.at).to_std().unwrap_or_default();
+        if age <= FIVE_HOURS {
+            self.five_hour += response.tokens;
+            if self.five_hour_start.is_none_or(|start| response.at < start) {
+                self.five_hour_start = Some(response.at);
+            }
+        }
+        if age <= WEEK {
+            self.weekly += response.tokens;
+        }
+    }
+}
+
+/// The usage of one response, as recorded on a transcript line
+#[derive(Debug, Clone, PartialEq)]
+struct Response {
+    at: DateTime<Utc>,
+    /// Message ID, repeated on each line of a response spanning several
+    id: Option<String>,
+    tokens: u64,
+}
+
+impl Response {
+    fn parse(line: &str) -> Option<Self> {
+        let entry = serde_json::from_str::<Value>(line).ok()?;
+        let usage = entry.pointer("/message/usage")?;
+        let at = entry
+            .get("timestamp")
+            .and_then(Value::as_str)
+            .and_then(|t| DateTime::parse_from_rfc3339(t).ok())?;
+        Some(Self {
+            at: at.with_timezone(&Utc),
+            id: entry
+                .pointer("/message/id")
+                .and_then(Value::as_str)
+                .map(str::to_string),
+            tokens: billed_tokens(usage),
+        })
+    }
+}
+
+/// What scans read from one session file
+#[derive(Debug, Default)]
+struct FileUsage {
+    /// Bytes read so far, up to the end of the last complete line
+    len: u64,
+    /// Modification time when last read
+    modified: Option<SystemTime>,
+    /// Responses of the last week read so far
+    responses: Vec<Response>,
+}
+
+impl FileUsage {
+    /// Read the lines appended since the file was last read. A line still
+    /// being written is left for the next scan.
+    fn read_appended(&mut self, path: &Path, modified: SystemTime) -> std::io::Result<()> {
+        let mut reader = BufReader::new(std::fs::File::open(path)?);
+        reader.seek(SeekFrom::Start(self.len))?;
+        let mut line = vec![];
+        loop {
+            line.clear();
+            let read = reader.read_until(b'\n', &mut line)?;
+            if read == 0 || line.last() != Some(&b'\n') {
+                break;
+            }
+            self.len += read as u64;
+            if let Some(response) = Response::parse(&String::from_utf8_lossy(&line)) {
+                self.responses.push(response);
+            }
+        }
+        self.modified = Some(modified);
+        Ok(())
+    }
+}
+
+/// Responses read from session files by earlier scans, so a scan only reads
+/// what was appended since
+#[derive(Debug, Default)]
+pub struct ScanCache {
+    files: HashMap<PathBuf, FileUsage>,
+}
+
+/// Tokens of a response's `usage` object that count against the limits.
+/// Cache reads are billed at a fraction of input, so they're left out.
+pub fn billed_tokens(usage: &Value) -> u64 {
+    [
+        "input_tokens",
+        "output_tokens",
+        "cache_creation_input_tokens",
+    ]
+    .iter()
+    .filter_map(|field| usage.get(*field).and_then(Value::as_u64))
+    .sum()
+}
+
+/// Directory Claude Code keeps session files in (honors `CLAUDE_CONFIG_DIR`)
+pub fn projects_dir() -> Option<PathBuf> {
+    let config_dir = match std::env::var_os("CLAUDE_CONFIG_DIR") {
+        Some(dir) => PathBuf::from(dir),
+        None => dirs::home_dir()?.join(".claude"),
+    };
+    Some(config_dir.join("projects"))
+}
+
+/// Transcripts of the subagents a session started, kept next to its
+/// session file (`<project>/<session>/subagents/*.jsonl`)
+pub fn subagent_files(session_file: &Path) -> Vec<PathBuf> {
+    let dir = session_file.with_extension("").join("subagents");
+    let mut files: Vec<PathBuf> = std::fs::read_dir(dir)
+        .into_iter()
+        .flatten()
+        .flatten()
+        .map(|entry| entry.path())
+        .filter(|path| path.extension().and_then(|e| e.to_str()) == Some("jsonl"))
+        .collect();
+    files.sort();
+    files
+}
+
+/// Tokens one session used, by its own agent and by its subagents
+#[derive(Debug, Clone, Copy, Default, PartialEq)]
+pub struct SessionTokens {
+    /// Used by the session's own agent
+    pub agent: u64,
+    /// Used by the subagents it started
+    pub subagents: u64,
+    pub subagent_count: usize,
+}
+
+impl SessionTokens {
+    /// Tokens used by the whole session
+    pub fn total(&self) -> u64 {
+        self.agent + self.subagents
+    }
+}
+
+/// Billed tokens of all responses in a transcript, counting each message ID
+/// in `seen` once
+fn transcript_tokens(text: &str, seen: &mut HashSet<String>) -> u64 {
+    text.lines()
+        .filter_map(|line| serde_json::from_str::<Value>(line).ok())
+        .filter_map(|entry| {
+            let usage = entry.pointer("/message/usage")?;
+            if let Some(id) = entry.pointer("/message/id").and_then(Value::as_str)
+                && !seen.insert(id.to_string())
+            {
+                return None;
+            }
+            Some(billed_tokens(usage))
+        })
+        .sum()
+}
+
+/// Tokens used over the life of a session, from its session file and its
+/// subagents' transcripts. Blocking.
+pub fn session_tokens(session_file: &Path) -> SessionTokens {
+    let mut seen = HashSet::new();
+    let read = |path: &Path, seen: &mut HashSet<String>| {
+        std::fs::read_to_string(path)
+            .map(|text| transcript_tokens(&text, seen))
+            .unwrap_or(0)
+    };
+    let mut tokens = SessionTokens {
+        agent: read(session_file, &mut seen),
+        ..Default::default()
+    };
+    for path in subagent_files(session_file) {
+        tokens.subagents += read(&path, &mut seen);
+        tokens.subagent_count += 1;
+    }
+    tokens
+}
+
+/// Sum the usage of the last 5 hours and 7 days from Claude Code's session
+/// files. Only files modified within the week are considered, and of those
+/// only what changed since `cache` last saw them is read. `report` is called
+/// with the running totals every few directories and once at the end (with
+/// a done progress). Blocking.
+pub fn scan(cache: &mut ScanCache, report: impl FnMut(UsageWindows, ScanProgress)) {
+    if let Some(projects) = projects_dir() {
+        scan_dir(&projects, cache, SystemTime::now(), report);
+    }
+}
+
+/// Scan the session files below `projects` as of `now`
+fn scan_dir(
+    projects: &Path,
+    cache: &mut ScanCache,
+    now: SystemTime,
+    mut report: impl FnMut(UsageWindows, ScanProgress),
+) {
+    let mut windows = UsageWindows::default();
+    let mut dirs: Vec<(PathBuf, SystemTime)> = std::fs::read_dir(projects)
+        .into_iter()
+        .flatten()
+        .flatten()
+        .filter_map(|entry| {
+            let modified = entry.metadata().and_then(|m| m.modified()).ok()?;
+            Some((entry.path(), modified))
+        })
+        .collect();
+
+    let mut progress = ScanProgress::default();
+    dirs.sort_by(|a, b| b.1.cmp(&a.1));
+    if dirs.len() > MAX_PROJECT_DIRS {
+        dirs.truncate(MAX_PROJECT_DIRS);
+        progress.truncated = true;
+    }
+    progress.dirs_total = dirs.len();
+
+    let cutoff = now - WEEK;
+    let now = DateTime::<Utc>::from(now);
+    let mut seen = HashSet::new();
+    let mut bytes_read = 0;
+    let mut scanned = HashSet::new();
+    for (dir, _) in dirs {
+        progress.dirs_scanned += 1;
+        if progress.dirs_scanned % BATCH_DIRS == 0 && !progress.is_done() {
+            report(windows, progress);
+        }
+        let Ok(files) = std::fs::read_dir(dir) else {
+            continue;
+        };
+        let paths = files
+            .flatten()
+            .map(|file| file.path())
+            .filter(|path| path.extension().and_then(|e| e.to_str()) == Some("jsonl"))
+            .flat_map(|path| {
+                let subagents = subagent_files(&path);
+                std::iter::once(path).chain(subagents)
+            });
+        for path in paths {
+            let Ok(metadata) = std::fs::metadata(&path) else {
+                continue;
+            };
+            let Ok(modified) = metadata.modified() else {
+                continue;
+            };
+            if modified < cutoff {
+                continue;
+            }
+
+            // Stat first: files unchanged since the last scan aren't read again
+            let file = cache.files.entry(path.clone()).or_default();
+            if metadata.len() < file.len {
+                // Rewritten rather than appended to
+                *file = FileUsage::default();
+            }
+            if metadata.len() != file.len || file.modified != Some(modified) {
+                let unread = metadata.len() - file.len;
+                if bytes_read + unread > MAX_SCAN_BYTES {
+                    // Over budget: count what earlier scans read of it
+                    progress.truncated = true;
+                } else {
+                    bytes_read += unread;
+                    if file.read_appended(&path, modified).is_err() {
+                        continue;
+                    }
+                }
+            }
+
+            file.responses
+                .retain(|response| (now - response.at).to_std().unwrap_or_default() <= WEEK);
+            for response in &file.responses {
+                windows.add(response, now, &mut seen);
+            }
+            scanned.insert(path);
+        }
+    }
+
+    // Forget files that dropped out of the week (or the scan)
+    cache.files.retain(|path, _| scanned.contains(path));
+    report(windows, progress);
+}
+
+/// Share of a limit used, in percent (may exceed 100)
+pub fn percent(used: u64, limit: u64) -> u64 {
+    if limit == 0 {
+        return 0;
+    }
+    used * 100 / limit
+}
+
+/// When queued prompts may be dispatched again, if they have to wait: the
+/// 5-hour window's usage, projected to the window's end at the pace since it
+/// opened, would pass `max_percent` of `limit`. Dispatch resumes when the
+/// window resets.
+pub fn dispatch_hold(
+    usage: &UsageWindows,
+    limit: u64,
+    max_percent: u64,
+    now: DateTime<Utc>,
+) -> Option<DateTime<Utc>> {
+    let start = usage.five_hour_start?;
+    let resets_at = start + chrono::Duration::from_std(FIVE_HOURS).ok()?;
+    if resets_at <= now {
+        return None;
+    }
+    let elapsed = (now - start)
+        .to_std()
+        .unwrap_or_default()
+        .clamp(MIN_PACE_WINDOW, FIVE_HOURS);
+    let projected =
+        (usage.five_hour as f64 * FIVE_HOURS.as_secs_f64() / elapsed.as_secs_f64()) as u64;
+    (percent(projected, limit) >= max_percent).then_some(resets_at)
+}
+
+/// Compact token count: 950, 12k, 3.4M
+pub fn format_tokens(tokens: u64) -> String {
+    if tokens >= 1_000_000 {
+        format!("{:.1}M", tokens as f64 / 1_000_000.0)
+    } else if tokens >= 1_000 {
+        format!("{}k", tokens / 1_000)
+    } else {
+        tokens.to_string()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs::OpenOptions;
+    use std::io::Write;
+
+    #[test]
+    fn test_add_line() {
+        let now = DateTime::parse_from_rfc3339("2025-06-02T12:00:00Z")
+            .unwrap()
+            .with_timezone(&Utc);
+        let mut windows = UsageWindows::default();
+        let mut seen = HashSet::new();
+
+        let recent = r#"{"type":"assistant","timestamp":"2025-06-02T10:00:00Z","message":{"id":"m1","usage":{"input_tokens":100,"output_tokens":50,"cache_creation_input_tokens":10,"cache_read_input_tokens":5000}}}"#;
+        let days_ago = r#"{"type":"assistant","timestamp":"2025-05-30T10:00:00Z","message":{"id":"m2","usage":{"input_tokens":1000,"output_tokens":0}}}"#;
+        let too_old = r#"{"type":"assistant","timestamp":"2025-05-01T10:00:00Z","message":{"id":"m3","usage":{"input_tokens":7}}}"#;
+
+        windows.add_line(recent, now, &mut seen);
+        // The same response on a second line is not counted again
+        windows.add_line(recent, now, &mut seen);
+        windows.add_line(days_ago, now, &mut seen);
+        windows.add_line(too_old, now, &mut seen);
+        windows.add_line(
+            r#"{"type":"user","message":{"content":"hi"}}"#,
+            now,
+            &mut seen,
+        );
+
+        assert_eq!(
+            windows,
+            UsageWindows {
+                five_hour: 160,
+                weekly: 1160,
+                five_hour_start: Some(
+                    DateTime::parse_from_rfc3339("2025-06-02T10:00:00Z")
+                        .unwrap()
+                        .with_timezone(&Utc)
+                ),
+            }
+        );
+    }
+
+    #[test]
+    fn test_scan_reads_appended_lines() {
+        let projects = std::env::temp_dir().join(format!("amux-scan-{}", std::process::id()));
+        let project = projects.join("-srv-api");
+        std::fs::create_dir_all(&project).unwrap();
+        let session_file = project.join("abc.jsonl");
+        let now = SystemTime::now();
+        let response = |id: &str, tokens: u64| {
+            format!(
+                "{{\"timestamp\":\"{}\",\"message\":{{\"id\":\"{}\",\"usage\":{{\"input_tokens\":{}}}}}}}\n",
+                DateTime::<Utc>::from(now).to_rfc3339(),
+                id,
+                tokens
+            )
+        };
+        let scan = |cache: &mut ScanCache| {
+            let mut result = None;
+            scan_dir(&projects, cache, now, |usage, progress| {
+                result = Some((usage.five_hour, progress.is_done()))
+            });
+            result.unwrap()
+        };
+
+        // A line still being written is left for the next scan
+        let partial = response("m2", 20);
+        std::fs::write(
+            &session_file,
+            format!("{}{}", response("m1", 100), &partial[..10]),
+        )
+        .unwrap();
+        let mut cache = ScanCache::default();
+        assert_eq!(scan(&mut cache), (100, true));
+        let read = cache.files[&session_file].len;
+        assert_eq!(read as usize, response("m1", 100).len());
+
+        // Only the appended bytes are read; earlier responses come from the cache
+        let mut file = OpenOptions::new().append(true).open(&session_file).unwrap();
+        write!(file, "{}{}", &partial[10..], response("m3", 3)).unwrap();
+        assert_eq!(scan(&mut cache), (123, true));
+        assert_eq!(cache.files[&session_file].responses.len(), 3);
+
+        // A rewritten file is read from the start
+        std::fs::write(&session_file, response("m4", 7)).unwrap();
+        assert_eq!(scan(&mut cache), (7, true));
+
+        std::fs::remove_dir_all(&projects).unwrap();
+        assert_eq!(scan(&mut cache), (0, true));
+        assert!(cache.files.is_empty());
+    }
+
+    #[test]
+    fn test_session_tokens() {
+        let dir = std::env::temp_dir().join(format!("amux-usage-{}", std::process::id()));
+        let session_file = dir.join("abc.jsonl");
+        let subagents = dir.join("abc").join("subagents");
+        std::fs::create_dir_all(&subagents).unwrap();
+
+        let response = |id: &str, tokens: u64| {
+            format!(
+                r#"{{"type":"assistant","message":{{"id":"{}","usage":{{"input_tokens":{},"cache_read_input_tokens":900}}}}}}"#,
+                id, tokens
+            )
+        };
+        let session = [response("m1", 100), response("m1", 100), response("m2", 20)].join("\n");
+        std::fs::write(&session_file, session).unwrap();
+        std::fs::write(subagents.join("agent-1.jsonl"), response("s1", 300)).unwrap();
+        std::fs::write(subagents.join("agent-2.jsonl"), response("s2", 5)).unwrap();
+        std::fs::write(subagents.join("notes.txt"), response("s3", 1000)).unwrap();
+
+        let tokens = session_tokens(&session_file);
+        let _ = std::fs::remove_dir_all(&dir);
+        assert_eq!(
+            tokens,
+            SessionTokens {
+                agent: 120,
+                subagents: 305,
+                subagent_count: 2,
+            }
+        );
+        assert_eq!(tokens.total(), 425);
+    }
+
+    #[test]
+    fn test_dispatch_hold() {
+        let start = DateTime::parse_from_rfc3339("2025-06-02T10:00:00Z")
+            .unwrap()
+            .with_timezone(&Utc);
+        let usage = UsageWindows {
+            five_hour: 4_000_000,
+            weekly: 4_000_000,
+            five_hour_start: Some(start),
+        };
+        let at = |hours: i64| start + chrono::Duration::hours(hours);
+
+        // 4M in the first hour projects to 20M by the end of the window
+        let resets = dispatch_hold(&usage, 20_000_000, 90, at(1));
+        assert_eq!(resets, Some(at(5)));
+        // The same usage spread over four hours projects to 5M
+        assert_eq!(dispatch_hold(&usage, 20_000_000, 90, at(4)), None);
+        // Once the window reset, nothing waits
+        assert_eq!(dispatch_hold(&usage, 1, 90, at(6)), None);
+        assert_eq!(dispatch_hold(&UsageWindows::default(), 1, 90, at(1)), None);
+    }
+
+    #[test]
+    fn test_percent_and_format() {
+        assert_eq!(percent(62, 100), 62);
+        assert_eq!(percent(5, 0), 0);
+        assert_eq!(format_tokens(950), "950");
+        assert_eq!(format_tokens(12_345), "12k");
+        assert_eq!(format_tokens(3_400_000), "3.4M");
+    }
+}
@@ -0,0 +1,89 @@
+//! Web tool results (WebSearch, WebFetch).
+//!
+//! Results of web tools are kept whole so the conversation view can list
+//! the pages the agent read (titles and URLs) and expand the fetched text
+//! on demand, instead of dumping it inline.
+
+use crate::acp::protocol::ToolCallKind;
+
+/// Whether a tool call reads from the web
+pub fn is_web_tool(kind: Option<&ToolCallKind>, title: &str) -> bool {
+    if kind == Some(&ToolCallKind::Fetch) {
+        return true;
+    }
+    let title = title.to_lowercase();
+    ["websearch", "web search", "webfetch", "web fetch"]
+        .iter()
+        .any(|prefix| title.starts_with(prefix))
+}
+
+/// Links in a web tool result as (title, url), in order and without duplicates.
+///
+/// Markdown links use their text as the title; bare URLs are their own title.
+pub fn extract_links(text: &str) -> Vec<(String, String)> {
+    let mut links: Vec<(String, String)> = vec![];
+    let mut push = |title: &str, url: &str| {
+        let url = url.trim_end_matches(['.', ',', ';', ')', '"', '\'']);
+        if !links.iter().any(|(_, u)| u == url) {
+            let title = if title.trim().is_empty() { url } else { title };
+            links.push((title.trim().to_string(), url.to_string()));
+        }
+    };
+
+    for line in text.lines() {
+        let mut rest = line;
+        while let Some(start) = ["http://", "https://"]
+            .iter()
+            .filter_map(|scheme| rest.find(scheme))
+            .min()
+        {
+            let before = &rest[..start];
+            let url_end = rest[start..]
+                .find(|c: char| c.is_whitespace() || c == ')' || c == '>' || c == ']')
+                .map_or(rest.len(), |end| start + end);
+            let url = &rest[start..url_end];
+
+            // [title](url): the title is the bracketed text right before "("
+            let title = before
+                .strip_suffix("](")
+                .and_then(|b| b.rfind('[').map(|open| &b[open + 1..]))
+                .unwrap_or("");
+            push(title, url);
+            rest = &rest[url_end..];
+        }
+    }
+
+    links
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_is_web_tool() {
+        assert!(is_web_tool(Some(&ToolCallKind::Fetch), "Fetch docs"));
+        assert!(is_web_tool(None, "WebSearch: rust ratatui"));
+        assert!(!is_web_tool(Some(&ToolCallKind::Read), "Read src/main.rs"));
+    }
+
+    #[test]
+    fn test_extract_links() {
+        let text = "Results:\n\
+            1. [Ratatui docs](https://ratatui.rs/concepts/). Widgets and layout\n\
+            2. See https://docs.rs/ratatui, also (https://docs.rs/ratatui)\n";
+        assert_eq!(
+            extract_links(text),
+            vec![
+                (
+                    "Ratatui docs".to_string(),
+                    "https://ratatui.rs/concepts/".to_string()
+                ),
+                (
+                    "https://docs.rs/ratatui".to_string(),
+                    "https://docs.rs/ratatui".to_string()
+                ),
+            ]
+        );
+    }
+}